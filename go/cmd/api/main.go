@@ -1,40 +1,144 @@
 package main
 
 import (
+	"crypto/rand"
 	"eaglebank/internal/accounts"
 	adapters2 "eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/events"
+	adapters9 "eaglebank/internal/events/adapters"
+	"eaglebank/internal/fx"
+	adapters7 "eaglebank/internal/fx/adapters"
+	"eaglebank/internal/grants"
+	adapters6 "eaglebank/internal/grants/adapters"
+	"eaglebank/internal/interest"
+	adapters8 "eaglebank/internal/interest/adapters"
+	adapters5 "eaglebank/internal/notifications/adapters"
 	"eaglebank/internal/transactions"
 	adapters3 "eaglebank/internal/transactions/adapters"
 	"eaglebank/internal/users"
 	"eaglebank/internal/users/adapters"
 	"eaglebank/internal/web"
+	"eaglebank/internal/webauthn"
+	adapters4 "eaglebank/internal/webauthn/adapters"
+	"eaglebank/internal/webhooks"
+	adapters10 "eaglebank/internal/webhooks/adapters"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"runtime"
 	"time"
 )
 
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-	usrStore := adapters.NewInMemoryUserStore()
-	usrSvc := users.NewUserService(usrStore)
+	port := "8080"
+
+	notifier, err := adapters5.NewOutboxDirNotifier("outbox")
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error creating notifier: %v", err).Error())
+		os.Exit(1)
+	}
+	tokenSecret := make([]byte, 32)
+	_, err = rand.Read(tokenSecret)
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error generating token secret: %v", err).Error())
+		os.Exit(1)
+	}
+
+	// Every store records the events describing its changes here, under the same lock as the changes.
+	outbox := adapters9.NewInMemoryOutbox()
+	usrStore := adapters.NewInMemoryUserStore(adapters.WithOutbox(outbox))
+	usrSvc := users.NewUserService(usrStore, adapters.NewInMemoryTokenStore(), notifier, users.Config{
+		TokenSecret:     tokenSecret,
+		VerificationTTL: 24 * time.Hour,
+		ResetTTL:        time.Hour,
+		BaseURL:         "http://localhost:" + port,
+	})
+
+	branches, err := adapters2.LoadBranchRegistry("branches.json")
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error loading branches: %v", err).Error())
+		os.Exit(1)
+	}
+	acctStore, err := adapters2.NewEventSourcedAccountStore(adapters2.NewInMemoryEventStore(), adapters2.WithOutbox(outbox))
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error building accounts from their events: %v", err).Error())
+		os.Exit(1)
+	}
+	// Commands which change an account run one at a time on its shard, while other accounts carry on in parallel.
+	executor := accounts.NewExecutor(runtime.GOMAXPROCS(0))
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore(), accounts.WithBranches(branches), accounts.WithExecutor(executor))
+
+	grantSvc := grants.NewGrantService(adapters6.NewInMemoryGrantStore(), adapters6.NewInMemoryAccessLog(), acctStore, usrStore)
+
+	rateProvider, err := adapters7.NewFileRateProvider("rates.json")
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error loading exchange rates: %v", err).Error())
+		os.Exit(1)
+	}
+	converter := fx.NewConverter(rateProvider, 0.005)
+
+	tanStore := adapters3.NewInMemoryTransactionStore(adapters3.WithOutbox(outbox))
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore, transactions.WithConverter(converter), transactions.WithExecutor(executor), transactions.WithBalanceSnapshots(adapters3.NewInMemoryBalanceSnapshotStore()))
+
+	interestSvc, err := interest.NewInterestService(interest.Config{
+		Rates: map[accounts.AccountType][]interest.Tier{
+			accounts.SavingsAcct: {{From: 0, AnnualRate: 0.02}, {From: 1000, AnnualRate: 0.025}, {From: 5000, AnnualRate: 0.03}},
+		},
+		DayCount: interest.Actual365,
+	}, adapters8.NewInMemoryAccrualStore(), acctStore, tanSvc)
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error creating interest service: %v", err).Error())
+		os.Exit(1)
+	}
 
-	acctStore := adapters2.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
+	fxSvc := fx.NewFXService(converter, adapters7.NewInMemoryQuoteStore(), tanSvc, 30*time.Second)
 
-	tanStore := adapters3.NewInMemoryTransactionStore()
-	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+	waSvc := webauthn.NewWebAuthnService(webauthn.Config{
+		RPID:         "localhost",
+		RPName:       "Eagle Bank",
+		Origin:       "http://localhost:" + port,
+		ChallengeTTL: 5 * time.Minute,
+	}, adapters4.NewInMemoryCredentialStore(), adapters4.NewInMemorySessionStore())
+
+	webhookSvc := webhooks.NewWebhookService(adapters10.NewInMemorySubscriptionStore(), adapters10.NewInMemoryDeliveryStore(), webhooks.DefaultConfig)
 
 	srv := web.NewServer(web.ServerArgs{
-		Logger:  logger,
-		UserSvc: usrSvc,
-		AcctSvc: acctSvc,
-		TanSvc:  tanSvc,
+		Logger:      logger,
+		UserSvc:     usrSvc,
+		AcctSvc:     acctSvc,
+		TanSvc:      tanSvc,
+		WebAuthnSvc: waSvc,
+		GrantSvc:    grantSvc,
+		FXSvc:       fxSvc,
+		InterestSvc: interestSvc,
+		WebhookSvc:  webhookSvc,
 	})
 
-	port := "8080"
+	dispatcher := events.NewDispatcher(outbox)
+	err = dispatcher.Subscribe("log", func(e events.Event) error {
+		logger.Info("event published",
+			slog.String("eventId", e.ID.String()),
+			slog.String("type", e.Type.String()),
+			slog.String("aggregateId", e.AggregateID))
+		return nil
+	})
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error subscribing to events: %v", err).Error())
+		os.Exit(1)
+	}
+	err = dispatcher.Subscribe("webhooks", webhookSvc.Handle)
+	if err != nil {
+		logger.Error(fmt.Errorf("fatal error subscribing to events: %v", err).Error())
+		os.Exit(1)
+	}
+
+	go runDailyJobs(logger, acctSvc, tanSvc, interestSvc)
+	go runDispatcher(logger, dispatcher)
+	go runWebhookDeliveries(logger, webhookSvc)
+
 	logger.Info("Starting Eagle Bank api, serving on :" + port)
 	s := &http.Server{
 		Addr:         ":" + port,
@@ -42,8 +146,130 @@ func main() {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
-	err := s.ListenAndServe()
+	err = s.ListenAndServe()
 	if err != nil {
 		logger.Error(fmt.Errorf("fatal error in server: %v", err).Error())
 	}
 }
+
+// runDispatcher delivers the events in the outbox to their subscribers a few times a second.
+func runDispatcher(logger *slog.Logger, dispatcher *events.Dispatcher) {
+	for now := range time.Tick(250 * time.Millisecond) {
+		result, err := dispatcher.Dispatch(now)
+		if err != nil {
+			logger.Error(fmt.Errorf("error dispatching events: %v", err).Error())
+			continue
+		}
+		for _, f := range result.Failures {
+			logger.Warn("event delivery failed",
+				slog.String("subscriber", f.Subscriber),
+				slog.String("eventId", f.Event.ID.String()),
+				slog.Int("attempts", f.Attempts),
+				slog.Time("nextRetry", f.NextRetry),
+				slog.String("error", f.Err.Error()))
+		}
+	}
+}
+
+// runWebhookDeliveries sends the webhook deliveries which are due a few times a second.
+func runWebhookDeliveries(logger *slog.Logger, webhookSvc *webhooks.WebhookService) {
+	for now := range time.Tick(250 * time.Millisecond) {
+		result, err := webhookSvc.DeliverDue(now)
+		if err != nil {
+			logger.Error(fmt.Errorf("error delivering webhooks: %v", err).Error())
+			continue
+		}
+		for _, dlv := range result.Failed {
+			logger.Warn("webhook delivery failed",
+				slog.String("deliveryId", dlv.ID.String()),
+				slog.String("webhookId", dlv.SubscriptionID.String()),
+				slog.Int("failures", dlv.Failures),
+				slog.Time("nextAttempt", dlv.NextAttempt),
+				slog.String("error", dlv.Attempts[len(dlv.Attempts)-1].Error))
+		}
+		for _, dlv := range result.DeadLettered {
+			logger.Error("webhook delivery dead-lettered",
+				slog.String("deliveryId", dlv.ID.String()),
+				slog.String("webhookId", dlv.SubscriptionID.String()),
+				slog.Int("failures", dlv.Failures),
+				slog.String("error", dlv.Attempts[len(dlv.Attempts)-1].Error))
+		}
+	}
+}
+
+// runDailyJobs runs end-of-day work once the UTC date changes: overdraft and savings interest are
+// accrued for the day just ended, inactive accounts are flagged dormant, expired holds are marked
+// as such, closing balances are snapshotted for balance histories, balances which don't match their
+// transactions are reported, and interest is charged or paid when a new month starts. Each job is
+// safe to repeat for the same day, and runs whether or not the ones before it failed; a failure is
+// logged and the day is not run again.
+func runDailyJobs(logger *slog.Logger, acctSvc *accounts.AccountService, tanSvc *transactions.TransactionService, interestSvc *interest.InterestService) {
+	lastRun := time.Now().UTC()
+	for now := range time.Tick(time.Minute) {
+		now = now.UTC()
+		if sameDate(now, lastRun) {
+			continue
+		}
+		err := acctSvc.AccrueOverdraftInterest(lastRun)
+		if err != nil {
+			logger.Error(fmt.Errorf("error accruing overdraft interest: %v", err).Error())
+		}
+		_, err = interestSvc.AccrueDay(lastRun)
+		if err != nil {
+			logger.Error(fmt.Errorf("error accruing interest: %v", err).Error())
+		}
+		flagged, err := acctSvc.FlagDormantAccounts(now)
+		if err != nil {
+			logger.Error(fmt.Errorf("error flagging dormant accounts: %v", err).Error())
+		} else {
+			logger.Info("flagged dormant accounts", slog.Int("accounts", len(flagged)))
+		}
+		expired, err := acctSvc.ExpireHolds(now)
+		if err != nil {
+			logger.Error(fmt.Errorf("error expiring holds: %v", err).Error())
+		} else {
+			logger.Info("expired holds", slog.Int("holds", len(expired)))
+		}
+		snapshots, err := tanSvc.TakeBalanceSnapshots(now)
+		if err != nil {
+			logger.Error(fmt.Errorf("error taking balance snapshots: %v", err).Error())
+		} else {
+			logger.Info("took balance snapshots", slog.Int("snapshots", snapshots))
+		}
+		report, err := tanSvc.Reconcile(false, transactions.SystemUserID)
+		if err != nil {
+			logger.Error(fmt.Errorf("error reconciling balances: %v", err).Error())
+		} else {
+			for _, d := range report.Discrepancies {
+				logger.Warn("balance does not match transactions",
+					slog.String("accountNumber", d.AccountNumber.String()),
+					slog.Float64("storedBalance", d.StoredBalance),
+					slog.Float64("ledgerBalance", d.LedgerBalance),
+					slog.Float64("difference", d.Difference))
+			}
+			logger.Info("reconciled balances", slog.Int("accounts", report.AccountsChecked), slog.Int("discrepancies", len(report.Discrepancies)))
+		}
+		if now.Month() != lastRun.Month() {
+			charged, err := tanSvc.ChargeOverdraftInterest()
+			if err != nil {
+				logger.Error(fmt.Errorf("error charging overdraft interest: %v", err).Error())
+			} else {
+				logger.Info("charged overdraft interest", slog.Int("accounts", len(charged)))
+			}
+			paid, err := interestSvc.PostMonth(lastRun.Year(), lastRun.Month())
+			if err != nil {
+				logger.Error(fmt.Errorf("error posting interest: %v", err).Error())
+			} else {
+				logger.Info("posted interest", slog.Int("accounts", len(paid)))
+			}
+		}
+		lastRun = now
+	}
+}
+
+// sameDate reports whether a and b fall on the same calendar day, both being in UTC.
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
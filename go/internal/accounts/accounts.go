@@ -1,50 +1,190 @@
 package accounts
 
 import (
+	"eaglebank/internal/events"
 	"eaglebank/internal/users"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 )
 
 type AccountStore interface {
 	GetByAcctNum(acctNum AccountNumber) (BankAccount, error)
 	GetByUserID(userID users.UserID) ([]BankAccount, error)
-	Put(acct BankAccount) error
+	List() ([]BankAccount, error)
+	// Create stores a new account, failing with ErrAccountNumberTaken rather than overwriting
+	// an existing account with the same number. Create and Put store the events describing the
+	// change along with it, all or nothing.
+	Create(acct BankAccount, evts ...events.Event) error
+	Put(acct BankAccount, evts ...events.Event) error
+	// PutAllWith checks the accounts' versions, then calls write and stores the accounts only if
+	// it succeeds, so a write to another store and the accounts are kept together or not at all.
+	PutAllWith(write func() error, accts ...BankAccount) error
 	Delete(acctNum AccountNumber) error
 }
 
+type HolderChangeStore interface {
+	Get(id HolderChangeID) (HolderChange, error)
+	GetByAcctNum(acctNum AccountNumber) ([]HolderChange, error)
+	GetByUserID(userID users.UserID) ([]HolderChange, error)
+	Put(change HolderChange) error
+}
+
+type OverdraftApplicationStore interface {
+	Get(id OverdraftApplicationID) (OverdraftApplication, error)
+	GetByAcctNum(acctNum AccountNumber) ([]OverdraftApplication, error)
+	GetByStatus(status OverdraftApplicationStatus) ([]OverdraftApplication, error)
+	Put(app OverdraftApplication) error
+}
+
+type userStore interface {
+	Get(id users.UserID) (users.User, error)
+}
+
 type AccountService struct {
-	accountStore AccountStore
+	accountStore   AccountStore
+	userStore      userStore
+	changeStore    HolderChangeStore
+	overdraftStore OverdraftApplicationStore
+	branches       BranchRegistry
+	dormancyMonths int
+	limits         LimitConfig
+	bank           Bank
+	executor       *Executor
+	acctNums       *AccountNumberPool
 }
 
-func NewAccountService(acctStore AccountStore) *AccountService {
-	return &AccountService{accountStore: acctStore}
+type AccountServiceOption func(*AccountService)
+
+// WithBranches replaces the default registry, which only holds HeadOffice.
+func WithBranches(branches BranchRegistry) AccountServiceOption {
+	return func(svc *AccountService) {
+		svc.branches = branches
+	}
+}
+
+// WithDormancyPeriod changes how many months without a customer transaction it takes for an
+// account to be flagged dormant, from DefaultDormancyMonths.
+func WithDormancyPeriod(months int) AccountServiceOption {
+	return func(svc *AccountService) {
+		svc.dormancyMonths = months
+	}
+}
+
+// WithLimitConfig replaces DefaultLimits.
+func WithLimitConfig(cfg LimitConfig) AccountServiceOption {
+	return func(svc *AccountService) {
+		svc.limits = cfg
+	}
+}
+
+// WithBank replaces DefaultBank, the bank code is used in the IBANs of new accounts.
+func WithBank(bank Bank) AccountServiceOption {
+	return func(svc *AccountService) {
+		svc.bank = bank
+	}
+}
+
+// WithExecutor runs the commands which change an account on its shard of the executor, so they are
+// applied one at a time in the order they arrive. Every service which changes accounts should share
+// the same executor. Without one, conflicting writes fail with ErrConflict.
+func WithExecutor(exec *Executor) AccountServiceOption {
+	return func(svc *AccountService) {
+		svc.executor = exec
+	}
+}
+
+// WithAccountNumberPool replaces the pool new account numbers are taken from, which by default
+// covers every account number.
+func WithAccountNumberPool(pool *AccountNumberPool) AccountServiceOption {
+	return func(svc *AccountService) {
+		svc.acctNums = pool
+	}
+}
+
+func NewAccountService(acctStore AccountStore, usrStore userStore, changeStore HolderChangeStore, overdraftStore OverdraftApplicationStore, opts ...AccountServiceOption) *AccountService {
+	svc := &AccountService{
+		accountStore:   acctStore,
+		userStore:      usrStore,
+		changeStore:    changeStore,
+		overdraftStore: overdraftStore,
+		branches:       BranchRegistry{branches: []Branch{HeadOffice}},
+		dormancyMonths: DefaultDormancyMonths,
+		limits:         DefaultLimits,
+		bank:           DefaultBank,
+		acctNums:       NewAccountNumberPool(0, 99999),
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
 func (svc *AccountService) CreateAccount(req CreateAccountRequest) (BankAccount, error) {
 	if !req.IsValid() {
 		return BankAccount{}, fmt.Errorf("invalid create account request %+v", req)
 	}
-	acctNum, err := NewRandAccountNumber()
+	usr, err := svc.fetchHolderUser(req.UserID)
 	if err != nil {
-		return BankAccount{}, fmt.Errorf("error generating account number %w", err)
+		return BankAccount{}, err
 	}
-	acct, err := NewBankAccount(
-		req.UserID,
-		acctNum,
-		"10-10-10",
-		req.Name,
-		req.AccountType,
-		GBP,
-	)
+	profile, err := svc.limits.Resolve(req.AccountType, usr.Tier)
 	if err != nil {
-		return BankAccount{}, fmt.Errorf("invalid bank account details")
+		return BankAccount{}, err
+	}
+	curr := req.Currency
+	if curr == "" {
+		curr = GBP
+	}
+	var branch Branch
+	if req.SortCode != "" {
+		branch, err = svc.branches.Get(req.SortCode)
+	} else {
+		branch, err = svc.branches.Default()
 	}
-	err = svc.accountStore.Put(acct)
 	if err != nil {
-		return BankAccount{}, fmt.Errorf("error creating bank account %w", err)
+		return BankAccount{}, err
+	}
+	for {
+		acctNum, err := svc.acctNums.Next()
+		if errors.Is(err, ErrAccountNumbersExhausted) {
+			return BankAccount{}, err
+		}
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("error generating account number %w", err)
+		}
+		iban, err := svc.bank.IBAN(branch.SortCode, acctNum)
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("error generating IBAN %w", err)
+		}
+		acct, err := NewBankAccount(
+			req.UserID,
+			acctNum,
+			branch.SortCode,
+			req.Name,
+			req.AccountType,
+			curr,
+			WithBusiness(req.Business),
+			WithLimits(profile),
+			WithIBAN(iban),
+		)
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("invalid bank account details")
+		}
+		evt, err := publishedEvent(events.AccountOpened, acct, acct.HolderIDs(), accountData(acct))
+		if err != nil {
+			return BankAccount{}, err
+		}
+		err = svc.accountStore.Create(acct, evt)
+		if errors.Is(err, ErrAccountNumberTaken) {
+			continue
+		}
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("error creating bank account %w", err)
+		}
+		return acct, nil
 	}
-	return acct, nil
 }
 
 func (svc *AccountService) ListAccounts(id users.UserID) ([]BankAccount, error) {
@@ -68,3 +208,713 @@ func (svc *AccountService) FetchAccount(acctNum AccountNumber) (BankAccount, err
 	}
 	return acct, nil
 }
+
+// FetchAccountByBankDetails looks an account up by its sort code and account number together, an
+// account number under the wrong sort code is not found.
+func (svc *AccountService) FetchAccountByBankDetails(sortCode SortCode, acctNum AccountNumber) (BankAccount, error) {
+	_, err := svc.branches.Get(sortCode)
+	if err != nil {
+		return BankAccount{}, err
+	}
+	acct, err := svc.FetchAccount(acctNum)
+	if err != nil {
+		return BankAccount{}, err
+	}
+	if acct.SortCode != sortCode {
+		return BankAccount{}, ErrAccountNotFound
+	}
+	return acct, nil
+}
+
+func (svc *AccountService) ListBranches() []Branch {
+	return svc.branches.List()
+}
+
+func (svc *AccountService) Bank() Bank {
+	return svc.bank
+}
+
+// RequestAddHolder invites another user to join the account as a secondary holder. The invitee
+// must approve the change before they are added.
+func (svc *AccountService) RequestAddHolder(acctNum AccountNumber, requestedBy, userID users.UserID, conds ...Precondition) (HolderChange, error) {
+	// Run on the account's shard, so two invitations for the same user can't both find none pending.
+	return Submit(svc.executor, acctNum, func() (HolderChange, error) {
+		acct, err := svc.fetchHeldAccount(acctNum, requestedBy)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		if acct.IsHolder(userID) {
+			return HolderChange{}, ErrAlreadyHolder
+		}
+		err = svc.checkUserCanHold(userID)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		_, found, err := svc.findPendingChange(acctNum, AddHolderChange, userID)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		if found {
+			return HolderChange{}, ErrHolderChangePending
+		}
+		change, err := NewHolderChange(acctNum, AddHolderChange, userID, SecondaryHolder, requestedBy)
+		if err != nil {
+			return HolderChange{}, fmt.Errorf("error creating holder change %w", err)
+		}
+		return svc.saveChange(change)
+	})
+}
+
+// RequestRemoveHolder records the requester's consent to removing a holder, opening a removal if
+// none is pending. The holder is removed once every remaining holder has consented.
+func (svc *AccountService) RequestRemoveHolder(acctNum AccountNumber, requestedBy, userID users.UserID, conds ...Precondition) (HolderChange, error) {
+	return Submit(svc.executor, acctNum, func() (HolderChange, error) {
+		acct, err := svc.fetchHeldAccount(acctNum, requestedBy)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		if !acct.IsHolder(userID) {
+			return HolderChange{}, ErrNotHolder
+		}
+		if len(acct.Holders) == 1 {
+			return HolderChange{}, ErrLastHolder
+		}
+		change, found, err := svc.findPendingChange(acctNum, RemoveHolderChange, userID)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		if !found {
+			change, err = NewHolderChange(acctNum, RemoveHolderChange, userID, "", requestedBy)
+			if err != nil {
+				return HolderChange{}, fmt.Errorf("error creating holder change %w", err)
+			}
+		}
+		if requestedBy == userID {
+			return svc.saveChange(change)
+		}
+		return svc.approve(acct, change, requestedBy, conds...)
+	})
+}
+
+func (svc *AccountService) ApproveHolderChange(id HolderChangeID, userID users.UserID) (HolderChange, error) {
+	change, _, err := svc.fetchPendingChange(id, userID)
+	if err != nil {
+		return HolderChange{}, err
+	}
+	// The change is read again on the account's shard, in case another decision got there first.
+	return Submit(svc.executor, change.AccountNumber, func() (HolderChange, error) {
+		change, acct, err := svc.fetchPendingChange(id, userID)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		return svc.approve(acct, change, userID)
+	})
+}
+
+func (svc *AccountService) RejectHolderChange(id HolderChangeID, userID users.UserID) (HolderChange, error) {
+	change, _, err := svc.fetchPendingChange(id, userID)
+	if err != nil {
+		return HolderChange{}, err
+	}
+	return Submit(svc.executor, change.AccountNumber, func() (HolderChange, error) {
+		change, _, err := svc.fetchPendingChange(id, userID)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		change.Status = RejectedChange
+		change.UpdatedTimestamp = time.Now()
+		return svc.saveChange(change)
+	})
+}
+
+// ListPendingHolderChanges returns the changes still waiting on the user's approval, both
+// invitations to them and removals from accounts they hold.
+func (svc *AccountService) ListPendingHolderChanges(userID users.UserID) ([]HolderChange, error) {
+	changes, err := svc.changeStore.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing holder changes %w", err)
+	}
+	accts, err := svc.ListAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, acct := range accts {
+		acctChanges, err := svc.changeStore.GetByAcctNum(acct.AccountNumber)
+		if err != nil {
+			return nil, fmt.Errorf("error listing holder changes %w", err)
+		}
+		changes = append(changes, acctChanges...)
+	}
+
+	var pending []HolderChange
+	for _, change := range changes {
+		if change.Status != PendingChange || slices.ContainsFunc(pending, func(c HolderChange) bool { return c.ID == change.ID }) {
+			continue
+		}
+		acct, err := svc.FetchAccount(change.AccountNumber)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(change.PendingApprovers(acct), userID) {
+			pending = append(pending, change)
+		}
+	}
+	return pending, nil
+}
+
+// ApplyForOverdraft records a holder's request for an overdraft limit, which staff then approve
+// or reject. Only one application per account can be pending at a time, which is checked on the
+// account's shard so two applications made at once can't both find none pending.
+func (svc *AccountService) ApplyForOverdraft(acctNum AccountNumber, userID users.UserID, limit float64, conds ...Precondition) (OverdraftApplication, error) {
+	return Submit(svc.executor, acctNum, func() (OverdraftApplication, error) {
+		acct, err := svc.fetchHeldAccount(acctNum, userID)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		apps, err := svc.overdraftStore.GetByAcctNum(acctNum)
+		if err != nil {
+			return OverdraftApplication{}, fmt.Errorf("error listing overdraft applications %w", err)
+		}
+		if slices.ContainsFunc(apps, func(a OverdraftApplication) bool { return a.Status == PendingOverdraft }) {
+			return OverdraftApplication{}, ErrOverdraftApplicationPending
+		}
+		app, err := NewOverdraftApplication(acct, limit, userID)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		return svc.saveOverdraftApplication(app)
+	})
+}
+
+// ApproveOverdraft sets the account's overdraft to the limit applied for, at DefaultOverdraftRate.
+// Staff cannot decide an application they made or one on an account they hold. The account and
+// the decided application are stored together, so neither is saved without the other.
+func (svc *AccountService) ApproveOverdraft(id OverdraftApplicationID, staffID users.UserID) (OverdraftApplication, error) {
+	app, err := svc.fetchPendingOverdraftApplication(id)
+	if err != nil {
+		return OverdraftApplication{}, err
+	}
+	// The application is read again on the account's shard, in case it was decided in between.
+	return Submit(svc.executor, app.AccountNumber, func() (OverdraftApplication, error) {
+		app, err := svc.fetchPendingOverdraftApplication(id)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		acct, err := svc.fetchOpenAccount(app.AccountNumber)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		err = checkOverdraftDecider(app, acct, staffID)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		acct, err = acct.WithOverdraft(Overdraft{Limit: app.Limit, AnnualRate: DefaultOverdraftRate})
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		acct.UpdatedTimestamp = time.Now()
+		acct.Version++
+		app = app.decide(ApprovedOverdraft, staffID)
+		err = svc.accountStore.PutAllWith(func() error {
+			_, err := svc.saveOverdraftApplication(app)
+			return err
+		}, acct)
+		if err != nil {
+			return OverdraftApplication{}, fmt.Errorf("error updating bank account %w", err)
+		}
+		return app, nil
+	})
+}
+
+func (svc *AccountService) RejectOverdraft(id OverdraftApplicationID, staffID users.UserID) (OverdraftApplication, error) {
+	app, err := svc.fetchPendingOverdraftApplication(id)
+	if err != nil {
+		return OverdraftApplication{}, err
+	}
+	return Submit(svc.executor, app.AccountNumber, func() (OverdraftApplication, error) {
+		app, err := svc.fetchPendingOverdraftApplication(id)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		acct, err := svc.FetchAccount(app.AccountNumber)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		err = checkOverdraftDecider(app, acct, staffID)
+		if err != nil {
+			return OverdraftApplication{}, err
+		}
+		return svc.saveOverdraftApplication(app.decide(RejectedOverdraft, staffID))
+	})
+}
+
+func (svc *AccountService) ListPendingOverdraftApplications() ([]OverdraftApplication, error) {
+	apps, err := svc.overdraftStore.GetByStatus(PendingOverdraft)
+	if err != nil {
+		return nil, fmt.Errorf("error listing overdraft applications %w", err)
+	}
+	return apps, nil
+}
+
+// AccrueOverdraftInterest accrues a day's debit interest on every overdrawn account. It is run once
+// a day after close of business, running it again for the same day has no effect.
+func (svc *AccountService) AccrueOverdraftInterest(day time.Time) error {
+	accts, err := svc.accountStore.List()
+	if err != nil {
+		return fmt.Errorf("error listing bank accounts %w", err)
+	}
+	for _, listed := range accts {
+		err = svc.executor.Do(listed.AccountNumber, func() error {
+			acct, err := svc.FetchAccount(listed.AccountNumber)
+			if err != nil || acct.Balance() >= 0 {
+				return err
+			}
+			acct = acct.AccrueOverdraftInterest(day)
+			acct.Version++
+			err = svc.accountStore.Put(acct)
+			if err != nil {
+				return fmt.Errorf("error updating bank account %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChangeAccountStatus is used by staff to freeze, reactivate or close an account.
+func (svc *AccountService) ChangeAccountStatus(acctNum AccountNumber, to AccountStatus, reason StatusReason, staffID users.UserID, conds ...Precondition) (BankAccount, error) {
+	return Submit(svc.executor, acctNum, func() (BankAccount, error) {
+		acct, err := svc.FetchAccount(acctNum)
+		if err != nil {
+			return BankAccount{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return BankAccount{}, err
+		}
+		acct, err = acct.ChangeStatus(to, reason, staffID)
+		if err != nil {
+			return BankAccount{}, err
+		}
+		evt, err := statusChangedEvent(acct)
+		if err != nil {
+			return BankAccount{}, err
+		}
+		acct.Version++
+		err = PreconditionError(svc.accountStore.Put(acct, evt), conds...)
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("error updating bank account %w", err)
+		}
+		return acct, nil
+	})
+}
+
+// FlagDormantAccounts marks active accounts with no customer transaction in the dormancy period
+// as dormant and returns them. It is run daily, accounts already flagged are left alone.
+func (svc *AccountService) FlagDormantAccounts(now time.Time) ([]BankAccount, error) {
+	accts, err := svc.accountStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("error listing bank accounts %w", err)
+	}
+	cutoff := now.AddDate(0, -svc.dormancyMonths, 0)
+	var flagged []BankAccount
+	for _, listed := range accts {
+		err = svc.executor.Do(listed.AccountNumber, func() error {
+			acct, err := svc.FetchAccount(listed.AccountNumber)
+			if err != nil || acct.Status != ActiveStatus || !acct.LastCustomerActivity().Before(cutoff) {
+				return err
+			}
+			acct, err = acct.ChangeStatus(DormantStatus, InactivityReason, "")
+			if err != nil {
+				return err
+			}
+			evt, err := statusChangedEvent(acct)
+			if err != nil {
+				return err
+			}
+			acct.Version++
+			err = svc.accountStore.Put(acct, evt)
+			if err != nil {
+				return fmt.Errorf("error updating bank account %w", err)
+			}
+			flagged = append(flagged, acct)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return flagged, nil
+}
+
+// PlaceHold reserves part of the account's available balance, for example for a card
+// authorisation or a legal hold. No money moves until the hold is released or expires.
+func (svc *AccountService) PlaceHold(acctNum AccountNumber, amt float64, reason HoldReason, expires time.Time, staffID users.UserID, conds ...Precondition) (Hold, error) {
+	return Submit(svc.executor, acctNum, func() (Hold, error) {
+		acct, err := svc.fetchOpenAccount(acctNum)
+		if err != nil {
+			return Hold{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return Hold{}, err
+		}
+		acct, hold, err := acct.PlaceHold(amt, reason, expires, staffID)
+		if err != nil {
+			return Hold{}, err
+		}
+		acct.Version++
+		err = PreconditionError(svc.accountStore.Put(acct), conds...)
+		if err != nil {
+			return Hold{}, fmt.Errorf("error updating bank account %w", err)
+		}
+		return hold, nil
+	})
+}
+
+func (svc *AccountService) ReleaseHold(acctNum AccountNumber, id HoldID, staffID users.UserID, conds ...Precondition) (Hold, error) {
+	return Submit(svc.executor, acctNum, func() (Hold, error) {
+		acct, err := svc.fetchOpenAccount(acctNum)
+		if err != nil {
+			return Hold{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return Hold{}, err
+		}
+		acct, hold, err := acct.ReleaseHold(id, staffID)
+		if err != nil {
+			return Hold{}, err
+		}
+		acct.Version++
+		err = PreconditionError(svc.accountStore.Put(acct), conds...)
+		if err != nil {
+			return Hold{}, fmt.Errorf("error updating bank account %w", err)
+		}
+		return hold, nil
+	})
+}
+
+func (svc *AccountService) ListHolds(acctNum AccountNumber) ([]Hold, error) {
+	acct, err := svc.FetchAccount(acctNum)
+	if err != nil {
+		return nil, err
+	}
+	return acct.Holds, nil
+}
+
+// ExpireHolds marks every hold past its expiry as expired and returns them. Expired holds stop
+// reserving funds as soon as they expire, this only brings their status up to date.
+func (svc *AccountService) ExpireHolds(now time.Time) ([]Hold, error) {
+	accts, err := svc.accountStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("error listing bank accounts %w", err)
+	}
+	var expired []Hold
+	for _, listed := range accts {
+		err = svc.executor.Do(listed.AccountNumber, func() error {
+			acct, err := svc.FetchAccount(listed.AccountNumber)
+			if err != nil {
+				return err
+			}
+			acct, acctExpired := acct.ExpireHolds(now)
+			if len(acctExpired) == 0 {
+				return nil
+			}
+			acct.Version++
+			err = svc.accountStore.Put(acct)
+			if err != nil {
+				return fmt.Errorf("error updating bank account %w", err)
+			}
+			expired = append(expired, acctExpired...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expired, nil
+}
+
+func (svc *AccountService) CreatePot(acctNum AccountNumber, name string, goal float64, targetDate time.Time, conds ...Precondition) (Pot, error) {
+	return Submit(svc.executor, acctNum, func() (Pot, error) {
+		acct, err := svc.fetchOpenAccount(acctNum)
+		if err != nil {
+			return Pot{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return Pot{}, err
+		}
+		acct, pot, err := acct.CreatePot(name, goal, targetDate)
+		if err != nil {
+			return Pot{}, err
+		}
+		acct.Version++
+		err = PreconditionError(svc.accountStore.Put(acct), conds...)
+		if err != nil {
+			return Pot{}, fmt.Errorf("error updating bank account %w", err)
+		}
+		return pot, nil
+	})
+}
+
+func (svc *AccountService) ListPots(acctNum AccountNumber) ([]Pot, error) {
+	acct, err := svc.FetchAccount(acctNum)
+	if err != nil {
+		return nil, err
+	}
+	return acct.Pots, nil
+}
+
+func (svc *AccountService) FetchPot(acctNum AccountNumber, id PotID) (Pot, error) {
+	acct, err := svc.FetchAccount(acctNum)
+	if err != nil {
+		return Pot{}, err
+	}
+	return acct.Pot(id)
+}
+
+func (svc *AccountService) UpdatePot(acctNum AccountNumber, id PotID, update PotUpdate, conds ...Precondition) (Pot, error) {
+	return Submit(svc.executor, acctNum, func() (Pot, error) {
+		acct, err := svc.fetchOpenAccount(acctNum)
+		if err != nil {
+			return Pot{}, err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return Pot{}, err
+		}
+		acct, pot, err := acct.UpdatePot(id, update)
+		if err != nil {
+			return Pot{}, err
+		}
+		acct.Version++
+		err = PreconditionError(svc.accountStore.Put(acct), conds...)
+		if err != nil {
+			return Pot{}, fmt.Errorf("error updating bank account %w", err)
+		}
+		return pot, nil
+	})
+}
+
+// DeletePot removes an empty pot. Its transactions stay in the account's history.
+func (svc *AccountService) DeletePot(acctNum AccountNumber, id PotID, conds ...Precondition) error {
+	return svc.executor.Do(acctNum, func() error {
+		acct, err := svc.fetchOpenAccount(acctNum)
+		if err != nil {
+			return err
+		}
+		err = CheckPreconditions(acct, conds...)
+		if err != nil {
+			return err
+		}
+		acct, err = acct.DeletePot(id)
+		if err != nil {
+			return err
+		}
+		acct.Version++
+		err = PreconditionError(svc.accountStore.Put(acct), conds...)
+		if err != nil {
+			return fmt.Errorf("error updating bank account %w", err)
+		}
+		return nil
+	})
+}
+
+func (svc *AccountService) fetchPendingOverdraftApplication(id OverdraftApplicationID) (OverdraftApplication, error) {
+	app, err := svc.overdraftStore.Get(id)
+	if err != nil {
+		if errors.Is(err, ErrOverdraftApplicationNotFound) {
+			return OverdraftApplication{}, err
+		}
+		return OverdraftApplication{}, fmt.Errorf("error fetching overdraft application %w", err)
+	}
+	if app.Status != PendingOverdraft {
+		return OverdraftApplication{}, ErrOverdraftApplicationClosed
+	}
+	return app, nil
+}
+
+func checkOverdraftDecider(app OverdraftApplication, acct BankAccount, staffID users.UserID) error {
+	if app.RequestedBy == staffID || acct.IsHolder(staffID) {
+		return ErrOwnOverdraftApplication
+	}
+	return nil
+}
+
+func (svc *AccountService) saveOverdraftApplication(app OverdraftApplication) (OverdraftApplication, error) {
+	err := svc.overdraftStore.Put(app)
+	if err != nil {
+		return OverdraftApplication{}, fmt.Errorf("error saving overdraft application %w", err)
+	}
+	return app, nil
+}
+
+func (svc *AccountService) approve(acct BankAccount, change HolderChange, userID users.UserID, conds ...Precondition) (HolderChange, error) {
+	change = change.Approve(userID)
+	if len(change.PendingApprovers(acct)) == 0 {
+		updated, err := change.apply(acct)
+		if err != nil {
+			return HolderChange{}, err
+		}
+		updated.UpdatedTimestamp = time.Now()
+		updated.Version++
+		// A removed holder is told too.
+		notify := acct.HolderIDs()
+		if !slices.Contains(notify, change.UserID) {
+			notify = append(notify, change.UserID)
+		}
+		evt, err := publishedEvent(events.AccountHoldersChanged, updated, notify, accountData(updated))
+		if err != nil {
+			return HolderChange{}, err
+		}
+		err = PreconditionError(svc.accountStore.Put(updated, evt), conds...)
+		if err != nil {
+			return HolderChange{}, fmt.Errorf("error updating bank account %w", err)
+		}
+		change.Status = CompletedChange
+	}
+	return svc.saveChange(change)
+}
+
+func (svc *AccountService) fetchPendingChange(id HolderChangeID, userID users.UserID) (HolderChange, BankAccount, error) {
+	change, err := svc.changeStore.Get(id)
+	if err != nil {
+		if errors.Is(err, ErrHolderChangeNotFound) {
+			return HolderChange{}, BankAccount{}, err
+		}
+		return HolderChange{}, BankAccount{}, fmt.Errorf("error fetching holder change %w", err)
+	}
+	acct, err := svc.fetchOpenAccount(change.AccountNumber)
+	if err != nil {
+		return HolderChange{}, BankAccount{}, err
+	}
+	if !slices.Contains(change.RequiredApprovers(acct), userID) {
+		return HolderChange{}, BankAccount{}, ErrNotApprover
+	}
+	if change.Status != PendingChange {
+		return HolderChange{}, BankAccount{}, ErrHolderChangeClosed
+	}
+	return change, acct, nil
+}
+
+func (svc *AccountService) findPendingChange(acctNum AccountNumber, kind HolderChangeKind, userID users.UserID) (HolderChange, bool, error) {
+	changes, err := svc.changeStore.GetByAcctNum(acctNum)
+	if err != nil {
+		return HolderChange{}, false, fmt.Errorf("error listing holder changes %w", err)
+	}
+	for _, change := range changes {
+		if change.Status == PendingChange && change.Kind == kind && change.UserID == userID {
+			return change, true, nil
+		}
+	}
+	return HolderChange{}, false, nil
+}
+
+// ApplyTier resolves the limits for a tier on every open account the user is the primary holder
+// of. It is called after the user's tier changes.
+func (svc *AccountService) ApplyTier(userID users.UserID, tier users.Tier) ([]BankAccount, error) {
+	if !tier.IsValid() {
+		return nil, fmt.Errorf("invalid tier %q", tier)
+	}
+	accts, err := svc.ListAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	updated := []BankAccount{}
+	for _, listed := range accts {
+		err = svc.executor.Do(listed.AccountNumber, func() error {
+			acct, err := svc.FetchAccount(listed.AccountNumber)
+			if err != nil || acct.PrimaryHolder() != userID || acct.Status == ClosedStatus {
+				return err
+			}
+			profile, err := svc.limits.Resolve(acct.AccountType, tier)
+			if err != nil {
+				return err
+			}
+			acct, err = acct.ChangeLimits(profile)
+			if err != nil {
+				return err
+			}
+			acct.UpdatedTimestamp = time.Now()
+			acct.Version++
+			err = svc.accountStore.Put(acct)
+			if err != nil {
+				return fmt.Errorf("error updating account limits %w", err)
+			}
+			updated = append(updated, acct)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+func (svc *AccountService) fetchHeldAccount(acctNum AccountNumber, userID users.UserID) (BankAccount, error) {
+	acct, err := svc.fetchOpenAccount(acctNum)
+	if err != nil {
+		return BankAccount{}, err
+	}
+	if !acct.IsHolder(userID) {
+		return BankAccount{}, ErrNotHolder
+	}
+	return acct, nil
+}
+
+// fetchOpenAccount fetches an account that can still be changed, closed accounts are read-only.
+func (svc *AccountService) fetchOpenAccount(acctNum AccountNumber) (BankAccount, error) {
+	acct, err := svc.FetchAccount(acctNum)
+	if err != nil {
+		return BankAccount{}, err
+	}
+	if acct.Status == ClosedStatus {
+		return BankAccount{}, ErrAccountClosed
+	}
+	return acct, nil
+}
+
+func (svc *AccountService) checkUserCanHold(userID users.UserID) error {
+	_, err := svc.fetchHolderUser(userID)
+	return err
+}
+
+// fetchHolderUser fetches a user who is allowed to hold accounts.
+func (svc *AccountService) fetchHolderUser(userID users.UserID) (users.User, error) {
+	usr, err := svc.userStore.Get(userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			return users.User{}, err
+		}
+		return users.User{}, fmt.Errorf("error fetching user %w", err)
+	}
+	if !usr.EmailVerified {
+		return users.User{}, users.ErrEmailNotVerified
+	}
+	return usr, nil
+}
+
+func (svc *AccountService) saveChange(change HolderChange) (HolderChange, error) {
+	err := svc.changeStore.Put(change)
+	if err != nil {
+		return HolderChange{}, fmt.Errorf("error saving holder change %w", err)
+	}
+	return change, nil
+}
@@ -3,9 +3,15 @@ package accounts_test
 import (
 	"eaglebank/internal/accounts"
 	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/events"
+	adapters3 "eaglebank/internal/events/adapters"
+	"eaglebank/internal/limits"
 	"eaglebank/internal/users"
+	adapters2 "eaglebank/internal/users/adapters"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,7 +20,8 @@ import (
 func TestAccountService(t *testing.T) {
 	t.Run("create account", func(t *testing.T) {
 		store := adapters.NewInMemoryAccountStore()
-		svc := accounts.NewAccountService(store)
+		usrStore := newVerifiedUserStore(t, "usr-123")
+		svc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 		t.Run("should successfully create account", func(t *testing.T) {
 			req := accounts.CreateAccountRequest{
 				UserID:      "usr-123",
@@ -27,6 +34,25 @@ func TestAccountService(t *testing.T) {
 			retAcct, err := store.GetByAcctNum(acct.AccountNumber)
 			require.NoError(t, err)
 			assert.Equal(t, acct, retAcct)
+
+			wantIBAN, err := accounts.NewGBIBAN("EAGL", accounts.HeadOffice.SortCode, acct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, wantIBAN, acct.IBAN)
+		})
+		t.Run("should retry when the account number is taken", func(t *testing.T) {
+			collidingStore := &collidingAccountStore{InMemoryAccountStore: adapters.NewInMemoryAccountStore(), collisions: 3}
+			collidingSvc := accounts.NewAccountService(collidingStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+			acct, err := collidingSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+			require.NoError(t, err)
+			assert.Equal(t, 4, collidingStore.attempts)
+			assert.True(t, acct.AccountNumber.IsValid())
+		})
+		t.Run("should give up when every account number is taken", func(t *testing.T) {
+			collidingStore := &collidingAccountStore{InMemoryAccountStore: adapters.NewInMemoryAccountStore(), collisions: 1000}
+			collidingSvc := accounts.NewAccountService(collidingStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore(), accounts.WithAccountNumberPool(accounts.NewAccountNumberPool(0, 99)))
+			_, err := collidingSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+			assert.ErrorIs(t, err, accounts.ErrAccountNumbersExhausted)
+			assert.Less(t, collidingStore.attempts, 100, "each number should be tried once")
 		})
 		t.Run("should fail for invalid user", func(t *testing.T) {
 			req := accounts.CreateAccountRequest{
@@ -37,9 +63,29 @@ func TestAccountService(t *testing.T) {
 			_, err := svc.CreateAccount(req)
 			assert.Error(t, err)
 		})
+		t.Run("should fail for unverified user", func(t *testing.T) {
+			usr := newTestUser(t, "usr-unverified")
+			require.NoError(t, usrStore.Put(usr))
+			req := accounts.CreateAccountRequest{
+				UserID:      usr.ID,
+				Name:        "Mr Foo",
+				AccountType: accounts.PersonalAcct,
+			}
+			_, err := svc.CreateAccount(req)
+			assert.ErrorIs(t, err, users.ErrEmailNotVerified)
+		})
+		t.Run("should fail for unknown user", func(t *testing.T) {
+			req := accounts.CreateAccountRequest{
+				UserID:      "usr-missing",
+				Name:        "Mr Foo",
+				AccountType: accounts.PersonalAcct,
+			}
+			_, err := svc.CreateAccount(req)
+			assert.ErrorIs(t, err, users.ErrUserNotFound)
+		})
 		t.Run("should fail if put fails", func(t *testing.T) {
 			failStore := newFailingAccountStore(t)
-			failSvc := accounts.NewAccountService(failStore)
+			failSvc := accounts.NewAccountService(failStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 			req := accounts.CreateAccountRequest{
 				UserID:      "usr-123",
 				Name:        "Mr Foo",
@@ -51,7 +97,8 @@ func TestAccountService(t *testing.T) {
 	})
 	t.Run("list accounts", func(t *testing.T) {
 		store := adapters.NewInMemoryAccountStore()
-		svc := accounts.NewAccountService(store)
+		usrStore := newVerifiedUserStore(t, "usr-123")
+		svc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 
 		userID := users.MustNewUserID("usr-123")
 		acct1, err := svc.CreateAccount(accounts.CreateAccountRequest{
@@ -81,14 +128,15 @@ func TestAccountService(t *testing.T) {
 		})
 		t.Run("should error if store errors for other reason", func(t *testing.T) {
 			failStore := newFailingAccountStore(t)
-			failSvc := accounts.NewAccountService(failStore)
+			failSvc := accounts.NewAccountService(failStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 			_, err = failSvc.ListAccounts(userID)
 			assert.Error(t, err)
 		})
 	})
 	t.Run("fetch account", func(t *testing.T) {
 		store := adapters.NewInMemoryAccountStore()
-		svc := accounts.NewAccountService(store)
+		usrStore := newVerifiedUserStore(t, "usr-123")
+		svc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 
 		userID := users.MustNewUserID("usr-123")
 		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{
@@ -110,11 +158,1058 @@ func TestAccountService(t *testing.T) {
 		})
 		t.Run("should error for any store error", func(t *testing.T) {
 			failStore := newFailingAccountStore(t)
-			failSvc := accounts.NewAccountService(failStore)
+			failSvc := accounts.NewAccountService(failStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 			_, err = failSvc.FetchAccount(acct.AccountNumber)
 			assert.Error(t, err)
 		})
 	})
+	t.Run("joint accounts", func(t *testing.T) {
+		store := adapters.NewInMemoryAccountStore()
+		usrStore := newVerifiedUserStore(t, "usr-alice", "usr-bob", "usr-carol")
+		require.NoError(t, usrStore.Put(newTestUser(t, "usr-unverified")))
+		svc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+		alice, bob, carol := users.UserID("usr-alice"), users.UserID("usr-bob"), users.UserID("usr-carol")
+
+		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: alice, Name: "Joint", AccountType: accounts.PersonalAcct})
+		require.NoError(t, err)
+		join := func(t *testing.T, userID users.UserID) {
+			t.Helper()
+			change, err := svc.RequestAddHolder(acct.AccountNumber, alice, userID)
+			require.NoError(t, err)
+			change, err = svc.ApproveHolderChange(change.ID, userID)
+			require.NoError(t, err)
+			require.Equal(t, accounts.CompletedChange, change.Status)
+		}
+
+		t.Run("should not add invitee until they accept", func(t *testing.T) {
+			change, err := svc.RequestAddHolder(acct.AccountNumber, alice, bob)
+			require.NoError(t, err)
+			assert.Equal(t, accounts.PendingChange, change.Status)
+
+			accts, err := svc.ListAccounts(bob)
+			require.NoError(t, err)
+			assert.Empty(t, accts)
+
+			pending, err := svc.ListPendingHolderChanges(bob)
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+			assert.Equal(t, change.ID, pending[0].ID)
+
+			_, err = svc.RequestAddHolder(acct.AccountNumber, alice, bob)
+			assert.ErrorIs(t, err, accounts.ErrHolderChangePending)
+		})
+		t.Run("should only let the invitee accept", func(t *testing.T) {
+			pending, err := svc.ListPendingHolderChanges(bob)
+			require.NoError(t, err)
+			_, err = svc.ApproveHolderChange(pending[0].ID, alice)
+			assert.ErrorIs(t, err, accounts.ErrNotApprover)
+		})
+		t.Run("should list joint account for every holder once accepted", func(t *testing.T) {
+			pending, err := svc.ListPendingHolderChanges(bob)
+			require.NoError(t, err)
+			change, err := svc.ApproveHolderChange(pending[0].ID, bob)
+			require.NoError(t, err)
+			assert.Equal(t, accounts.CompletedChange, change.Status)
+
+			for _, id := range []users.UserID{alice, bob} {
+				accts, err := svc.ListAccounts(id)
+				require.NoError(t, err)
+				require.Len(t, accts, 1)
+				assert.Equal(t, []users.UserID{alice, bob}, accts[0].HolderIDs())
+				assert.Equal(t, alice, accts[0].PrimaryHolder())
+			}
+
+			_, err = svc.ApproveHolderChange(change.ID, bob)
+			assert.ErrorIs(t, err, accounts.ErrHolderChangeClosed)
+		})
+		t.Run("should reject invites from non-holders and for existing or unverified users", func(t *testing.T) {
+			_, err := svc.RequestAddHolder(acct.AccountNumber, carol, carol)
+			assert.ErrorIs(t, err, accounts.ErrNotHolder)
+			_, err = svc.RequestAddHolder(acct.AccountNumber, alice, bob)
+			assert.ErrorIs(t, err, accounts.ErrAlreadyHolder)
+			_, err = svc.RequestAddHolder(acct.AccountNumber, alice, "usr-unverified")
+			assert.ErrorIs(t, err, users.ErrEmailNotVerified)
+		})
+		t.Run("should not add invitee who rejects", func(t *testing.T) {
+			change, err := svc.RequestAddHolder(acct.AccountNumber, bob, carol)
+			require.NoError(t, err)
+			change, err = svc.RejectHolderChange(change.ID, carol)
+			require.NoError(t, err)
+			assert.Equal(t, accounts.RejectedChange, change.Status)
+
+			gotAcct, err := svc.FetchAccount(acct.AccountNumber)
+			require.NoError(t, err)
+			assert.False(t, gotAcct.IsHolder(carol))
+		})
+		t.Run("should require every remaining holder to consent to a removal", func(t *testing.T) {
+			join(t, carol)
+
+			change, err := svc.RequestRemoveHolder(acct.AccountNumber, bob, alice)
+			require.NoError(t, err)
+			assert.Equal(t, accounts.PendingChange, change.Status)
+
+			pending, err := svc.ListPendingHolderChanges(carol)
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+
+			gotAcct, err := svc.FetchAccount(acct.AccountNumber)
+			require.NoError(t, err)
+			assert.True(t, gotAcct.IsHolder(alice))
+
+			_, err = svc.ApproveHolderChange(change.ID, alice)
+			assert.ErrorIs(t, err, accounts.ErrNotApprover)
+
+			change, err = svc.RequestRemoveHolder(acct.AccountNumber, carol, alice)
+			require.NoError(t, err)
+			assert.Equal(t, accounts.CompletedChange, change.Status)
+
+			gotAcct, err = svc.FetchAccount(acct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, []users.UserID{bob, carol}, gotAcct.HolderIDs())
+			assert.Equal(t, bob, gotAcct.PrimaryHolder(), "longest standing holder should be promoted")
+
+			accts, err := svc.ListAccounts(alice)
+			require.NoError(t, err)
+			assert.Empty(t, accts)
+		})
+		t.Run("should let a holder ask to leave but still need consent", func(t *testing.T) {
+			change, err := svc.RequestRemoveHolder(acct.AccountNumber, carol, carol)
+			require.NoError(t, err)
+			assert.Equal(t, accounts.PendingChange, change.Status)
+			assert.Empty(t, change.Approvals)
+
+			change, err = svc.ApproveHolderChange(change.ID, bob)
+			require.NoError(t, err)
+			assert.Equal(t, accounts.CompletedChange, change.Status)
+		})
+		t.Run("should not remove the last holder", func(t *testing.T) {
+			_, err := svc.RequestRemoveHolder(acct.AccountNumber, bob, bob)
+			assert.ErrorIs(t, err, accounts.ErrLastHolder)
+		})
+	})
+	t.Run("account types", func(t *testing.T) {
+		store := adapters.NewInMemoryAccountStore()
+		svc := accounts.NewAccountService(store, newVerifiedUserStore(t, "usr-123"), adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+		business := accounts.BusinessDetails{CompanyName: "Foo Ltd", RegistrationNumber: "SC123456"}
+		t.Run("should create every account type", func(t *testing.T) {
+			for _, acctType := range accounts.AccountTypes() {
+				req := accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: acctType}
+				if acctType == accounts.BusinessAcct {
+					req.Business = business
+				}
+				acct, err := svc.CreateAccount(req)
+				require.NoError(t, err, acctType)
+				assert.Equal(t, acctType, acct.AccountType)
+				assert.Equal(t, req.Business, acct.Business)
+			}
+		})
+		t.Run("should require business details for business account", func(t *testing.T) {
+			req := accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.BusinessAcct}
+			_, err := svc.CreateAccount(req)
+			assert.Error(t, err)
+
+			req.Business = accounts.BusinessDetails{CompanyName: "Foo Ltd", RegistrationNumber: "123"}
+			_, err = svc.CreateAccount(req)
+			assert.Error(t, err)
+		})
+		t.Run("should reject business details for other account types", func(t *testing.T) {
+			req := accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.CurrentAcct, Business: business}
+			_, err := svc.CreateAccount(req)
+			assert.Error(t, err)
+		})
+	})
+}
+
+func TestIBAN(t *testing.T) {
+	t.Run("should accept valid IBANs from other countries", func(t *testing.T) {
+		for _, s := range []string{"GB82WEST12345698765432", "DE89370400440532013000", "FR1420041010050500013M02606", "NL91ABNA0417164300"} {
+			assert.True(t, accounts.IBAN(s).IsValid(), s)
+		}
+	})
+	t.Run("should normalise the printed form", func(t *testing.T) {
+		iban, err := accounts.NewIBAN("gb82 west 1234 5698 7654 32")
+		require.NoError(t, err)
+		assert.Equal(t, accounts.IBAN("GB82WEST12345698765432"), iban)
+		assert.Equal(t, "GB", iban.Country())
+	})
+	t.Run("should reject bad check digits, lengths and countries", func(t *testing.T) {
+		for _, s := range []string{"GB83WEST12345698765432", "GB82WEST1234569876543", "XX82WEST12345698765432", ""} {
+			_, err := accounts.NewIBAN(s)
+			assert.Error(t, err, s)
+		}
+	})
+	t.Run("should generate a valid GB IBAN from the sort code and account number", func(t *testing.T) {
+		iban, err := accounts.NewGBIBAN("EAGL", "10-20-30", "01000004")
+		require.NoError(t, err)
+		assert.True(t, iban.IsValid())
+		assert.Regexp(t, `^GB\d{2}EAGL10203001000004$`, iban.String())
+
+		_, err = accounts.NewGBIBAN("EAG1", "10-20-30", "01000004")
+		assert.Error(t, err)
+	})
+	t.Run("should validate BICs and the bank config", func(t *testing.T) {
+		assert.True(t, accounts.BIC("EAGLGB2L").IsValid())
+		assert.True(t, accounts.BIC("DEUTDEFF500").IsValid())
+		assert.False(t, accounts.BIC("EAGLGB2").IsValid())
+		assert.True(t, accounts.DefaultBank.IsValid())
+		assert.False(t, accounts.Bank{Code: "WEST", BIC: "EAGLGB2L"}.IsValid())
+	})
+}
+
+func TestOverdraft(t *testing.T) {
+	newOverdrawnAccount := func(t *testing.T) accounts.BankAccount {
+		t.Helper()
+		acct, err := accounts.NewBankAccount("usr-123", "01000020", "10-10-10", "Mr Foo", accounts.PersonalAcct, accounts.GBP)
+		require.NoError(t, err)
+		acct, err = acct.WithOverdraft(accounts.Overdraft{Limit: 500, AnnualRate: 0.365})
+		require.NoError(t, err)
+		acct, err = acct.Withdraw(100)
+		require.NoError(t, err)
+		return acct
+	}
+	t.Run("should withdraw into the overdraft up to the limit", func(t *testing.T) {
+		acct := newOverdrawnAccount(t)
+		assert.Equal(t, -100.0, acct.Balance())
+		assert.Equal(t, 400.0, acct.AvailableBalance())
+		assert.Equal(t, 400.0, acct.AvailableOverdraft())
+
+		acct, err := acct.Withdraw(400)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, acct.AvailableBalance())
+
+		_, err = acct.Withdraw(0.01)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+	})
+	t.Run("should show the whole overdraft available when in credit", func(t *testing.T) {
+		acct := newOverdrawnAccount(t)
+		acct, err := acct.Deposit(150)
+		require.NoError(t, err)
+		assert.Equal(t, 550.0, acct.AvailableBalance())
+		assert.Equal(t, 500.0, acct.AvailableOverdraft())
+	})
+	t.Run("should refuse to go below zero without an overdraft", func(t *testing.T) {
+		acct, err := accounts.NewBankAccount("usr-123", "01000020", "10-10-10", "Mr Foo", accounts.PersonalAcct, accounts.GBP)
+		require.NoError(t, err)
+		_, err = acct.Withdraw(1)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+	})
+	t.Run("should reject invalid overdrafts", func(t *testing.T) {
+		acct, err := accounts.NewBankAccount("usr-123", "01000020", "10-10-10", "Mr Foo", accounts.PersonalAcct, accounts.GBP)
+		require.NoError(t, err)
+		for _, overdraft := range []accounts.Overdraft{
+			{Limit: -1},
+			{Limit: accounts.MaxOverdraftLimit + 1},
+			{Limit: 100.001},
+			{Limit: 100, AnnualRate: 1},
+		} {
+			_, err = acct.WithOverdraft(overdraft)
+			assert.Error(t, err, overdraft)
+		}
+	})
+	t.Run("should accrue daily interest on the overdrawn balance once per day", func(t *testing.T) {
+		acct := newOverdrawnAccount(t)
+		day := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+		acct = acct.AccrueOverdraftInterest(day)
+		acct = acct.AccrueOverdraftInterest(day.Add(time.Hour))
+		assert.InDelta(t, 0.1, acct.AccruedOverdraftInterest(), 1e-9)
+
+		acct = acct.AccrueOverdraftInterest(day.AddDate(0, 0, 1))
+		assert.InDelta(t, 0.2, acct.AccruedOverdraftInterest(), 1e-9)
+	})
+	t.Run("should not accrue interest on a balance in credit", func(t *testing.T) {
+		acct := newOverdrawnAccount(t)
+		acct, err := acct.Deposit(200)
+		require.NoError(t, err)
+		acct = acct.AccrueOverdraftInterest(time.Now())
+		assert.Equal(t, 0.0, acct.AccruedOverdraftInterest())
+	})
+	t.Run("should charge the rounded accrued interest to the balance", func(t *testing.T) {
+		acct := newOverdrawnAccount(t)
+		day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		for i := range 31 {
+			acct = acct.AccrueOverdraftInterest(day.AddDate(0, 0, i))
+		}
+		acct, charged := acct.ChargeOverdraftInterest()
+		assert.Equal(t, 3.1, charged)
+		assert.Equal(t, -103.1, acct.Balance())
+		assert.Equal(t, 0.0, acct.AccruedOverdraftInterest())
+	})
+}
+
+func TestBankAccountWithdrawalLimit(t *testing.T) {
+	newFundedAccount := func(t *testing.T, acctType accounts.AccountType) accounts.BankAccount {
+		t.Helper()
+		acct, err := accounts.NewBankAccount("usr-123", "01000020", "10-10-10", "Mr Foo", acctType, accounts.GBP)
+		require.NoError(t, err)
+		acct, err = acct.Deposit(100)
+		require.NoError(t, err)
+		return acct
+	}
+	t.Run("should allow 3 savings withdrawals a month", func(t *testing.T) {
+		acct := newFundedAccount(t, accounts.SavingsAcct)
+		var err error
+		for range 3 {
+			acct, err = acct.Withdraw(1)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, acct.MonthlyWithdrawals())
+
+		_, err = acct.Withdraw(1)
+		assert.ErrorIs(t, err, accounts.ErrWithdrawalLimitReached)
+	})
+	t.Run("should not count debits towards the limit", func(t *testing.T) {
+		acct := newFundedAccount(t, accounts.SavingsAcct)
+		var err error
+		for range 5 {
+			acct, err = acct.Debit(1)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 0, acct.MonthlyWithdrawals())
+		assert.Equal(t, 95.0, acct.Balance())
+	})
+	t.Run("should not limit current account withdrawals", func(t *testing.T) {
+		acct := newFundedAccount(t, accounts.CurrentAcct)
+		var err error
+		for range 5 {
+			acct, err = acct.Withdraw(1)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 95.0, acct.Balance())
+	})
+	t.Run("should count withdrawals in UTC months whatever their time zone", func(t *testing.T) {
+		// Just before midnight UTC at the end of January, which is already February two hours east.
+		beforeMidnight := time.Date(2026, time.January, 31, 23, 59, 0, 0, time.UTC)
+		east := time.FixedZone("UTC+2", 2*60*60)
+		west := time.FixedZone("UTC-5", -5*60*60)
+		acct := newFundedAccount(t, accounts.SavingsAcct)
+		var err error
+		for range 3 {
+			acct, err = acct.WithdrawAt(1, beforeMidnight.In(east))
+			require.NoError(t, err)
+		}
+
+		_, err = acct.WithdrawAt(1, beforeMidnight.Add(30*time.Second).In(west))
+		assert.ErrorIs(t, err, accounts.ErrWithdrawalLimitReached)
+
+		acct, err = acct.WithdrawAt(1, beforeMidnight.Add(90*time.Second).In(west))
+		require.NoError(t, err)
+		assert.Equal(t, 96.0, acct.Balance())
+	})
+}
+
+func TestLimits(t *testing.T) {
+	profile := limits.Profile{MaxBalance: 1000, MaxTransaction: 500, DailyWithdrawal: 300, MonthlyWithdrawal: 400}
+	newAccount := func(t *testing.T, balance float64) accounts.BankAccount {
+		t.Helper()
+		acct, err := accounts.NewBankAccount("usr-123", "01000004", "10-10-10", "Mr Foo", accounts.CurrentAcct, accounts.GBP, accounts.WithLimits(profile))
+		require.NoError(t, err)
+		for balance > 0 {
+			amt := min(balance, profile.MaxTransaction)
+			acct, err = acct.Deposit(amt)
+			require.NoError(t, err)
+			balance -= amt
+		}
+		return acct
+	}
+	assertExceeded := func(t *testing.T, err error, kind limits.Kind) {
+		t.Helper()
+		var exceeded *limits.ExceededError
+		require.ErrorAs(t, err, &exceeded)
+		assert.Equal(t, kind, exceeded.Limit)
+	}
+
+	t.Run("should default to the standard tier limits", func(t *testing.T) {
+		acct, err := accounts.NewBankAccount("usr-123", "01000004", "10-10-10", "Mr Foo", accounts.CurrentAcct, accounts.GBP)
+		require.NoError(t, err)
+		want, err := accounts.DefaultLimits.Resolve(accounts.CurrentAcct, users.StandardTier)
+		require.NoError(t, err)
+		assert.Equal(t, want, acct.Limits)
+	})
+	t.Run("should refuse deposits over the max balance", func(t *testing.T) {
+		_, err := newAccount(t, 500).Deposit(500.01)
+		assertExceeded(t, err, limits.MaxTransaction)
+		_, err = newAccount(t, 600).Deposit(400.01)
+		assertExceeded(t, err, limits.MaxBalance)
+	})
+	t.Run("should refuse debits over the max transaction", func(t *testing.T) {
+		_, err := newAccount(t, 1000).Debit(500.01)
+		assertExceeded(t, err, limits.MaxTransaction)
+	})
+	t.Run("should limit the total withdrawn in a day and a month", func(t *testing.T) {
+		acct := newAccount(t, 1000)
+		acct, err := acct.Withdraw(200)
+		require.NoError(t, err)
+		_, err = acct.Withdraw(100.01)
+		assertExceeded(t, err, limits.DailyWithdrawal)
+		acct, err = acct.Withdraw(100)
+		require.NoError(t, err)
+		assert.Equal(t, 300.0, acct.DailyWithdrawn())
+		assert.Equal(t, 300.0, acct.MonthlyWithdrawn())
+
+		_, err = acct.Debit(100)
+		require.NoError(t, err, "debits do not count as withdrawals")
+	})
+	t.Run("should start a new day's withdrawals at midnight UTC", func(t *testing.T) {
+		midnight := time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)
+		east := time.FixedZone("UTC+2", 2*60*60)
+		acct, err := newAccount(t, 1000).WithdrawAt(300, midnight.Add(-time.Minute))
+		require.NoError(t, err)
+		_, err = acct.WithdrawAt(0.01, midnight.Add(-time.Second).In(east))
+		assertExceeded(t, err, limits.DailyWithdrawal)
+		_, err = acct.WithdrawAt(100, midnight.Add(time.Second).In(east))
+		require.NoError(t, err)
+	})
+	t.Run("should not count failed withdrawals", func(t *testing.T) {
+		acct, err := newAccount(t, 50).Withdraw(100)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+		assert.Equal(t, 0.0, acct.DailyWithdrawn())
+	})
+	t.Run("should tighten account type limits by tier", func(t *testing.T) {
+		cfg := accounts.LimitConfig{
+			AccountTypes: map[accounts.AccountType]limits.Profile{},
+			Tiers:        map[users.Tier]limits.Profile{users.StandardTier: {MaxBalance: 100}},
+		}
+		for _, acctType := range accounts.AccountTypes() {
+			cfg.AccountTypes[acctType] = profile
+		}
+		require.True(t, cfg.IsValid())
+
+		standard, err := cfg.Resolve(accounts.CurrentAcct, users.StandardTier)
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, standard.MaxBalance)
+		assert.Equal(t, profile.MaxTransaction, standard.MaxTransaction)
+
+		premium, err := cfg.Resolve(accounts.CurrentAcct, users.PremiumTier)
+		require.NoError(t, err)
+		assert.Equal(t, profile, premium)
+	})
+	t.Run("should resolve limits for the holder's tier", func(t *testing.T) {
+		usrStore := newVerifiedUserStore(t, "usr-123")
+		store := adapters.NewInMemoryAccountStore()
+		svc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+		require.NoError(t, err)
+		standard, err := accounts.DefaultLimits.Resolve(accounts.PersonalAcct, users.StandardTier)
+		require.NoError(t, err)
+		assert.Equal(t, standard, acct.Limits)
+
+		updated, err := svc.ApplyTier("usr-123", users.PremiumTier)
+		require.NoError(t, err)
+		require.Len(t, updated, 1)
+		premium, err := accounts.DefaultLimits.Resolve(accounts.PersonalAcct, users.PremiumTier)
+		require.NoError(t, err)
+		gotAcct, err := svc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, premium, gotAcct.Limits)
+	})
+}
+
+func TestCompanyNumber(t *testing.T) {
+	for _, valid := range []string{"01234567", "SC123456", "NI000001"} {
+		_, err := accounts.NewCompanyNumber(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"", "1234567", "sc123456", "SC1234567", "ABC12345"} {
+		_, err := accounts.NewCompanyNumber(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestOverdraftApplications(t *testing.T) {
+	store := adapters.NewInMemoryAccountStore()
+	usrStore := newVerifiedUserStore(t, "usr-alice", "usr-bob")
+	svc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	newAccount := func(t *testing.T) accounts.BankAccount {
+		t.Helper()
+		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-alice", Name: "Mr Foo", AccountType: accounts.CurrentAcct})
+		require.NoError(t, err)
+		return acct
+	}
+
+	t.Run("should approve an overdraft at the default rate", func(t *testing.T) {
+		acct := newAccount(t)
+		app, err := svc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", 250)
+		require.NoError(t, err)
+		assert.Equal(t, accounts.PendingOverdraft, app.Status)
+
+		pending, err := svc.ListPendingOverdraftApplications()
+		require.NoError(t, err)
+		assert.Contains(t, pending, app)
+
+		app, err = svc.ApproveOverdraft(app.ID, "usr-teller")
+		require.NoError(t, err)
+		assert.Equal(t, accounts.ApprovedOverdraft, app.Status)
+		assert.Equal(t, users.UserID("usr-teller"), app.DecidedBy)
+
+		acct, err = svc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, accounts.Overdraft{Limit: 250, AnnualRate: accounts.DefaultOverdraftRate}, acct.Overdraft)
+
+		_, err = svc.ApproveOverdraft(app.ID, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrOverdraftApplicationClosed)
+	})
+	t.Run("should leave the account unchanged when rejected", func(t *testing.T) {
+		acct := newAccount(t)
+		app, err := svc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", 250)
+		require.NoError(t, err)
+		app, err = svc.RejectOverdraft(app.ID, "usr-teller")
+		require.NoError(t, err)
+		assert.Equal(t, accounts.RejectedOverdraft, app.Status)
+
+		acct, err = svc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.True(t, acct.Overdraft.IsZero())
+	})
+	t.Run("should allow only one pending application per account", func(t *testing.T) {
+		acct := newAccount(t)
+		_, err := svc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", 100)
+		require.NoError(t, err)
+		_, err = svc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", 200)
+		assert.ErrorIs(t, err, accounts.ErrOverdraftApplicationPending)
+	})
+	t.Run("should fail for a user who does not hold the account", func(t *testing.T) {
+		acct := newAccount(t)
+		_, err := svc.ApplyForOverdraft(acct.AccountNumber, "usr-bob", 100)
+		assert.ErrorIs(t, err, accounts.ErrNotHolder)
+	})
+	t.Run("should fail for a limit above the maximum", func(t *testing.T) {
+		acct := newAccount(t)
+		_, err := svc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", accounts.MaxOverdraftLimit+1)
+		assert.ErrorIs(t, err, accounts.ErrInvalidOverdraftLimit)
+	})
+	t.Run("should not let staff decide an application on an account they hold", func(t *testing.T) {
+		acct := newAccount(t)
+		app, err := svc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", 100)
+		require.NoError(t, err)
+
+		_, err = svc.ApproveOverdraft(app.ID, "usr-alice")
+		assert.ErrorIs(t, err, accounts.ErrOwnOverdraftApplication)
+		_, err = svc.RejectOverdraft(app.ID, "usr-alice")
+		assert.ErrorIs(t, err, accounts.ErrOwnOverdraftApplication)
+
+		acct, err = svc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.True(t, acct.Overdraft.IsZero())
+	})
+	t.Run("should leave the account unchanged when the decision can't be saved", func(t *testing.T) {
+		appStore := &failingOverdraftApplicationStore{InMemoryOverdraftApplicationStore: adapters.NewInMemoryOverdraftApplicationStore()}
+		failSvc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), appStore)
+		acct := newAccount(t)
+		app, err := failSvc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", 250)
+		require.NoError(t, err)
+
+		appStore.fail = true
+		_, err = failSvc.ApproveOverdraft(app.ID, "usr-teller")
+		assert.ErrorIs(t, err, errSaveFailed)
+		got, err := svc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, acct, got)
+	})
+	t.Run("should fail for an unknown application", func(t *testing.T) {
+		_, err := svc.ApproveOverdraft("ovd-missing", "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrOverdraftApplicationNotFound)
+	})
+	t.Run("should accrue interest on overdrawn accounts only", func(t *testing.T) {
+		overdrawn := newAccount(t)
+		app, err := svc.ApplyForOverdraft(overdrawn.AccountNumber, "usr-alice", 500)
+		require.NoError(t, err)
+		_, err = svc.ApproveOverdraft(app.ID, "usr-teller")
+		require.NoError(t, err)
+		overdrawn, err = svc.FetchAccount(overdrawn.AccountNumber)
+		require.NoError(t, err)
+		overdrawn, err = overdrawn.Withdraw(100)
+		require.NoError(t, err)
+		overdrawn.Version++
+		require.NoError(t, store.Put(overdrawn))
+		inCredit := newAccount(t)
+
+		day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		require.NoError(t, svc.AccrueOverdraftInterest(day))
+		require.NoError(t, svc.AccrueOverdraftInterest(day))
+
+		overdrawn, err = svc.FetchAccount(overdrawn.AccountNumber)
+		require.NoError(t, err)
+		assert.InDelta(t, 100*accounts.DefaultOverdraftRate/365, overdrawn.AccruedOverdraftInterest(), 1e-9)
+		inCredit, err = svc.FetchAccount(inCredit.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, inCredit.AccruedOverdraftInterest())
+	})
+}
+
+func TestAccountStatus(t *testing.T) {
+	newAccount := func(t *testing.T) accounts.BankAccount {
+		t.Helper()
+		acct, err := accounts.NewBankAccount("usr-123", "01000004", "10-10-10", "Mr Foo", accounts.CurrentAcct, accounts.GBP)
+		require.NoError(t, err)
+		return acct
+	}
+
+	t.Run("should open accounts as active", func(t *testing.T) {
+		acct := newAccount(t)
+		assert.Equal(t, accounts.ActiveStatus, acct.Status)
+		assert.Empty(t, acct.StatusHistory)
+	})
+	t.Run("should record each change in the history", func(t *testing.T) {
+		acct, err := newAccount(t).ChangeStatus(accounts.FrozenStatus, accounts.SuspectedFraudReason, "usr-teller")
+		require.NoError(t, err)
+		acct, err = acct.ChangeStatus(accounts.ActiveStatus, accounts.ReviewCompleteReason, "usr-support")
+		require.NoError(t, err)
+
+		assert.Equal(t, accounts.ActiveStatus, acct.Status)
+		require.Len(t, acct.StatusHistory, 2)
+		assert.Equal(t, accounts.ActiveStatus, acct.StatusHistory[0].From)
+		assert.Equal(t, accounts.FrozenStatus, acct.StatusHistory[0].To)
+		assert.Equal(t, accounts.SuspectedFraudReason, acct.StatusHistory[0].Reason)
+		assert.Equal(t, users.UserID("usr-teller"), acct.StatusHistory[0].ChangedBy)
+		assert.Equal(t, accounts.ReviewCompleteReason, acct.StatusHistory[1].Reason)
+	})
+	t.Run("should refuse transitions that are not allowed", func(t *testing.T) {
+		frozen, err := newAccount(t).ChangeStatus(accounts.FrozenStatus, accounts.LegalOrderReason, "usr-teller")
+		require.NoError(t, err)
+		_, err = frozen.ChangeStatus(accounts.DormantStatus, accounts.InactivityReason, "")
+		assert.ErrorIs(t, err, accounts.ErrInvalidStatusTransition)
+		_, err = frozen.ChangeStatus(accounts.FrozenStatus, accounts.LegalOrderReason, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrInvalidStatusTransition)
+
+		closed, err := newAccount(t).ChangeStatus(accounts.ClosedStatus, accounts.CustomerRequestReason, "usr-teller")
+		require.NoError(t, err)
+		_, err = closed.ChangeStatus(accounts.ActiveStatus, accounts.CustomerRequestReason, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrInvalidStatusTransition)
+
+		_, err = newAccount(t).ChangeStatus(accounts.FrozenStatus, "because", "usr-teller")
+		assert.Error(t, err)
+	})
+	t.Run("should only close an empty account", func(t *testing.T) {
+		acct, err := newAccount(t).Deposit(10)
+		require.NoError(t, err)
+		_, err = acct.ChangeStatus(accounts.ClosedStatus, accounts.CustomerRequestReason, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrAccountNotEmpty)
+	})
+	t.Run("should take deposits but refuse debits while frozen or dormant", func(t *testing.T) {
+		for status, wantErr := range map[accounts.AccountStatus]error{accounts.FrozenStatus: accounts.ErrAccountFrozen, accounts.DormantStatus: accounts.ErrAccountDormant} {
+			acct, err := newAccount(t).ChangeStatus(status, accounts.LegalOrderReason, "usr-teller")
+			require.NoError(t, err)
+			acct, err = acct.Deposit(10)
+			require.NoError(t, err)
+			_, err = acct.Withdraw(5)
+			assert.ErrorIs(t, err, wantErr)
+			_, err = acct.Debit(5)
+			assert.ErrorIs(t, err, wantErr)
+		}
+	})
+	t.Run("should refuse everything once closed", func(t *testing.T) {
+		acct, err := newAccount(t).ChangeStatus(accounts.ClosedStatus, accounts.CustomerRequestReason, "usr-teller")
+		require.NoError(t, err)
+		_, err = acct.Deposit(10)
+		assert.ErrorIs(t, err, accounts.ErrAccountClosed)
+		_, err = acct.Debit(10)
+		assert.ErrorIs(t, err, accounts.ErrAccountClosed)
+	})
+
+	t.Run("service", func(t *testing.T) {
+		store := adapters.NewInMemoryAccountStore()
+		svc := accounts.NewAccountService(store, newVerifiedUserStore(t, "usr-123", "usr-456"), adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+		newServiceAccount := func(t *testing.T) accounts.BankAccount {
+			t.Helper()
+			acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.CurrentAcct})
+			require.NoError(t, err)
+			return acct
+		}
+
+		t.Run("should change and store the status", func(t *testing.T) {
+			acct := newServiceAccount(t)
+			changed, err := svc.ChangeAccountStatus(acct.AccountNumber, accounts.FrozenStatus, accounts.SuspectedFraudReason, "usr-teller")
+			require.NoError(t, err)
+			assert.Equal(t, accounts.FrozenStatus, changed.Status)
+
+			stored, err := svc.FetchAccount(acct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, changed, stored)
+		})
+		t.Run("should error for an unknown account", func(t *testing.T) {
+			_, err := svc.ChangeAccountStatus("01999990", accounts.FrozenStatus, accounts.SuspectedFraudReason, "usr-teller")
+			assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+		})
+		t.Run("should make closed accounts read-only", func(t *testing.T) {
+			acct := newServiceAccount(t)
+			_, err := svc.ChangeAccountStatus(acct.AccountNumber, accounts.ClosedStatus, accounts.CustomerRequestReason, "usr-teller")
+			require.NoError(t, err)
+
+			_, err = svc.RequestAddHolder(acct.AccountNumber, "usr-123", "usr-456")
+			assert.ErrorIs(t, err, accounts.ErrAccountClosed)
+			_, err = svc.ApplyForOverdraft(acct.AccountNumber, "usr-123", 100)
+			assert.ErrorIs(t, err, accounts.ErrAccountClosed)
+		})
+		t.Run("should flag accounts without customer activity as dormant once", func(t *testing.T) {
+			dormantStore := adapters.NewInMemoryAccountStore()
+			dormantSvc := accounts.NewAccountService(dormantStore, newVerifiedUserStore(t, "usr-123"), adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore(), accounts.WithDormancyPeriod(6))
+			inactive, err := dormantSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.CurrentAcct})
+			require.NoError(t, err)
+			active, err := dormantSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.CurrentAcct})
+			require.NoError(t, err)
+			active = active.RecordCustomerActivity(time.Now().AddDate(0, 3, 0))
+			active.Version++
+			require.NoError(t, dormantStore.Put(active))
+
+			flagged, err := dormantSvc.FlagDormantAccounts(time.Now().AddDate(0, 6, 1))
+			require.NoError(t, err)
+			require.Len(t, flagged, 1)
+			assert.Equal(t, inactive.AccountNumber, flagged[0].AccountNumber)
+			assert.Equal(t, accounts.DormantStatus, flagged[0].Status)
+			assert.Equal(t, accounts.InactivityReason, flagged[0].StatusHistory[0].Reason)
+			assert.Empty(t, flagged[0].StatusHistory[0].ChangedBy)
+
+			flagged, err = dormantSvc.FlagDormantAccounts(time.Now().AddDate(0, 6, 1))
+			require.NoError(t, err)
+			assert.Empty(t, flagged)
+		})
+	})
+}
+
+func TestHolds(t *testing.T) {
+	newAccount := func(t *testing.T, balance float64) accounts.BankAccount {
+		t.Helper()
+		acct, err := accounts.NewBankAccount("usr-123", "01000004", "10-10-10", "Mr Foo", accounts.CurrentAcct, accounts.GBP)
+		require.NoError(t, err)
+		acct, err = acct.Deposit(balance)
+		require.NoError(t, err)
+		return acct
+	}
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	t.Run("should take active holds off the available balance", func(t *testing.T) {
+		acct, hold, err := newAccount(t, 100).PlaceHold(30, accounts.CardAuthorisationHold, tomorrow, "usr-teller")
+		require.NoError(t, err)
+		assert.Equal(t, accounts.ActiveHold, hold.Status)
+		assert.Equal(t, 100.0, acct.Balance())
+		assert.Equal(t, 30.0, acct.HeldAmount())
+		assert.Equal(t, 70.0, acct.AvailableBalance())
+	})
+	t.Run("should refuse withdrawals into held funds", func(t *testing.T) {
+		acct, _, err := newAccount(t, 100).PlaceHold(30, accounts.LegalHold, tomorrow, "usr-teller")
+		require.NoError(t, err)
+		_, err = acct.Withdraw(70.01)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+		_, err = acct.Debit(70.01)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+		acct, err = acct.Withdraw(70)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, acct.AvailableBalance())
+	})
+	t.Run("should let held funds be withdrawn once released", func(t *testing.T) {
+		acct, hold, err := newAccount(t, 100).PlaceHold(30, accounts.PendingPaymentHold, tomorrow, "usr-teller")
+		require.NoError(t, err)
+		acct, released, err := acct.ReleaseHold(hold.ID, "usr-support")
+		require.NoError(t, err)
+		assert.Equal(t, accounts.ReleasedHold, released.Status)
+		assert.Equal(t, users.UserID("usr-support"), released.ReleasedBy)
+		assert.Equal(t, 100.0, acct.AvailableBalance())
+
+		_, _, err = acct.ReleaseHold(hold.ID, "usr-support")
+		assert.ErrorIs(t, err, accounts.ErrHoldNotActive)
+		_, _, err = acct.ReleaseHold("hld-missing", "usr-support")
+		assert.ErrorIs(t, err, accounts.ErrHoldNotFound)
+	})
+	t.Run("should stop holding funds once expired", func(t *testing.T) {
+		acct, hold, err := newAccount(t, 100).PlaceHold(30, accounts.CardAuthorisationHold, time.Now().Add(time.Millisecond), "usr-teller")
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		assert.Equal(t, 100.0, acct.AvailableBalance())
+
+		acct, expired := acct.ExpireHolds(time.Now())
+		require.Len(t, expired, 1)
+		assert.Equal(t, hold.ID, expired[0].ID)
+		assert.Equal(t, accounts.ExpiredHold, acct.Holds[0].Status)
+		_, expired = acct.ExpireHolds(time.Now())
+		assert.Empty(t, expired)
+	})
+	t.Run("should reject invalid holds", func(t *testing.T) {
+		acct := newAccount(t, 100)
+		_, _, err := acct.PlaceHold(0, accounts.LegalHold, tomorrow, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrInvalidHoldAmount)
+		_, _, err = acct.PlaceHold(0.001, accounts.LegalHold, tomorrow, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrInvalidHoldAmount)
+		_, _, err = acct.PlaceHold(10, accounts.LegalHold, time.Now().Add(-time.Hour), "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrHoldExpiryInPast)
+		_, _, err = acct.PlaceHold(100.01, accounts.LegalHold, tomorrow, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+		_, _, err = acct.PlaceHold(10, "because", tomorrow, "usr-teller")
+		assert.Error(t, err)
+	})
+
+	t.Run("service", func(t *testing.T) {
+		store := adapters.NewInMemoryAccountStore()
+		svc := accounts.NewAccountService(store, newVerifiedUserStore(t, "usr-123"), adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.CurrentAcct})
+		require.NoError(t, err)
+		acct, err = acct.Deposit(100)
+		require.NoError(t, err)
+		acct.Version++
+		require.NoError(t, store.Put(acct))
+
+		hold, err := svc.PlaceHold(acct.AccountNumber, 40, accounts.LegalHold, tomorrow, "usr-teller")
+		require.NoError(t, err)
+		expiring, err := svc.PlaceHold(acct.AccountNumber, 10, accounts.CardAuthorisationHold, time.Now().Add(time.Hour), "usr-teller")
+		require.NoError(t, err)
+
+		t.Run("should store placed holds on the account", func(t *testing.T) {
+			holds, err := svc.ListHolds(acct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, []accounts.Hold{hold, expiring}, holds)
+
+			got, err := svc.FetchAccount(acct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, 50.0, got.AvailableBalance())
+		})
+		t.Run("should expire holds past their expiry", func(t *testing.T) {
+			expired, err := svc.ExpireHolds(time.Now().Add(2 * time.Hour))
+			require.NoError(t, err)
+			require.Len(t, expired, 1)
+			assert.Equal(t, expiring.ID, expired[0].ID)
+		})
+		t.Run("should release a hold", func(t *testing.T) {
+			released, err := svc.ReleaseHold(acct.AccountNumber, hold.ID, "usr-teller")
+			require.NoError(t, err)
+			assert.Equal(t, accounts.ReleasedHold, released.Status)
+
+			got, err := svc.FetchAccount(acct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, 100.0, got.AvailableBalance())
+		})
+		t.Run("should error for an unknown account", func(t *testing.T) {
+			_, err := svc.PlaceHold("01999990", 10, accounts.LegalHold, tomorrow, "usr-teller")
+			assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+		})
+	})
+}
+
+func TestPots(t *testing.T) {
+	newAccount := func(t *testing.T, balance float64) accounts.BankAccount {
+		t.Helper()
+		acct, err := accounts.NewBankAccount("usr-123", "01000004", "10-10-10", "Mr Foo", accounts.CurrentAcct, accounts.GBP)
+		require.NoError(t, err)
+		acct, err = acct.Deposit(balance)
+		require.NoError(t, err)
+		return acct
+	}
+	nextYear := time.Now().AddDate(1, 0, 0)
+
+	t.Run("should ring-fence money moved into a pot", func(t *testing.T) {
+		acct, pot, err := newAccount(t, 100).CreatePot("Holiday", 1000, nextYear)
+		require.NoError(t, err)
+		acct, pot, err = acct.MoveToPot(pot.ID, 30)
+		require.NoError(t, err)
+		assert.Equal(t, 30.0, pot.Balance)
+		assert.Equal(t, 100.0, acct.Balance())
+		assert.Equal(t, 70.0, acct.MainBalance())
+		assert.Equal(t, 70.0, acct.AvailableBalance())
+
+		_, err = acct.Debit(70.01)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+	})
+	t.Run("should not fund pots from the overdraft or held funds", func(t *testing.T) {
+		acct, err := newAccount(t, 100).WithOverdraft(accounts.Overdraft{Limit: 500})
+		require.NoError(t, err)
+		acct, _, err = acct.PlaceHold(40, accounts.LegalHold, nextYear, "usr-teller")
+		require.NoError(t, err)
+		acct, pot, err := acct.CreatePot("Holiday", 0, time.Time{})
+		require.NoError(t, err)
+		_, _, err = acct.MoveToPot(pot.ID, 60.01)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+		_, _, err = acct.MoveToPot(pot.ID, 60)
+		assert.NoError(t, err)
+	})
+	t.Run("should only delete empty pots", func(t *testing.T) {
+		acct, pot, err := newAccount(t, 100).CreatePot("Holiday", 0, time.Time{})
+		require.NoError(t, err)
+		acct, _, err = acct.MoveToPot(pot.ID, 10)
+		require.NoError(t, err)
+		_, err = acct.DeletePot(pot.ID)
+		assert.ErrorIs(t, err, accounts.ErrPotNotEmpty)
+
+		acct, _, err = acct.MoveFromPot(pot.ID, 10)
+		require.NoError(t, err)
+		acct, err = acct.DeletePot(pot.ID)
+		require.NoError(t, err)
+		assert.Empty(t, acct.Pots)
+	})
+	t.Run("should update only the fields given", func(t *testing.T) {
+		acct, pot, err := newAccount(t, 0).CreatePot("Holiday", 1000, nextYear)
+		require.NoError(t, err)
+		name := "Summer holiday"
+		_, updated, err := acct.UpdatePot(pot.ID, accounts.PotUpdate{Name: &name})
+		require.NoError(t, err)
+		assert.Equal(t, name, updated.Name)
+		assert.Equal(t, 1000.0, updated.Goal)
+		assert.Equal(t, nextYear, updated.TargetDate)
+	})
+	t.Run("should reject invalid pots", func(t *testing.T) {
+		acct := newAccount(t, 0)
+		_, _, err := acct.CreatePot(" ", 0, time.Time{})
+		assert.Error(t, err)
+		_, _, err = acct.CreatePot("Holiday", -1, time.Time{})
+		assert.Error(t, err)
+		_, _, err = acct.CreatePot("Holiday", 0, time.Now().Add(-time.Hour))
+		assert.ErrorIs(t, err, accounts.ErrPotTargetInPast)
+		for range accounts.MaxPots {
+			acct, _, err = acct.CreatePot("Pot", 0, time.Time{})
+			require.NoError(t, err)
+		}
+		_, _, err = acct.CreatePot("One too many", 0, time.Time{})
+		assert.ErrorIs(t, err, accounts.ErrTooManyPots)
+	})
+	t.Run("should not close an account with money in pots", func(t *testing.T) {
+		acct, err := newAccount(t, 10).WithOverdraft(accounts.Overdraft{Limit: 100})
+		require.NoError(t, err)
+		acct, pot, err := acct.CreatePot("Holiday", 0, time.Time{})
+		require.NoError(t, err)
+		acct, _, err = acct.MoveToPot(pot.ID, 10)
+		require.NoError(t, err)
+		acct, err = acct.Debit(10)
+		require.NoError(t, err)
+		require.Equal(t, 0.0, acct.Balance())
+
+		_, err = acct.ChangeStatus(accounts.ClosedStatus, accounts.CustomerRequestReason, "usr-teller")
+		assert.ErrorIs(t, err, accounts.ErrAccountNotEmpty)
+	})
+}
+
+func TestBranches(t *testing.T) {
+	manchester := accounts.Branch{
+		SortCode: "10-20-30",
+		Name:     "Eagle Bank Manchester",
+		Address:  users.MustNewAddress("20 Market Street", "Manchester", "Greater Manchester", "M1 1PT"),
+	}
+	t.Run("registry", func(t *testing.T) {
+		t.Run("should error without branches", func(t *testing.T) {
+			_, err := accounts.NewBranchRegistry()
+			assert.Error(t, err)
+		})
+		t.Run("should error for a duplicate sort code", func(t *testing.T) {
+			dup := manchester
+			dup.SortCode = accounts.HeadOffice.SortCode
+			_, err := accounts.NewBranchRegistry(accounts.HeadOffice, dup)
+			assert.Error(t, err)
+		})
+		t.Run("should error for a malformed sort code", func(t *testing.T) {
+			bad := manchester
+			bad.SortCode = "102030"
+			_, err := accounts.NewBranchRegistry(bad)
+			assert.Error(t, err)
+		})
+		t.Run("should error for an unknown sort code", func(t *testing.T) {
+			registry, err := accounts.NewBranchRegistry(accounts.HeadOffice)
+			require.NoError(t, err)
+			_, err = registry.Get("10-20-30")
+			assert.ErrorIs(t, err, accounts.ErrBranchNotFound)
+		})
+	})
+
+	store := adapters.NewInMemoryAccountStore()
+	usrStore := newVerifiedUserStore(t, "usr-123")
+	registry, err := accounts.NewBranchRegistry(accounts.HeadOffice, manchester)
+	require.NoError(t, err)
+	svc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore(), accounts.WithBranches(registry))
+
+	t.Run("should open accounts at the default branch", func(t *testing.T) {
+		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+		require.NoError(t, err)
+		assert.Equal(t, accounts.HeadOffice.SortCode, acct.SortCode)
+	})
+	t.Run("should open accounts at the requested branch", func(t *testing.T) {
+		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct, SortCode: manchester.SortCode})
+		require.NoError(t, err)
+		assert.Equal(t, manchester.SortCode, acct.SortCode)
+
+		t.Run("and find them by sort code and account number", func(t *testing.T) {
+			got, err := svc.FetchAccountByBankDetails(manchester.SortCode, acct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, acct, got)
+
+			_, err = svc.FetchAccountByBankDetails(accounts.HeadOffice.SortCode, acct.AccountNumber)
+			assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+			_, err = svc.FetchAccountByBankDetails("99-99-99", acct.AccountNumber)
+			assert.ErrorIs(t, err, accounts.ErrBranchNotFound)
+		})
+	})
+	t.Run("should fail for a sort code the bank does not own", func(t *testing.T) {
+		_, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct, SortCode: "99-99-99"})
+		assert.ErrorIs(t, err, accounts.ErrBranchNotFound)
+	})
+	t.Run("should fail without a default branch", func(t *testing.T) {
+		emptySvc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore(), accounts.WithBranches(accounts.BranchRegistry{}))
+		_, err := emptySvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+		assert.ErrorIs(t, err, accounts.ErrBranchNotFound)
+	})
+	t.Run("should default to the head office", func(t *testing.T) {
+		defaultSvc := accounts.NewAccountService(store, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+		assert.Equal(t, []accounts.Branch{accounts.HeadOffice}, defaultSvc.ListBranches())
+	})
+}
+
+func TestAccountNumber(t *testing.T) {
+	t.Run("should validate the check digit", func(t *testing.T) {
+		for _, valid := range []string{"01000004", "01234528", "01999990"} {
+			_, err := accounts.NewAccountNumber(valid)
+			assert.NoError(t, err, valid)
+		}
+		for _, invalid := range []string{"", "01000000", "01234567", "0100004", "02000004"} {
+			_, err := accounts.NewAccountNumber(invalid)
+			assert.Error(t, err, invalid)
+		}
+	})
+	t.Run("should append the check digit to a base", func(t *testing.T) {
+		acctNum, err := accounts.NewCheckedAccountNumber(23452)
+		require.NoError(t, err)
+		assert.Equal(t, accounts.AccountNumber("01234528"), acctNum)
+	})
+	t.Run("should error for a base without a check digit", func(t *testing.T) {
+		_, err := accounts.NewCheckedAccountNumber(8)
+		assert.ErrorIs(t, err, accounts.ErrNoCheckDigit)
+	})
+	t.Run("pool should hand out every number in its range once", func(t *testing.T) {
+		pool := accounts.NewAccountNumberPool(0, 999)
+		seen := make(map[accounts.AccountNumber]bool)
+		for {
+			acctNum, err := pool.Next()
+			if errors.Is(err, accounts.ErrAccountNumbersExhausted) {
+				break
+			}
+			require.NoError(t, err)
+			require.True(t, acctNum.IsValid(), acctNum)
+			require.False(t, seen[acctNum], "%s handed out twice", acctNum)
+			seen[acctNum] = true
+		}
+		want := 0
+		for base := range 1000 {
+			if _, err := accounts.NewCheckedAccountNumber(base); err == nil {
+				want++
+			}
+		}
+		assert.Len(t, seen, want)
+	})
+	t.Run("should generate valid random numbers", func(t *testing.T) {
+		for range 100 {
+			acctNum, err := accounts.NewRandAccountNumber()
+			require.NoError(t, err)
+			assert.True(t, acctNum.IsValid(), acctNum)
+		}
+	})
+}
+
+// collidingAccountStore reports the first collisions account numbers as taken.
+type collidingAccountStore struct {
+	*adapters.InMemoryAccountStore
+	collisions int
+	attempts   int
+}
+
+func (c *collidingAccountStore) Create(acct accounts.BankAccount, evts ...events.Event) error {
+	c.attempts++
+	if c.attempts <= c.collisions {
+		return accounts.ErrAccountNumberTaken
+	}
+	return c.InMemoryAccountStore.Create(acct, evts...)
 }
 
 type failingAccountStore struct{}
@@ -127,7 +1222,19 @@ func (f failingAccountStore) GetByAcctNum(acctNum accounts.AccountNumber) (accou
 	return accounts.BankAccount{}, errors.New("some error")
 }
 
-func (f failingAccountStore) Put(acct accounts.BankAccount) error {
+func (f failingAccountStore) List() ([]accounts.BankAccount, error) {
+	return nil, errors.New("some error")
+}
+
+func (f failingAccountStore) Create(acct accounts.BankAccount, evts ...events.Event) error {
+	return errors.New("error")
+}
+
+func (f failingAccountStore) Put(acct accounts.BankAccount, evts ...events.Event) error {
+	return errors.New("error")
+}
+
+func (f failingAccountStore) PutAllWith(write func() error, accts ...accounts.BankAccount) error {
 	return errors.New("error")
 }
 
@@ -140,3 +1247,517 @@ func newFailingAccountStore(t *testing.T) *failingAccountStore {
 	t.Helper()
 	return &failingAccountStore{}
 }
+
+func newTestUser(t *testing.T, id users.UserID) users.User {
+	t.Helper()
+	return users.MustNewUser(
+		id,
+		"Mr Foo",
+		users.MustNewAddress("line1", "town", "county", "postcode"),
+		users.MustNewPhoneNumber("+440000000000"),
+		users.MustNewEmail("foo@bar.com"),
+	)
+}
+
+func newVerifiedUserStore(t *testing.T, ids ...users.UserID) *adapters2.InMemoryUserStore {
+	t.Helper()
+	store := adapters2.NewInMemoryUserStore()
+	for _, id := range ids {
+		usr := newTestUser(t, id)
+		usr.EmailVerified = true
+		require.NoError(t, store.Put(usr))
+	}
+	return store
+}
+
+func TestCurrency(t *testing.T) {
+	t.Run("should use ISO 4217 minor unit exponents", func(t *testing.T) {
+		assert.Equal(t, 2, accounts.GBP.Exponent())
+		assert.Equal(t, 2, accounts.EUR.Exponent())
+		assert.Equal(t, 2, accounts.USD.Exponent())
+		assert.Equal(t, 0, accounts.JPY.Exponent())
+		assert.Equal(t, -1, accounts.Currency("XXX").Exponent())
+	})
+	t.Run("should only allow amounts in whole minor units", func(t *testing.T) {
+		assert.True(t, accounts.GBP.IsValidAmount(10.99))
+		assert.False(t, accounts.GBP.IsValidAmount(10.999))
+		assert.True(t, accounts.JPY.IsValidAmount(1000))
+		assert.False(t, accounts.JPY.IsValidAmount(10.5))
+		assert.False(t, accounts.Currency("XXX").IsValidAmount(10))
+	})
+	t.Run("should round to the minor unit", func(t *testing.T) {
+		assert.Equal(t, 0.3, accounts.GBP.Round(0.1+0.2))
+		assert.Equal(t, 11.0, accounts.JPY.Round(10.5))
+	})
+	t.Run("should default new accounts to GBP", func(t *testing.T) {
+		svc := accounts.NewAccountService(adapters.NewInMemoryAccountStore(), newVerifiedUserStore(t, "usr-123"), adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+		acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+		require.NoError(t, err)
+		assert.Equal(t, accounts.GBP, acct.Currency)
+
+		acct, err = svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct, Currency: accounts.USD})
+		require.NoError(t, err)
+		assert.Equal(t, accounts.USD, acct.Currency)
+
+		_, err = svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct, Currency: "XXX"})
+		assert.Error(t, err)
+	})
+}
+
+func TestAccountEvents(t *testing.T) {
+	opened, err := accounts.NewBankAccount("usr-123", "01000004", "10-10-10", "Mr Foo", accounts.CurrentAcct, accounts.GBP)
+	require.NoError(t, err)
+	now := time.Now()
+
+	t.Run("should open an account from nothing", func(t *testing.T) {
+		events, err := accounts.BankAccount{}.Changes(opened, 0, now)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, accounts.AccountOpened, events[0].Type)
+		assert.Equal(t, uint64(1), events[0].Version)
+		assert.Equal(t, opened.AccountNumber, events[0].AccountNumber)
+	})
+	t.Run("should record balance and name changes as their own events", func(t *testing.T) {
+		deposited, err := opened.Deposit(100)
+		require.NoError(t, err)
+		events, err := opened.Changes(deposited, 1, now)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, accounts.Deposited, events[0].Type)
+		assert.Equal(t, 100.0, events[0].Amount)
+		assert.Equal(t, uint64(2), events[0].Version)
+
+		renamed := deposited
+		renamed.Name = "Mr Bar"
+		withdrawn, err := renamed.Debit(30.5)
+		require.NoError(t, err)
+		events, err = deposited.Changes(withdrawn, 2, now)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, accounts.Renamed, events[0].Type)
+		assert.Equal(t, "Mr Bar", events[0].Name)
+		assert.Equal(t, accounts.Withdrawn, events[1].Type)
+		assert.Equal(t, 30.5, events[1].Amount)
+	})
+	t.Run("should record holder changes as their own events", func(t *testing.T) {
+		joint, err := opened.AddHolder("usr-456", accounts.SecondaryHolder)
+		require.NoError(t, err)
+		events, err := opened.Changes(joint, 1, now)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, accounts.HolderAdded, events[0].Type)
+		assert.Equal(t, users.UserID("usr-456"), events[0].Holder.UserID)
+	})
+	t.Run("should record nothing for a write which changes nothing", func(t *testing.T) {
+		events, err := opened.Changes(opened, 1, now)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+	t.Run("should fail for a change no event describes", func(t *testing.T) {
+		changed := opened
+		changed.Currency = accounts.EUR
+		_, err := opened.Changes(changed, 1, now)
+		assert.ErrorIs(t, err, accounts.ErrInvalidEvent)
+	})
+	t.Run("should record closing an account", func(t *testing.T) {
+		closed, err := opened.ChangeStatus(accounts.ClosedStatus, accounts.CustomerRequestReason, "usr-support")
+		require.NoError(t, err)
+		events, err := opened.Changes(closed, 1, now)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, accounts.Closed, events[0].Type)
+		assert.Equal(t, accounts.CustomerRequestReason, events[0].StatusChange.Reason)
+	})
+	t.Run("should replay the events back to the same account", func(t *testing.T) {
+		deposited, err := opened.Deposit(250)
+		require.NoError(t, err)
+		joint, err := deposited.AddHolder("usr-456", accounts.SecondaryHolder)
+		require.NoError(t, err)
+		withdrawn, err := joint.Withdraw(250)
+		require.NoError(t, err)
+		closed, err := withdrawn.ChangeStatus(accounts.ClosedStatus, accounts.CustomerRequestReason, "usr-support")
+		require.NoError(t, err)
+
+		var events []accounts.AccountEvent
+		prev := accounts.BankAccount{}
+		for _, next := range []accounts.BankAccount{opened, deposited, joint, withdrawn, closed} {
+			changes, err := prev.Changes(next, uint64(len(events)), now)
+			require.NoError(t, err)
+			events, prev = append(events, changes...), next
+		}
+
+		got, err := accounts.Replay(events)
+		require.NoError(t, err)
+		assert.Equal(t, closed, got)
+		assert.Equal(t, 1, got.MonthlyWithdrawals())
+
+		midway, err := accounts.Replay(events[:2])
+		require.NoError(t, err)
+		assert.Equal(t, deposited, midway)
+	})
+	t.Run("should replay every kind of change from its events alone", func(t *testing.T) {
+		steps := []func(accounts.BankAccount) (accounts.BankAccount, error){
+			func(a accounts.BankAccount) (accounts.BankAccount, error) { return a.Deposit(500) },
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				return a.AddHolder("usr-456", accounts.SecondaryHolder)
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) { return a.RemoveHolder("usr-123") },
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _, err := a.PlaceHold(10, accounts.LegalHold, now.Add(time.Hour), "usr-teller")
+				return a, err
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _, err := a.ReleaseHold(a.Holds[0].ID, "usr-teller")
+				return a, err
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _, err := a.PlaceHold(20, accounts.LegalHold, time.Now().Add(time.Millisecond), "usr-teller")
+				return a, err
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _ = a.ExpireHolds(now.Add(time.Hour))
+				return a, nil
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _, err := a.CreatePot("Holiday", 300, time.Time{})
+				return a, err
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				name := "Summer holiday"
+				a, _, err := a.UpdatePot(a.Pots[0].ID, accounts.PotUpdate{Name: &name})
+				return a, err
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _, err := a.MoveToPot(a.Pots[0].ID, 50)
+				return a, err
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _, err := a.MoveFromPot(a.Pots[0].ID, 50)
+				return a, err
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) { return a.DeletePot(a.Pots[0].ID) },
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				return a.WithOverdraft(accounts.Overdraft{Limit: 1000, AnnualRate: accounts.DefaultOverdraftRate})
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				profile := a.Limits
+				profile.MaxTransaction = 2500
+				return a.ChangeLimits(profile)
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) { return a.WithdrawAt(600, now) },
+			func(a accounts.BankAccount) (accounts.BankAccount, error) { return a.RecordCustomerActivity(now), nil },
+			func(a accounts.BankAccount) (accounts.BankAccount, error) { return a.AccrueOverdraftInterest(now), nil },
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a, _ = a.ChargeOverdraftInterest()
+				return a, nil
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				return a.ChangeStatus(accounts.FrozenStatus, accounts.SuspectedFraudReason, "usr-support")
+			},
+			func(a accounts.BankAccount) (accounts.BankAccount, error) {
+				a.Name = "Mr Bar"
+				return a, nil
+			},
+		}
+
+		acct := opened
+		events, err := accounts.BankAccount{}.Changes(acct, 0, now)
+		require.NoError(t, err)
+		for i, step := range steps {
+			next, err := step(acct)
+			require.NoError(t, err, "step %d", i)
+			next.UpdatedTimestamp = now.Add(time.Duration(i) * time.Second)
+			next.Version++
+			changes, err := acct.Changes(next, uint64(len(events)), now)
+			require.NoError(t, err, "step %d", i)
+			require.NotEmpty(t, changes, "step %d", i)
+			events, acct = append(events, changes...), next
+		}
+
+		got, err := accounts.Replay(events)
+		require.NoError(t, err)
+		assert.Equal(t, acct, got)
+		seen := make(map[accounts.EventType]bool)
+		for _, e := range events {
+			seen[e.Type] = true
+		}
+		for _, typ := range accounts.EventTypes() {
+			if typ != accounts.Closed && typ != accounts.AccountDeleted {
+				assert.True(t, seen[typ], "no %s event", typ)
+			}
+		}
+	})
+	t.Run("should not replay a stream with a missing version", func(t *testing.T) {
+		events, err := accounts.BankAccount{}.Changes(opened, 0, now)
+		require.NoError(t, err)
+		events[0].Version = 2
+		_, err = accounts.Replay(events)
+		assert.ErrorIs(t, err, accounts.ErrInvalidEvent)
+	})
+	t.Run("should not apply events before the account is opened", func(t *testing.T) {
+		_, err := accounts.BankAccount{}.Apply(accounts.AccountEvent{AccountNumber: opened.AccountNumber, Version: 1, Type: accounts.Deposited, Amount: 10})
+		assert.ErrorIs(t, err, accounts.ErrInvalidEvent)
+	})
+	t.Run("should not find a deleted account", func(t *testing.T) {
+		events, err := accounts.BankAccount{}.Changes(opened, 0, now)
+		require.NoError(t, err)
+		events = append(events, accounts.AccountEvent{AccountNumber: opened.AccountNumber, Version: 2, Type: accounts.AccountDeleted})
+		_, err = accounts.Replay(events)
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+	})
+}
+
+func TestExecutor(t *testing.T) {
+	acctNums := []accounts.AccountNumber{"01000001", "01000002", "01000003", "01000004", "01000005", "01000006"}
+
+	t.Run("should run an account's commands one at a time", func(t *testing.T) {
+		exec := accounts.NewExecutor(4)
+		defer exec.Close()
+		// The counters aren't synchronised, so the race detector fails the test if two commands
+		// for the same account overlap.
+		counts := make(map[accounts.AccountNumber]*int)
+		for _, acctNum := range acctNums {
+			counts[acctNum] = new(int)
+		}
+		var wg sync.WaitGroup
+		for i := range 600 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				acctNum := acctNums[i%len(acctNums)]
+				assert.NoError(t, exec.Do(acctNum, func() error {
+					*counts[acctNum]++
+					return nil
+				}))
+			}()
+		}
+		wg.Wait()
+		for _, acctNum := range acctNums {
+			assert.Equal(t, 100, *counts[acctNum])
+		}
+	})
+	t.Run("should run commands for several accounts apart from any other command for them", func(t *testing.T) {
+		exec := accounts.NewExecutor(4)
+		defer exec.Close()
+		balances := make(map[accounts.AccountNumber]*int)
+		for _, acctNum := range acctNums {
+			balances[acctNum] = new(int)
+		}
+		var wg sync.WaitGroup
+		for i := range 300 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Transfers go both ways between each pair, in either order.
+				from, to := acctNums[i%len(acctNums)], acctNums[(i+1)%len(acctNums)]
+				if i%2 == 0 {
+					from, to = to, from
+				}
+				assert.NoError(t, exec.DoAll([]accounts.AccountNumber{from, to}, func() error {
+					*balances[from]--
+					*balances[to]++
+					return nil
+				}))
+				assert.NoError(t, exec.Do(from, func() error {
+					*balances[from]++
+					return nil
+				}))
+			}()
+		}
+		wg.Wait()
+		total := 0
+		for _, acctNum := range acctNums {
+			total += *balances[acctNum]
+		}
+		assert.Equal(t, 300, total)
+	})
+	t.Run("should return the command's error", func(t *testing.T) {
+		exec := accounts.NewExecutor(2)
+		defer exec.Close()
+		acct, err := accounts.Submit(exec, acctNums[0], func() (accounts.BankAccount, error) {
+			return accounts.BankAccount{}, accounts.ErrAccountClosed
+		})
+		assert.ErrorIs(t, err, accounts.ErrAccountClosed)
+		assert.Zero(t, acct)
+	})
+	t.Run("should return a panicking command as an error and carry on", func(t *testing.T) {
+		exec := accounts.NewExecutor(1)
+		defer exec.Close()
+		err := exec.Do(acctNums[0], func() error {
+			panic("boom")
+		})
+		assert.ErrorContains(t, err, "boom")
+		got, err := accounts.Submit(exec, acctNums[0], func() (int, error) { return 1, nil })
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+	})
+	t.Run("should refuse commands once closed", func(t *testing.T) {
+		exec := accounts.NewExecutor(2)
+		var wg sync.WaitGroup
+		for i := range 100 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := exec.Do(acctNums[i%len(acctNums)], func() error { return nil })
+				if err != nil {
+					assert.ErrorIs(t, err, accounts.ErrExecutorClosed)
+				}
+			}()
+		}
+		exec.Close()
+		wg.Wait()
+
+		ran := false
+		err := exec.DoAll(acctNums, func() error {
+			ran = true
+			return nil
+		})
+		assert.ErrorIs(t, err, accounts.ErrExecutorClosed)
+		assert.False(t, ran)
+		exec.Close()
+	})
+	t.Run("should run commands straight away without an executor", func(t *testing.T) {
+		var exec *accounts.Executor
+		got, err := accounts.SubmitAll(exec, acctNums, func() (int, error) { return 1, nil })
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+		exec.Close()
+	})
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	exec := accounts.NewExecutor(4)
+	defer exec.Close()
+	svc := accounts.NewAccountService(adapters.NewInMemoryAccountStore(), newVerifiedUserStore(t, "usr-alice", "usr-bob"), slowHolderChangeStore{adapters.NewInMemoryHolderChangeStore()}, slowOverdraftApplicationStore{adapters.NewInMemoryOverdraftApplicationStore()}, accounts.WithExecutor(exec))
+	acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-alice", Name: "Joint", AccountType: accounts.PersonalAcct})
+	require.NoError(t, err)
+
+	t.Run("should open one overdraft application when several are made at once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := svc.ApplyForOverdraft(acct.AccountNumber, "usr-alice", 100)
+				if err != nil {
+					assert.ErrorIs(t, err, accounts.ErrOverdraftApplicationPending)
+				}
+			}()
+		}
+		wg.Wait()
+		pending, err := svc.ListPendingOverdraftApplications()
+		require.NoError(t, err)
+		assert.Len(t, pending, 1)
+	})
+	t.Run("should open one invitation when the same user is invited at once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := svc.RequestAddHolder(acct.AccountNumber, "usr-alice", "usr-bob")
+				if err != nil {
+					assert.ErrorIs(t, err, accounts.ErrHolderChangePending)
+				}
+			}()
+		}
+		wg.Wait()
+		pending, err := svc.ListPendingHolderChanges("usr-bob")
+		require.NoError(t, err)
+		assert.Len(t, pending, 1)
+	})
+}
+
+// slowHolderChangeStore widens the gap between finding no pending change and saving a new one, so
+// requests which aren't run one at a time overlap.
+type slowHolderChangeStore struct {
+	*adapters.InMemoryHolderChangeStore
+}
+
+func (s slowHolderChangeStore) GetByAcctNum(acctNum accounts.AccountNumber) ([]accounts.HolderChange, error) {
+	changes, err := s.InMemoryHolderChangeStore.GetByAcctNum(acctNum)
+	time.Sleep(5 * time.Millisecond)
+	return changes, err
+}
+
+// slowOverdraftApplicationStore is slowHolderChangeStore for overdraft applications.
+type slowOverdraftApplicationStore struct {
+	*adapters.InMemoryOverdraftApplicationStore
+}
+
+func (s slowOverdraftApplicationStore) GetByAcctNum(acctNum accounts.AccountNumber) ([]accounts.OverdraftApplication, error) {
+	apps, err := s.InMemoryOverdraftApplicationStore.GetByAcctNum(acctNum)
+	time.Sleep(5 * time.Millisecond)
+	return apps, err
+}
+
+var errSaveFailed = errors.New("save failed")
+
+// failingOverdraftApplicationStore fails every save once fail is set.
+type failingOverdraftApplicationStore struct {
+	*adapters.InMemoryOverdraftApplicationStore
+	fail bool
+}
+
+func (s *failingOverdraftApplicationStore) Put(app accounts.OverdraftApplication) error {
+	if s.fail {
+		return errSaveFailed
+	}
+	return s.InMemoryOverdraftApplicationStore.Put(app)
+}
+
+func TestPublishedEvents(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T, outbox *adapters3.InMemoryOutbox) accounts.AccountStore{
+		"in memory": func(t *testing.T, outbox *adapters3.InMemoryOutbox) accounts.AccountStore {
+			return adapters.NewInMemoryAccountStore(adapters.WithOutbox(outbox))
+		},
+		"event sourced": func(t *testing.T, outbox *adapters3.InMemoryOutbox) accounts.AccountStore {
+			store, err := adapters.NewEventSourcedAccountStore(adapters.NewInMemoryEventStore(), adapters.WithOutbox(outbox))
+			require.NoError(t, err)
+			return store
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			outbox := adapters3.NewInMemoryOutbox()
+			store := newStore(t, outbox)
+			svc := accounts.NewAccountService(store, newVerifiedUserStore(t, "usr-123"), adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+
+			acct, err := svc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+			require.NoError(t, err)
+			frozen, err := svc.ChangeAccountStatus(acct.AccountNumber, accounts.FrozenStatus, accounts.SuspectedFraudReason, "usr-teller")
+			require.NoError(t, err)
+
+			t.Run("should record an event with each change", func(t *testing.T) {
+				recorded := outbox.Events()
+				require.Len(t, recorded, 2)
+				assert.Equal(t, events.AccountOpened, recorded[0].Type)
+				assert.Equal(t, events.AccountStatusChanged, recorded[1].Type)
+				for _, e := range recorded {
+					assert.Equal(t, acct.AccountNumber.String(), e.AggregateID)
+					assert.Equal(t, []string{"usr-123"}, e.UserIDs)
+				}
+				var data events.AccountStatusData
+				require.NoError(t, recorded[1].Decode(&data))
+				assert.Equal(t, events.AccountStatusData{AccountNumber: acct.AccountNumber.String(), From: "active", To: "frozen", Reason: "suspected_fraud"}, data)
+			})
+			t.Run("should not record the events of a write which fails", func(t *testing.T) {
+				evt, err := events.NewEvent(events.AccountStatusChanged, acct.AccountNumber.String(), nil, events.AccountStatusData{})
+				require.NoError(t, err)
+				assert.ErrorIs(t, store.Put(frozen, evt), accounts.ErrConflict)
+				assert.Len(t, outbox.Events(), 2)
+			})
+			t.Run("should record the events of a write which only moves the version on", func(t *testing.T) {
+				evt, err := events.NewEvent(events.AccountStatusChanged, acct.AccountNumber.String(), nil, events.AccountStatusData{})
+				require.NoError(t, err)
+				next := frozen
+				next.Version++
+				require.NoError(t, store.Put(next, evt))
+				assert.Len(t, outbox.Events(), 3)
+
+				got, err := store.GetByAcctNum(acct.AccountNumber)
+				require.NoError(t, err)
+				assert.Equal(t, next.Version, got.Version)
+			})
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/events"
+	"eaglebank/internal/users"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventSourcedAccountStore keeps each account as a stream of events in an EventStore and answers
+// reads from a projection built from them. Writes are turned into events by comparing the account
+// with its projection, and are appended at the next version of its stream, so the store fails with
+// ErrVersionConflict rather than overwriting events another writer appended to the same streams.
+// As with InMemoryAccountStore, an account whose Version is not the next one after its projection
+// fails with ErrConflict.
+//
+// The events passed to Create and Put are published ones for the outbox, recorded once the
+// account's own events have been appended.
+type EventSourcedAccountStore struct {
+	mu         sync.RWMutex
+	events     accounts.EventStore
+	projection *InMemoryAccountStore
+	versions   map[accounts.AccountNumber]uint64
+	outbox     events.Recorder
+}
+
+// NewEventSourcedAccountStore builds the projection from the events already stored.
+func NewEventSourcedAccountStore(eventStore accounts.EventStore, opts ...AccountStoreOption) (*EventSourcedAccountStore, error) {
+	s := &EventSourcedAccountStore{events: eventStore, outbox: newAccountStoreOptions(opts).outbox}
+	err := s.Rebuild()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Rebuild throws the projection away and builds it again by replaying every event from the start.
+func (s *EventSourcedAccountStore) Rebuild() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := s.events.LoadAll()
+	if err != nil {
+		return fmt.Errorf("error loading account events %w", err)
+	}
+	acctsByNumber := make(map[accounts.AccountNumber]accounts.BankAccount)
+	versions := make(map[accounts.AccountNumber]uint64)
+	for _, e := range events {
+		if e.Version != versions[e.AccountNumber]+1 {
+			return fmt.Errorf("%w: account %s expected version %d, got %d", accounts.ErrInvalidEvent, e.AccountNumber, versions[e.AccountNumber]+1, e.Version)
+		}
+		acct, err := acctsByNumber[e.AccountNumber].Apply(e)
+		if err != nil {
+			return err
+		}
+		acctsByNumber[e.AccountNumber] = acct
+		versions[e.AccountNumber] = e.Version
+	}
+	projection := NewInMemoryAccountStore()
+	for _, acct := range acctsByNumber {
+		// Deleted accounts replay to the zero account.
+		if acct.AccountNumber != "" {
+			err = projection.Put(acct)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	s.projection, s.versions = projection, versions
+	return nil
+}
+
+// Events returns the account's event stream, including events from before it was deleted.
+func (s *EventSourcedAccountStore) Events(acctNum accounts.AccountNumber) ([]accounts.AccountEvent, error) {
+	return s.events.Load(acctNum)
+}
+
+// GetAtVersion replays the account's stream up to and including the given version, showing the
+// account as it was after that event.
+func (s *EventSourcedAccountStore) GetAtVersion(acctNum accounts.AccountNumber, version uint64) (accounts.BankAccount, error) {
+	events, err := s.events.Load(acctNum)
+	if err != nil {
+		return accounts.BankAccount{}, err
+	}
+	if version > uint64(len(events)) {
+		return accounts.BankAccount{}, fmt.Errorf("%w: account %s has no version %d", accounts.ErrInvalidEvent, acctNum, version)
+	}
+	return accounts.Replay(events[:version])
+}
+
+// Version is the version of the last event in the account's stream, zero if it has none.
+func (s *EventSourcedAccountStore) Version(acctNum accounts.AccountNumber) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.versions[acctNum]
+}
+
+func (s *EventSourcedAccountStore) GetByAcctNum(acctNum accounts.AccountNumber) (accounts.BankAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.projection.GetByAcctNum(acctNum)
+}
+
+// GetByUserID returns every account the user holds, including joint accounts.
+func (s *EventSourcedAccountStore) GetByUserID(userID users.UserID) ([]accounts.BankAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.projection.GetByUserID(userID)
+}
+
+// List returns every account in account number order.
+func (s *EventSourcedAccountStore) List() ([]accounts.BankAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.projection.List()
+}
+
+func (s *EventSourcedAccountStore) Create(acct accounts.BankAccount, evts ...events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.projection.GetByAcctNum(acct.AccountNumber); err == nil {
+		return accounts.ErrAccountNumberTaken
+	}
+	return s.append(evts, nil, acct)
+}
+
+func (s *EventSourcedAccountStore) Put(acct accounts.BankAccount, evts ...events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.append(evts, nil, acct)
+}
+
+// PutAll appends the events for every account in one go, so either all of the changes are
+// recorded or none are.
+func (s *EventSourcedAccountStore) PutAll(accts ...accounts.BankAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.append(nil, nil, accts...)
+}
+
+// PutAllWith is InMemoryAccountStore.PutAllWith. The accounts' events are appended with
+// EventStore.AppendWith, so write is only called once the EventStore has accepted their versions
+// and the events are only kept if it succeeds. When another writer has appended to the same
+// stream PutAllWith fails with ErrVersionConflict without calling write.
+func (s *EventSourcedAccountStore) PutAllWith(write func() error, accts ...accounts.BankAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.append(nil, write, accts...)
+}
+
+func (s *EventSourcedAccountStore) append(published []events.Event, write func() error, accts ...accounts.BankAccount) error {
+	now := time.Now()
+	// An account may be passed more than once, each copy is compared with the one before it.
+	latest := make(map[accounts.AccountNumber]accounts.BankAccount)
+	versions := make(map[accounts.AccountNumber]uint64)
+	var events []accounts.AccountEvent
+	for _, acct := range accts {
+		old, ok := latest[acct.AccountNumber]
+		if !ok {
+			var err error
+			old, err = s.projection.GetByAcctNum(acct.AccountNumber)
+			if err != nil && !errors.Is(err, accounts.ErrAccountNotFound) {
+				return err
+			}
+			versions[acct.AccountNumber] = s.versions[acct.AccountNumber]
+		}
+		if old.AccountNumber != "" && acct.Version != old.Version+1 {
+			return fmt.Errorf("%w: account %s is at version %d, got %d", accounts.ErrConflict, acct.AccountNumber, old.Version, acct.Version)
+		}
+		changes, err := old.Changes(acct, versions[acct.AccountNumber], now)
+		if err != nil {
+			return err
+		}
+		events = append(events, changes...)
+		latest[acct.AccountNumber] = acct
+		versions[acct.AccountNumber] += uint64(len(changes))
+	}
+	// A write may change nothing the events record, such as only the account's version, but its
+	// published events and the projection are updated all the same.
+	if len(events) == 0 {
+		if write != nil {
+			err := write()
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := s.events.AppendWith(write, events...)
+		if err != nil {
+			return fmt.Errorf("error appending account events %w", err)
+		}
+	}
+	if s.outbox != nil && len(published) > 0 {
+		s.outbox.Record(published...)
+	}
+	for acctNum, version := range versions {
+		s.versions[acctNum] = version
+	}
+	return s.projection.PutAll(accts...)
+}
+
+func (s *EventSourcedAccountStore) Delete(acctNum accounts.AccountNumber) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.projection.GetByAcctNum(acctNum); err != nil {
+		return err
+	}
+	version := s.versions[acctNum] + 1
+	err := s.events.Append(accounts.AccountEvent{AccountNumber: acctNum, Version: version, Type: accounts.AccountDeleted, Timestamp: time.Now()})
+	if err != nil {
+		return fmt.Errorf("error appending account events %w", err)
+	}
+	s.versions[acctNum] = version
+	return s.projection.Delete(acctNum)
+}
@@ -0,0 +1,173 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSourcedAccountStore(t *testing.T) {
+	newStore := func(t *testing.T, events accounts.EventStore) *EventSourcedAccountStore {
+		t.Helper()
+		store, err := NewEventSourcedAccountStore(events)
+		require.NoError(t, err)
+		return store
+	}
+	eventTypes := func(events []accounts.AccountEvent) []accounts.EventType {
+		types := make([]accounts.EventType, 0, len(events))
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		return types
+	}
+
+	t.Run("should error getting account which does not exist", func(t *testing.T) {
+		store := newStore(t, NewInMemoryEventStore())
+		_, err := store.GetByAcctNum("01000039")
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+		assert.ErrorIs(t, store.Delete("01000039"), accounts.ErrAccountNotFound)
+	})
+	t.Run("should record each write as events and read it back", func(t *testing.T) {
+		store := newStore(t, NewInMemoryEventStore())
+		acct := newTestAccount(t)
+		require.NoError(t, store.Create(acct))
+		assert.ErrorIs(t, store.Create(acct), accounts.ErrAccountNumberTaken)
+
+		funded := acct.CorrectBalance(100)
+		funded.Name = "Mr Bar"
+		funded.Version++
+		require.NoError(t, store.Put(funded))
+		joint, err := funded.AddHolder("usr-456", accounts.SecondaryHolder)
+		require.NoError(t, err)
+		joint.Version++
+		spent := joint.CorrectBalance(40)
+		spent.Version++
+		require.NoError(t, store.PutAll(joint, spent))
+		// An out of date copy records nothing.
+		assert.ErrorIs(t, store.Put(funded), accounts.ErrConflict)
+
+		got, err := store.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, spent, got)
+		accts, err := store.GetByUserID("usr-456")
+		require.NoError(t, err)
+		assert.Equal(t, []accounts.BankAccount{spent}, accts)
+
+		events, err := store.Events(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, []accounts.EventType{accounts.AccountOpened, accounts.Renamed, accounts.Deposited, accounts.HolderAdded, accounts.Withdrawn}, eventTypes(events))
+		assert.Equal(t, uint64(5), store.Version(acct.AccountNumber))
+
+		before, err := store.GetAtVersion(acct.AccountNumber, 3)
+		require.NoError(t, err)
+		assert.Equal(t, funded, before)
+		_, err = store.GetAtVersion(acct.AccountNumber, 6)
+		assert.ErrorIs(t, err, accounts.ErrInvalidEvent)
+	})
+	t.Run("should keep the stream of a deleted account", func(t *testing.T) {
+		store := newStore(t, NewInMemoryEventStore())
+		acct := newTestAccount(t)
+		require.NoError(t, store.Put(acct))
+		require.NoError(t, store.Delete(acct.AccountNumber))
+
+		_, err := store.GetByAcctNum(acct.AccountNumber)
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+		_, err = store.GetByUserID(acct.PrimaryHolder())
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+		events, err := store.Events(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, []accounts.EventType{accounts.AccountOpened, accounts.AccountDeleted}, eventTypes(events))
+
+		require.NoError(t, store.Create(acct))
+		assert.Equal(t, uint64(3), store.Version(acct.AccountNumber))
+	})
+	t.Run("should rebuild the same projection from the events", func(t *testing.T) {
+		events := NewInMemoryEventStore()
+		store := newStore(t, events)
+		acct1, acct2, acct3 := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+		require.NoError(t, store.PutAll(acct1, acct2, acct3))
+		acct1 = acct1.CorrectBalance(25)
+		acct1.Version++
+		acct2, err := acct2.AddHolder("usr-456", accounts.SecondaryHolder)
+		require.NoError(t, err)
+		acct2.Version++
+		require.NoError(t, store.PutAll(acct1, acct2))
+		require.NoError(t, store.Delete(acct3.AccountNumber))
+		want, err := store.List()
+		require.NoError(t, err)
+
+		require.NoError(t, store.Rebuild())
+		got, err := store.List()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		fresh := newStore(t, events)
+		got, err = fresh.List()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.Equal(t, store.Version(acct1.AccountNumber), fresh.Version(acct1.AccountNumber))
+	})
+	t.Run("should append nothing when the write made with it fails", func(t *testing.T) {
+		events := NewInMemoryEventStore()
+		store := newStore(t, events)
+		acct := newTestAccount(t)
+		require.NoError(t, store.Put(acct))
+		updated := acct.CorrectBalance(10)
+		updated.Version++
+
+		errWrite := errors.New("write failed")
+		assert.ErrorIs(t, store.PutAllWith(func() error { return errWrite }, updated), errWrite)
+		stream, err := events.Load(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, stream, 1)
+		got, err := store.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, acct, got)
+
+		require.NoError(t, store.PutAllWith(func() error { return nil }, updated))
+		got, err = store.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 10.0, got.Balance())
+	})
+	t.Run("should not overwrite events appended by another writer", func(t *testing.T) {
+		events := NewInMemoryEventStore()
+		store1 := newStore(t, events)
+		acct := newTestAccount(t)
+		require.NoError(t, store1.Put(acct))
+		store2 := newStore(t, events)
+
+		first, second := acct.CorrectBalance(10), acct.CorrectBalance(20)
+		first.Version++
+		second.Version++
+		require.NoError(t, store1.Put(first))
+		err := store2.Put(second)
+		assert.ErrorIs(t, err, accounts.ErrVersionConflict)
+		got, err := store2.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, acct, got)
+
+		require.NoError(t, store2.Rebuild())
+		got, err = store2.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 10.0, got.Balance())
+	})
+	t.Run("should not make the write when another writer has appended first", func(t *testing.T) {
+		events := NewInMemoryEventStore()
+		store1 := newStore(t, events)
+		acct := newTestAccount(t)
+		require.NoError(t, store1.Put(acct))
+		store2 := newStore(t, events)
+
+		first, second := acct.CorrectBalance(10), acct.CorrectBalance(20)
+		first.Version++
+		second.Version++
+		require.NoError(t, store1.Put(first))
+		written := false
+		err := store2.PutAllWith(func() error { written = true; return nil }, second)
+		assert.ErrorIs(t, err, accounts.ErrVersionConflict)
+		assert.False(t, written)
+	})
+}
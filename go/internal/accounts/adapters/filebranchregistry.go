@@ -0,0 +1,48 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// branchFile is the JSON layout of one branch in the branches file, which holds a list of them.
+// The first branch is the default for new accounts.
+type branchFile struct {
+	SortCode string `json:"sortCode"`
+	Name     string `json:"name"`
+	Address  struct {
+		Line1    string `json:"line1"`
+		Line2    string `json:"line2,omitempty"`
+		Line3    string `json:"line3,omitempty"`
+		Town     string `json:"town"`
+		County   string `json:"county"`
+		Postcode string `json:"postcode"`
+	} `json:"address"`
+}
+
+// LoadBranchRegistry reads the bank's branches from a local JSON file. Unlike the rates file it is
+// only read at startup, sort codes are not expected to change while the server is running.
+func LoadBranchRegistry(path string) (accounts.BranchRegistry, error) {
+	by, err := os.ReadFile(path)
+	if err != nil {
+		return accounts.BranchRegistry{}, fmt.Errorf("error reading branches file %w", err)
+	}
+	var file []branchFile
+	err = json.Unmarshal(by, &file)
+	if err != nil {
+		return accounts.BranchRegistry{}, fmt.Errorf("error parsing branches file %w", err)
+	}
+	branches := make([]accounts.Branch, 0, len(file))
+	for _, b := range file {
+		addr, err := users.NewAddress(b.Address.Line1, b.Address.Town, b.Address.County, b.Address.Postcode,
+			users.WithLine2(b.Address.Line2), users.WithLine3(b.Address.Line3))
+		if err != nil {
+			return accounts.BranchRegistry{}, fmt.Errorf("invalid address for branch %s %w", b.SortCode, err)
+		}
+		branches = append(branches, accounts.Branch{SortCode: accounts.SortCode(b.SortCode), Name: b.Name, Address: addr})
+	}
+	return accounts.NewBranchRegistry(branches...)
+}
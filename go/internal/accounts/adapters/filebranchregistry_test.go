@@ -0,0 +1,63 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBranchRegistry(t *testing.T) {
+	writeBranches := func(t *testing.T, contents string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "branches.json")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	t.Run("should load every branch with the first as default", func(t *testing.T) {
+		path := writeBranches(t, `[
+			{"sortCode": "20-00-00", "name": "North", "address": {"line1": "1 High St", "town": "Leeds", "county": "West Yorkshire", "postcode": "LS1 1AA"}},
+			{"sortCode": "20-00-01", "name": "South", "address": {"line1": "2 High St", "line2": "Floor 3", "town": "Brighton", "county": "East Sussex", "postcode": "BN1 1AA"}}
+		]`)
+		registry, err := LoadBranchRegistry(path)
+		require.NoError(t, err)
+
+		assert.Len(t, registry.List(), 2)
+		def, err := registry.Default()
+		require.NoError(t, err)
+		assert.Equal(t, accounts.SortCode("20-00-00"), def.SortCode)
+		south, err := registry.Get("20-00-01")
+		require.NoError(t, err)
+		assert.Equal(t, "South", south.Name)
+		assert.Equal(t, "Floor 3", south.Address.Line2)
+	})
+	t.Run("should error for a malformed sort code", func(t *testing.T) {
+		path := writeBranches(t, `[{"sortCode": "200000", "name": "North", "address": {"line1": "1 High St", "town": "Leeds", "county": "West Yorkshire", "postcode": "LS1 1AA"}}]`)
+		_, err := LoadBranchRegistry(path)
+		assert.Error(t, err)
+	})
+	t.Run("should error for a missing address", func(t *testing.T) {
+		path := writeBranches(t, `[{"sortCode": "20-00-00", "name": "North"}]`)
+		_, err := LoadBranchRegistry(path)
+		assert.Error(t, err)
+	})
+	t.Run("should error for a file without branches", func(t *testing.T) {
+		_, err := LoadBranchRegistry(writeBranches(t, `[]`))
+		assert.Error(t, err)
+	})
+	t.Run("should error for a missing file", func(t *testing.T) {
+		_, err := LoadBranchRegistry(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+	})
+	t.Run("should load the branches file shipped with the api", func(t *testing.T) {
+		registry, err := LoadBranchRegistry("../../../branches.json")
+		require.NoError(t, err)
+		def, err := registry.Default()
+		require.NoError(t, err)
+		assert.Equal(t, accounts.HeadOffice.SortCode, def.SortCode)
+	})
+}
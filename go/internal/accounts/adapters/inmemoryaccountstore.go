@@ -2,20 +2,49 @@ package adapters
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/events"
 	"eaglebank/internal/users"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
 )
 
 type InMemoryAccountStore struct {
-	mu            sync.RWMutex
-	acctsByNumber map[accounts.AccountNumber]accounts.BankAccount
-	acctsByUserID map[users.UserID][]accounts.BankAccount
+	mu               sync.RWMutex
+	acctsByNumber    map[accounts.AccountNumber]accounts.BankAccount
+	acctNumsByUserID map[users.UserID][]accounts.AccountNumber
+	outbox           events.Recorder
 }
 
-func NewInMemoryAccountStore() *InMemoryAccountStore {
+// AccountStoreOption configures InMemoryAccountStore and EventSourcedAccountStore.
+type AccountStoreOption func(*accountStoreOptions)
+
+type accountStoreOptions struct {
+	outbox events.Recorder
+}
+
+// WithOutbox records the events passed to Create and Put in the outbox, without one they are
+// dropped.
+func WithOutbox(outbox events.Recorder) AccountStoreOption {
+	return func(o *accountStoreOptions) {
+		o.outbox = outbox
+	}
+}
+
+func newAccountStoreOptions(opts []AccountStoreOption) accountStoreOptions {
+	var o accountStoreOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func NewInMemoryAccountStore(opts ...AccountStoreOption) *InMemoryAccountStore {
 	return &InMemoryAccountStore{
-		acctsByNumber: make(map[accounts.AccountNumber]accounts.BankAccount),
-		acctsByUserID: make(map[users.UserID][]accounts.BankAccount),
+		acctsByNumber:    make(map[accounts.AccountNumber]accounts.BankAccount),
+		acctNumsByUserID: make(map[users.UserID][]accounts.AccountNumber),
+		outbox:           newAccountStoreOptions(opts).outbox,
 	}
 }
 
@@ -30,34 +59,126 @@ func (s *InMemoryAccountStore) GetByAcctNum(acctNum accounts.AccountNumber) (acc
 	return acct, nil
 }
 
+// GetByUserID returns every account the user holds, including joint accounts.
 func (s *InMemoryAccountStore) GetByUserID(userID users.UserID) ([]accounts.BankAccount, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	accts, ok := s.acctsByUserID[userID]
+	acctNums, ok := s.acctNumsByUserID[userID]
 	if !ok {
 		return nil, accounts.ErrAccountNotFound
 	}
-	result := make([]accounts.BankAccount, len(accts))
-	copy(result, accts)
+	result := make([]accounts.BankAccount, 0, len(acctNums))
+	for _, acctNum := range acctNums {
+		result = append(result, s.acctsByNumber[acctNum])
+	}
+	return result, nil
+}
+
+// List returns every account in account number order.
+func (s *InMemoryAccountStore) List() ([]accounts.BankAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]accounts.BankAccount, 0, len(s.acctsByNumber))
+	for _, acct := range s.acctsByNumber {
+		result = append(result, acct)
+	}
+	slices.SortFunc(result, func(a, b accounts.BankAccount) int {
+		return strings.Compare(a.AccountNumber.String(), b.AccountNumber.String())
+	})
 	return result, nil
 }
 
-func (s *InMemoryAccountStore) Put(acct accounts.BankAccount) error {
+func (s *InMemoryAccountStore) Create(acct accounts.BankAccount, evts ...events.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.acctsByNumber[acct.AccountNumber] = acct
-	for i, a := range s.acctsByUserID[acct.UserID] {
-		if a.AccountNumber == acct.AccountNumber {
-			s.acctsByUserID[acct.UserID][i] = acct
-			return nil
+	if _, ok := s.acctsByNumber[acct.AccountNumber]; ok {
+		return accounts.ErrAccountNumberTaken
+	}
+	s.record(evts)
+	s.put(acct)
+	return nil
+}
+
+// Put stores a new account, or replaces a stored one when the account's Version is the next one
+// after it, otherwise it fails with ErrConflict. The events are recorded along with the account.
+func (s *InMemoryAccountStore) Put(acct accounts.BankAccount, evts ...events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putAll([]accounts.BankAccount{acct}, evts, nil)
+}
+
+// PutAll writes every account under one lock, so readers see either none or all of them. If any
+// account's Version is out of date none are written.
+func (s *InMemoryAccountStore) PutAll(accts ...accounts.BankAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putAll(accts, nil, nil)
+}
+
+// PutAllWith writes the accounts together with a write to another store, standing in for one
+// database transaction across both. Under the store's lock it checks every account's Version, then
+// calls write, and only stores the accounts if write succeeds. If any account is out of date write
+// is never called.
+func (s *InMemoryAccountStore) PutAllWith(write func() error, accts ...accounts.BankAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putAll(accts, nil, write)
+}
+
+func (s *InMemoryAccountStore) putAll(accts []accounts.BankAccount, evts []events.Event, write func() error) error {
+	versions := make(map[accounts.AccountNumber]uint64)
+	for _, acct := range accts {
+		version, ok := versions[acct.AccountNumber]
+		if !ok {
+			old, stored := s.acctsByNumber[acct.AccountNumber]
+			version, ok = old.Version, stored
+		}
+		if ok && acct.Version != version+1 {
+			return fmt.Errorf("%w: account %s is at version %d, got %d", accounts.ErrConflict, acct.AccountNumber, version, acct.Version)
 		}
+		versions[acct.AccountNumber] = acct.Version
+	}
+	if write != nil {
+		err := write()
+		if err != nil {
+			return err
+		}
+	}
+	s.record(evts)
+	for _, acct := range accts {
+		s.put(acct)
 	}
-	s.acctsByUserID[acct.UserID] = append(s.acctsByUserID[acct.UserID], acct)
 	return nil
 }
 
+func (s *InMemoryAccountStore) record(evts []events.Event) {
+	if s.outbox != nil && len(evts) > 0 {
+		s.outbox.Record(evts...)
+	}
+}
+
+func (s *InMemoryAccountStore) put(acct accounts.BankAccount) {
+	if old, ok := s.acctsByNumber[acct.AccountNumber]; ok {
+		for _, userID := range old.HolderIDs() {
+			if !acct.IsHolder(userID) {
+				s.unindex(userID, acct.AccountNumber)
+			}
+		}
+	}
+	s.acctsByNumber[acct.AccountNumber] = acct
+	for _, userID := range acct.HolderIDs() {
+		if !slices.Contains(s.acctNumsByUserID[userID], acct.AccountNumber) {
+			s.acctNumsByUserID[userID] = append(s.acctNumsByUserID[userID], acct.AccountNumber)
+		}
+	}
+}
+
 func (s *InMemoryAccountStore) Delete(acctNum accounts.AccountNumber) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -67,20 +188,17 @@ func (s *InMemoryAccountStore) Delete(acctNum accounts.AccountNumber) error {
 		return accounts.ErrAccountNotFound
 	}
 	delete(s.acctsByNumber, acctNum)
-
-	accts, ok := s.acctsByUserID[acctToDel.UserID]
-	if !ok {
-		return accounts.ErrAccountNotFound
-	}
-	for i, acct := range accts {
-		if acct.AccountNumber == acctToDel.AccountNumber {
-			copy(accts[i:], accts[i+1:])
-			accts[len(accts)-1] = accounts.BankAccount{}
-			accts = accts[:len(accts)-1]
-			s.acctsByUserID[acctToDel.UserID] = accts
-			break
-		}
+	for _, userID := range acctToDel.HolderIDs() {
+		s.unindex(userID, acctNum)
 	}
-
 	return nil
 }
+
+func (s *InMemoryAccountStore) unindex(userID users.UserID, acctNum accounts.AccountNumber) {
+	acctNums := slices.DeleteFunc(s.acctNumsByUserID[userID], func(n accounts.AccountNumber) bool { return n == acctNum })
+	if len(acctNums) == 0 {
+		delete(s.acctNumsByUserID, userID)
+		return
+	}
+	s.acctNumsByUserID[userID] = acctNums
+}
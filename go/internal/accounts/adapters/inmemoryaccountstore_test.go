@@ -2,6 +2,7 @@ package adapters
 
 import (
 	"eaglebank/internal/accounts"
+	"errors"
 	"testing"
 	"time"
 
@@ -13,12 +14,12 @@ func TestNewInMemoryAccountStore(t *testing.T) {
 	store := NewInMemoryAccountStore()
 
 	t.Run("should error getting account which does not exist", func(t *testing.T) {
-		missingID := accounts.AccountNumber("0100000")
+		missingID := accounts.AccountNumber("01000039")
 		_, err := store.GetByAcctNum(missingID)
 		assert.Error(t, err)
 	})
 	t.Run("should error not found deleting account which does not exist", func(t *testing.T) {
-		missingID := accounts.AccountNumber("0100000")
+		missingID := accounts.AccountNumber("01000039")
 		err := store.Delete(missingID)
 		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
 	})
@@ -39,13 +40,14 @@ func TestNewInMemoryAccountStore(t *testing.T) {
 			require.Equal(t, acct1, gotAcct)
 		})
 		t.Run("should get both accounts by userID", func(t *testing.T) {
-			gotAccts, err := store.GetByUserID(acct1.UserID)
+			gotAccts, err := store.GetByUserID(acct1.PrimaryHolder())
 			require.NoError(t, err)
 			require.Len(t, gotAccts, 2)
 		})
 		t.Run("should update existing account", func(t *testing.T) {
 			updatedAcct := acct1
 			updatedAcct.Name = "new name"
+			updatedAcct.Version++
 			require.NotEqual(t, acct1.Name, updatedAcct.Name)
 
 			err := store.Put(updatedAcct)
@@ -55,24 +57,131 @@ func TestNewInMemoryAccountStore(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, updatedAcct, gotAcct)
 
-			gotAccts, err := store.GetByUserID(acct1.UserID)
+			gotAccts, err := store.GetByUserID(acct1.PrimaryHolder())
 			require.NoError(t, err)
 			require.Len(t, gotAccts, 2)
 			require.Contains(t, gotAccts, updatedAcct)
 		})
+		t.Run("should get joint account for every holder", func(t *testing.T) {
+			joint, err := acct2.AddHolder("usr-456", accounts.SecondaryHolder)
+			require.NoError(t, err)
+			joint.Version++
+			require.NoError(t, store.Put(joint))
+
+			gotAccts, err := store.GetByUserID("usr-456")
+			require.NoError(t, err)
+			require.Equal(t, []accounts.BankAccount{joint}, gotAccts)
+
+			gotAccts, err = store.GetByUserID(acct1.PrimaryHolder())
+			require.NoError(t, err)
+			require.Contains(t, gotAccts, joint)
+		})
+		t.Run("should stop returning account for removed holder", func(t *testing.T) {
+			joint, err := store.GetByAcctNum(acct2.AccountNumber)
+			require.NoError(t, err)
+			single, err := joint.RemoveHolder("usr-456")
+			require.NoError(t, err)
+			single.Version++
+			require.NoError(t, store.Put(single))
+
+			_, err = store.GetByUserID("usr-456")
+			require.ErrorIs(t, err, accounts.ErrAccountNotFound)
+			acct2 = single
+		})
 		t.Run("should delete existing account", func(t *testing.T) {
 			err := store.Delete(acct1.AccountNumber)
 			require.NoError(t, err)
 
 			require.NotContains(t, store.acctsByNumber, acct1.AccountNumber)
 			require.Contains(t, store.acctsByNumber, acct2.AccountNumber)
-			accts := store.acctsByUserID[acct1.UserID]
-			require.Len(t, accts, 1)
-			require.NotContains(t, accts, acct1)
-			require.Contains(t, accts, acct2)
+			acctNums := store.acctNumsByUserID[acct1.PrimaryHolder()]
+			require.Equal(t, []accounts.AccountNumber{acct2.AccountNumber}, acctNums)
 		})
 	})
+	t.Run("should not overwrite an existing account on create", func(t *testing.T) {
+		acct := newTestAccount(t)
+		require.NoError(t, store.Create(acct))
+
+		dup := newTestAccount(t)
+		dup.AccountNumber = acct.AccountNumber
+		dup.Name = "Mr Bar"
+		assert.ErrorIs(t, store.Create(dup), accounts.ErrAccountNumberTaken)
+
+		got, err := store.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, acct, got)
+	})
+	t.Run("should not overwrite a change made since the account was read", func(t *testing.T) {
+		acct := newTestAccount(t)
+		require.NoError(t, store.Put(acct))
+		first, second := acct, acct
+		first.Name, second.Name = "Mr Bar", "Mr Baz"
+		first.Version++
+		second.Version++
 
+		require.NoError(t, store.Put(first))
+		assert.ErrorIs(t, store.Put(second), accounts.ErrConflict)
+		other := newTestAccount(t)
+		assert.ErrorIs(t, store.PutAll(other, second), accounts.ErrConflict)
+
+		got, err := store.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, first, got)
+		_, err = store.GetByAcctNum(other.AccountNumber)
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+	})
+	t.Run("should list every account in account number order", func(t *testing.T) {
+		listStore := NewInMemoryAccountStore()
+		acct1, acct2 := newTestAccount(t), newTestAccount(t)
+		require.NoError(t, listStore.PutAll(acct1, acct2))
+		if acct2.AccountNumber < acct1.AccountNumber {
+			acct1, acct2 = acct2, acct1
+		}
+
+		accts, err := listStore.List()
+		require.NoError(t, err)
+		assert.Equal(t, []accounts.BankAccount{acct1, acct2}, accts)
+	})
+	t.Run("should put every account together", func(t *testing.T) {
+		acct1, acct2 := newTestAccount(t), newTestAccount(t)
+		acct2.Name = "Mr Foo's Savings"
+
+		require.NoError(t, store.PutAll(acct1, acct2))
+
+		got, err := store.GetByAcctNum(acct2.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, acct2, got)
+		accts, err := store.GetByUserID("usr-123")
+		require.NoError(t, err)
+		assert.Contains(t, accts, acct1)
+	})
+	t.Run("should put the accounts only with a write which succeeds", func(t *testing.T) {
+		acct := newTestAccount(t)
+		require.NoError(t, store.Put(acct))
+		updated := acct.CorrectBalance(10)
+		updated.Version++
+
+		errWrite := errors.New("write failed")
+		err := store.PutAllWith(func() error { return errWrite }, updated)
+		assert.ErrorIs(t, err, errWrite)
+		got, err := store.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, acct, got)
+
+		stale := acct.CorrectBalance(20)
+		err = store.PutAllWith(func() error {
+			t.Error("write should not be called for an out of date account")
+			return nil
+		}, stale)
+		assert.ErrorIs(t, err, accounts.ErrConflict)
+
+		written := false
+		require.NoError(t, store.PutAllWith(func() error { written = true; return nil }, updated))
+		assert.True(t, written)
+		got, err = store.GetByAcctNum(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, updated, got)
+	})
 }
 
 func newTestAccount(t *testing.T) accounts.BankAccount {
@@ -83,7 +192,7 @@ func newTestAccount(t *testing.T) accounts.BankAccount {
 	now := time.Now()
 
 	return accounts.BankAccount{
-		UserID:           "usr-123",
+		Holders:          []accounts.Holder{{UserID: "usr-123", Role: accounts.PrimaryHolder, AddedTimestamp: now}},
 		AccountNumber:    acctNum,
 		SortCode:         "01-01-01",
 		Name:             "Mr Foo",
@@ -0,0 +1,71 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+type InMemoryEventStore struct {
+	mu        sync.RWMutex
+	events    []accounts.AccountEvent
+	positions map[accounts.AccountNumber][]int
+}
+
+func NewInMemoryEventStore() *InMemoryEventStore {
+	return &InMemoryEventStore{positions: make(map[accounts.AccountNumber][]int)}
+}
+
+func (s *InMemoryEventStore) Append(events ...accounts.AccountEvent) error {
+	return s.AppendWith(nil, events...)
+}
+
+func (s *InMemoryEventStore) AppendWith(write func() error, events ...accounts.AccountEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := make(map[accounts.AccountNumber]uint64)
+	for _, e := range events {
+		if _, ok := next[e.AccountNumber]; !ok {
+			next[e.AccountNumber] = uint64(len(s.positions[e.AccountNumber])) + 1
+		}
+		if e.Version != next[e.AccountNumber] {
+			return fmt.Errorf("%w: account %s expected version %d, got %d", accounts.ErrVersionConflict, e.AccountNumber, next[e.AccountNumber], e.Version)
+		}
+		next[e.AccountNumber]++
+	}
+	if write != nil {
+		err := write()
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		s.positions[e.AccountNumber] = append(s.positions[e.AccountNumber], len(s.events))
+		s.events = append(s.events, e)
+	}
+	return nil
+}
+
+func (s *InMemoryEventStore) Load(acctNum accounts.AccountNumber) ([]accounts.AccountEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	positions, ok := s.positions[acctNum]
+	if !ok {
+		return nil, accounts.ErrAccountNotFound
+	}
+	result := make([]accounts.AccountEvent, 0, len(positions))
+	for _, i := range positions {
+		result = append(result, s.events[i])
+	}
+	return result, nil
+}
+
+func (s *InMemoryEventStore) LoadAll() ([]accounts.AccountEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.events), nil
+}
@@ -0,0 +1,62 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryEventStore(t *testing.T) {
+	store := NewInMemoryEventStore()
+	acct1, acct2 := newTestAccount(t), newTestAccount(t)
+	opened := func(acct accounts.BankAccount) accounts.AccountEvent {
+		return accounts.AccountEvent{AccountNumber: acct.AccountNumber, Version: 1, Type: accounts.AccountOpened, Account: &acct}
+	}
+	deposited := func(acct accounts.BankAccount, version uint64) accounts.AccountEvent {
+		return accounts.AccountEvent{AccountNumber: acct.AccountNumber, Version: version, Type: accounts.Deposited, Amount: 10}
+	}
+
+	t.Run("should not find a stream which does not exist", func(t *testing.T) {
+		_, err := store.Load(acct1.AccountNumber)
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+	})
+	t.Run("should append events to several streams together", func(t *testing.T) {
+		require.NoError(t, store.Append(opened(acct1), deposited(acct1, 2), opened(acct2)))
+		require.NoError(t, store.Append(deposited(acct2, 2)))
+
+		events, err := store.Load(acct1.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, []accounts.AccountEvent{opened(acct1), deposited(acct1, 2)}, events)
+
+		events, err = store.LoadAll()
+		require.NoError(t, err)
+		assert.Equal(t, []accounts.AccountEvent{opened(acct1), deposited(acct1, 2), opened(acct2), deposited(acct2, 2)}, events)
+	})
+	t.Run("should append nothing when any version is out of date", func(t *testing.T) {
+		err := store.Append(deposited(acct1, 3), deposited(acct2, 2))
+		assert.ErrorIs(t, err, accounts.ErrVersionConflict)
+		err = store.Append(deposited(acct1, 3), deposited(acct1, 5))
+		assert.ErrorIs(t, err, accounts.ErrVersionConflict)
+
+		events, err := store.Load(acct1.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+	})
+	t.Run("should append nothing when the write made with the events fails", func(t *testing.T) {
+		errWrite := errors.New("write failed")
+		err := store.AppendWith(func() error { return errWrite }, deposited(acct1, 3))
+		assert.ErrorIs(t, err, errWrite)
+
+		called := false
+		err = store.AppendWith(func() error { called = true; return nil }, deposited(acct1, 4))
+		assert.ErrorIs(t, err, accounts.ErrVersionConflict)
+		assert.False(t, called)
+
+		events, err := store.Load(acct1.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+	})
+}
@@ -0,0 +1,63 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"sync"
+)
+
+type InMemoryHolderChangeStore struct {
+	mu      sync.RWMutex
+	changes map[accounts.HolderChangeID]accounts.HolderChange
+	order   []accounts.HolderChangeID
+}
+
+func NewInMemoryHolderChangeStore() *InMemoryHolderChangeStore {
+	return &InMemoryHolderChangeStore{
+		changes: make(map[accounts.HolderChangeID]accounts.HolderChange),
+	}
+}
+
+func (s *InMemoryHolderChangeStore) Get(id accounts.HolderChangeID) (accounts.HolderChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	change, ok := s.changes[id]
+	if !ok {
+		return accounts.HolderChange{}, accounts.ErrHolderChangeNotFound
+	}
+	return change, nil
+}
+
+func (s *InMemoryHolderChangeStore) GetByAcctNum(acctNum accounts.AccountNumber) ([]accounts.HolderChange, error) {
+	return s.filter(func(c accounts.HolderChange) bool { return c.AccountNumber == acctNum }), nil
+}
+
+// GetByUserID returns the changes that add or remove the user, not those they requested.
+func (s *InMemoryHolderChangeStore) GetByUserID(userID users.UserID) ([]accounts.HolderChange, error) {
+	return s.filter(func(c accounts.HolderChange) bool { return c.UserID == userID }), nil
+}
+
+func (s *InMemoryHolderChangeStore) Put(change accounts.HolderChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.changes[change.ID]; !ok {
+		s.order = append(s.order, change.ID)
+	}
+	s.changes[change.ID] = change
+	return nil
+}
+
+func (s *InMemoryHolderChangeStore) filter(keep func(accounts.HolderChange) bool) []accounts.HolderChange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []accounts.HolderChange
+	for _, id := range s.order {
+		if change := s.changes[id]; keep(change) {
+			result = append(result, change)
+		}
+	}
+	return result
+}
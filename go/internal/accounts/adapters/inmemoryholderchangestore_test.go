@@ -0,0 +1,50 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryHolderChangeStore(t *testing.T) {
+	store := NewInMemoryHolderChangeStore()
+
+	t.Run("should error not found getting change which does not exist", func(t *testing.T) {
+		_, err := store.Get("hch-missing")
+		assert.ErrorIs(t, err, accounts.ErrHolderChangeNotFound)
+	})
+	t.Run("should perform put-get-update cycle without errors", func(t *testing.T) {
+		invite, err := accounts.NewHolderChange("01000004", accounts.AddHolderChange, "usr-bob", accounts.SecondaryHolder, "usr-alice")
+		require.NoError(t, err)
+		removal, err := accounts.NewHolderChange("01000012", accounts.RemoveHolderChange, "usr-alice", "", "usr-bob")
+		require.NoError(t, err)
+		require.NoError(t, store.Put(invite))
+		require.NoError(t, store.Put(removal))
+
+		t.Run("should get an existing change", func(t *testing.T) {
+			got, err := store.Get(invite.ID)
+			require.NoError(t, err)
+			assert.Equal(t, invite, got)
+		})
+		t.Run("should get changes by account number", func(t *testing.T) {
+			got, err := store.GetByAcctNum("01000012")
+			require.NoError(t, err)
+			assert.Equal(t, []accounts.HolderChange{removal}, got)
+		})
+		t.Run("should get changes by the user being added or removed", func(t *testing.T) {
+			got, err := store.GetByUserID("usr-bob")
+			require.NoError(t, err)
+			assert.Equal(t, []accounts.HolderChange{invite}, got)
+		})
+		t.Run("should update existing change in place", func(t *testing.T) {
+			approved := invite.Approve("usr-bob")
+			require.NoError(t, store.Put(approved))
+
+			got, err := store.GetByUserID("usr-bob")
+			require.NoError(t, err)
+			assert.Equal(t, []accounts.HolderChange{approved}, got)
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"sync"
+)
+
+type InMemoryOverdraftApplicationStore struct {
+	mu           sync.RWMutex
+	applications map[accounts.OverdraftApplicationID]accounts.OverdraftApplication
+	order        []accounts.OverdraftApplicationID
+}
+
+func NewInMemoryOverdraftApplicationStore() *InMemoryOverdraftApplicationStore {
+	return &InMemoryOverdraftApplicationStore{
+		applications: make(map[accounts.OverdraftApplicationID]accounts.OverdraftApplication),
+	}
+}
+
+func (s *InMemoryOverdraftApplicationStore) Get(id accounts.OverdraftApplicationID) (accounts.OverdraftApplication, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.applications[id]
+	if !ok {
+		return accounts.OverdraftApplication{}, accounts.ErrOverdraftApplicationNotFound
+	}
+	return app, nil
+}
+
+func (s *InMemoryOverdraftApplicationStore) GetByAcctNum(acctNum accounts.AccountNumber) ([]accounts.OverdraftApplication, error) {
+	return s.filter(func(a accounts.OverdraftApplication) bool { return a.AccountNumber == acctNum }), nil
+}
+
+func (s *InMemoryOverdraftApplicationStore) GetByStatus(status accounts.OverdraftApplicationStatus) ([]accounts.OverdraftApplication, error) {
+	return s.filter(func(a accounts.OverdraftApplication) bool { return a.Status == status }), nil
+}
+
+func (s *InMemoryOverdraftApplicationStore) Put(app accounts.OverdraftApplication) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.applications[app.ID]; !ok {
+		s.order = append(s.order, app.ID)
+	}
+	s.applications[app.ID] = app
+	return nil
+}
+
+func (s *InMemoryOverdraftApplicationStore) filter(keep func(accounts.OverdraftApplication) bool) []accounts.OverdraftApplication {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []accounts.OverdraftApplication
+	for _, id := range s.order {
+		if app := s.applications[id]; keep(app) {
+			result = append(result, app)
+		}
+	}
+	return result
+}
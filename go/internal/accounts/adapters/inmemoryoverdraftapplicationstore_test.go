@@ -0,0 +1,49 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryOverdraftApplicationStore(t *testing.T) {
+	store := NewInMemoryOverdraftApplicationStore()
+
+	t.Run("should error not found getting application which does not exist", func(t *testing.T) {
+		_, err := store.Get("ovd-missing")
+		assert.ErrorIs(t, err, accounts.ErrOverdraftApplicationNotFound)
+	})
+	t.Run("should perform put-get-update cycle without errors", func(t *testing.T) {
+		acct1, acct2 := newTestAccount(t), newTestAccount(t)
+		app1, err := accounts.NewOverdraftApplication(acct1, 100, "usr-123")
+		require.NoError(t, err)
+		app2, err := accounts.NewOverdraftApplication(acct2, 200, "usr-123")
+		require.NoError(t, err)
+		require.NoError(t, store.Put(app1))
+		require.NoError(t, store.Put(app2))
+
+		t.Run("should get an existing application", func(t *testing.T) {
+			got, err := store.Get(app1.ID)
+			require.NoError(t, err)
+			assert.Equal(t, app1, got)
+		})
+		t.Run("should get applications by account number", func(t *testing.T) {
+			got, err := store.GetByAcctNum(acct2.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, []accounts.OverdraftApplication{app2}, got)
+		})
+		t.Run("should get applications by status", func(t *testing.T) {
+			app1.Status = accounts.ApprovedOverdraft
+			require.NoError(t, store.Put(app1))
+
+			got, err := store.GetByStatus(accounts.PendingOverdraft)
+			require.NoError(t, err)
+			assert.Equal(t, []accounts.OverdraftApplication{app2}, got)
+			got, err = store.GetByStatus(accounts.ApprovedOverdraft)
+			require.NoError(t, err)
+			assert.Equal(t, []accounts.OverdraftApplication{app1}, got)
+		})
+	})
+}
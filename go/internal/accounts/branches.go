@@ -0,0 +1,69 @@
+package accounts
+
+import (
+	"eaglebank/internal/users"
+	"fmt"
+)
+
+// Branch is a branch of the bank and the sort code it owns.
+type Branch struct {
+	SortCode SortCode
+	Name     string
+	Address  users.Address
+}
+
+func (b Branch) IsValid() bool {
+	return b.SortCode.IsValid() && b.Name != ""
+}
+
+// HeadOffice is the branch every account was opened at before the bank had more than one, it is
+// the default registry when AccountService is not given one.
+var HeadOffice = Branch{
+	SortCode: "10-10-10",
+	Name:     "Eagle Bank Head Office",
+	Address:  users.Address{Line1: "1 Eagle Street", Town: "London", County: "Greater London", Postcode: "EC1A 1AA"},
+}
+
+// BranchRegistry holds the sort codes the bank owns. The first branch is the default for new
+// accounts which do not ask for a particular sort code.
+type BranchRegistry struct {
+	branches []Branch
+}
+
+func NewBranchRegistry(branches ...Branch) (BranchRegistry, error) {
+	if len(branches) == 0 {
+		return BranchRegistry{}, fmt.Errorf("branch registry needs at least one branch")
+	}
+	seen := make(map[SortCode]bool, len(branches))
+	for _, b := range branches {
+		if !b.IsValid() {
+			return BranchRegistry{}, fmt.Errorf("invalid branch %+v", b)
+		}
+		if seen[b.SortCode] {
+			return BranchRegistry{}, fmt.Errorf("duplicate sort code %q", b.SortCode)
+		}
+		seen[b.SortCode] = true
+	}
+	return BranchRegistry{branches: branches}, nil
+}
+
+func (r BranchRegistry) Get(sortCode SortCode) (Branch, error) {
+	for _, b := range r.branches {
+		if b.SortCode == sortCode {
+			return b, nil
+		}
+	}
+	return Branch{}, ErrBranchNotFound
+}
+
+func (r BranchRegistry) List() []Branch {
+	return append([]Branch(nil), r.branches...)
+}
+
+// Default fails with ErrBranchNotFound for the zero registry, which has no branches.
+func (r BranchRegistry) Default() (Branch, error) {
+	if len(r.branches) == 0 {
+		return Branch{}, ErrBranchNotFound
+	}
+	return r.branches[0], nil
+}
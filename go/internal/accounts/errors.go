@@ -4,4 +4,39 @@ import "errors"
 
 var ErrAccountNotFound = errors.New("account not found")
 var ErrInsufficientFunds = errors.New("insufficient funds")
-var ErrTooManyFunds = errors.New("you have too much money")
+var ErrWithdrawalLimitReached = errors.New("monthly withdrawal limit reached for this account type")
+var ErrAlreadyHolder = errors.New("user already holds this account")
+var ErrNotHolder = errors.New("user does not hold this account")
+var ErrLastHolder = errors.New("cannot remove the last holder of an account")
+var ErrHolderChangeNotFound = errors.New("holder change not found")
+var ErrHolderChangePending = errors.New("a change for this holder is already pending")
+var ErrHolderChangeClosed = errors.New("holder change is no longer pending")
+var ErrNotApprover = errors.New("user cannot approve this holder change")
+var ErrNoCheckDigit = errors.New("account number base has no valid check digit")
+var ErrAccountNumberTaken = errors.New("account number already in use")
+var ErrAccountNumbersExhausted = errors.New("could not allocate a free account number")
+var ErrBranchNotFound = errors.New("branch not found")
+var ErrInvalidOverdraftLimit = errors.New("overdraft limit must be above zero and no more than the maximum")
+var ErrOverdraftApplicationNotFound = errors.New("overdraft application not found")
+var ErrOverdraftApplicationPending = errors.New("an overdraft application for this account is already pending")
+var ErrOverdraftApplicationClosed = errors.New("overdraft application is no longer pending")
+var ErrOwnOverdraftApplication = errors.New("staff cannot decide an overdraft application on their own account")
+var ErrInvalidStatusTransition = errors.New("account cannot move to that status")
+var ErrAccountNotEmpty = errors.New("account must have a zero balance to be closed")
+var ErrAccountFrozen = errors.New("account is frozen")
+var ErrAccountDormant = errors.New("account is dormant")
+var ErrAccountClosed = errors.New("account is closed")
+var ErrInvalidHoldAmount = errors.New("hold amount must be above zero")
+var ErrHoldExpiryInPast = errors.New("hold expiry must be in the future")
+var ErrHoldNotFound = errors.New("hold not found")
+var ErrHoldNotActive = errors.New("hold has already been released or expired")
+var ErrPotNotFound = errors.New("pot not found")
+var ErrPotNotEmpty = errors.New("pot must be emptied before it is deleted")
+var ErrTooManyPots = errors.New("account has the maximum number of pots")
+var ErrPotTargetInPast = errors.New("pot target date must be in the future")
+var ErrInvalidPotAmount = errors.New("pot transfer amount must be positive")
+var ErrInvalidEvent = errors.New("invalid account event")
+var ErrVersionConflict = errors.New("account event stream has moved on from the expected version")
+var ErrConflict = errors.New("account has been changed since it was read")
+var ErrPreconditionFailed = errors.New("account is no longer at the version the request was made against")
+var ErrExecutorClosed = errors.New("account executor is closed")
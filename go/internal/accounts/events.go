@@ -0,0 +1,417 @@
+package accounts
+
+import (
+	"eaglebank/internal/limits"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EventType is the kind of change an AccountEvent records.
+type EventType string
+
+const AccountOpened EventType = "account_opened"
+const Deposited EventType = "deposited"
+const Withdrawn EventType = "withdrawn"
+const Renamed EventType = "renamed"
+const Closed EventType = "closed"
+const StatusChanged EventType = "status_changed"
+const HolderAdded EventType = "holder_added"
+const HolderRemoved EventType = "holder_removed"
+const HoldPlaced EventType = "hold_placed"
+const HoldReleased EventType = "hold_released"
+const HoldExpired EventType = "hold_expired"
+const PotCreated EventType = "pot_created"
+const PotUpdated EventType = "pot_updated"
+const PotDeleted EventType = "pot_deleted"
+const MovedToPot EventType = "moved_to_pot"
+const MovedFromPot EventType = "moved_from_pot"
+const OverdraftChanged EventType = "overdraft_changed"
+const LimitsChanged EventType = "limits_changed"
+const OverdraftInterestAccrued EventType = "overdraft_interest_accrued"
+const OverdraftInterestCharged EventType = "overdraft_interest_charged"
+const CustomerActivityRecorded EventType = "customer_activity_recorded"
+const WithdrawalCounted EventType = "withdrawal_counted"
+
+// AccountDeleted removes the account. Its stream is kept, so the account can still be audited.
+const AccountDeleted EventType = "account_deleted"
+
+func EventTypes() []EventType {
+	return []EventType{
+		AccountOpened, Deposited, Withdrawn, Renamed, Closed, StatusChanged, HolderAdded, HolderRemoved,
+		HoldPlaced, HoldReleased, HoldExpired, PotCreated, PotUpdated, PotDeleted, MovedToPot, MovedFromPot,
+		OverdraftChanged, LimitsChanged, OverdraftInterestAccrued, OverdraftInterestCharged,
+		CustomerActivityRecorded, WithdrawalCounted, AccountDeleted,
+	}
+}
+
+func (t EventType) IsValid() bool {
+	return slices.Contains(EventTypes(), t)
+}
+
+func (t EventType) String() string {
+	return string(t)
+}
+
+// AccountEvent is one change in an account's append-only event stream. Version is the event's
+// position in the stream, starting from 1.
+type AccountEvent struct {
+	AccountNumber AccountNumber
+	Version       uint64
+	Type          EventType
+	// Amount is set for Deposited, Withdrawn, MovedToPot, MovedFromPot, OverdraftInterestCharged
+	// and WithdrawalCounted.
+	Amount float64
+	// Name is set for Renamed.
+	Name string
+	// StatusChange is set for Closed and StatusChanged.
+	StatusChange StatusChange
+	// Holder is the holder added or removed.
+	Holder Holder
+	// Hold is the hold as placed, released or expired.
+	Hold Hold
+	// Pot is the pot as created, updated, deleted or after money moved in or out of it.
+	Pot Pot
+	// Overdraft is set for OverdraftChanged, the zero Overdraft when it was removed.
+	Overdraft Overdraft
+	// Limits is set for LimitsChanged.
+	Limits limits.Profile
+	// Interest is set for OverdraftInterestAccrued, the total accrued and not yet charged.
+	Interest float64
+	// At is the last day accrued for OverdraftInterestAccrued, when the holders transacted for
+	// CustomerActivityRecorded and the day of the withdrawal for WithdrawalCounted.
+	At time.Time
+	// Account is set for AccountOpened.
+	Account *BankAccount
+	// AccountVersion and AccountUpdatedTimestamp are the account's Version and UpdatedTimestamp
+	// after the write the event was part of.
+	AccountVersion          uint64
+	AccountUpdatedTimestamp time.Time
+	Timestamp               time.Time
+}
+
+type EventStore interface {
+	// Append adds events to the end of their accounts' streams, all or none of them. Each event's
+	// Version must follow on from the last one in its stream, otherwise Append fails with
+	// ErrVersionConflict, so a writer working from an out of date stream cannot overwrite changes
+	// it has not seen.
+	Append(events ...AccountEvent) error
+	// AppendWith is Append with another write made as part of it. write is called once every
+	// event's version has been checked, and the events are added only if it succeeds, so neither
+	// is kept without the other.
+	AppendWith(write func() error, events ...AccountEvent) error
+	// Load returns the account's events in version order, or ErrAccountNotFound.
+	Load(acctNum AccountNumber) ([]AccountEvent, error)
+	// LoadAll returns every event in the order they were appended.
+	LoadAll() ([]AccountEvent, error)
+}
+
+// Apply returns the account with the event's change made. Unlike the account's other methods it
+// does not check status or limits, since the event records a change which has already happened.
+// Applying AccountDeleted returns the zero BankAccount.
+func (ba BankAccount) Apply(e AccountEvent) (BankAccount, error) {
+	switch {
+	case ba.AccountNumber == "" && e.Type != AccountOpened:
+		return BankAccount{}, fmt.Errorf("%w: %s before account %q was opened", ErrInvalidEvent, e.Type, e.AccountNumber)
+	case ba.AccountNumber != "" && e.Type == AccountOpened:
+		return BankAccount{}, fmt.Errorf("%w: account %q is already open", ErrInvalidEvent, e.AccountNumber)
+	case ba.AccountNumber != "" && e.AccountNumber != ba.AccountNumber:
+		return BankAccount{}, fmt.Errorf("%w: %s for account %q applied to %q", ErrInvalidEvent, e.Type, e.AccountNumber, ba.AccountNumber)
+	}
+	switch e.Type {
+	case AccountOpened:
+		if e.Account == nil || e.Account.AccountNumber != e.AccountNumber {
+			return BankAccount{}, fmt.Errorf("%w: %s without the account", ErrInvalidEvent, e.Type)
+		}
+		return *e.Account, nil
+	case Deposited:
+		ba.balance = ba.Currency.Round(ba.balance + e.Amount)
+	case Withdrawn:
+		ba.balance = ba.Currency.Round(ba.balance - e.Amount)
+	case Renamed:
+		ba.Name = e.Name
+	case Closed:
+		ba.StatusHistory = append(slices.Clone(ba.StatusHistory), e.StatusChange)
+		ba.Status = ClosedStatus
+		ba.Overdraft = Overdraft{}
+	case StatusChanged:
+		ba.StatusHistory = append(slices.Clone(ba.StatusHistory), e.StatusChange)
+		ba.Status = e.StatusChange.To
+	case HolderAdded:
+		ba.Holders = append(slices.Clone(ba.Holders), e.Holder)
+	case HolderRemoved:
+		var err error
+		ba, err = ba.RemoveHolder(e.Holder.UserID)
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+		}
+	case HoldPlaced:
+		ba.Holds = append(slices.Clone(ba.Holds), e.Hold)
+	case HoldReleased, HoldExpired:
+		i := slices.IndexFunc(ba.Holds, func(h Hold) bool { return h.ID == e.Hold.ID })
+		if i < 0 {
+			return BankAccount{}, fmt.Errorf("%w: %s of unknown hold %s", ErrInvalidEvent, e.Type, e.Hold.ID)
+		}
+		ba.Holds = slices.Clone(ba.Holds)
+		ba.Holds[i] = e.Hold
+	case PotCreated:
+		ba.Pots = append(slices.Clone(ba.Pots), e.Pot)
+	case PotUpdated, MovedToPot, MovedFromPot:
+		i, err := ba.potIndex(e.Pot.ID)
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("%w: %s of unknown pot %s", ErrInvalidEvent, e.Type, e.Pot.ID)
+		}
+		ba.Pots = slices.Clone(ba.Pots)
+		ba.Pots[i] = e.Pot
+	case PotDeleted:
+		i, err := ba.potIndex(e.Pot.ID)
+		if err != nil {
+			return BankAccount{}, fmt.Errorf("%w: %s of unknown pot %s", ErrInvalidEvent, e.Type, e.Pot.ID)
+		}
+		ba.Pots = slices.Delete(slices.Clone(ba.Pots), i, i+1)
+	case OverdraftChanged:
+		ba.Overdraft = e.Overdraft
+	case LimitsChanged:
+		ba.Limits = e.Limits
+	case OverdraftInterestAccrued:
+		ba.overdraftInterest = e.Interest
+		ba.interestAccruedThrough = e.At
+	case OverdraftInterestCharged:
+		ba.overdraftInterest = 0
+	case CustomerActivityRecorded:
+		ba.lastCustomerActivity = e.At
+	case WithdrawalCounted:
+		ba = ba.countWithdrawal(e.Amount, e.At)
+	case AccountDeleted:
+		return BankAccount{}, nil
+	default:
+		return BankAccount{}, fmt.Errorf("%w: unknown type %q", ErrInvalidEvent, e.Type)
+	}
+	ba.Version = e.AccountVersion
+	ba.UpdatedTimestamp = e.AccountUpdatedTimestamp
+	return ba, nil
+}
+
+// Replay builds the account from its events, which must be in version order with none missing.
+// It returns ErrAccountNotFound when there are no events or the last one is AccountDeleted.
+func Replay(events []AccountEvent) (BankAccount, error) {
+	var ba BankAccount
+	for i, e := range events {
+		if e.Version != uint64(i+1) {
+			return BankAccount{}, fmt.Errorf("%w: expected version %d, got %d", ErrInvalidEvent, i+1, e.Version)
+		}
+		var err error
+		ba, err = ba.Apply(e)
+		if err != nil {
+			return BankAccount{}, err
+		}
+	}
+	if ba.AccountNumber == "" {
+		return BankAccount{}, ErrAccountNotFound
+	}
+	return ba, nil
+}
+
+// Changes returns the events which take the account to next, numbered on from version, the
+// version of the last event applied to ba. Each kind of change has an event of its own, and a
+// write which changes nothing has none. A change no event describes, such as to the account's
+// currency, fails with ErrInvalidEvent rather than being lost from the stream.
+func (ba BankAccount) Changes(next BankAccount, version uint64, at time.Time) ([]AccountEvent, error) {
+	var events []AccountEvent
+	add := func(e AccountEvent) error {
+		version++
+		e.AccountNumber, e.Version, e.Timestamp = next.AccountNumber, version, at
+		e.AccountVersion, e.AccountUpdatedTimestamp = next.Version, next.UpdatedTimestamp
+		var err error
+		ba, err = ba.Apply(e)
+		if err != nil {
+			return err
+		}
+		events = append(events, e)
+		return nil
+	}
+	if ba.AccountNumber == "" {
+		err := add(AccountEvent{Type: AccountOpened, Account: &next})
+		if err != nil {
+			return nil, err
+		}
+		return events, nil
+	}
+	if ba.AccountNumber != next.AccountNumber || ba.SortCode != next.SortCode || ba.IBAN != next.IBAN ||
+		ba.AccountType != next.AccountType || ba.Currency != next.Currency || ba.Business != next.Business ||
+		!ba.CreatedTimestamp.Equal(next.CreatedTimestamp) {
+		return nil, fmt.Errorf("%w: account %s's details cannot change", ErrInvalidEvent, ba.AccountNumber)
+	}
+	for _, diff := range []func(BankAccount, BankAccount) ([]AccountEvent, error){
+		nameChanges, statusChanges, holderChanges, holdChanges, potChanges, overdraftChanges,
+		balanceChanges, interestChanges, activityChanges, withdrawalChanges,
+	} {
+		changes, err := diff(ba, next)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range changes {
+			err = add(e)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	if !slices.Equal(ba.Holders, next.Holders) || !slices.Equal(ba.Holds, next.Holds) || !slices.Equal(ba.Pots, next.Pots) ||
+		ba.withdrawals() != next.withdrawals() {
+		return nil, fmt.Errorf("%w: account %s's changes cannot be recorded as events", ErrInvalidEvent, ba.AccountNumber)
+	}
+	return events, nil
+}
+
+func nameChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	if next.Name == ba.Name {
+		return nil, nil
+	}
+	return []AccountEvent{{Type: Renamed, Name: next.Name}}, nil
+}
+
+// statusChanges records the status changes added to the account's history, which is only ever
+// added to.
+func statusChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	if len(next.StatusHistory) < len(ba.StatusHistory) || !slices.Equal(ba.StatusHistory, next.StatusHistory[:len(ba.StatusHistory)]) {
+		return nil, fmt.Errorf("%w: account %s's status history was rewritten", ErrInvalidEvent, ba.AccountNumber)
+	}
+	var events []AccountEvent
+	for _, change := range next.StatusHistory[len(ba.StatusHistory):] {
+		if change.To == ClosedStatus {
+			events = append(events, AccountEvent{Type: Closed, StatusChange: change})
+		} else {
+			events = append(events, AccountEvent{Type: StatusChanged, StatusChange: change})
+		}
+	}
+	if len(events) == 0 && next.Status != ba.Status {
+		return nil, fmt.Errorf("%w: account %s's status changed without a record of it", ErrInvalidEvent, ba.AccountNumber)
+	}
+	return events, nil
+}
+
+// holderChanges removes holders before adding them, as removing the primary holder promotes the
+// longest standing remaining one.
+func holderChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	var events []AccountEvent
+	for _, h := range ba.Holders {
+		if !next.IsHolder(h.UserID) {
+			events = append(events, AccountEvent{Type: HolderRemoved, Holder: h})
+		}
+	}
+	for _, h := range next.Holders {
+		if !ba.IsHolder(h.UserID) {
+			events = append(events, AccountEvent{Type: HolderAdded, Holder: h})
+		}
+	}
+	return events, nil
+}
+
+// holdChanges records holds placed, released and expired. Holds are never removed.
+func holdChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	var events []AccountEvent
+	for _, h := range next.Holds {
+		i := slices.IndexFunc(ba.Holds, func(old Hold) bool { return old.ID == h.ID })
+		switch {
+		case i < 0:
+			events = append(events, AccountEvent{Type: HoldPlaced, Hold: h})
+		case ba.Holds[i] == h:
+		case h.Status == ReleasedHold:
+			events = append(events, AccountEvent{Type: HoldReleased, Hold: h})
+		case h.Status == ExpiredHold:
+			events = append(events, AccountEvent{Type: HoldExpired, Hold: h})
+		default:
+			return nil, fmt.Errorf("%w: hold %s changed without being released or expiring", ErrInvalidEvent, h.ID)
+		}
+	}
+	return events, nil
+}
+
+// potChanges deletes pots before creating them, so the pots end up in the same order.
+func potChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	var events []AccountEvent
+	for _, p := range ba.Pots {
+		if _, err := next.Pot(p.ID); err != nil {
+			events = append(events, AccountEvent{Type: PotDeleted, Pot: p})
+		}
+	}
+	for _, p := range next.Pots {
+		old, err := ba.Pot(p.ID)
+		switch {
+		case err != nil:
+			events = append(events, AccountEvent{Type: PotCreated, Pot: p})
+		case old == p:
+		case old.Name != p.Name || old.Goal != p.Goal || !old.TargetDate.Equal(p.TargetDate):
+			events = append(events, AccountEvent{Type: PotUpdated, Pot: p})
+		case p.Balance > old.Balance:
+			events = append(events, AccountEvent{Type: MovedToPot, Pot: p, Amount: ba.Currency.Round(p.Balance - old.Balance)})
+		default:
+			events = append(events, AccountEvent{Type: MovedFromPot, Pot: p, Amount: ba.Currency.Round(old.Balance - p.Balance)})
+		}
+	}
+	return events, nil
+}
+
+func overdraftChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	var events []AccountEvent
+	if next.Overdraft != ba.Overdraft {
+		events = append(events, AccountEvent{Type: OverdraftChanged, Overdraft: next.Overdraft})
+	}
+	if next.Limits != ba.Limits {
+		events = append(events, AccountEvent{Type: LimitsChanged, Limits: next.Limits})
+	}
+	return events, nil
+}
+
+func balanceChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	diff := next.Currency.Round(next.balance - ba.balance)
+	switch {
+	case diff > 0:
+		return []AccountEvent{{Type: Deposited, Amount: diff}}, nil
+	case diff < 0:
+		return []AccountEvent{{Type: Withdrawn, Amount: -diff}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// interestChanges records accruals, and charges which take the accrued interest back to zero.
+func interestChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	switch {
+	case !next.interestAccruedThrough.Equal(ba.interestAccruedThrough) || (next.overdraftInterest != ba.overdraftInterest && next.overdraftInterest != 0):
+		return []AccountEvent{{Type: OverdraftInterestAccrued, Interest: next.overdraftInterest, At: next.interestAccruedThrough}}, nil
+	case next.overdraftInterest != ba.overdraftInterest:
+		return []AccountEvent{{Type: OverdraftInterestCharged, Amount: ba.Currency.Round(ba.overdraftInterest)}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func activityChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	if next.lastCustomerActivity.Equal(ba.lastCustomerActivity) {
+		return nil, nil
+	}
+	return []AccountEvent{{Type: CustomerActivityRecorded, At: next.lastCustomerActivity}}, nil
+}
+
+// withdrawalChanges records the customer withdrawal counted towards the limits, there being at most
+// one in a write.
+func withdrawalChanges(ba, next BankAccount) ([]AccountEvent, error) {
+	if next.withdrawals() == ba.withdrawals() {
+		return nil, nil
+	}
+	day, err := time.Parse(time.DateOnly, next.withdrawalDay)
+	if err != nil {
+		return nil, fmt.Errorf("%w: account %s's withdrawals counted on day %q", ErrInvalidEvent, ba.AccountNumber, next.withdrawalDay)
+	}
+	counted := ba.rollWithdrawalPeriod(day)
+	if next.monthlyWithdrawals != counted.monthlyWithdrawals+1 {
+		return nil, fmt.Errorf("%w: account %s counted %d withdrawals in one write", ErrInvalidEvent, ba.AccountNumber, next.monthlyWithdrawals-counted.monthlyWithdrawals)
+	}
+	amt := ba.Currency.Round(next.monthlyWithdrawn - counted.monthlyWithdrawn)
+	return []AccountEvent{{Type: WithdrawalCounted, Amount: amt, At: day}}, nil
+}
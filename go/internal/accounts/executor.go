@@ -0,0 +1,152 @@
+package accounts
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+)
+
+// Executor runs commands against accounts one at a time per account, in the order they were
+// submitted, while commands for accounts on other shards run in parallel. Each shard is a goroutine
+// working through its own mailbox, and an account always hashes to the same shard, so a command
+// which reads an account, changes it and stores it can't interleave with another for that account.
+//
+// A nil *Executor runs commands straight away on the caller's goroutine, leaving the stores'
+// version checks to catch conflicting writes.
+type Executor struct {
+	shards []chan func()
+	// mu is held for reading while a command is sent to the shards and for writing while Close
+	// closes them, so nothing is sent to a closed mailbox.
+	mu     sync.RWMutex
+	closed bool
+	// enqueue orders commands spanning several shards, so every shard sees them in the same order
+	// and two of them can't each be holding a shard the other is waiting for.
+	enqueue sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// mailboxSize is how many commands a shard queues before submitting another blocks.
+const mailboxSize = 64
+
+// NewExecutor starts an executor with the given number of shards, usually runtime.GOMAXPROCS(0).
+func NewExecutor(shards int) *Executor {
+	e := &Executor{shards: make([]chan func(), max(shards, 1))}
+	for i := range e.shards {
+		mailbox := make(chan func(), mailboxSize)
+		e.shards[i] = mailbox
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			for cmd := range mailbox {
+				cmd()
+			}
+		}()
+	}
+	return e
+}
+
+// Do runs fn once every command submitted earlier for the account has finished, and returns its
+// error. fn must not submit commands to the executor itself, it would wait on its own shard.
+func (e *Executor) Do(acctNum AccountNumber, fn func() error) error {
+	return e.DoAll([]AccountNumber{acctNum}, fn)
+}
+
+// DoAll is Do for a command spanning several accounts, such as a transfer between them. No other
+// command for any of the accounts runs at the same time as fn. Once the executor is closed fn is
+// not run and DoAll fails with ErrExecutorClosed.
+func (e *Executor) DoAll(acctNums []AccountNumber, fn func() error) error {
+	if e == nil {
+		return fn()
+	}
+	done := make(chan error, 1)
+	run := func() {
+		defer func() {
+			// A panic is handed back to the caller rather than taking the shard down with it.
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic running account command: %v", p)
+			}
+		}()
+		done <- fn()
+	}
+
+	shards := e.shardsFor(acctNums)
+	e.mu.RLock()
+	if e.closed {
+		e.mu.RUnlock()
+		return ErrExecutorClosed
+	}
+	if len(shards) == 1 {
+		e.shards[shards[0]] <- run
+	} else {
+		// Each shard stops when it reaches the command, the first runs it once all of them have
+		// stopped and then lets the others carry on.
+		var arrived sync.WaitGroup
+		arrived.Add(len(shards))
+		release := make(chan struct{})
+		e.enqueue.Lock()
+		for i, shard := range shards {
+			if i == 0 {
+				e.shards[shard] <- func() {
+					defer close(release)
+					arrived.Done()
+					arrived.Wait()
+					run()
+				}
+			} else {
+				e.shards[shard] <- func() {
+					arrived.Done()
+					<-release
+				}
+			}
+		}
+		e.enqueue.Unlock()
+	}
+	e.mu.RUnlock()
+	return <-done
+}
+
+// Close waits for the commands already submitted to finish and stops the shards. Commands
+// submitted after it fail with ErrExecutorClosed, and closing it again does nothing.
+func (e *Executor) Close() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		for _, mailbox := range e.shards {
+			close(mailbox)
+		}
+	}
+	e.mu.Unlock()
+	e.wg.Wait()
+}
+
+// shardsFor returns the distinct shards the accounts hash to, in ascending order.
+func (e *Executor) shardsFor(acctNums []AccountNumber) []int {
+	shards := make([]int, 0, len(acctNums))
+	for _, acctNum := range acctNums {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(acctNum))
+		shards = append(shards, int(h.Sum32()%uint32(len(e.shards))))
+	}
+	slices.Sort(shards)
+	return slices.Compact(shards)
+}
+
+// Submit runs fn as a command for the account and returns its result.
+func Submit[T any](e *Executor, acctNum AccountNumber, fn func() (T, error)) (T, error) {
+	return SubmitAll(e, []AccountNumber{acctNum}, fn)
+}
+
+// SubmitAll runs fn as a command for all the accounts and returns its result.
+func SubmitAll[T any](e *Executor, acctNums []AccountNumber, fn func() (T, error)) (T, error) {
+	var result T
+	err := e.DoAll(acctNums, func() error {
+		var err error
+		result, err = fn()
+		return err
+	})
+	return result, err
+}
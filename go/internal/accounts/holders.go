@@ -0,0 +1,121 @@
+package accounts
+
+import (
+	"eaglebank/internal/users"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HolderChangeID string
+
+var holderChangeIDRegex = regexp.MustCompile(`^hch-[A-Za-z0-9]+$`)
+
+func (id HolderChangeID) IsValid() bool {
+	return holderChangeIDRegex.MatchString(id.String())
+}
+
+func (id HolderChangeID) String() string {
+	return string(id)
+}
+
+func NewHolderChangeID(s string) (HolderChangeID, error) {
+	id := HolderChangeID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid holder change ID %q: must match format hch-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandHolderChangeID() (HolderChangeID, error) {
+	return NewHolderChangeID("hch-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+type HolderChangeKind string
+
+// AddHolderChange is an invitation for another user to join the account, RemoveHolderChange is a
+// request to take a holder off it.
+const AddHolderChange HolderChangeKind = "add"
+const RemoveHolderChange HolderChangeKind = "remove"
+
+type HolderChangeStatus string
+
+const PendingChange HolderChangeStatus = "pending"
+const CompletedChange HolderChangeStatus = "completed"
+const RejectedChange HolderChangeStatus = "rejected"
+
+type HolderChange struct {
+	ID               HolderChangeID
+	AccountNumber    AccountNumber
+	Kind             HolderChangeKind
+	UserID           users.UserID
+	Role             HolderRole
+	RequestedBy      users.UserID
+	Approvals        []users.UserID
+	Status           HolderChangeStatus
+	CreatedTimestamp time.Time
+	UpdatedTimestamp time.Time
+}
+
+func NewHolderChange(acctNum AccountNumber, kind HolderChangeKind, userID users.UserID, role HolderRole, requestedBy users.UserID) (HolderChange, error) {
+	id, err := NewRandHolderChangeID()
+	if err != nil {
+		return HolderChange{}, err
+	}
+	now := time.Now()
+	return HolderChange{
+		ID:               id,
+		AccountNumber:    acctNum,
+		Kind:             kind,
+		UserID:           userID,
+		Role:             role,
+		RequestedBy:      requestedBy,
+		Status:           PendingChange,
+		CreatedTimestamp: now,
+		UpdatedTimestamp: now,
+	}, nil
+}
+
+// RequiredApprovers is who must consent before the change is applied: the invitee for an
+// addition, and every holder who would remain on the account for a removal.
+func (c HolderChange) RequiredApprovers(acct BankAccount) []users.UserID {
+	if c.Kind == AddHolderChange {
+		return []users.UserID{c.UserID}
+	}
+	var approvers []users.UserID
+	for _, id := range acct.HolderIDs() {
+		if id != c.UserID {
+			approvers = append(approvers, id)
+		}
+	}
+	return approvers
+}
+
+func (c HolderChange) PendingApprovers(acct BankAccount) []users.UserID {
+	var pending []users.UserID
+	for _, id := range c.RequiredApprovers(acct) {
+		if !slices.Contains(c.Approvals, id) {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+func (c HolderChange) Approve(userID users.UserID) HolderChange {
+	if !slices.Contains(c.Approvals, userID) {
+		c.Approvals = append(slices.Clone(c.Approvals), userID)
+	}
+	c.UpdatedTimestamp = time.Now()
+	return c
+}
+
+func (c HolderChange) apply(acct BankAccount) (BankAccount, error) {
+	if c.Kind == AddHolderChange {
+		return acct.AddHolder(c.UserID, c.Role)
+	}
+	return acct.RemoveHolder(c.UserID)
+}
@@ -0,0 +1,163 @@
+package accounts
+
+import (
+	"eaglebank/internal/users"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HoldID string
+
+var holdIDRegex = regexp.MustCompile(`^hld-[A-Za-z0-9]+$`)
+
+func (id HoldID) IsValid() bool {
+	return holdIDRegex.MatchString(id.String())
+}
+
+func (id HoldID) String() string {
+	return string(id)
+}
+
+func NewHoldID(s string) (HoldID, error) {
+	id := HoldID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid hold ID %q: must match format hld-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandHoldID() (HoldID, error) {
+	return NewHoldID("hld-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+// HoldReason says why funds are being reserved.
+type HoldReason string
+
+const CardAuthorisationHold HoldReason = "card_authorisation"
+const LegalHold HoldReason = "legal_hold"
+const PendingPaymentHold HoldReason = "pending_payment"
+
+func HoldReasons() []HoldReason {
+	return []HoldReason{CardAuthorisationHold, LegalHold, PendingPaymentHold}
+}
+
+func (r HoldReason) IsValid() bool {
+	return slices.Contains(HoldReasons(), r)
+}
+
+func (r HoldReason) String() string {
+	return string(r)
+}
+
+type HoldStatus string
+
+const ActiveHold HoldStatus = "active"
+const ReleasedHold HoldStatus = "released"
+const ExpiredHold HoldStatus = "expired"
+
+// Hold earmarks part of an account's balance without moving any money. While active the held
+// amount is taken off the available balance, so it cannot be withdrawn.
+type Hold struct {
+	ID               HoldID
+	Amount           float64
+	Reason           HoldReason
+	Status           HoldStatus
+	CreatedBy        users.UserID
+	CreatedTimestamp time.Time
+	ExpiresTimestamp time.Time
+	// ReleasedBy is the member of staff who released the hold, empty when it expired.
+	ReleasedBy        users.UserID
+	ReleasedTimestamp time.Time
+}
+
+// IsActive reports whether the hold still reserves funds at the given time. A hold stops counting
+// as soon as it expires, even before it has been marked expired.
+func (h Hold) IsActive(at time.Time) bool {
+	return h.Status == ActiveHold && at.Before(h.ExpiresTimestamp)
+}
+
+// PlaceHold reserves amt of the available balance until the hold is released or expires.
+func (ba BankAccount) PlaceHold(amt float64, reason HoldReason, expires time.Time, createdBy users.UserID) (BankAccount, Hold, error) {
+	now := time.Now()
+	if amt <= 0 || !ba.Currency.IsValidAmount(amt) {
+		return BankAccount{}, Hold{}, fmt.Errorf("%w: %v", ErrInvalidHoldAmount, amt)
+	}
+	if !reason.IsValid() {
+		return BankAccount{}, Hold{}, fmt.Errorf("invalid hold reason %q", reason)
+	}
+	if !expires.After(now) {
+		return BankAccount{}, Hold{}, ErrHoldExpiryInPast
+	}
+	if amt > ba.AvailableBalance() {
+		return BankAccount{}, Hold{}, ErrInsufficientFunds
+	}
+	id, err := NewRandHoldID()
+	if err != nil {
+		return BankAccount{}, Hold{}, err
+	}
+	hold := Hold{
+		ID:               id,
+		Amount:           amt,
+		Reason:           reason,
+		Status:           ActiveHold,
+		CreatedBy:        createdBy,
+		CreatedTimestamp: now,
+		ExpiresTimestamp: expires,
+	}
+	ba.Holds = append(slices.Clone(ba.Holds), hold)
+	return ba, hold, nil
+}
+
+// ReleaseHold returns the held funds to the available balance.
+func (ba BankAccount) ReleaseHold(id HoldID, releasedBy users.UserID) (BankAccount, Hold, error) {
+	i := slices.IndexFunc(ba.Holds, func(h Hold) bool { return h.ID == id })
+	if i < 0 {
+		return BankAccount{}, Hold{}, ErrHoldNotFound
+	}
+	now := time.Now()
+	if !ba.Holds[i].IsActive(now) {
+		return BankAccount{}, Hold{}, ErrHoldNotActive
+	}
+	ba.Holds = slices.Clone(ba.Holds)
+	ba.Holds[i].Status = ReleasedHold
+	ba.Holds[i].ReleasedBy = releasedBy
+	ba.Holds[i].ReleasedTimestamp = now
+	return ba, ba.Holds[i], nil
+}
+
+// ExpireHolds marks holds which have passed their expiry as expired and returns them.
+func (ba BankAccount) ExpireHolds(at time.Time) (BankAccount, []Hold) {
+	var expired []Hold
+	for i, h := range ba.Holds {
+		if h.Status != ActiveHold || h.IsActive(at) {
+			continue
+		}
+		if expired == nil {
+			ba.Holds = slices.Clone(ba.Holds)
+		}
+		ba.Holds[i].Status = ExpiredHold
+		ba.Holds[i].ReleasedTimestamp = h.ExpiresTimestamp
+		expired = append(expired, ba.Holds[i])
+	}
+	return ba, expired
+}
+
+// HeldAmount is the total of the holds active now.
+func (ba BankAccount) HeldAmount() float64 {
+	return ba.heldAt(time.Now())
+}
+
+func (ba BankAccount) heldAt(at time.Time) float64 {
+	held := 0.0
+	for _, h := range ba.Holds {
+		if h.IsActive(at) {
+			held += h.Amount
+		}
+	}
+	return ba.Currency.Round(held)
+}
@@ -0,0 +1,124 @@
+package accounts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IBAN is an International Bank Account Number (ISO 13616) in its electronic form, upper case
+// with no spaces.
+type IBAN string
+
+var ibanRegex = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+
+// ibanLengths is the IBAN length for each country in the IBAN registry that the bank accepts
+// payments from.
+var ibanLengths = map[string]int{
+	"AT": 20, "BE": 16, "BG": 22, "CH": 21, "CY": 28, "CZ": 24, "DE": 22, "DK": 18, "EE": 20,
+	"ES": 24, "FI": 18, "FR": 27, "GB": 22, "GI": 23, "GR": 27, "HR": 21, "HU": 28, "IE": 22,
+	"IS": 26, "IT": 27, "LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MT": 31, "NL": 18,
+	"NO": 15, "PL": 28, "PT": 25, "RO": 24, "SE": 24, "SI": 19, "SK": 24, "SM": 27,
+}
+
+// IsValid checks the format, the length for the IBAN's country and the mod-97 check digits.
+func (i IBAN) IsValid() bool {
+	s := i.String()
+	if !ibanRegex.MatchString(s) {
+		return false
+	}
+	if length, ok := ibanLengths[i.Country()]; !ok || len(s) != length {
+		return false
+	}
+	return mod97(s[4:]+s[:4]) == 1
+}
+
+func (i IBAN) String() string {
+	return string(i)
+}
+
+// Country is the ISO 3166 country code the IBAN starts with.
+func (i IBAN) Country() string {
+	if len(i) < 2 {
+		return ""
+	}
+	return string(i[:2])
+}
+
+// NewIBAN accepts an IBAN in either its electronic form or the printed form, which is split into
+// groups of four and may be lower case.
+func NewIBAN(s string) (IBAN, error) {
+	iban := IBAN(strings.ToUpper(strings.ReplaceAll(s, " ", "")))
+	if !iban.IsValid() {
+		return "", fmt.Errorf("invalid IBAN %q", s)
+	}
+	return iban, nil
+}
+
+// NewGBIBAN builds the IBAN for a UK account from the bank's four letter code, the sort code and the
+// account number.
+func NewGBIBAN(bankCode string, sortCode SortCode, acctNum AccountNumber) (IBAN, error) {
+	if !bankCodeRegex.MatchString(bankCode) || !sortCode.IsValid() || !acctNum.IsValid() {
+		return "", fmt.Errorf("invalid UK bank details %q %q %q", bankCode, sortCode, acctNum)
+	}
+	bban := bankCode + strings.ReplaceAll(sortCode.String(), "-", "") + acctNum.String()
+	check := 98 - mod97(bban+"GB00")
+	return NewIBAN(fmt.Sprintf("GB%02d%s", check, bban))
+}
+
+// mod97 is the remainder of s divided by 97, with letters read as two digit numbers from A=10
+// to Z=35. It works through s a character at a time so the number never overflows.
+func mod97(s string) int {
+	rem := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			rem = (rem*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			rem = (rem*100 + int(c-'A') + 10) % 97
+		}
+	}
+	return rem
+}
+
+// BIC is a Business Identifier Code (ISO 9362): a four letter bank code, two letter country code,
+// two character location code and an optional three character branch code.
+type BIC string
+
+var bicRegex = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+func (b BIC) IsValid() bool {
+	return bicRegex.MatchString(b.String())
+}
+
+func (b BIC) String() string {
+	return string(b)
+}
+
+func NewBIC(s string) (BIC, error) {
+	bic := BIC(strings.ToUpper(s))
+	if !bic.IsValid() {
+		return "", fmt.Errorf("invalid BIC %q", s)
+	}
+	return bic, nil
+}
+
+var bankCodeRegex = regexp.MustCompile(`^[A-Z]{4}$`)
+
+// Bank holds the identifiers the bank is known by internationally. Code is the four letter bank
+// code used in its IBANs, which is also the start of its BIC.
+type Bank struct {
+	Code string
+	BIC  BIC
+}
+
+// DefaultBank is used unless the service is given other details with WithBank.
+var DefaultBank = Bank{Code: "EAGL", BIC: "EAGLGB2L"}
+
+func (b Bank) IsValid() bool {
+	return bankCodeRegex.MatchString(b.Code) && b.BIC.IsValid() && strings.HasPrefix(b.BIC.String(), b.Code)
+}
+
+func (b Bank) IBAN(sortCode SortCode, acctNum AccountNumber) (IBAN, error) {
+	return NewGBIBAN(b.Code, sortCode, acctNum)
+}
@@ -0,0 +1,90 @@
+package accounts
+
+import (
+	"eaglebank/internal/limits"
+	"eaglebank/internal/users"
+	"fmt"
+	"time"
+)
+
+// LimitConfig holds the limit profiles the bank applies. An account gets its type's profile,
+// tightened by the profile for its primary holder's tier, so a tier can only lower a type's limits.
+type LimitConfig struct {
+	AccountTypes map[AccountType]limits.Profile
+	Tiers        map[users.Tier]limits.Profile
+}
+
+// DefaultLimits is used unless the service is given other limits with WithLimitConfig.
+var DefaultLimits = LimitConfig{
+	AccountTypes: map[AccountType]limits.Profile{
+		PersonalAcct: {MaxBalance: 250000, MaxTransaction: 50000, DailyWithdrawal: 25000, MonthlyWithdrawal: 100000},
+		CurrentAcct:  {MaxBalance: 250000, MaxTransaction: 50000, DailyWithdrawal: 25000, MonthlyWithdrawal: 100000},
+		SavingsAcct:  {MaxBalance: 250000, MaxTransaction: 50000, DailyWithdrawal: 10000, MonthlyWithdrawal: 20000},
+		BusinessAcct: {MaxBalance: 250000, MaxTransaction: 50000, DailyWithdrawal: 25000, MonthlyWithdrawal: 100000},
+	},
+	Tiers: map[users.Tier]limits.Profile{
+		users.StandardTier: {MaxBalance: 10000, MaxTransaction: 10000, DailyWithdrawal: 5000, MonthlyWithdrawal: 10000},
+		users.PremiumTier:  {MaxBalance: 100000, MaxTransaction: 50000, DailyWithdrawal: 25000, MonthlyWithdrawal: 100000},
+	},
+}
+
+// IsValid requires a complete profile for every account type. Tier profiles may leave limits at
+// zero to keep the type's limit.
+func (c LimitConfig) IsValid() bool {
+	for _, acctType := range AccountTypes() {
+		if !c.AccountTypes[acctType].IsValid() {
+			return false
+		}
+	}
+	for tier := range c.Tiers {
+		if !tier.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve returns the profile for an account of the given type held by a user on the given tier.
+func (c LimitConfig) Resolve(acctType AccountType, tier users.Tier) (limits.Profile, error) {
+	profile, ok := c.AccountTypes[acctType]
+	if !ok || !profile.IsValid() {
+		return limits.Profile{}, fmt.Errorf("no limits for account type %q", acctType)
+	}
+	return profile.Tighten(c.Tiers[tier]), nil
+}
+
+// WithLimits sets the account's limits, NewBankAccount resolves DefaultLimits for the standard
+// tier when it is not given.
+func WithLimits(profile limits.Profile) BankAccountOption {
+	return func(ba *BankAccount) {
+		ba.Limits = profile
+	}
+}
+
+// ChangeLimits replaces the account's limits, for example when the primary holder's tier changes.
+// A balance already over a lowered maximum is kept but cannot grow.
+func (ba BankAccount) ChangeLimits(profile limits.Profile) (BankAccount, error) {
+	if !profile.IsValid() {
+		return BankAccount{}, fmt.Errorf("invalid limit profile %+v", profile)
+	}
+	ba.Limits = profile
+	return ba, nil
+}
+
+// DailyWithdrawn is the total withdrawn by customers today, the UTC day.
+func (ba BankAccount) DailyWithdrawn() float64 {
+	_, day := withdrawalPeriod(time.Now())
+	if ba.withdrawalDay != day {
+		return 0
+	}
+	return ba.dailyWithdrawn
+}
+
+// MonthlyWithdrawn is the total withdrawn by customers this UTC calendar month.
+func (ba BankAccount) MonthlyWithdrawn() float64 {
+	period, _ := withdrawalPeriod(time.Now())
+	if ba.withdrawalPeriod != period {
+		return 0
+	}
+	return ba.monthlyWithdrawn
+}
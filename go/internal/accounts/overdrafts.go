@@ -0,0 +1,82 @@
+package accounts
+
+import (
+	"eaglebank/internal/users"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OverdraftApplicationID string
+
+var overdraftApplicationIDRegex = regexp.MustCompile(`^ovd-[A-Za-z0-9]+$`)
+
+func (id OverdraftApplicationID) IsValid() bool {
+	return overdraftApplicationIDRegex.MatchString(id.String())
+}
+
+func (id OverdraftApplicationID) String() string {
+	return string(id)
+}
+
+func NewOverdraftApplicationID(s string) (OverdraftApplicationID, error) {
+	id := OverdraftApplicationID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid overdraft application ID %q: must match format ovd-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandOverdraftApplicationID() (OverdraftApplicationID, error) {
+	return NewOverdraftApplicationID("ovd-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+type OverdraftApplicationStatus string
+
+const PendingOverdraft OverdraftApplicationStatus = "pending"
+const ApprovedOverdraft OverdraftApplicationStatus = "approved"
+const RejectedOverdraft OverdraftApplicationStatus = "rejected"
+
+// OverdraftApplication is a holder's request for an arranged overdraft, or a change to the limit
+// of an existing one. It takes effect once a member of staff approves it.
+type OverdraftApplication struct {
+	ID            OverdraftApplicationID
+	AccountNumber AccountNumber
+	Limit         float64
+	RequestedBy   users.UserID
+	Status        OverdraftApplicationStatus
+	// DecidedBy is the member of staff who approved or rejected the application.
+	DecidedBy        users.UserID
+	CreatedTimestamp time.Time
+	UpdatedTimestamp time.Time
+}
+
+func NewOverdraftApplication(acct BankAccount, limit float64, requestedBy users.UserID) (OverdraftApplication, error) {
+	if limit <= 0 || limit > MaxOverdraftLimit || !acct.Currency.IsValidAmount(limit) {
+		return OverdraftApplication{}, fmt.Errorf("%w: %v", ErrInvalidOverdraftLimit, limit)
+	}
+	id, err := NewRandOverdraftApplicationID()
+	if err != nil {
+		return OverdraftApplication{}, err
+	}
+	now := time.Now()
+	return OverdraftApplication{
+		ID:               id,
+		AccountNumber:    acct.AccountNumber,
+		Limit:            limit,
+		RequestedBy:      requestedBy,
+		Status:           PendingOverdraft,
+		CreatedTimestamp: now,
+		UpdatedTimestamp: now,
+	}, nil
+}
+
+func (a OverdraftApplication) decide(status OverdraftApplicationStatus, decidedBy users.UserID) OverdraftApplication {
+	a.Status = status
+	a.DecidedBy = decidedBy
+	a.UpdatedTimestamp = time.Now()
+	return a
+}
@@ -0,0 +1,209 @@
+package accounts
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PotID string
+
+var potIDRegex = regexp.MustCompile(`^pot-[A-Za-z0-9]+$`)
+
+func (id PotID) IsValid() bool {
+	return potIDRegex.MatchString(id.String())
+}
+
+func (id PotID) String() string {
+	return string(id)
+}
+
+func NewPotID(s string) (PotID, error) {
+	id := PotID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid pot ID %q: must match format pot-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandPotID() (PotID, error) {
+	return NewPotID("pot-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+// MaxPots is how many pots one account can have.
+const MaxPots = 10
+
+// Pot ring-fences part of an account's balance under a name. Money in pots is still part of the
+// account's balance, so it counts towards the account's limits, but it is not available to spend
+// until it is moved back out.
+type Pot struct {
+	ID      PotID
+	Name    string
+	Balance float64
+	// Goal is the amount being saved towards, zero means the pot has no goal.
+	Goal float64
+	// TargetDate is when the goal should be reached, zero means no date.
+	TargetDate       time.Time
+	CreatedTimestamp time.Time
+	UpdatedTimestamp time.Time
+}
+
+func (p Pot) IsValid() bool {
+	return p.ID.IsValid() && strings.TrimSpace(p.Name) != "" && p.Balance >= 0 && p.Goal >= 0
+}
+
+// PotUpdate changes the fields which are set and leaves the rest as they are.
+type PotUpdate struct {
+	Name       *string
+	Goal       *float64
+	TargetDate *time.Time
+}
+
+// CreatePot adds an empty pot to the account.
+func (ba BankAccount) CreatePot(name string, goal float64, targetDate time.Time) (BankAccount, Pot, error) {
+	if len(ba.Pots) >= MaxPots {
+		return BankAccount{}, Pot{}, ErrTooManyPots
+	}
+	id, err := NewRandPotID()
+	if err != nil {
+		return BankAccount{}, Pot{}, err
+	}
+	now := time.Now()
+	pot := Pot{ID: id, CreatedTimestamp: now}
+	pot, err = ba.applyPotUpdate(pot, PotUpdate{Name: &name, Goal: &goal, TargetDate: &targetDate}, now)
+	if err != nil {
+		return BankAccount{}, Pot{}, err
+	}
+	ba.Pots = append(slices.Clone(ba.Pots), pot)
+	return ba, pot, nil
+}
+
+func (ba BankAccount) UpdatePot(id PotID, update PotUpdate) (BankAccount, Pot, error) {
+	i, err := ba.potIndex(id)
+	if err != nil {
+		return BankAccount{}, Pot{}, err
+	}
+	pot, err := ba.applyPotUpdate(ba.Pots[i], update, time.Now())
+	if err != nil {
+		return BankAccount{}, Pot{}, err
+	}
+	ba.Pots = slices.Clone(ba.Pots)
+	ba.Pots[i] = pot
+	return ba, pot, nil
+}
+
+func (ba BankAccount) applyPotUpdate(pot Pot, update PotUpdate, now time.Time) (Pot, error) {
+	if update.Name != nil {
+		pot.Name = *update.Name
+	}
+	if update.Goal != nil {
+		if !ba.Currency.IsValidAmount(*update.Goal) {
+			return Pot{}, fmt.Errorf("invalid pot goal %v", *update.Goal)
+		}
+		pot.Goal = *update.Goal
+	}
+	if update.TargetDate != nil {
+		if !update.TargetDate.IsZero() && !update.TargetDate.After(now) {
+			return Pot{}, ErrPotTargetInPast
+		}
+		pot.TargetDate = *update.TargetDate
+	}
+	if !pot.IsValid() {
+		return Pot{}, fmt.Errorf("invalid pot %+v", pot)
+	}
+	pot.UpdatedTimestamp = now
+	return pot, nil
+}
+
+// DeletePot removes a pot, which must be emptied first.
+func (ba BankAccount) DeletePot(id PotID) (BankAccount, error) {
+	i, err := ba.potIndex(id)
+	if err != nil {
+		return BankAccount{}, err
+	}
+	if ba.Pots[i].Balance != 0 {
+		return BankAccount{}, ErrPotNotEmpty
+	}
+	ba.Pots = slices.Delete(slices.Clone(ba.Pots), i, i+1)
+	return ba, nil
+}
+
+func (ba BankAccount) Pot(id PotID) (Pot, error) {
+	i, err := ba.potIndex(id)
+	if err != nil {
+		return Pot{}, err
+	}
+	return ba.Pots[i], nil
+}
+
+// MoveToPot moves money from the main balance into a pot. Only the account's own money can be
+// moved, not the overdraft or funds under a hold.
+func (ba BankAccount) MoveToPot(id PotID, amt float64) (BankAccount, Pot, error) {
+	if !ba.Status.AllowsDebits() {
+		return BankAccount{}, Pot{}, ba.Status.err()
+	}
+	if amt <= 0 || !ba.Currency.IsValidAmount(amt) {
+		return BankAccount{}, Pot{}, fmt.Errorf("%w: %v", ErrInvalidPotAmount, amt)
+	}
+	i, err := ba.potIndex(id)
+	if err != nil {
+		return BankAccount{}, Pot{}, err
+	}
+	if ba.Currency.Round(ba.MainBalance()-ba.HeldAmount()-amt) < 0 {
+		return BankAccount{}, Pot{}, ErrInsufficientFunds
+	}
+	ba = ba.changePotBalance(i, amt)
+	return ba, ba.Pots[i], nil
+}
+
+// MoveFromPot moves money from a pot back into the main balance.
+func (ba BankAccount) MoveFromPot(id PotID, amt float64) (BankAccount, Pot, error) {
+	if !ba.Status.AllowsDebits() {
+		return BankAccount{}, Pot{}, ba.Status.err()
+	}
+	if amt <= 0 || !ba.Currency.IsValidAmount(amt) {
+		return BankAccount{}, Pot{}, fmt.Errorf("%w: %v", ErrInvalidPotAmount, amt)
+	}
+	i, err := ba.potIndex(id)
+	if err != nil {
+		return BankAccount{}, Pot{}, err
+	}
+	if amt > ba.Pots[i].Balance {
+		return BankAccount{}, Pot{}, ErrInsufficientFunds
+	}
+	ba = ba.changePotBalance(i, -amt)
+	return ba, ba.Pots[i], nil
+}
+
+func (ba BankAccount) changePotBalance(i int, amt float64) BankAccount {
+	ba.Pots = slices.Clone(ba.Pots)
+	ba.Pots[i].Balance = ba.Currency.Round(ba.Pots[i].Balance + amt)
+	ba.Pots[i].UpdatedTimestamp = time.Now()
+	return ba
+}
+
+func (ba BankAccount) potIndex(id PotID) (int, error) {
+	i := slices.IndexFunc(ba.Pots, func(p Pot) bool { return p.ID == id })
+	if i < 0 {
+		return 0, ErrPotNotFound
+	}
+	return i, nil
+}
+
+// PotsBalance is the total held in the account's pots.
+func (ba BankAccount) PotsBalance() float64 {
+	total := 0.0
+	for _, p := range ba.Pots {
+		total += p.Balance
+	}
+	return ba.Currency.Round(total)
+}
+
+// MainBalance is the part of the balance not in a pot.
+func (ba BankAccount) MainBalance() float64 {
+	return ba.Currency.Round(ba.balance - ba.PotsBalance())
+}
@@ -0,0 +1,38 @@
+package accounts
+
+import (
+	"errors"
+	"slices"
+)
+
+// Precondition makes a command conditional on the account being at one of the given versions, as
+// when a client sends If-Match with the ETag it read. The command checks it against the account it
+// fetched and writes the next version, so the store's compare-and-swap refuses the write if the
+// account has moved on in between. Either way the command fails with ErrPreconditionFailed.
+type Precondition struct {
+	versions []uint64
+}
+
+// IfVersion is met by an account at any of the versions. With none it is never met.
+func IfVersion(versions ...uint64) Precondition {
+	return Precondition{versions: versions}
+}
+
+// CheckPreconditions returns ErrPreconditionFailed unless the account meets every condition.
+func CheckPreconditions(acct BankAccount, conds ...Precondition) error {
+	for _, cond := range conds {
+		if !slices.Contains(cond.versions, acct.Version) {
+			return ErrPreconditionFailed
+		}
+	}
+	return nil
+}
+
+// PreconditionError turns a conflicting write into ErrPreconditionFailed when the command was
+// conditional, since the account was no longer at the version the caller expected.
+func PreconditionError(err error, conds ...Precondition) error {
+	if len(conds) > 0 && errors.Is(err, ErrConflict) {
+		return ErrPreconditionFailed
+	}
+	return err
+}
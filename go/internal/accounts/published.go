@@ -0,0 +1,44 @@
+package accounts
+
+import (
+	"eaglebank/internal/events"
+	"eaglebank/internal/users"
+)
+
+// publishedEvent builds an event about the account for the outbox, for other parts of the system
+// to react to. These are separate from the AccountEvents an account's own stream is made of.
+func publishedEvent(typ events.EventType, acct BankAccount, userIDs []users.UserID, data any) (events.Event, error) {
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+	return events.NewEvent(typ, acct.AccountNumber.String(), ids, data)
+}
+
+func accountData(acct BankAccount) events.AccountData {
+	holders := make([]string, 0, len(acct.Holders))
+	for _, id := range acct.HolderIDs() {
+		holders = append(holders, id.String())
+	}
+	return events.AccountData{
+		AccountNumber: acct.AccountNumber.String(),
+		AccountType:   acct.AccountType.String(),
+		Currency:      acct.Currency.String(),
+		Status:        acct.Status.String(),
+		Holders:       holders,
+	}
+}
+
+// statusChangedEvent announces the account's latest status change.
+func statusChangedEvent(acct BankAccount) (events.Event, error) {
+	var change StatusChange
+	if len(acct.StatusHistory) > 0 {
+		change = acct.StatusHistory[len(acct.StatusHistory)-1]
+	}
+	return publishedEvent(events.AccountStatusChanged, acct, acct.HolderIDs(), events.AccountStatusData{
+		AccountNumber: acct.AccountNumber.String(),
+		From:          change.From.String(),
+		To:            change.To.String(),
+		Reason:        change.Reason.String(),
+	})
+}
@@ -0,0 +1,120 @@
+package accounts
+
+import (
+	"eaglebank/internal/users"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type AccountStatus string
+
+// ActiveStatus accounts allow everything. FrozenStatus accounts take money in but not out while
+// staff investigate. DormantStatus accounts have had no customer transaction for the dormancy
+// period and, like frozen ones, accept deposits but refuse withdrawals until staff reactivate them.
+// ClosedStatus accounts are read-only and cannot be reopened.
+const ActiveStatus AccountStatus = "active"
+const FrozenStatus AccountStatus = "frozen"
+const DormantStatus AccountStatus = "dormant"
+const ClosedStatus AccountStatus = "closed"
+
+// statusTransitions lists the statuses each status can move to.
+var statusTransitions = map[AccountStatus][]AccountStatus{
+	ActiveStatus:  {FrozenStatus, DormantStatus, ClosedStatus},
+	FrozenStatus:  {ActiveStatus, ClosedStatus},
+	DormantStatus: {ActiveStatus, FrozenStatus, ClosedStatus},
+	ClosedStatus:  {},
+}
+
+func AccountStatuses() []AccountStatus {
+	return []AccountStatus{ActiveStatus, FrozenStatus, DormantStatus, ClosedStatus}
+}
+
+func (s AccountStatus) IsValid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+func (s AccountStatus) String() string {
+	return string(s)
+}
+
+func (s AccountStatus) CanTransitionTo(to AccountStatus) bool {
+	return slices.Contains(statusTransitions[s], to)
+}
+
+// AllowsCredits reports whether money can be paid into an account with this status.
+func (s AccountStatus) AllowsCredits() bool {
+	return s != ClosedStatus
+}
+
+// AllowsDebits reports whether money can be taken out of an account with this status.
+func (s AccountStatus) AllowsDebits() bool {
+	return s == ActiveStatus
+}
+
+// err is the error returned when the status refuses a transaction.
+func (s AccountStatus) err() error {
+	switch s {
+	case FrozenStatus:
+		return ErrAccountFrozen
+	case DormantStatus:
+		return ErrAccountDormant
+	default:
+		return ErrAccountClosed
+	}
+}
+
+func NewAccountStatus(s string) (AccountStatus, error) {
+	status := AccountStatus(s)
+	if !status.IsValid() {
+		return "", fmt.Errorf("invalid account status %q", s)
+	}
+	return status, nil
+}
+
+// StatusReason is the reason code recorded with every status change.
+type StatusReason string
+
+const CustomerRequestReason StatusReason = "customer_request"
+const SuspectedFraudReason StatusReason = "suspected_fraud"
+const LegalOrderReason StatusReason = "legal_order"
+const ReviewCompleteReason StatusReason = "review_complete"
+
+// InactivityReason is recorded when the bank flags an account dormant.
+const InactivityReason StatusReason = "inactivity"
+
+func StatusReasons() []StatusReason {
+	return []StatusReason{CustomerRequestReason, SuspectedFraudReason, LegalOrderReason, ReviewCompleteReason, InactivityReason}
+}
+
+func (r StatusReason) IsValid() bool {
+	return slices.Contains(StatusReasons(), r)
+}
+
+func (r StatusReason) String() string {
+	return string(r)
+}
+
+func NewStatusReason(s string) (StatusReason, error) {
+	reason := StatusReason(s)
+	if !reason.IsValid() {
+		return "", fmt.Errorf("invalid status reason %q", s)
+	}
+	return reason, nil
+}
+
+// StatusChange is one entry in an account's status history.
+type StatusChange struct {
+	From   AccountStatus
+	To     AccountStatus
+	Reason StatusReason
+	// ChangedBy is the member of staff who made the change, empty when the bank made it
+	// automatically.
+	ChangedBy users.UserID
+	Timestamp time.Time
+}
+
+// DefaultDormancyMonths is how long an account can go without a customer transaction before it is
+// flagged dormant.
+const DefaultDormancyMonths = 12
@@ -1,24 +1,62 @@
 package accounts
 
 import (
+	"eaglebank/internal/limits"
 	"eaglebank/internal/users"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand/v2"
 	"regexp"
+	"slices"
+	"sync"
 	"time"
 )
 
 type AccountType string
 
 const PersonalAcct AccountType = "personal"
+const CurrentAcct AccountType = "current"
+const SavingsAcct AccountType = "savings"
+const BusinessAcct AccountType = "business"
 
-func (a AccountType) IsValid() bool {
-	switch a {
-	case PersonalAcct:
-		return true
-	default:
-		return false
+// AccountTypeRules is the single definition of what each account type allows. Domain validation,
+// the web validator's acctType tag and the openapi.yaml enums are all derived from or checked
+// against accountTypeRules.
+type AccountTypeRules struct {
+	Type AccountType
+	// MaxMonthlyWithdrawals limits customer withdrawals per calendar month, zero means unlimited.
+	MaxMonthlyWithdrawals int
+	RequiresBusiness      bool
+}
+
+var accountTypeRules = []AccountTypeRules{
+	{Type: PersonalAcct},
+	{Type: CurrentAcct},
+	{Type: SavingsAcct, MaxMonthlyWithdrawals: 3},
+	{Type: BusinessAcct, RequiresBusiness: true},
+}
+
+func AccountTypes() []AccountType {
+	types := make([]AccountType, 0, len(accountTypeRules))
+	for _, r := range accountTypeRules {
+		types = append(types, r.Type)
+	}
+	return types
+}
+
+func (a AccountType) Rules() (AccountTypeRules, bool) {
+	for _, r := range accountTypeRules {
+		if r.Type == a {
+			return r, true
+		}
 	}
+	return AccountTypeRules{}, false
+}
+
+func (a AccountType) IsValid() bool {
+	_, ok := a.Rules()
+	return ok
 }
 
 func (a AccountType) String() string {
@@ -37,30 +75,110 @@ type AccountNumber string
 
 var accountNumberRegex = regexp.MustCompile(`^01\d{6}$`)
 
+// accountNumberWeights are the modulus 11 weights applied to each digit of an account number.
+// A number is valid when the weighted sum of its digits is divisible by 11, so the final digit
+// acts as a check digit in the style of the VocaLink modulus checks.
+var accountNumberWeights = [8]int{8, 7, 6, 5, 4, 3, 2, 1}
+
 func (a AccountNumber) IsValid() bool {
-	return accountNumberRegex.MatchString(a.String())
+	return accountNumberRegex.MatchString(a.String()) && a.weightedSum()%11 == 0
 }
 
 func (a AccountNumber) String() string {
 	return string(a)
 }
 
+func (a AccountNumber) weightedSum() int {
+	sum := 0
+	for i, d := range a.String() {
+		sum += int(d-'0') * accountNumberWeights[i]
+	}
+	return sum
+}
+
 func NewAccountNumber(s string) (AccountNumber, error) {
 	acct := AccountNumber(s)
 	if !acct.IsValid() {
-		return "", fmt.Errorf("invalid account number %q: must match format 01XXXXXX", s)
+		return "", fmt.Errorf("invalid account number %q: must match format 01XXXXXX and pass the modulus check", s)
 	}
 	return acct, nil
 }
 
+// NewCheckedAccountNumber appends the check digit to the 5 digit base. Roughly one base in 11
+// has no valid check digit, in which case it returns ErrNoCheckDigit and the caller should move
+// on to another base.
+func NewCheckedAccountNumber(base int) (AccountNumber, error) {
+	if base < 0 || base > 99999 {
+		return "", fmt.Errorf("invalid account number base %d: must be 5 digits", base)
+	}
+	acct := AccountNumber(fmt.Sprintf("01%05d0", base))
+	check := (11 - acct.weightedSum()%11) % 11
+	if check == 10 {
+		return "", ErrNoCheckDigit
+	}
+	return NewAccountNumber(fmt.Sprintf("01%05d%d", base, check))
+}
+
+// NewRandAccountNumber returns a random account number which passes the modulus check. It does
+// not check uniqueness, AccountService reserves the number in the store before using it.
 func NewRandAccountNumber() (AccountNumber, error) {
-	return NewAccountNumber(fmt.Sprintf("01%06d", rand.IntN(1000000)))
+	for {
+		acct, err := NewCheckedAccountNumber(rand.IntN(100000))
+		if errors.Is(err, ErrNoCheckDigit) {
+			continue
+		}
+		return acct, err
+	}
+}
+
+// AccountNumberPool hands out every account number with a base in its range once, in a random
+// order, so numbers are hard to guess but allocating one never needs more tries than there are
+// numbers already taken. It is safe for concurrent use.
+type AccountNumberPool struct {
+	mu          sync.Mutex
+	first, last int
+	// free holds the bases not yet handed out, shuffled when the pool is first used.
+	free []int32
+	init bool
+}
+
+// NewAccountNumberPool covers the bases first to last inclusive, the whole range is 0 to 99999.
+func NewAccountNumberPool(first, last int) *AccountNumberPool {
+	return &AccountNumberPool{first: max(first, 0), last: min(last, 99999)}
+}
+
+// Next returns an account number not handed out before, or ErrAccountNumbersExhausted once every
+// number in the range has been. It does not check the number is free in the store, so the caller
+// should take the next one if it is not.
+func (p *AccountNumberPool) Next() (AccountNumber, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.init {
+		p.init = true
+		for base := p.first; base <= p.last; base++ {
+			p.free = append(p.free, int32(base))
+		}
+		rand.Shuffle(len(p.free), func(i, j int) { p.free[i], p.free[j] = p.free[j], p.free[i] })
+	}
+	for len(p.free) > 0 {
+		base := p.free[len(p.free)-1]
+		p.free = p.free[:len(p.free)-1]
+		acct, err := NewCheckedAccountNumber(int(base))
+		if errors.Is(err, ErrNoCheckDigit) {
+			continue
+		}
+		return acct, err
+	}
+	return "", ErrAccountNumbersExhausted
 }
 
 type SortCode string
 
+var sortCodeRegex = regexp.MustCompile(`^\d{2}-\d{2}-\d{2}$`)
+
+// IsValid only checks the format, whether the bank owns the sort code is up to the BranchRegistry.
 func (c SortCode) IsValid() bool {
-	return c == "10-10-10"
+	return sortCodeRegex.MatchString(c.String())
 }
 
 func (c SortCode) String() string {
@@ -70,7 +188,7 @@ func (c SortCode) String() string {
 func NewSortCode(s string) (SortCode, error) {
 	code := SortCode(s)
 	if !code.IsValid() {
-		return "", fmt.Errorf("invalid sort code %q: must be 10-10-10", s)
+		return "", fmt.Errorf("invalid sort code %q: must match format XX-XX-XX", s)
 	}
 	return code, nil
 }
@@ -78,14 +196,60 @@ func NewSortCode(s string) (SortCode, error) {
 type Currency string
 
 const GBP Currency = "GBP"
+const EUR Currency = "EUR"
+const USD Currency = "USD"
+const JPY Currency = "JPY"
+
+// currencyExponents lists the supported ISO 4217 currencies and the number of decimal places in
+// their minor unit. Validation and the openapi.yaml currency enums are checked against it.
+var currencyExponents = []struct {
+	currency Currency
+	exponent int
+}{
+	{GBP, 2},
+	{EUR, 2},
+	{USD, 2},
+	{JPY, 0},
+}
+
+func Currencies() []Currency {
+	currs := make([]Currency, 0, len(currencyExponents))
+	for _, c := range currencyExponents {
+		currs = append(currs, c.currency)
+	}
+	return currs
+}
+
+// Exponent is the number of decimal places in the currency's minor unit, -1 if unsupported.
+func (c Currency) Exponent() int {
+	for _, ce := range currencyExponents {
+		if ce.currency == c {
+			return ce.exponent
+		}
+	}
+	return -1
+}
 
 func (c Currency) IsValid() bool {
-	switch c {
-	case GBP:
-		return true
-	default:
+	return c.Exponent() >= 0
+}
+
+// IsValidAmount reports whether amt can be expressed in whole minor units of the currency.
+func (c Currency) IsValidAmount(amt float64) bool {
+	if !c.IsValid() {
 		return false
 	}
+	scaled := amt * math.Pow10(c.Exponent())
+	return math.Abs(scaled-math.Round(scaled)) < 1e-6
+}
+
+// Round rounds amt to the nearest minor unit of the currency, unsupported currencies are left as is.
+func (c Currency) Round(amt float64) float64 {
+	if !c.IsValid() {
+		return amt
+	}
+	scale := math.Pow10(c.Exponent())
+	return math.Round(amt*scale) / scale
 }
 
 func (c Currency) String() string {
@@ -100,29 +264,160 @@ func NewCurrency(s string) (Currency, error) {
 	return currency, nil
 }
 
-const BalanceMax float64 = 10000
-const BalanceMin float64 = 0
+type CompanyNumber string
+
+// Companies House numbers are eight digits, or a two letter prefix such as SC followed by six digits.
+var companyNumberRegex = regexp.MustCompile(`^(\d{8}|[A-Z]{2}\d{6})$`)
+
+func (n CompanyNumber) IsValid() bool {
+	return companyNumberRegex.MatchString(n.String())
+}
+
+func (n CompanyNumber) String() string {
+	return string(n)
+}
+
+func NewCompanyNumber(s string) (CompanyNumber, error) {
+	num := CompanyNumber(s)
+	if !num.IsValid() {
+		return "", fmt.Errorf("invalid company registration number %q", s)
+	}
+	return num, nil
+}
+
+type BusinessDetails struct {
+	CompanyName        string
+	RegistrationNumber CompanyNumber
+}
+
+func (b BusinessDetails) IsZero() bool {
+	return b == BusinessDetails{}
+}
+
+func (b BusinessDetails) IsValid() bool {
+	return b.CompanyName != "" && b.RegistrationNumber.IsValid()
+}
+
+// validForType checks business details are present and valid exactly when the type requires them.
+func (b BusinessDetails) validForType(acctType AccountType) bool {
+	rules, ok := acctType.Rules()
+	if !ok {
+		return false
+	}
+	if rules.RequiresBusiness {
+		return b.IsValid()
+	}
+	return b.IsZero()
+}
+
+type HolderRole string
+
+const PrimaryHolder HolderRole = "primary"
+const SecondaryHolder HolderRole = "secondary"
+
+func (r HolderRole) IsValid() bool {
+	switch r {
+	case PrimaryHolder, SecondaryHolder:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r HolderRole) String() string {
+	return string(r)
+}
+
+type Holder struct {
+	UserID         users.UserID
+	Role           HolderRole
+	AddedTimestamp time.Time
+}
+
+func (h Holder) IsValid() bool {
+	return h.UserID.IsValid() && h.Role.IsValid()
+}
+
+// MaxOverdraftLimit is the largest overdraft the bank will arrange on one account.
+const MaxOverdraftLimit float64 = 5000
+
+// DefaultOverdraftRate is the annual debit interest rate fixed on an overdraft when it is approved.
+const DefaultOverdraftRate float64 = 0.1999
+
+// overdraftDayCount is the number of days in a year used to turn the annual overdraft rate into a
+// daily one.
+const overdraftDayCount = 365
+
+// Overdraft is an arranged overdraft, the zero value means the account has none.
+type Overdraft struct {
+	Limit float64
+	// AnnualRate is the debit interest charged on the overdrawn balance, 0.1999 is 19.99%.
+	AnnualRate float64
+}
+
+func (o Overdraft) IsZero() bool {
+	return o == Overdraft{}
+}
+
+func (o Overdraft) IsValid() bool {
+	return o.Limit >= 0 && o.Limit <= MaxOverdraftLimit && o.AnnualRate >= 0 && o.AnnualRate < 1
+}
 
 type BankAccount struct {
-	UserID           users.UserID
+	Holders          []Holder
 	AccountNumber    AccountNumber
 	SortCode         SortCode
+	IBAN             IBAN
 	Name             string
 	AccountType      AccountType
 	balance          float64
 	Currency         Currency
+	Business         BusinessDetails
+	Overdraft        Overdraft
+	Limits           limits.Profile
+	Holds            []Hold
+	Pots             []Pot
+	Status           AccountStatus
+	StatusHistory    []StatusChange
 	CreatedTimestamp time.Time
 	UpdatedTimestamp time.Time
+	// Version counts the writes to the account, starting from 1. Each write must carry the next
+	// version, so a writer working from an out of date copy fails with ErrConflict rather than
+	// overwriting a change it has not seen.
+	Version uint64
+	// lastCustomerActivity is when the holders last made a transaction themselves, staff
+	// adjustments and bank postings such as interest do not count towards dormancy.
+	lastCustomerActivity time.Time
+	// overdraftInterest is debit interest accrued day by day up to and including
+	// interestAccruedThrough, it is charged to the balance monthly.
+	overdraftInterest      float64
+	interestAccruedThrough time.Time
+	// withdrawalPeriod is the calendar month, as YYYY-MM, that monthlyWithdrawals and
+	// monthlyWithdrawn count, withdrawalDay is the day, as YYYY-MM-DD, that dailyWithdrawn counts.
+	withdrawalPeriod   string
+	monthlyWithdrawals int
+	monthlyWithdrawn   float64
+	withdrawalDay      string
+	dailyWithdrawn     float64
 }
 
 func (ba BankAccount) IsValid() bool {
 	if ba.Name == "" {
 		return false
 	}
-	if ba.balance < BalanceMin || ba.balance > BalanceMax {
+	if ba.balance < -ba.Overdraft.Limit {
+		return false
+	}
+	if !ba.Limits.IsValid() {
 		return false
 	}
-	if !ba.UserID.IsValid() {
+	if !ba.Overdraft.IsValid() {
+		return false
+	}
+	if !ba.Status.IsValid() {
+		return false
+	}
+	if !validHolders(ba.Holders) {
 		return false
 	}
 	if !ba.AccountNumber.IsValid() {
@@ -131,22 +426,275 @@ func (ba BankAccount) IsValid() bool {
 	if !ba.SortCode.IsValid() {
 		return false
 	}
+	if ba.IBAN != "" && !ba.IBAN.IsValid() {
+		return false
+	}
 	if !ba.AccountType.IsValid() {
 		return false
 	}
+	if !ba.Business.validForType(ba.AccountType) {
+		return false
+	}
 	if !ba.Currency.IsValid() {
 		return false
 	}
 	return true
 }
 
+// validHolders requires exactly one primary holder and no user holding the account twice.
+func validHolders(holders []Holder) bool {
+	primaries := 0
+	seen := make(map[users.UserID]bool, len(holders))
+	for _, h := range holders {
+		if !h.IsValid() || seen[h.UserID] {
+			return false
+		}
+		seen[h.UserID] = true
+		if h.Role == PrimaryHolder {
+			primaries++
+		}
+	}
+	return primaries == 1
+}
+
+func (ba BankAccount) PrimaryHolder() users.UserID {
+	for _, h := range ba.Holders {
+		if h.Role == PrimaryHolder {
+			return h.UserID
+		}
+	}
+	return ""
+}
+
+func (ba BankAccount) HolderIDs() []users.UserID {
+	ids := make([]users.UserID, 0, len(ba.Holders))
+	for _, h := range ba.Holders {
+		ids = append(ids, h.UserID)
+	}
+	return ids
+}
+
+func (ba BankAccount) IsHolder(userID users.UserID) bool {
+	return slices.Contains(ba.HolderIDs(), userID)
+}
+
+func (ba BankAccount) AddHolder(userID users.UserID, role HolderRole) (BankAccount, error) {
+	if ba.IsHolder(userID) {
+		return BankAccount{}, ErrAlreadyHolder
+	}
+	holders := append(slices.Clone(ba.Holders), Holder{UserID: userID, Role: role, AddedTimestamp: time.Now()})
+	if !validHolders(holders) {
+		return BankAccount{}, fmt.Errorf("invalid holders %+v", holders)
+	}
+	ba.Holders = holders
+	return ba, nil
+}
+
+// RemoveHolder removes the user from the account. If they were the primary holder the longest
+// standing remaining holder is promoted.
+func (ba BankAccount) RemoveHolder(userID users.UserID) (BankAccount, error) {
+	i := slices.IndexFunc(ba.Holders, func(h Holder) bool { return h.UserID == userID })
+	if i < 0 {
+		return BankAccount{}, ErrNotHolder
+	}
+	if len(ba.Holders) == 1 {
+		return BankAccount{}, ErrLastHolder
+	}
+	removed := ba.Holders[i]
+	holders := slices.Delete(slices.Clone(ba.Holders), i, i+1)
+	if removed.Role == PrimaryHolder {
+		holders[0].Role = PrimaryHolder
+	}
+	ba.Holders = holders
+	return ba, nil
+}
+
 func (ba BankAccount) Balance() float64 {
 	return ba.balance
 }
 
+// AvailableBalance is what can be withdrawn: the main balance plus any unused overdraft, less the
+// funds reserved by active holds. Money in pots is not available.
+func (ba BankAccount) AvailableBalance() float64 {
+	return max(ba.Currency.Round(ba.MainBalance()+ba.Overdraft.Limit-ba.HeldAmount()), 0)
+}
+
+// AvailableOverdraft is the part of the overdraft limit not yet used.
+func (ba BankAccount) AvailableOverdraft() float64 {
+	return min(ba.Overdraft.Limit, ba.AvailableBalance())
+}
+
+// WithOverdraft arranges, changes or with a zero Overdraft removes the account's overdraft. An
+// account already overdrawn beyond the new limit keeps its balance but cannot withdraw further.
+func (ba BankAccount) WithOverdraft(overdraft Overdraft) (BankAccount, error) {
+	if !overdraft.IsValid() || !ba.Currency.IsValidAmount(overdraft.Limit) {
+		return BankAccount{}, fmt.Errorf("invalid overdraft %+v", overdraft)
+	}
+	ba.Overdraft = overdraft
+	return ba, nil
+}
+
+// AccrueOverdraftInterest adds a day's debit interest on an overdrawn end-of-day balance. Days up
+// to the last accrual are ignored, so accruing the same day twice has no effect.
+func (ba BankAccount) AccrueOverdraftInterest(day time.Time) BankAccount {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if !day.After(ba.interestAccruedThrough) {
+		return ba
+	}
+	if ba.balance < 0 {
+		ba.overdraftInterest += -ba.balance * ba.Overdraft.AnnualRate / overdraftDayCount
+	}
+	ba.interestAccruedThrough = day
+	return ba
+}
+
+func (ba BankAccount) AccruedOverdraftInterest() float64 {
+	return ba.overdraftInterest
+}
+
+// ChargeOverdraftInterest takes the accrued interest, rounded to the currency's minor unit, off
+// the balance and returns the amount charged. The charge is made even if it takes the account
+// beyond its overdraft limit.
+func (ba BankAccount) ChargeOverdraftInterest() (BankAccount, float64) {
+	amt := ba.Currency.Round(ba.overdraftInterest)
+	ba.overdraftInterest = 0
+	ba.balance = ba.Currency.Round(ba.balance - amt)
+	return ba, amt
+}
+
+// ChangeStatus moves the account to a new status, recording the reason and who made the change in
+// its history. An account can only be closed once it is empty.
+func (ba BankAccount) ChangeStatus(to AccountStatus, reason StatusReason, changedBy users.UserID) (BankAccount, error) {
+	if !reason.IsValid() {
+		return BankAccount{}, fmt.Errorf("invalid status reason %q", reason)
+	}
+	if !ba.Status.CanTransitionTo(to) {
+		return BankAccount{}, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, ba.Status, to)
+	}
+	if to == ClosedStatus {
+		if ba.balance != 0 || ba.PotsBalance() != 0 || ba.overdraftInterest != 0 {
+			return BankAccount{}, ErrAccountNotEmpty
+		}
+		ba.Overdraft = Overdraft{}
+	}
+	now := time.Now()
+	ba.StatusHistory = append(slices.Clone(ba.StatusHistory), StatusChange{
+		From: ba.Status, To: to, Reason: reason, ChangedBy: changedBy, Timestamp: now,
+	})
+	ba.Status = to
+	ba.UpdatedTimestamp = now
+	return ba, nil
+}
+
+// LastCustomerActivity is when a holder last transacted on the account, or when it was opened if
+// they never have.
+func (ba BankAccount) LastCustomerActivity() time.Time {
+	if ba.lastCustomerActivity.IsZero() {
+		return ba.CreatedTimestamp
+	}
+	return ba.lastCustomerActivity
+}
+
+// RecordCustomerActivity notes a transaction made by a holder, which resets the dormancy clock.
+func (ba BankAccount) RecordCustomerActivity(at time.Time) BankAccount {
+	if at.After(ba.lastCustomerActivity) {
+		ba.lastCustomerActivity = at
+	}
+	return ba
+}
+
+// Withdraw is a customer withdrawal. It counts towards the account type's monthly number of
+// withdrawals and the daily and monthly withdrawal limits.
 func (ba BankAccount) Withdraw(amt float64) (BankAccount, error) {
-	newBalance := ba.balance - amt
-	if newBalance < BalanceMin {
+	return ba.WithdrawAt(amt, time.Now())
+}
+
+// WithdrawAt is a customer withdrawal made at the given time. Days and months are UTC calendar
+// days and months, whatever the server's time zone.
+func (ba BankAccount) WithdrawAt(amt float64, at time.Time) (BankAccount, error) {
+	ba = ba.rollWithdrawalPeriod(at)
+	rules, _ := ba.AccountType.Rules()
+	if rules.MaxMonthlyWithdrawals > 0 && ba.monthlyWithdrawals >= rules.MaxMonthlyWithdrawals {
+		return BankAccount{}, ErrWithdrawalLimitReached
+	}
+	dailyWithdrawn := ba.Currency.Round(ba.dailyWithdrawn + amt)
+	if err := ba.Limits.Check(limits.DailyWithdrawal, dailyWithdrawn); err != nil {
+		return BankAccount{}, err
+	}
+	monthlyWithdrawn := ba.Currency.Round(ba.monthlyWithdrawn + amt)
+	if err := ba.Limits.Check(limits.MonthlyWithdrawal, monthlyWithdrawn); err != nil {
+		return BankAccount{}, err
+	}
+	ba, err := ba.Debit(amt)
+	if err != nil {
+		return BankAccount{}, err
+	}
+	return ba.countWithdrawal(amt, at), nil
+}
+
+// rollWithdrawalPeriod starts the counts afresh when at is in a later month or day than they count.
+func (ba BankAccount) rollWithdrawalPeriod(at time.Time) BankAccount {
+	period, day := withdrawalPeriod(at)
+	if ba.withdrawalPeriod != period {
+		ba.withdrawalPeriod = period
+		ba.monthlyWithdrawals = 0
+		ba.monthlyWithdrawn = 0
+	}
+	if ba.withdrawalDay != day {
+		ba.withdrawalDay = day
+		ba.dailyWithdrawn = 0
+	}
+	return ba
+}
+
+// countWithdrawal counts a withdrawal made at the given time towards the limits.
+func (ba BankAccount) countWithdrawal(amt float64, at time.Time) BankAccount {
+	ba = ba.rollWithdrawalPeriod(at)
+	ba.monthlyWithdrawals++
+	ba.dailyWithdrawn = ba.Currency.Round(ba.dailyWithdrawn + amt)
+	ba.monthlyWithdrawn = ba.Currency.Round(ba.monthlyWithdrawn + amt)
+	return ba
+}
+
+// withdrawalCounts are the counters customer withdrawals are checked against.
+type withdrawalCounts struct {
+	period  string
+	count   int
+	monthly float64
+	day     string
+	daily   float64
+}
+
+func (ba BankAccount) withdrawals() withdrawalCounts {
+	return withdrawalCounts{ba.withdrawalPeriod, ba.monthlyWithdrawals, ba.monthlyWithdrawn, ba.withdrawalDay, ba.dailyWithdrawn}
+}
+
+func (ba BankAccount) MonthlyWithdrawals() int {
+	period, _ := withdrawalPeriod(time.Now())
+	if ba.withdrawalPeriod != period {
+		return 0
+	}
+	return ba.monthlyWithdrawals
+}
+
+// withdrawalPeriod is the UTC month and day withdrawals made at the given time count towards.
+func withdrawalPeriod(at time.Time) (month string, day string) {
+	at = at.UTC()
+	return at.Format("2006-01"), at.Format(time.DateOnly)
+}
+
+// Debit takes money out of the account without counting as a customer withdrawal. The balance may
+// go as far below zero as the overdraft limit allows, and never into funds under a hold or in a
+// pot.
+func (ba BankAccount) Debit(amt float64) (BankAccount, error) {
+	if !ba.Status.AllowsDebits() {
+		return BankAccount{}, ba.Status.err()
+	}
+	if err := ba.Limits.Check(limits.MaxTransaction, amt); err != nil {
+		return BankAccount{}, err
+	}
+	newBalance := ba.Currency.Round(ba.balance - amt)
+	if ba.Currency.Round(newBalance-ba.PotsBalance()-ba.HeldAmount()) < -ba.Overdraft.Limit {
 		return BankAccount{}, ErrInsufficientFunds
 	}
 	ba.balance = newBalance
@@ -154,26 +702,66 @@ func (ba BankAccount) Withdraw(amt float64) (BankAccount, error) {
 }
 
 func (ba BankAccount) Deposit(amt float64) (BankAccount, error) {
-	newBalance := ba.balance + amt
-	if newBalance > BalanceMax {
-		return BankAccount{}, ErrTooManyFunds
+	if !ba.Status.AllowsCredits() {
+		return BankAccount{}, ba.Status.err()
+	}
+	if err := ba.Limits.Check(limits.MaxTransaction, amt); err != nil {
+		return BankAccount{}, err
+	}
+	newBalance := ba.Currency.Round(ba.balance + amt)
+	if err := ba.Limits.Check(limits.MaxBalance, newBalance); err != nil {
+		return BankAccount{}, err
 	}
 	ba.balance = newBalance
 	return ba, nil
 }
 
-func NewBankAccount(userID users.UserID, acctNum AccountNumber, sortCode SortCode, name string, acctType AccountType, curr Currency) (BankAccount, error) {
+// CorrectBalance sets the balance to the one worked out from the account's transactions, after a
+// partial failure left the two out of step. It ignores the account's status and limits, since it
+// records money which has already moved rather than moving any.
+func (ba BankAccount) CorrectBalance(balance float64) BankAccount {
+	ba.balance = ba.Currency.Round(balance)
+	return ba
+}
+
+type BankAccountOption func(*BankAccount)
+
+func WithIBAN(iban IBAN) BankAccountOption {
+	return func(ba *BankAccount) {
+		ba.IBAN = iban
+	}
+}
+
+func WithBusiness(business BusinessDetails) BankAccountOption {
+	return func(ba *BankAccount) {
+		ba.Business = business
+	}
+}
+
+func NewBankAccount(userID users.UserID, acctNum AccountNumber, sortCode SortCode, name string, acctType AccountType, curr Currency, opts ...BankAccountOption) (BankAccount, error) {
 	now := time.Now()
 	acct := BankAccount{
-		UserID:           userID,
+		Holders:          []Holder{{UserID: userID, Role: PrimaryHolder, AddedTimestamp: now}},
 		AccountNumber:    acctNum,
 		SortCode:         sortCode,
 		Name:             name,
 		AccountType:      acctType,
 		balance:          0,
 		Currency:         curr,
+		Status:           ActiveStatus,
 		CreatedTimestamp: now,
 		UpdatedTimestamp: now,
+		Version:          1,
+	}
+	for _, opt := range opts {
+		opt(&acct)
+	}
+	if acct.Limits.IsZero() {
+		profile, err := DefaultLimits.Resolve(acctType, users.StandardTier)
+		if err != nil {
+			return BankAccount{}, err
+		}
+		acct.Limits = profile
 	}
 	if !acct.IsValid() {
 		return BankAccount{}, fmt.Errorf("invalid bank account %+v", acct)
@@ -185,6 +773,11 @@ type CreateAccountRequest struct {
 	UserID      users.UserID
 	Name        string
 	AccountType AccountType
+	// Currency defaults to GBP when empty.
+	Currency Currency
+	// SortCode picks the branch, the registry's default branch is used when empty.
+	SortCode SortCode
+	Business BusinessDetails
 }
 
 func (r CreateAccountRequest) IsValid() bool {
@@ -197,14 +790,26 @@ func (r CreateAccountRequest) IsValid() bool {
 	if !r.AccountType.IsValid() {
 		return false
 	}
+	if r.Currency != "" && !r.Currency.IsValid() {
+		return false
+	}
+	if r.SortCode != "" && !r.SortCode.IsValid() {
+		return false
+	}
+	if !r.Business.validForType(r.AccountType) {
+		return false
+	}
 	return true
 }
 
-func NewCreateAccountRequest(userID users.UserID, name string, acctType AccountType) (CreateAccountRequest, error) {
+func NewCreateAccountRequest(userID users.UserID, name string, acctType AccountType, curr Currency, sortCode SortCode, business BusinessDetails) (CreateAccountRequest, error) {
 	req := CreateAccountRequest{
 		UserID:      userID,
 		Name:        name,
 		AccountType: acctType,
+		Currency:    curr,
+		SortCode:    sortCode,
+		Business:    business,
 	}
 	if !req.IsValid() {
 		return CreateAccountRequest{}, fmt.Errorf("invalid create account request %+v", req)
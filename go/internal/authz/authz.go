@@ -0,0 +1,136 @@
+package authz
+
+import (
+	"eaglebank/internal/users"
+	"fmt"
+	"slices"
+)
+
+type Action string
+
+const (
+	ReadUser          Action = "user:read"
+	VerifyEmail       Action = "user:verify-email"
+	CreateAccount     Action = "account:create"
+	ListAccounts      Action = "account:list"
+	ReadAccount       Action = "account:read"
+	ManageHolders     Action = "account:holders"
+	ManageGrants      Action = "account:grants"
+	CreateTransaction Action = "transaction:create"
+	ReadTransactions  Action = "transaction:read"
+	PostAdjustment    Action = "adjustment:create"
+	ApplyOverdraft    Action = "overdraft:apply"
+	DecideOverdraft   Action = "overdraft:decide"
+	ChangeStatus      Action = "account:status"
+	ManageHolds       Action = "hold:manage"
+	ChangeTier        Action = "user:tier"
+	ManagePots        Action = "pot:manage"
+	Reconcile         Action = "reconciliation:run"
+	RepairBalances    Action = "reconciliation:repair"
+)
+
+// Scope is how far a role's permission for an action reaches.
+type Scope int
+
+const (
+	ScopeNone Scope = iota
+	ScopeOwn
+	ScopeAny
+	// ScopeOthers reaches every resource except the subject's own, for staff actions which would
+	// be a conflict of interest on their own accounts.
+	ScopeOthers
+)
+
+type Subject struct {
+	UserID users.UserID
+	Role   users.Role
+}
+
+// Resource describes what is being acted on. Owners are the users who hold the resource, an empty
+// list means the resource is not owned by anyone in particular.
+type Resource struct {
+	Owners []users.UserID
+}
+
+func OwnedBy(owners ...users.UserID) Resource {
+	return Resource{Owners: owners}
+}
+
+type Rules map[users.Role]map[Action]Scope
+
+var DefaultRules = Rules{
+	users.CustomerRole: {
+		ReadUser:          ScopeOwn,
+		VerifyEmail:       ScopeOwn,
+		CreateAccount:     ScopeOwn,
+		ListAccounts:      ScopeOwn,
+		ReadAccount:       ScopeOwn,
+		ManageHolders:     ScopeOwn,
+		ManageGrants:      ScopeOwn,
+		CreateTransaction: ScopeOwn,
+		ReadTransactions:  ScopeOwn,
+		ApplyOverdraft:    ScopeOwn,
+		ManagePots:        ScopeOwn,
+	},
+	users.TellerRole: {
+		ReadUser:          ScopeAny,
+		VerifyEmail:       ScopeOwn,
+		CreateAccount:     ScopeOwn,
+		ListAccounts:      ScopeAny,
+		ReadAccount:       ScopeAny,
+		CreateTransaction: ScopeOwn,
+		ReadTransactions:  ScopeAny,
+		PostAdjustment:    ScopeOthers,
+		DecideOverdraft:   ScopeOthers,
+		ChangeStatus:      ScopeOthers,
+		ManageHolds:       ScopeOthers,
+	},
+	users.SupportRole: {
+		ReadUser:          ScopeAny,
+		VerifyEmail:       ScopeOwn,
+		CreateAccount:     ScopeOwn,
+		ListAccounts:      ScopeAny,
+		ReadAccount:       ScopeAny,
+		CreateTransaction: ScopeOwn,
+		ReadTransactions:  ScopeAny,
+		PostAdjustment:    ScopeOthers,
+		DecideOverdraft:   ScopeOthers,
+		ChangeStatus:      ScopeOthers,
+		ManageHolds:       ScopeOthers,
+		ChangeTier:        ScopeOthers,
+		Reconcile:         ScopeAny,
+		RepairBalances:    ScopeAny,
+	},
+	users.AuditorRole: {
+		ReadUser:         ScopeAny,
+		VerifyEmail:      ScopeOwn,
+		ListAccounts:     ScopeAny,
+		ReadAccount:      ScopeAny,
+		ReadTransactions: ScopeAny,
+		Reconcile:        ScopeAny,
+	},
+}
+
+type Policy struct {
+	rules Rules
+}
+
+func NewPolicy(rules Rules) Policy {
+	return Policy{rules: rules}
+}
+
+func (p Policy) Authorize(sub Subject, action Action, res Resource) error {
+	switch p.rules[sub.Role][action] {
+	case ScopeAny:
+		return nil
+	case ScopeOwn:
+		if slices.Contains(res.Owners, sub.UserID) {
+			return nil
+		}
+	case ScopeOthers:
+		if !slices.Contains(res.Owners, sub.UserID) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s may not %s", ErrForbidden, sub.Role, action)
+}
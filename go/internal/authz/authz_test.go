@@ -0,0 +1,122 @@
+package authz_test
+
+import (
+	"eaglebank/internal/authz"
+	"eaglebank/internal/users"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := authz.NewPolicy(authz.DefaultRules)
+	owner := users.UserID("usr-owner")
+	other := users.UserID("usr-other")
+
+	t.Run("should allow customer to act on their own resources", func(t *testing.T) {
+		sub := authz.Subject{UserID: owner, Role: users.CustomerRole}
+		assert.NoError(t, policy.Authorize(sub, authz.ReadAccount, authz.OwnedBy(owner)))
+		assert.NoError(t, policy.Authorize(sub, authz.CreateTransaction, authz.OwnedBy(other, owner)))
+	})
+	t.Run("should forbid customer acting on another user's resources", func(t *testing.T) {
+		sub := authz.Subject{UserID: other, Role: users.CustomerRole}
+		err := policy.Authorize(sub, authz.ReadAccount, authz.OwnedBy(owner))
+		assert.ErrorIs(t, err, authz.ErrForbidden)
+	})
+	t.Run("should forbid customer posting adjustments to their own account", func(t *testing.T) {
+		sub := authz.Subject{UserID: owner, Role: users.CustomerRole}
+		err := policy.Authorize(sub, authz.PostAdjustment, authz.OwnedBy(owner))
+		assert.ErrorIs(t, err, authz.ErrForbidden)
+	})
+	t.Run("should allow teller to read and adjust any account", func(t *testing.T) {
+		sub := authz.Subject{UserID: other, Role: users.TellerRole}
+		assert.NoError(t, policy.Authorize(sub, authz.ReadAccount, authz.OwnedBy(owner)))
+		assert.NoError(t, policy.Authorize(sub, authz.PostAdjustment, authz.OwnedBy(owner)))
+	})
+	t.Run("should forbid teller transacting on a customer's account", func(t *testing.T) {
+		sub := authz.Subject{UserID: other, Role: users.TellerRole}
+		err := policy.Authorize(sub, authz.CreateTransaction, authz.OwnedBy(owner))
+		assert.ErrorIs(t, err, authz.ErrForbidden)
+	})
+	t.Run("should only allow auditor to read", func(t *testing.T) {
+		sub := authz.Subject{UserID: other, Role: users.AuditorRole}
+		assert.NoError(t, policy.Authorize(sub, authz.ReadTransactions, authz.OwnedBy(owner)))
+		assert.ErrorIs(t, policy.Authorize(sub, authz.CreateAccount, authz.OwnedBy(other)), authz.ErrForbidden)
+		assert.ErrorIs(t, policy.Authorize(sub, authz.PostAdjustment, authz.OwnedBy(owner)), authz.ErrForbidden)
+	})
+	t.Run("should only allow staff to decide overdrafts", func(t *testing.T) {
+		customer := authz.Subject{UserID: owner, Role: users.CustomerRole}
+		assert.NoError(t, policy.Authorize(customer, authz.ApplyOverdraft, authz.OwnedBy(owner)))
+		assert.ErrorIs(t, policy.Authorize(customer, authz.DecideOverdraft, authz.OwnedBy(owner)), authz.ErrForbidden)
+
+		teller := authz.Subject{UserID: other, Role: users.TellerRole}
+		assert.NoError(t, policy.Authorize(teller, authz.DecideOverdraft, authz.OwnedBy()))
+		assert.ErrorIs(t, policy.Authorize(teller, authz.ApplyOverdraft, authz.OwnedBy(owner)), authz.ErrForbidden)
+	})
+	t.Run("should only allow staff to change account status", func(t *testing.T) {
+		customer := authz.Subject{UserID: owner, Role: users.CustomerRole}
+		assert.ErrorIs(t, policy.Authorize(customer, authz.ChangeStatus, authz.OwnedBy(owner)), authz.ErrForbidden)
+
+		support := authz.Subject{UserID: other, Role: users.SupportRole}
+		assert.NoError(t, policy.Authorize(support, authz.ChangeStatus, authz.OwnedBy(owner)))
+
+		auditor := authz.Subject{UserID: other, Role: users.AuditorRole}
+		assert.ErrorIs(t, policy.Authorize(auditor, authz.ChangeStatus, authz.OwnedBy(owner)), authz.ErrForbidden)
+	})
+	t.Run("should only allow staff to manage holds", func(t *testing.T) {
+		customer := authz.Subject{UserID: owner, Role: users.CustomerRole}
+		assert.ErrorIs(t, policy.Authorize(customer, authz.ManageHolds, authz.OwnedBy(owner)), authz.ErrForbidden)
+
+		teller := authz.Subject{UserID: other, Role: users.TellerRole}
+		assert.NoError(t, policy.Authorize(teller, authz.ManageHolds, authz.OwnedBy(owner)))
+	})
+	t.Run("should only allow support to change tiers", func(t *testing.T) {
+		teller := authz.Subject{UserID: other, Role: users.TellerRole}
+		assert.ErrorIs(t, policy.Authorize(teller, authz.ChangeTier, authz.OwnedBy(owner)), authz.ErrForbidden)
+
+		support := authz.Subject{UserID: other, Role: users.SupportRole}
+		assert.NoError(t, policy.Authorize(support, authz.ChangeTier, authz.OwnedBy(owner)))
+	})
+	t.Run("should only allow holders to manage their pots", func(t *testing.T) {
+		customer := authz.Subject{UserID: owner, Role: users.CustomerRole}
+		assert.NoError(t, policy.Authorize(customer, authz.ManagePots, authz.OwnedBy(owner)))
+
+		stranger := authz.Subject{UserID: other, Role: users.CustomerRole}
+		assert.ErrorIs(t, policy.Authorize(stranger, authz.ManagePots, authz.OwnedBy(owner)), authz.ErrForbidden)
+
+		teller := authz.Subject{UserID: other, Role: users.TellerRole}
+		assert.ErrorIs(t, policy.Authorize(teller, authz.ManagePots, authz.OwnedBy(owner)), authz.ErrForbidden)
+	})
+	t.Run("should let auditors reconcile but only support repair balances", func(t *testing.T) {
+		auditor := authz.Subject{UserID: other, Role: users.AuditorRole}
+		assert.NoError(t, policy.Authorize(auditor, authz.Reconcile, authz.OwnedBy()))
+		assert.ErrorIs(t, policy.Authorize(auditor, authz.RepairBalances, authz.OwnedBy()), authz.ErrForbidden)
+
+		support := authz.Subject{UserID: other, Role: users.SupportRole}
+		assert.NoError(t, policy.Authorize(support, authz.RepairBalances, authz.OwnedBy()))
+
+		customer := authz.Subject{UserID: owner, Role: users.CustomerRole}
+		assert.ErrorIs(t, policy.Authorize(customer, authz.Reconcile, authz.OwnedBy()), authz.ErrForbidden)
+	})
+	t.Run("should only send verification emails to the user themselves", func(t *testing.T) {
+		for _, role := range []users.Role{users.CustomerRole, users.TellerRole, users.SupportRole, users.AuditorRole} {
+			sub := authz.Subject{UserID: owner, Role: role}
+			assert.NoError(t, policy.Authorize(sub, authz.VerifyEmail, authz.OwnedBy(owner)), role)
+			assert.ErrorIs(t, policy.Authorize(sub, authz.VerifyEmail, authz.OwnedBy(other)), authz.ErrForbidden, role)
+		}
+	})
+	t.Run("should not let staff act on their own accounts", func(t *testing.T) {
+		for _, role := range []users.Role{users.TellerRole, users.SupportRole} {
+			sub := authz.Subject{UserID: other, Role: role}
+			for _, action := range []authz.Action{authz.PostAdjustment, authz.DecideOverdraft, authz.ChangeStatus, authz.ManageHolds} {
+				assert.NoError(t, policy.Authorize(sub, action, authz.OwnedBy(owner)), role, action)
+				assert.ErrorIs(t, policy.Authorize(sub, action, authz.OwnedBy(owner, other)), authz.ErrForbidden, role, action)
+			}
+		}
+	})
+	t.Run("should forbid unknown roles", func(t *testing.T) {
+		sub := authz.Subject{UserID: owner, Role: users.Role("admin")}
+		err := policy.Authorize(sub, authz.ReadAccount, authz.OwnedBy(owner))
+		assert.ErrorIs(t, err, authz.ErrForbidden)
+	})
+}
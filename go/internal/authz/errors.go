@@ -0,0 +1,5 @@
+package authz
+
+import "errors"
+
+var ErrForbidden = errors.New("forbidden")
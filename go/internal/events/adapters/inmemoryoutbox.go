@@ -0,0 +1,67 @@
+package adapters
+
+import (
+	"eaglebank/internal/events"
+	"slices"
+	"sync"
+)
+
+// InMemoryOutbox is shared by the in-memory stores, standing in for an outbox table in the same
+// database as them.
+type InMemoryOutbox struct {
+	mu     sync.RWMutex
+	events []events.Event
+	// cursors is, per subscriber, the position before which every event has been acknowledged.
+	cursors map[string]int
+	// acked holds the events acknowledged beyond the subscriber's cursor.
+	acked map[string]map[events.EventID]bool
+}
+
+func NewInMemoryOutbox() *InMemoryOutbox {
+	return &InMemoryOutbox{cursors: make(map[string]int), acked: make(map[string]map[events.EventID]bool)}
+}
+
+func (o *InMemoryOutbox) Record(evts ...events.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.events = append(o.events, evts...)
+}
+
+func (o *InMemoryOutbox) Pending(subscriber string) ([]events.Event, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	result := make([]events.Event, 0)
+	for _, e := range o.events[o.cursors[subscriber]:] {
+		if !o.acked[subscriber][e.ID] {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (o *InMemoryOutbox) Ack(subscriber string, id events.EventID) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.acked[subscriber] == nil {
+		o.acked[subscriber] = make(map[events.EventID]bool)
+	}
+	o.acked[subscriber][id] = true
+	cursor := o.cursors[subscriber]
+	for cursor < len(o.events) && o.acked[subscriber][o.events[cursor].ID] {
+		delete(o.acked[subscriber], o.events[cursor].ID)
+		cursor++
+	}
+	o.cursors[subscriber] = cursor
+	return nil
+}
+
+// Events returns every event recorded, in order.
+func (o *InMemoryOutbox) Events() []events.Event {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return slices.Clone(o.events)
+}
@@ -0,0 +1,43 @@
+package adapters
+
+import (
+	"eaglebank/internal/events"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryOutbox(t *testing.T) {
+	outbox := NewInMemoryOutbox()
+	var recorded []events.Event
+	for _, aggregateID := range []string{"usr-1", "usr-2", "usr-3"} {
+		e, err := events.NewEvent(events.UserCreated, aggregateID, nil, events.UserData{UserID: aggregateID})
+		require.NoError(t, err)
+		recorded = append(recorded, e)
+	}
+	outbox.Record(recorded...)
+
+	t.Run("should return every event to a new subscriber in order", func(t *testing.T) {
+		pending, err := outbox.Pending("sub")
+		require.NoError(t, err)
+		assert.Equal(t, recorded, pending)
+		assert.Equal(t, recorded, outbox.Events())
+	})
+	t.Run("should stop returning acknowledged events, in any order", func(t *testing.T) {
+		require.NoError(t, outbox.Ack("sub", recorded[1].ID))
+		pending, err := outbox.Pending("sub")
+		require.NoError(t, err)
+		assert.Equal(t, []events.Event{recorded[0], recorded[2]}, pending)
+
+		require.NoError(t, outbox.Ack("sub", recorded[0].ID))
+		pending, err = outbox.Pending("sub")
+		require.NoError(t, err)
+		assert.Equal(t, []events.Event{recorded[2]}, pending)
+	})
+	t.Run("should keep each subscriber's acknowledgements apart", func(t *testing.T) {
+		pending, err := outbox.Pending("other")
+		require.NoError(t, err)
+		assert.Equal(t, recorded, pending)
+	})
+}
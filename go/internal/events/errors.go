@@ -0,0 +1,6 @@
+package events
+
+import "errors"
+
+var ErrInvalidEvent = errors.New("invalid event")
+var ErrSubscriberExists = errors.New("subscriber already registered")
@@ -0,0 +1,187 @@
+package events
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Recorder is the write side of an outbox. Stores record the events describing a change while they
+// hold the lock for the change itself, in the same way a database-backed store would insert them in
+// the same transaction, so an event is published if and only if its change is stored. Recording
+// can't fail on its own for that reason.
+type Recorder interface {
+	Record(events ...Event)
+}
+
+// Outbox keeps every recorded event, in the order recorded, until each subscriber has acknowledged
+// it.
+type Outbox interface {
+	Recorder
+	// Pending returns the events the subscriber hasn't acknowledged, oldest first.
+	Pending(subscriber string) ([]Event, error)
+	// Ack records that the event has been delivered to the subscriber.
+	Ack(subscriber string, id EventID) error
+}
+
+// Handler reacts to an event. An error has the event delivered again later, so handlers must
+// cope with seeing the same event more than once.
+type Handler func(e Event) error
+
+const DefaultMinBackoff = time.Second
+const DefaultMaxBackoff = 5 * time.Minute
+
+type subscriber struct {
+	name    string
+	handler Handler
+	types   []EventType
+}
+
+func (s subscriber) wants(t EventType) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, t)
+}
+
+// retry is the state of an event a subscriber has failed to handle.
+type retry struct {
+	attempts int
+	next     time.Time
+}
+
+// Dispatcher delivers the events in an outbox to its subscribers, at least once each. A failed
+// delivery is retried with exponential backoff, and until it succeeds the subscriber is given no
+// later events for the same aggregate, so each subscriber sees an aggregate's events in order.
+// Other aggregates carry on regardless.
+type Dispatcher struct {
+	outbox     Outbox
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu          sync.Mutex
+	subscribers []subscriber
+	retries     map[string]map[EventID]retry
+}
+
+type DispatcherOption func(*Dispatcher)
+
+// WithBackoff changes how long a failed delivery waits before it is retried, from
+// DefaultMinBackoff doubling on each attempt up to DefaultMaxBackoff.
+func WithBackoff(minBackoff, maxBackoff time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.minBackoff, d.maxBackoff = minBackoff, maxBackoff
+	}
+}
+
+func NewDispatcher(outbox Outbox, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		outbox:     outbox,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		retries:    make(map[string]map[EventID]retry),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Subscribe registers a handler for the given event types, or for every event if none are given.
+// The name identifies the subscriber in the outbox, so a subscriber registered again under the
+// same name after a restart carries on from where it got to.
+func (d *Dispatcher) Subscribe(name string, handler Handler, types ...EventType) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if slices.ContainsFunc(d.subscribers, func(s subscriber) bool { return s.name == name }) {
+		return fmt.Errorf("%w: %q", ErrSubscriberExists, name)
+	}
+	for _, t := range types {
+		if !t.IsValid() {
+			return fmt.Errorf("%w: unknown type %q", ErrInvalidEvent, t)
+		}
+	}
+	d.subscribers = append(d.subscribers, subscriber{name: name, handler: handler, types: types})
+	d.retries[name] = make(map[EventID]retry)
+	return nil
+}
+
+// Failure is a delivery which failed during a Dispatch.
+type Failure struct {
+	Subscriber string
+	Event      Event
+	Attempts   int
+	NextRetry  time.Time
+	Err        error
+}
+
+type DispatchResult struct {
+	Delivered int
+	Failures  []Failure
+}
+
+// Dispatch makes one pass over the outbox, delivering each subscriber's pending events which are
+// not waiting to be retried after now. It is run repeatedly, a few times a second.
+func (d *Dispatcher) Dispatch(now time.Time) (DispatchResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var result DispatchResult
+	for _, sub := range d.subscribers {
+		pending, err := d.outbox.Pending(sub.name)
+		if err != nil {
+			return result, fmt.Errorf("error fetching pending events %w", err)
+		}
+		blocked := make(map[string]bool)
+		for _, e := range pending {
+			if blocked[e.AggregateID] {
+				continue
+			}
+			if sub.wants(e.Type) {
+				if r, ok := d.retries[sub.name][e.ID]; ok && now.Before(r.next) {
+					blocked[e.AggregateID] = true
+					continue
+				}
+				err = deliver(sub.handler, e)
+				if err != nil {
+					r := d.retries[sub.name][e.ID]
+					r.attempts++
+					r.next = now.Add(d.backoff(r.attempts))
+					d.retries[sub.name][e.ID] = r
+					result.Failures = append(result.Failures, Failure{Subscriber: sub.name, Event: e, Attempts: r.attempts, NextRetry: r.next, Err: err})
+					blocked[e.AggregateID] = true
+					continue
+				}
+				delete(d.retries[sub.name], e.ID)
+				result.Delivered++
+			}
+			err = d.outbox.Ack(sub.name, e.ID)
+			if err != nil {
+				return result, fmt.Errorf("error acknowledging event %w", err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// backoff is how long to wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.minBackoff
+	for range attempts - 1 {
+		wait *= 2
+		if wait >= d.maxBackoff {
+			return d.maxBackoff
+		}
+	}
+	return wait
+}
+
+// deliver calls the handler, turning a panic into an error so one bad handler can't stop the
+// others being dispatched to.
+func deliver(handler Handler, e Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic handling %s event: %v", e.Type, p)
+		}
+	}()
+	return handler(e)
+}
@@ -0,0 +1,131 @@
+package events_test
+
+import (
+	"eaglebank/internal/events"
+	"eaglebank/internal/events/adapters"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent(t *testing.T) {
+	t.Run("should carry its data as JSON", func(t *testing.T) {
+		e, err := events.NewEvent(events.UserCreated, "usr-123", []string{"usr-123"}, events.UserData{UserID: "usr-123", Tier: "standard"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"userId":"usr-123","role":"","tier":"standard","emailVerified":false}`, string(e.Data))
+
+		var data events.UserData
+		require.NoError(t, e.Decode(&data))
+		assert.Equal(t, "standard", data.Tier)
+	})
+	t.Run("should refuse an unknown type or a missing aggregate", func(t *testing.T) {
+		_, err := events.NewEvent("user.exploded", "usr-123", nil, events.UserData{})
+		assert.ErrorIs(t, err, events.ErrInvalidEvent)
+		_, err = events.NewEvent(events.UserCreated, "", nil, events.UserData{})
+		assert.ErrorIs(t, err, events.ErrInvalidEvent)
+	})
+}
+
+func TestDispatcher(t *testing.T) {
+	newEvent := func(t *testing.T, typ events.EventType, aggregateID string) events.Event {
+		t.Helper()
+		e, err := events.NewEvent(typ, aggregateID, nil, events.AccountData{AccountNumber: aggregateID})
+		require.NoError(t, err)
+		return e
+	}
+	now := time.Now()
+
+	t.Run("should deliver each subscriber the types it asked for once, in order", func(t *testing.T) {
+		outbox := adapters.NewInMemoryOutbox()
+		d := events.NewDispatcher(outbox)
+		var all, postings []events.EventID
+		require.NoError(t, d.Subscribe("all", func(e events.Event) error {
+			all = append(all, e.ID)
+			return nil
+		}))
+		require.NoError(t, d.Subscribe("postings", func(e events.Event) error {
+			postings = append(postings, e.ID)
+			return nil
+		}, events.TransactionPosted))
+		opened, posted := newEvent(t, events.AccountOpened, "01000001"), newEvent(t, events.TransactionPosted, "01000001")
+		outbox.Record(opened, posted)
+
+		result, err := d.Dispatch(now)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Delivered)
+		assert.Empty(t, result.Failures)
+		assert.Equal(t, []events.EventID{opened.ID, posted.ID}, all)
+		assert.Equal(t, []events.EventID{posted.ID}, postings)
+
+		result, err = d.Dispatch(now)
+		require.NoError(t, err)
+		assert.Zero(t, result.Delivered)
+	})
+	t.Run("should refuse a second subscriber with the same name or an unknown type", func(t *testing.T) {
+		d := events.NewDispatcher(adapters.NewInMemoryOutbox())
+		require.NoError(t, d.Subscribe("all", func(e events.Event) error { return nil }))
+		assert.ErrorIs(t, d.Subscribe("all", func(e events.Event) error { return nil }), events.ErrSubscriberExists)
+		assert.ErrorIs(t, d.Subscribe("other", func(e events.Event) error { return nil }, "user.exploded"), events.ErrInvalidEvent)
+	})
+	t.Run("should retry a failed delivery with backoff, holding back only the same aggregate", func(t *testing.T) {
+		outbox := adapters.NewInMemoryOutbox()
+		d := events.NewDispatcher(outbox, events.WithBackoff(time.Second, 3*time.Second))
+		first, second, other := newEvent(t, events.AccountOpened, "01000001"), newEvent(t, events.TransactionPosted, "01000001"), newEvent(t, events.AccountOpened, "01000002")
+		outbox.Record(first, second, other)
+		failing := true
+		var got []events.EventID
+		require.NoError(t, d.Subscribe("flaky", func(e events.Event) error {
+			if e.ID == first.ID && failing {
+				return errors.New("receiver down")
+			}
+			got = append(got, e.ID)
+			return nil
+		}))
+
+		result, err := d.Dispatch(now)
+		require.NoError(t, err)
+		require.Len(t, result.Failures, 1)
+		assert.Equal(t, first.ID, result.Failures[0].Event.ID)
+		assert.Equal(t, 1, result.Failures[0].Attempts)
+		assert.Equal(t, now.Add(time.Second), result.Failures[0].NextRetry)
+		assert.Equal(t, []events.EventID{other.ID}, got)
+
+		// Not yet due.
+		result, err = d.Dispatch(now.Add(500 * time.Millisecond))
+		require.NoError(t, err)
+		assert.Empty(t, result.Failures)
+		assert.Zero(t, result.Delivered)
+
+		result, err = d.Dispatch(now.Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, result.Failures, 1)
+		assert.Equal(t, 2, result.Failures[0].Attempts)
+		assert.Equal(t, now.Add(3*time.Second), result.Failures[0].NextRetry)
+
+		result, err = d.Dispatch(now.Add(3 * time.Second))
+		require.NoError(t, err)
+		require.Len(t, result.Failures, 1)
+		assert.Equal(t, now.Add(6*time.Second), result.Failures[0].NextRetry, "backoff is capped")
+
+		failing = false
+		result, err = d.Dispatch(now.Add(6 * time.Second))
+		require.NoError(t, err)
+		assert.Empty(t, result.Failures)
+		assert.Equal(t, 2, result.Delivered)
+		assert.Equal(t, []events.EventID{other.ID, first.ID, second.ID}, got)
+	})
+	t.Run("should treat a panicking handler as a failed delivery", func(t *testing.T) {
+		outbox := adapters.NewInMemoryOutbox()
+		d := events.NewDispatcher(outbox)
+		require.NoError(t, d.Subscribe("panicky", func(e events.Event) error { panic("boom") }))
+		outbox.Record(newEvent(t, events.AccountOpened, "01000001"))
+
+		result, err := d.Dispatch(now)
+		require.NoError(t, err)
+		require.Len(t, result.Failures, 1)
+		assert.ErrorContains(t, result.Failures[0].Err, "boom")
+	})
+}
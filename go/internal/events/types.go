@@ -0,0 +1,129 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventID string
+
+func (id EventID) String() string {
+	return string(id)
+}
+
+func NewRandEventID() EventID {
+	clean := strings.ReplaceAll(uuid.New().String(), "-", "")
+	return EventID("evt-" + clean)
+}
+
+// EventType says what happened, and so which of the Data types an event carries.
+type EventType string
+
+// UserCreated and UserUpdated carry UserData.
+const UserCreated EventType = "user.created"
+const UserUpdated EventType = "user.updated"
+
+// AccountOpened and AccountHoldersChanged carry AccountData.
+const AccountOpened EventType = "account.opened"
+const AccountHoldersChanged EventType = "account.holders_changed"
+
+// AccountStatusChanged carries AccountStatusData.
+const AccountStatusChanged EventType = "account.status_changed"
+
+// TransactionPosted carries TransactionData. Its aggregate is the account, so an account's
+// postings are delivered in order along with its other events.
+const TransactionPosted EventType = "transaction.posted"
+
+func EventTypes() []EventType {
+	return []EventType{UserCreated, UserUpdated, AccountOpened, AccountHoldersChanged, AccountStatusChanged, TransactionPosted}
+}
+
+func (t EventType) IsValid() bool {
+	return slices.Contains(EventTypes(), t)
+}
+
+func (t EventType) String() string {
+	return string(t)
+}
+
+// Event is a change to a user, account or transaction, published for other parts of the system to
+// react to. Events about the same aggregate, a user or an account, are delivered in the order they
+// happened.
+type Event struct {
+	ID          EventID
+	Type        EventType
+	AggregateID string
+	// UserIDs are the users the event concerns, such as an account's holders.
+	UserIDs           []string
+	Data              json.RawMessage
+	OccurredTimestamp time.Time
+}
+
+func (e Event) IsValid() bool {
+	return e.ID != "" && e.Type.IsValid() && e.AggregateID != "" && json.Valid(e.Data)
+}
+
+func NewEvent(typ EventType, aggregateID string, userIDs []string, data any) (Event, error) {
+	by, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("error encoding %s event data %w", typ, err)
+	}
+	e := Event{
+		ID:                NewRandEventID(),
+		Type:              typ,
+		AggregateID:       aggregateID,
+		UserIDs:           userIDs,
+		Data:              by,
+		OccurredTimestamp: time.Now(),
+	}
+	if !e.IsValid() {
+		return Event{}, fmt.Errorf("%w %+v", ErrInvalidEvent, e)
+	}
+	return e, nil
+}
+
+// Decode unmarshals the event's data into v, which should be the Data type for its EventType.
+func (e Event) Decode(v any) error {
+	err := json.Unmarshal(e.Data, v)
+	if err != nil {
+		return fmt.Errorf("error decoding %s event data %w", e.Type, err)
+	}
+	return nil
+}
+
+type UserData struct {
+	UserID        string `json:"userId"`
+	Role          string `json:"role"`
+	Tier          string `json:"tier"`
+	EmailVerified bool   `json:"emailVerified"`
+}
+
+type AccountData struct {
+	AccountNumber string   `json:"accountNumber"`
+	AccountType   string   `json:"accountType"`
+	Currency      string   `json:"currency"`
+	Status        string   `json:"status"`
+	Holders       []string `json:"holders"`
+}
+
+type AccountStatusData struct {
+	AccountNumber string `json:"accountNumber"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Reason        string `json:"reason"`
+}
+
+type TransactionData struct {
+	TransactionID string  `json:"transactionId"`
+	AccountNumber string  `json:"accountNumber"`
+	Type          string  `json:"type"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	Sequence      uint64  `json:"sequence"`
+	BalanceAfter  float64 `json:"balanceAfter"`
+}
@@ -0,0 +1,82 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/fx"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// rateFile is the JSON layout of the rates file, for example
+// {"base": "GBP", "rates": {"EUR": 1.17, "USD": 1.27}}.
+type rateFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// FileRateProvider reads mid-market rates from a local JSON file, reloading it whenever the file's
+// modification time changes.
+type FileRateProvider struct {
+	path    string
+	mu      sync.RWMutex
+	modTime time.Time
+	table   fx.RateTable
+}
+
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	p := &FileRateProvider{path: path}
+	err := p.reload()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *FileRateProvider) Rate(from, to accounts.Currency) (float64, error) {
+	err := p.reload()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", fx.ErrRateUnavailable, err)
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.table.Rate(from, to)
+}
+
+func (p *FileRateProvider) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("error reading rates file %w", err)
+	}
+	p.mu.RLock()
+	current := info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+	if current {
+		return nil
+	}
+
+	by, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("error reading rates file %w", err)
+	}
+	var file rateFile
+	err = json.Unmarshal(by, &file)
+	if err != nil {
+		return fmt.Errorf("error parsing rates file %w", err)
+	}
+	table := fx.RateTable{Base: accounts.Currency(file.Base), Rates: make(map[accounts.Currency]float64, len(file.Rates))}
+	for curr, rate := range file.Rates {
+		table.Rates[accounts.Currency(curr)] = rate
+	}
+	if !table.IsValid() {
+		return fmt.Errorf("invalid rates file %s", p.path)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.table = table
+	p.modTime = info.ModTime()
+	return nil
+}
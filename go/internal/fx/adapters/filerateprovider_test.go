@@ -0,0 +1,64 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/fx"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRateProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	writeRates := func(t *testing.T, contents string, modTime time.Time) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	start := time.Now().Add(-time.Hour)
+	writeRates(t, `{"base": "GBP", "rates": {"EUR": 1.25, "USD": 1.5}}`, start)
+
+	provider, err := NewFileRateProvider(path)
+	require.NoError(t, err)
+
+	t.Run("should return rates relative to the base currency", func(t *testing.T) {
+		rate, err := provider.Rate(accounts.GBP, accounts.EUR)
+		require.NoError(t, err)
+		assert.Equal(t, 1.25, rate)
+
+		rate, err = provider.Rate(accounts.EUR, accounts.GBP)
+		require.NoError(t, err)
+		assert.Equal(t, 0.8, rate)
+
+		rate, err = provider.Rate(accounts.EUR, accounts.USD)
+		require.NoError(t, err)
+		assert.Equal(t, 1.2, rate)
+	})
+	t.Run("should error for currency missing from the file", func(t *testing.T) {
+		_, err := provider.Rate(accounts.GBP, accounts.JPY)
+		assert.ErrorIs(t, err, fx.ErrRateUnavailable)
+	})
+	t.Run("should reload when the file changes", func(t *testing.T) {
+		writeRates(t, `{"base": "GBP", "rates": {"EUR": 1.1, "JPY": 190}}`, start.Add(time.Minute))
+
+		rate, err := provider.Rate(accounts.GBP, accounts.EUR)
+		require.NoError(t, err)
+		assert.Equal(t, 1.1, rate)
+
+		_, err = provider.Rate(accounts.GBP, accounts.JPY)
+		assert.NoError(t, err)
+	})
+	t.Run("should error for invalid file", func(t *testing.T) {
+		writeRates(t, `{"base": "GBP", "rates": {"EUR": -1}}`, start.Add(2*time.Minute))
+
+		_, err := provider.Rate(accounts.GBP, accounts.EUR)
+		assert.ErrorIs(t, err, fx.ErrRateUnavailable)
+
+		_, err = NewFileRateProvider(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+	})
+}
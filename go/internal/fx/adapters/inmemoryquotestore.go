@@ -0,0 +1,45 @@
+package adapters
+
+import (
+	"eaglebank/internal/fx"
+	"sync"
+)
+
+type InMemoryQuoteStore struct {
+	mu     sync.RWMutex
+	quotes map[fx.QuoteID]fx.Quote
+}
+
+func NewInMemoryQuoteStore() *InMemoryQuoteStore {
+	return &InMemoryQuoteStore{quotes: make(map[fx.QuoteID]fx.Quote)}
+}
+
+func (s *InMemoryQuoteStore) Get(id fx.QuoteID) (fx.Quote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quote, ok := s.quotes[id]
+	if !ok {
+		return fx.Quote{}, fx.ErrQuoteNotFound
+	}
+	return quote, nil
+}
+
+func (s *InMemoryQuoteStore) Put(quote fx.Quote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quotes[quote.ID] = quote
+	return nil
+}
+
+func (s *InMemoryQuoteStore) Delete(id fx.QuoteID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.quotes[id]; !ok {
+		return fx.ErrQuoteNotFound
+	}
+	delete(s.quotes, id)
+	return nil
+}
@@ -0,0 +1,46 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/fx"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryQuoteStore(t *testing.T) {
+	store := NewInMemoryQuoteStore()
+	id, err := fx.NewRandQuoteID()
+	require.NoError(t, err)
+	now := time.Now()
+	quote := fx.Quote{
+		ID:               id,
+		UserID:           "usr-123",
+		FromCurrency:     accounts.GBP,
+		FromAmount:       10,
+		ToCurrency:       accounts.EUR,
+		ToAmount:         12,
+		Rate:             1.2,
+		CreatedTimestamp: now,
+		ExpiresTimestamp: now.Add(time.Minute),
+	}
+
+	t.Run("should error getting quote which does not exist", func(t *testing.T) {
+		_, err := store.Get(id)
+		assert.ErrorIs(t, err, fx.ErrQuoteNotFound)
+	})
+	t.Run("should get quote after put", func(t *testing.T) {
+		require.NoError(t, store.Put(quote))
+		got, err := store.Get(id)
+		require.NoError(t, err)
+		assert.Equal(t, quote, got)
+	})
+	t.Run("should delete quote", func(t *testing.T) {
+		require.NoError(t, store.Delete(id))
+		_, err := store.Get(id)
+		assert.ErrorIs(t, err, fx.ErrQuoteNotFound)
+		assert.ErrorIs(t, store.Delete(id), fx.ErrQuoteNotFound)
+	})
+}
@@ -0,0 +1,7 @@
+package fx
+
+import "errors"
+
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+var ErrQuoteNotFound = errors.New("quote not found")
+var ErrQuoteExpired = errors.New("quote has expired")
@@ -0,0 +1,133 @@
+package fx
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/transactions"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateProvider supplies mid-market rates as units of to per one unit of from.
+type RateProvider interface {
+	Rate(from, to accounts.Currency) (float64, error)
+}
+
+type QuoteStore interface {
+	Get(id QuoteID) (Quote, error)
+	Put(quote Quote) error
+	Delete(id QuoteID) error
+}
+
+type ledger interface {
+	CreateConversion(req transactions.CreateConversionRequest) (transactions.Transaction, transactions.Transaction, error)
+}
+
+// Converter prices conversions at the provider's mid-market rate less the spread. It is also used
+// directly as the transactions.Converter for deposits and withdrawals in another currency.
+type Converter struct {
+	rates  RateProvider
+	spread float64
+}
+
+func NewConverter(rates RateProvider, spread float64) *Converter {
+	return &Converter{rates: rates, spread: spread}
+}
+
+func (c *Converter) Convert(amt float64, from, to accounts.Currency) (converted, rate, spread float64, err error) {
+	mid, err := c.rates.Rate(from, to)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %w", transactions.ErrConversionUnavailable, err)
+	}
+	rate = mid * (1 - c.spread)
+	return to.Round(amt * rate), rate, c.spread, nil
+}
+
+type FXService struct {
+	converter *Converter
+	quotes    QuoteStore
+	ledger    ledger
+	quoteTTL  time.Duration
+	// mu stops a quote being executed twice by concurrent requests.
+	mu sync.Mutex
+}
+
+func NewFXService(converter *Converter, quoteStore QuoteStore, ledger ledger, quoteTTL time.Duration) *FXService {
+	return &FXService{converter: converter, quotes: quoteStore, ledger: ledger, quoteTTL: quoteTTL}
+}
+
+func (svc *FXService) CreateQuote(req CreateQuoteRequest) (Quote, error) {
+	if !req.IsValid() {
+		return Quote{}, fmt.Errorf("invalid create quote request %+v", req)
+	}
+	toAmt, rate, spread, err := svc.converter.Convert(req.FromAmount, req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		return Quote{}, err
+	}
+	id, err := NewRandQuoteID()
+	if err != nil {
+		return Quote{}, fmt.Errorf("error generating quote ID %w", err)
+	}
+	now := time.Now()
+	quote := Quote{
+		ID:               id,
+		UserID:           req.UserID,
+		FromCurrency:     req.FromCurrency,
+		FromAmount:       req.FromAmount,
+		ToCurrency:       req.ToCurrency,
+		ToAmount:         toAmt,
+		Rate:             rate,
+		Spread:           spread,
+		CreatedTimestamp: now,
+		ExpiresTimestamp: now.Add(svc.quoteTTL),
+	}
+	err = svc.quotes.Put(quote)
+	if err != nil {
+		return Quote{}, fmt.Errorf("error saving quote %w", err)
+	}
+	return quote, nil
+}
+
+// ExecuteQuote performs the conversion at the quoted rate. A quote can only be executed once, by
+// the user it was issued to, before it expires.
+func (svc *FXService) ExecuteQuote(req ExecuteQuoteRequest) (transactions.Transaction, transactions.Transaction, error) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	quote, err := svc.quotes.Get(req.QuoteID)
+	if err != nil {
+		if errors.Is(err, ErrQuoteNotFound) {
+			return transactions.Transaction{}, transactions.Transaction{}, err
+		}
+		return transactions.Transaction{}, transactions.Transaction{}, fmt.Errorf("error fetching quote %w", err)
+	}
+	if quote.UserID != req.UserID {
+		return transactions.Transaction{}, transactions.Transaction{}, ErrQuoteNotFound
+	}
+	if quote.IsExpired(time.Now()) {
+		return transactions.Transaction{}, transactions.Transaction{}, ErrQuoteExpired
+	}
+
+	debit, credit, err := svc.ledger.CreateConversion(transactions.CreateConversionRequest{
+		UserID:       req.UserID,
+		FromAccount:  req.FromAccount,
+		ToAccount:    req.ToAccount,
+		FromAmount:   quote.FromAmount,
+		FromCurrency: quote.FromCurrency,
+		ToAmount:     quote.ToAmount,
+		ToCurrency:   quote.ToCurrency,
+		Rate:         quote.Rate,
+		Spread:       quote.Spread,
+		Reference:    req.Reference,
+	})
+	if err != nil {
+		return transactions.Transaction{}, transactions.Transaction{}, err
+	}
+
+	err = svc.quotes.Delete(quote.ID)
+	if err != nil {
+		return transactions.Transaction{}, transactions.Transaction{}, fmt.Errorf("error closing quote %w", err)
+	}
+	return debit, credit, nil
+}
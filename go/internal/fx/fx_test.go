@@ -0,0 +1,119 @@
+package fx_test
+
+import (
+	"eaglebank/internal/accounts"
+	adapters2 "eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/fx"
+	"eaglebank/internal/fx/adapters"
+	"eaglebank/internal/transactions"
+	adapters3 "eaglebank/internal/transactions/adapters"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRates = fx.RateTable{Base: accounts.GBP, Rates: map[accounts.Currency]float64{accounts.EUR: 1.25}}
+
+func TestFXService(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	gbpAcct := mustPutAccount(t, acctStore, "01000004", accounts.GBP, 100)
+	eurAcct := mustPutAccount(t, acctStore, "01000012", accounts.EUR, 0)
+	tanSvc := transactions.NewTransactionService(adapters3.NewInMemoryTransactionStore(), acctStore)
+	converter := fx.NewConverter(testRates, 0.02)
+	svc := fx.NewFXService(converter, adapters.NewInMemoryQuoteStore(), tanSvc, time.Minute)
+
+	newQuote := func(t *testing.T, svc *fx.FXService, amt float64) fx.Quote {
+		t.Helper()
+		req, err := fx.NewCreateQuoteRequest("usr-123", accounts.GBP, accounts.EUR, amt)
+		require.NoError(t, err)
+		quote, err := svc.CreateQuote(req)
+		require.NoError(t, err)
+		return quote
+	}
+	execute := func(quote fx.Quote) fx.ExecuteQuoteRequest {
+		return fx.ExecuteQuoteRequest{
+			QuoteID:     quote.ID,
+			UserID:      "usr-123",
+			FromAccount: gbpAcct.AccountNumber,
+			ToAccount:   eurAcct.AccountNumber,
+		}
+	}
+
+	t.Run("create quote", func(t *testing.T) {
+		t.Run("should take the spread off the mid-market rate", func(t *testing.T) {
+			quote := newQuote(t, svc, 100)
+			assert.InDelta(t, 1.225, quote.Rate, 1e-9)
+			assert.Equal(t, 0.02, quote.Spread)
+			assert.Equal(t, 122.5, quote.ToAmount)
+			assert.Equal(t, time.Minute, quote.ExpiresTimestamp.Sub(quote.CreatedTimestamp))
+		})
+		t.Run("should reject converting to the same currency", func(t *testing.T) {
+			_, err := fx.NewCreateQuoteRequest("usr-123", accounts.GBP, accounts.GBP, 10)
+			assert.Error(t, err)
+		})
+		t.Run("should error when there is no rate", func(t *testing.T) {
+			req, err := fx.NewCreateQuoteRequest("usr-123", accounts.GBP, accounts.USD, 10)
+			require.NoError(t, err)
+			_, err = svc.CreateQuote(req)
+			assert.ErrorIs(t, err, fx.ErrRateUnavailable)
+			assert.ErrorIs(t, err, transactions.ErrConversionUnavailable)
+		})
+	})
+	t.Run("execute quote", func(t *testing.T) {
+		t.Run("should not let another user execute the quote", func(t *testing.T) {
+			req := execute(newQuote(t, svc, 10))
+			req.UserID = "usr-456"
+			_, _, err := svc.ExecuteQuote(req)
+			assert.ErrorIs(t, err, fx.ErrQuoteNotFound)
+		})
+		t.Run("should keep the quote if the conversion fails", func(t *testing.T) {
+			quote := newQuote(t, svc, 40)
+			req := execute(quote)
+			req.ToAccount = "01999990"
+			_, _, err := svc.ExecuteQuote(req)
+			assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+
+			_, _, err = svc.ExecuteQuote(execute(quote))
+			require.NoError(t, err)
+		})
+		t.Run("should convert at the quoted rate", func(t *testing.T) {
+			quote := newQuote(t, svc, 50)
+			debit, credit, err := svc.ExecuteQuote(execute(quote))
+			require.NoError(t, err)
+
+			assert.Equal(t, 50.0, debit.Amount)
+			assert.Equal(t, quote.Rate, debit.Conversion.Rate)
+			assert.Equal(t, quote.Spread, debit.Conversion.Spread)
+			assert.Equal(t, quote.ToAmount, debit.Conversion.Amount)
+			assert.Equal(t, quote.ToAmount, credit.Amount)
+			assert.Equal(t, quote.FromAmount, credit.Conversion.Amount)
+
+			gotGBP, err := acctStore.GetByAcctNum(gbpAcct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, 10.0, gotGBP.Balance())
+			gotEUR, err := acctStore.GetByAcctNum(eurAcct.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, 49.0+61.25, gotEUR.Balance())
+
+			_, _, err = svc.ExecuteQuote(execute(quote))
+			assert.ErrorIs(t, err, fx.ErrQuoteNotFound)
+		})
+		t.Run("should reject an expired quote", func(t *testing.T) {
+			expiringSvc := fx.NewFXService(converter, adapters.NewInMemoryQuoteStore(), tanSvc, 0)
+			_, _, err := expiringSvc.ExecuteQuote(execute(newQuote(t, expiringSvc, 1)))
+			assert.ErrorIs(t, err, fx.ErrQuoteExpired)
+		})
+	})
+}
+
+func mustPutAccount(t *testing.T, store *adapters2.InMemoryAccountStore, acctNum accounts.AccountNumber, curr accounts.Currency, balance float64) accounts.BankAccount {
+	t.Helper()
+	acct, err := accounts.NewBankAccount("usr-123", acctNum, "10-10-10", "Mr Foo", accounts.PersonalAcct, curr)
+	require.NoError(t, err)
+	acct, err = acct.Deposit(balance)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(acct))
+	return acct
+}
@@ -0,0 +1,139 @@
+package fx
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateTable holds mid-market rates as units of each currency per one unit of Base.
+type RateTable struct {
+	Base  accounts.Currency
+	Rates map[accounts.Currency]float64
+}
+
+func (t RateTable) IsValid() bool {
+	if !t.Base.IsValid() {
+		return false
+	}
+	for curr, rate := range t.Rates {
+		if !curr.IsValid() || rate <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Rate is the mid-market rate as units of to per one unit of from.
+func (t RateTable) Rate(from, to accounts.Currency) (float64, error) {
+	fromRate, err := t.baseRate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := t.baseRate(to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+func (t RateTable) baseRate(curr accounts.Currency) (float64, error) {
+	if curr == t.Base {
+		return 1, nil
+	}
+	rate, ok := t.Rates[curr]
+	if !ok {
+		return 0, fmt.Errorf("no rate for %s %w", curr, ErrRateUnavailable)
+	}
+	return rate, nil
+}
+
+type QuoteID string
+
+var quoteIDRegex = regexp.MustCompile(`^fxq-[A-Za-z0-9]+$`)
+
+func (id QuoteID) IsValid() bool {
+	return quoteIDRegex.MatchString(id.String())
+}
+
+func (id QuoteID) String() string {
+	return string(id)
+}
+
+func NewQuoteID(s string) (QuoteID, error) {
+	id := QuoteID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid quote ID %q: must match format fxq-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandQuoteID() (QuoteID, error) {
+	return NewQuoteID("fxq-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+// Quote locks a rate for one user's conversion until ExpiresTimestamp. Rate already has Spread
+// taken off the mid-market rate.
+type Quote struct {
+	ID               QuoteID
+	UserID           users.UserID
+	FromCurrency     accounts.Currency
+	FromAmount       float64
+	ToCurrency       accounts.Currency
+	ToAmount         float64
+	Rate             float64
+	Spread           float64
+	CreatedTimestamp time.Time
+	ExpiresTimestamp time.Time
+}
+
+func (q Quote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresTimestamp)
+}
+
+type CreateQuoteRequest struct {
+	UserID       users.UserID
+	FromCurrency accounts.Currency
+	ToCurrency   accounts.Currency
+	FromAmount   float64
+}
+
+func (r CreateQuoteRequest) IsValid() bool {
+	if !r.UserID.IsValid() {
+		return false
+	}
+	if !r.FromCurrency.IsValid() || !r.ToCurrency.IsValid() || r.FromCurrency == r.ToCurrency {
+		return false
+	}
+	if r.FromAmount <= 0 || !r.FromCurrency.IsValidAmount(r.FromAmount) {
+		return false
+	}
+	return true
+}
+
+func NewCreateQuoteRequest(userID users.UserID, from, to accounts.Currency, amt float64) (CreateQuoteRequest, error) {
+	req := CreateQuoteRequest{
+		UserID:       userID,
+		FromCurrency: from,
+		ToCurrency:   to,
+		FromAmount:   amt,
+	}
+	if !req.IsValid() {
+		return CreateQuoteRequest{}, fmt.Errorf("invalid create quote request %+v", req)
+	}
+	return req, nil
+}
+
+// ExecuteQuoteRequest converts a quote's FromAmount out of FromAccount into ToAccount.
+type ExecuteQuoteRequest struct {
+	QuoteID     QuoteID
+	UserID      users.UserID
+	FromAccount accounts.AccountNumber
+	ToAccount   accounts.AccountNumber
+	Reference   string
+}
@@ -0,0 +1,32 @@
+package adapters
+
+import (
+	"eaglebank/internal/grants"
+	"sync"
+)
+
+type InMemoryAccessLog struct {
+	mu       sync.RWMutex
+	accesses map[grants.GrantID][]grants.Access
+}
+
+func NewInMemoryAccessLog() *InMemoryAccessLog {
+	return &InMemoryAccessLog{accesses: make(map[grants.GrantID][]grants.Access)}
+}
+
+func (l *InMemoryAccessLog) Append(access grants.Access) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.accesses[access.GrantID] = append(l.accesses[access.GrantID], access)
+	return nil
+}
+
+func (l *InMemoryAccessLog) GetByGrantID(id grants.GrantID) ([]grants.Access, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]grants.Access, len(l.accesses[id]))
+	copy(result, l.accesses[id])
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package adapters
+
+import (
+	"eaglebank/internal/grants"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryAccessLog(t *testing.T) {
+	log := NewInMemoryAccessLog()
+
+	t.Run("should return no accesses for unused grant", func(t *testing.T) {
+		got, err := log.GetByGrantID("gnt-unused")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+	t.Run("should return accesses in order for a grant", func(t *testing.T) {
+		first := grants.Access{GrantID: "gnt-1", GranteeID: "usr-accountant", Scope: grants.BalanceScope, Timestamp: time.Now()}
+		second := grants.Access{GrantID: "gnt-1", GranteeID: "usr-accountant", Scope: grants.TransactionsScope, Timestamp: time.Now()}
+		other := grants.Access{GrantID: "gnt-2", GranteeID: "usr-accountant", Scope: grants.BalanceScope, Timestamp: time.Now()}
+		require.NoError(t, log.Append(first))
+		require.NoError(t, log.Append(other))
+		require.NoError(t, log.Append(second))
+
+		got, err := log.GetByGrantID("gnt-1")
+		require.NoError(t, err)
+		assert.Equal(t, []grants.Access{first, second}, got)
+	})
+}
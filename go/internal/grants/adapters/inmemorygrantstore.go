@@ -0,0 +1,61 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/grants"
+	"eaglebank/internal/users"
+	"sync"
+)
+
+type InMemoryGrantStore struct {
+	mu     sync.RWMutex
+	grants map[grants.GrantID]grants.Grant
+	order  []grants.GrantID
+}
+
+func NewInMemoryGrantStore() *InMemoryGrantStore {
+	return &InMemoryGrantStore{grants: make(map[grants.GrantID]grants.Grant)}
+}
+
+func (s *InMemoryGrantStore) Get(id grants.GrantID) (grants.Grant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	grant, ok := s.grants[id]
+	if !ok {
+		return grants.Grant{}, grants.ErrGrantNotFound
+	}
+	return grant, nil
+}
+
+func (s *InMemoryGrantStore) GetByAcctNum(acctNum accounts.AccountNumber) ([]grants.Grant, error) {
+	return s.filter(func(g grants.Grant) bool { return g.AccountNumber == acctNum }), nil
+}
+
+func (s *InMemoryGrantStore) GetByGranteeID(userID users.UserID) ([]grants.Grant, error) {
+	return s.filter(func(g grants.Grant) bool { return g.GranteeID == userID }), nil
+}
+
+func (s *InMemoryGrantStore) Put(grant grants.Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.grants[grant.ID]; !ok {
+		s.order = append(s.order, grant.ID)
+	}
+	s.grants[grant.ID] = grant
+	return nil
+}
+
+func (s *InMemoryGrantStore) filter(keep func(grants.Grant) bool) []grants.Grant {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []grants.Grant
+	for _, id := range s.order {
+		if grant := s.grants[id]; keep(grant) {
+			result = append(result, grant)
+		}
+	}
+	return result
+}
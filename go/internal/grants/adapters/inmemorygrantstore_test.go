@@ -0,0 +1,51 @@
+package adapters
+
+import (
+	"eaglebank/internal/grants"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryGrantStore(t *testing.T) {
+	store := NewInMemoryGrantStore()
+
+	t.Run("should error not found getting grant which does not exist", func(t *testing.T) {
+		_, err := store.Get("gnt-missing")
+		assert.ErrorIs(t, err, grants.ErrGrantNotFound)
+	})
+	t.Run("should perform put-get-update cycle without errors", func(t *testing.T) {
+		grant, err := grants.NewGrant("gnt-1", "01000004", "usr-owner", "usr-accountant", []grants.Scope{grants.BalanceScope}, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, store.Put(grant))
+
+		t.Run("should get an existing grant", func(t *testing.T) {
+			got, err := store.Get(grant.ID)
+			require.NoError(t, err)
+			assert.Equal(t, grant, got)
+		})
+		t.Run("should get grants by account number and grantee", func(t *testing.T) {
+			got, err := store.GetByAcctNum("01000004")
+			require.NoError(t, err)
+			assert.Equal(t, []grants.Grant{grant}, got)
+
+			got, err = store.GetByGranteeID("usr-accountant")
+			require.NoError(t, err)
+			assert.Equal(t, []grants.Grant{grant}, got)
+
+			got, err = store.GetByGranteeID("usr-owner")
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+		t.Run("should update existing grant", func(t *testing.T) {
+			grant.RevokedTimestamp = time.Now()
+			require.NoError(t, store.Put(grant))
+
+			got, err := store.GetByAcctNum("01000004")
+			require.NoError(t, err)
+			assert.Equal(t, []grants.Grant{grant}, got)
+		})
+	})
+}
@@ -0,0 +1,7 @@
+package grants
+
+import "errors"
+
+var ErrGrantNotFound = errors.New("grant not found")
+var ErrNotGrantor = errors.New("only the user who created a grant can manage it")
+var ErrInvalidGrant = errors.New("invalid grant")
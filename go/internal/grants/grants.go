@@ -0,0 +1,156 @@
+package grants
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type GrantStore interface {
+	Get(id GrantID) (Grant, error)
+	GetByAcctNum(acctNum accounts.AccountNumber) ([]Grant, error)
+	GetByGranteeID(userID users.UserID) ([]Grant, error)
+	Put(grant Grant) error
+}
+
+type AccessLog interface {
+	Append(access Access) error
+	GetByGrantID(id GrantID) ([]Access, error)
+}
+
+type accountStore interface {
+	GetByAcctNum(acctNum accounts.AccountNumber) (accounts.BankAccount, error)
+}
+
+type userStore interface {
+	Get(id users.UserID) (users.User, error)
+}
+
+type GrantService struct {
+	grantStore   GrantStore
+	accessLog    AccessLog
+	accountStore accountStore
+	userStore    userStore
+}
+
+func NewGrantService(grantStore GrantStore, accessLog AccessLog, acctStore accountStore, usrStore userStore) *GrantService {
+	return &GrantService{grantStore: grantStore, accessLog: accessLog, accountStore: acctStore, userStore: usrStore}
+}
+
+func (svc *GrantService) CreateGrant(req CreateGrantRequest, conds ...accounts.Precondition) (Grant, error) {
+	acct, err := svc.accountStore.GetByAcctNum(req.AccountNumber)
+	if err != nil {
+		if errors.Is(err, accounts.ErrAccountNotFound) {
+			return Grant{}, err
+		}
+		return Grant{}, fmt.Errorf("error fetching bank account %w", err)
+	}
+	if !acct.IsHolder(req.GrantorID) {
+		return Grant{}, accounts.ErrNotHolder
+	}
+	err = accounts.CheckPreconditions(acct, conds...)
+	if err != nil {
+		return Grant{}, err
+	}
+	_, err = svc.userStore.Get(req.GranteeID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			return Grant{}, err
+		}
+		return Grant{}, fmt.Errorf("error fetching user %w", err)
+	}
+	id, err := NewRandGrantID()
+	if err != nil {
+		return Grant{}, err
+	}
+	grant, err := NewGrant(id, req.AccountNumber, req.GrantorID, req.GranteeID, req.Scopes, req.Expires)
+	if err != nil {
+		return Grant{}, fmt.Errorf("%w: %w", ErrInvalidGrant, err)
+	}
+	err = svc.grantStore.Put(grant)
+	if err != nil {
+		return Grant{}, fmt.Errorf("error creating grant %w", err)
+	}
+	return grant, nil
+}
+
+func (svc *GrantService) ListAccountGrants(acctNum accounts.AccountNumber) ([]Grant, error) {
+	grants, err := svc.grantStore.GetByAcctNum(acctNum)
+	if err != nil {
+		return nil, fmt.Errorf("error listing grants %w", err)
+	}
+	return grants, nil
+}
+
+func (svc *GrantService) ListReceivedGrants(userID users.UserID) ([]Grant, error) {
+	grants, err := svc.grantStore.GetByGranteeID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing grants %w", err)
+	}
+	return grants, nil
+}
+
+func (svc *GrantService) RevokeGrant(id GrantID, userID users.UserID) (Grant, error) {
+	grant, err := svc.fetchOwnGrant(id, userID)
+	if err != nil {
+		return Grant{}, err
+	}
+	if grant.IsRevoked() {
+		return grant, nil
+	}
+	grant.RevokedTimestamp = time.Now()
+	err = svc.grantStore.Put(grant)
+	if err != nil {
+		return Grant{}, fmt.Errorf("error revoking grant %w", err)
+	}
+	return grant, nil
+}
+
+func (svc *GrantService) ListAccesses(id GrantID, userID users.UserID) ([]Access, error) {
+	_, err := svc.fetchOwnGrant(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	accesses, err := svc.accessLog.GetByGrantID(id)
+	if err != nil {
+		return nil, fmt.Errorf("error listing grant accesses %w", err)
+	}
+	return accesses, nil
+}
+
+// UseGrant finds an active grant giving the user the scope on the account and records the access
+// against it. ErrGrantNotFound means the user has no such grant.
+func (svc *GrantService) UseGrant(userID users.UserID, acctNum accounts.AccountNumber, scope Scope) (Grant, error) {
+	grants, err := svc.grantStore.GetByGranteeID(userID)
+	if err != nil {
+		return Grant{}, fmt.Errorf("error listing grants %w", err)
+	}
+	now := time.Now()
+	for _, grant := range grants {
+		if grant.AccountNumber != acctNum || !grant.Allows(scope, now) {
+			continue
+		}
+		err = svc.accessLog.Append(Access{GrantID: grant.ID, GranteeID: userID, Scope: scope, Timestamp: now})
+		if err != nil {
+			return Grant{}, fmt.Errorf("error logging grant access %w", err)
+		}
+		return grant, nil
+	}
+	return Grant{}, ErrGrantNotFound
+}
+
+func (svc *GrantService) fetchOwnGrant(id GrantID, userID users.UserID) (Grant, error) {
+	grant, err := svc.grantStore.Get(id)
+	if err != nil {
+		if errors.Is(err, ErrGrantNotFound) {
+			return Grant{}, err
+		}
+		return Grant{}, fmt.Errorf("error fetching grant %w", err)
+	}
+	if grant.GrantorID != userID {
+		return Grant{}, ErrNotGrantor
+	}
+	return grant, nil
+}
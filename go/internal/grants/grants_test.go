@@ -0,0 +1,138 @@
+package grants_test
+
+import (
+	"eaglebank/internal/accounts"
+	adapters2 "eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/grants"
+	"eaglebank/internal/grants/adapters"
+	"eaglebank/internal/users"
+	adapters3 "eaglebank/internal/users/adapters"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrantService(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	usrStore := adapters3.NewInMemoryUserStore()
+	for _, id := range []users.UserID{"usr-owner", "usr-accountant"} {
+		require.NoError(t, usrStore.Put(users.MustNewUser(
+			id,
+			"Mr Foo",
+			users.MustNewAddress("line1", "town", "county", "postcode"),
+			users.MustNewPhoneNumber("+440000000000"),
+			users.MustNewEmail("foo@bar.com"),
+		)))
+	}
+	acct, err := accounts.NewBankAccount("usr-owner", "01000004", "10-10-10", "Mr Foo", accounts.PersonalAcct, accounts.GBP)
+	require.NoError(t, err)
+	require.NoError(t, acctStore.Put(acct))
+	accessLog := adapters.NewInMemoryAccessLog()
+	svc := grants.NewGrantService(adapters.NewInMemoryGrantStore(), accessLog, acctStore, usrStore)
+
+	newReq := func() grants.CreateGrantRequest {
+		return grants.CreateGrantRequest{
+			AccountNumber: acct.AccountNumber,
+			GrantorID:     "usr-owner",
+			GranteeID:     "usr-accountant",
+			Scopes:        []grants.Scope{grants.BalanceScope},
+			Expires:       time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("create grant", func(t *testing.T) {
+		t.Run("should fail if grantor does not hold account", func(t *testing.T) {
+			req := newReq()
+			req.GrantorID = "usr-accountant"
+			req.GranteeID = "usr-owner"
+			_, err := svc.CreateGrant(req)
+			assert.ErrorIs(t, err, accounts.ErrNotHolder)
+		})
+		t.Run("should fail for unknown grantee", func(t *testing.T) {
+			req := newReq()
+			req.GranteeID = "usr-missing"
+			_, err := svc.CreateGrant(req)
+			assert.ErrorIs(t, err, users.ErrUserNotFound)
+		})
+		t.Run("should fail for expiry in the past", func(t *testing.T) {
+			req := newReq()
+			req.Expires = time.Now().Add(-time.Hour)
+			_, err := svc.CreateGrant(req)
+			assert.ErrorIs(t, err, grants.ErrInvalidGrant)
+		})
+		t.Run("should fail without scopes", func(t *testing.T) {
+			req := newReq()
+			req.Scopes = nil
+			_, err := svc.CreateGrant(req)
+			assert.ErrorIs(t, err, grants.ErrInvalidGrant)
+		})
+	})
+	t.Run("use grant", func(t *testing.T) {
+		grant, err := svc.CreateGrant(newReq())
+		require.NoError(t, err)
+		t.Run("should allow granted scope and log the access", func(t *testing.T) {
+			got, err := svc.UseGrant("usr-accountant", acct.AccountNumber, grants.BalanceScope)
+			require.NoError(t, err)
+			assert.Equal(t, grant.ID, got.ID)
+
+			accesses, err := svc.ListAccesses(grant.ID, "usr-owner")
+			require.NoError(t, err)
+			require.Len(t, accesses, 1)
+			assert.Equal(t, grants.BalanceScope, accesses[0].Scope)
+			assert.Equal(t, users.UserID("usr-accountant"), accesses[0].GranteeID)
+		})
+		t.Run("should not allow other scopes", func(t *testing.T) {
+			_, err := svc.UseGrant("usr-accountant", acct.AccountNumber, grants.TransactionsScope)
+			assert.ErrorIs(t, err, grants.ErrGrantNotFound)
+		})
+		t.Run("should only let the grantor see accesses or revoke", func(t *testing.T) {
+			_, err := svc.ListAccesses(grant.ID, "usr-accountant")
+			assert.ErrorIs(t, err, grants.ErrNotGrantor)
+			_, err = svc.RevokeGrant(grant.ID, "usr-accountant")
+			assert.ErrorIs(t, err, grants.ErrNotGrantor)
+		})
+		t.Run("should not allow revoked grant", func(t *testing.T) {
+			revoked, err := svc.RevokeGrant(grant.ID, "usr-owner")
+			require.NoError(t, err)
+			assert.True(t, revoked.IsRevoked())
+
+			_, err = svc.UseGrant("usr-accountant", acct.AccountNumber, grants.BalanceScope)
+			assert.ErrorIs(t, err, grants.ErrGrantNotFound)
+		})
+		t.Run("should error if access cannot be logged", func(t *testing.T) {
+			grantStore := adapters.NewInMemoryGrantStore()
+			failSvc := grants.NewGrantService(grantStore, failingAccessLog{}, acctStore, usrStore)
+			_, err := failSvc.CreateGrant(newReq())
+			require.NoError(t, err)
+
+			_, err = failSvc.UseGrant("usr-accountant", acct.AccountNumber, grants.BalanceScope)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, grants.ErrGrantNotFound)
+		})
+	})
+	t.Run("should not allow expired grant", func(t *testing.T) {
+		grant := grants.Grant{
+			ID:               "gnt-expired",
+			AccountNumber:    acct.AccountNumber,
+			GrantorID:        "usr-owner",
+			GranteeID:        "usr-accountant",
+			Scopes:           []grants.Scope{grants.StatementsScope},
+			Expires:          time.Now().Add(-time.Minute),
+			CreatedTimestamp: time.Now().Add(-time.Hour),
+		}
+		assert.False(t, grant.Allows(grants.StatementsScope, time.Now()))
+	})
+}
+
+type failingAccessLog struct{}
+
+func (f failingAccessLog) Append(access grants.Access) error {
+	return errors.New("some error")
+}
+
+func (f failingAccessLog) GetByGrantID(id grants.GrantID) ([]grants.Access, error) {
+	return nil, errors.New("some error")
+}
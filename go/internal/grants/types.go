@@ -0,0 +1,139 @@
+package grants
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Scope string
+
+const BalanceScope Scope = "balance"
+const TransactionsScope Scope = "transactions"
+const StatementsScope Scope = "statements"
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case BalanceScope, TransactionsScope, StatementsScope:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s Scope) String() string {
+	return string(s)
+}
+
+func NewScope(s string) (Scope, error) {
+	scope := Scope(s)
+	if !scope.IsValid() {
+		return "", fmt.Errorf("invalid grant scope %q", s)
+	}
+	return scope, nil
+}
+
+type GrantID string
+
+var grantIDRegex = regexp.MustCompile(`^gnt-[A-Za-z0-9]+$`)
+
+func (id GrantID) IsValid() bool {
+	return grantIDRegex.MatchString(id.String())
+}
+
+func (id GrantID) String() string {
+	return string(id)
+}
+
+func NewGrantID(s string) (GrantID, error) {
+	id := GrantID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid grant ID %q: must match format gnt-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandGrantID() (GrantID, error) {
+	return NewGrantID("gnt-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+// Grant gives the grantee read-only access to one account within the listed scopes until it
+// expires or the grantor revokes it.
+type Grant struct {
+	ID               GrantID
+	AccountNumber    accounts.AccountNumber
+	GrantorID        users.UserID
+	GranteeID        users.UserID
+	Scopes           []Scope
+	Expires          time.Time
+	CreatedTimestamp time.Time
+	RevokedTimestamp time.Time
+}
+
+func (g Grant) IsValid() bool {
+	if !g.ID.IsValid() || !g.AccountNumber.IsValid() {
+		return false
+	}
+	if !g.GrantorID.IsValid() || !g.GranteeID.IsValid() || g.GrantorID == g.GranteeID {
+		return false
+	}
+	if len(g.Scopes) == 0 {
+		return false
+	}
+	for _, s := range g.Scopes {
+		if !s.IsValid() {
+			return false
+		}
+	}
+	return g.Expires.After(g.CreatedTimestamp)
+}
+
+func (g Grant) IsRevoked() bool {
+	return !g.RevokedTimestamp.IsZero()
+}
+
+func (g Grant) IsActive(now time.Time) bool {
+	return !g.IsRevoked() && now.Before(g.Expires)
+}
+
+func (g Grant) Allows(scope Scope, now time.Time) bool {
+	return g.IsActive(now) && slices.Contains(g.Scopes, scope)
+}
+
+func NewGrant(id GrantID, acctNum accounts.AccountNumber, grantorID, granteeID users.UserID, scopes []Scope, expires time.Time) (Grant, error) {
+	grant := Grant{
+		ID:               id,
+		AccountNumber:    acctNum,
+		GrantorID:        grantorID,
+		GranteeID:        granteeID,
+		Scopes:           scopes,
+		Expires:          expires,
+		CreatedTimestamp: time.Now(),
+	}
+	if !grant.IsValid() {
+		return Grant{}, fmt.Errorf("invalid grant %+v", grant)
+	}
+	return grant, nil
+}
+
+type CreateGrantRequest struct {
+	AccountNumber accounts.AccountNumber
+	GrantorID     users.UserID
+	GranteeID     users.UserID
+	Scopes        []Scope
+	Expires       time.Time
+}
+
+// Access records a single use of a grant.
+type Access struct {
+	GrantID   GrantID
+	GranteeID users.UserID
+	Scope     Scope
+	Timestamp time.Time
+}
@@ -0,0 +1,73 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/interest"
+	"sync"
+	"time"
+)
+
+type accrualKey struct {
+	acctNum accounts.AccountNumber
+	date    time.Time
+}
+
+type InMemoryAccrualStore struct {
+	mu       sync.RWMutex
+	accruals map[interest.AccrualID]interest.Accrual
+	byDay    map[accrualKey]interest.AccrualID
+	order    []interest.AccrualID
+}
+
+func NewInMemoryAccrualStore() *InMemoryAccrualStore {
+	return &InMemoryAccrualStore{
+		accruals: make(map[interest.AccrualID]interest.Accrual),
+		byDay:    make(map[accrualKey]interest.AccrualID),
+	}
+}
+
+func (s *InMemoryAccrualStore) Create(accrual interest.Accrual) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := accrualKey{acctNum: accrual.AccountNumber, date: accrual.Date}
+	if _, ok := s.byDay[key]; ok {
+		return interest.ErrAlreadyAccrued
+	}
+	s.byDay[key] = accrual.ID
+	s.accruals[accrual.ID] = accrual
+	s.order = append(s.order, accrual.ID)
+	return nil
+}
+
+func (s *InMemoryAccrualStore) Put(accrual interest.Accrual) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accruals[accrual.ID]; !ok {
+		return interest.ErrAccrualNotFound
+	}
+	s.accruals[accrual.ID] = accrual
+	return nil
+}
+
+func (s *InMemoryAccrualStore) GetByAcctNum(acctNum accounts.AccountNumber) ([]interest.Accrual, error) {
+	return s.filter(func(a interest.Accrual) bool { return a.AccountNumber == acctNum }), nil
+}
+
+func (s *InMemoryAccrualStore) GetUnposted() ([]interest.Accrual, error) {
+	return s.filter(func(a interest.Accrual) bool { return !a.IsPosted() }), nil
+}
+
+func (s *InMemoryAccrualStore) filter(keep func(interest.Accrual) bool) []interest.Accrual {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []interest.Accrual
+	for _, id := range s.order {
+		if accrual := s.accruals[id]; keep(accrual) {
+			result = append(result, accrual)
+		}
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/interest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryAccrualStore(t *testing.T) {
+	store := NewInMemoryAccrualStore()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	newAccrual := func(t *testing.T, acctNum accounts.AccountNumber, date time.Time) interest.Accrual {
+		t.Helper()
+		id, err := interest.NewRandAccrualID()
+		require.NoError(t, err)
+		return interest.Accrual{ID: id, AccountNumber: acctNum, Date: date, Balance: 100, Currency: accounts.GBP, Rate: 0.02, DayCount: interest.Actual365, Amount: 0.01}
+	}
+
+	accrual1 := newAccrual(t, "01000004", day)
+	accrual2 := newAccrual(t, "01000012", day)
+	t.Run("should create accruals for different accounts on the same day", func(t *testing.T) {
+		require.NoError(t, store.Create(accrual1))
+		require.NoError(t, store.Create(accrual2))
+	})
+	t.Run("should refuse a second accrual for the same account and day", func(t *testing.T) {
+		err := store.Create(newAccrual(t, "01000004", day))
+		assert.ErrorIs(t, err, interest.ErrAlreadyAccrued)
+	})
+	t.Run("should get accruals by account number", func(t *testing.T) {
+		got, err := store.GetByAcctNum("01000012")
+		require.NoError(t, err)
+		assert.Equal(t, []interest.Accrual{accrual2}, got)
+	})
+	t.Run("should update an accrual once posted", func(t *testing.T) {
+		accrual1.TransactionID = "tan-123"
+		require.NoError(t, store.Put(accrual1))
+
+		got, err := store.GetUnposted()
+		require.NoError(t, err)
+		assert.Equal(t, []interest.Accrual{accrual2}, got)
+	})
+	t.Run("should error updating an accrual which does not exist", func(t *testing.T) {
+		err := store.Put(newAccrual(t, "01000004", day.AddDate(0, 0, 1)))
+		assert.ErrorIs(t, err, interest.ErrAccrualNotFound)
+	})
+}
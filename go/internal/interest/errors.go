@@ -0,0 +1,6 @@
+package interest
+
+import "errors"
+
+var ErrAlreadyAccrued = errors.New("interest already accrued for this account and day")
+var ErrAccrualNotFound = errors.New("accrual not found")
@@ -0,0 +1,134 @@
+package interest
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/transactions"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type AccrualStore interface {
+	// Create stores a new accrual, failing with ErrAlreadyAccrued if the account already has one
+	// for the same day.
+	Create(accrual Accrual) error
+	Put(accrual Accrual) error
+	GetByAcctNum(acctNum accounts.AccountNumber) ([]Accrual, error)
+	GetUnposted() ([]Accrual, error)
+}
+
+type accountStore interface {
+	List() ([]accounts.BankAccount, error)
+}
+
+type ledger interface {
+	PostInterest(acctNum accounts.AccountNumber, amt float64, ref string) (transactions.Transaction, error)
+}
+
+type InterestService struct {
+	config       Config
+	accrualStore AccrualStore
+	accountStore accountStore
+	ledger       ledger
+}
+
+func NewInterestService(config Config, accrualStore AccrualStore, acctStore accountStore, ledger ledger) (*InterestService, error) {
+	if !config.IsValid() {
+		return nil, fmt.Errorf("invalid interest config %+v", config)
+	}
+	return &InterestService{config: config, accrualStore: accrualStore, accountStore: acctStore, ledger: ledger}, nil
+}
+
+// AccrueDay records a day's interest for every account whose type earns it, using the balance at
+// the time it runs as the end-of-day balance. Accounts already accrued for the day are skipped, so
+// the day can be re-run safely.
+func (svc *InterestService) AccrueDay(day time.Time) ([]Accrual, error) {
+	accts, err := svc.accountStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("error listing bank accounts %w", err)
+	}
+	var accrued []Accrual
+	for _, acct := range accts {
+		accrual, ok, err := NewAccrual(acct, day, svc.config)
+		if err != nil {
+			return nil, fmt.Errorf("error creating accrual %w", err)
+		}
+		if !ok {
+			continue
+		}
+		err = svc.accrualStore.Create(accrual)
+		if errors.Is(err, ErrAlreadyAccrued) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error saving accrual %w", err)
+		}
+		accrued = append(accrued, accrual)
+	}
+	return accrued, nil
+}
+
+// PostMonth pays each account the month's unposted accruals, rounded to the currency's minor
+// unit, as a single Interest transaction and marks the accruals with it. Accruals already posted
+// are skipped, so re-running a month pays nothing twice. A failure for one account does not stop
+// the others being posted.
+func (svc *InterestService) PostMonth(year int, month time.Month) ([]transactions.Transaction, error) {
+	unposted, err := svc.accrualStore.GetUnposted()
+	if err != nil {
+		return nil, fmt.Errorf("error listing accruals %w", err)
+	}
+	byAcct := monthAccruals(unposted, year, month)
+	acctNums := make([]accounts.AccountNumber, 0, len(byAcct))
+	for acctNum := range byAcct {
+		acctNums = append(acctNums, acctNum)
+	}
+	slices.SortFunc(acctNums, func(a, b accounts.AccountNumber) int { return strings.Compare(a.String(), b.String()) })
+
+	var posted []transactions.Transaction
+	var errs []error
+	for _, acctNum := range acctNums {
+		tan, err := svc.postAccruals(byAcct[acctNum], year, month)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error posting interest to %s %w", acctNum, err))
+			continue
+		}
+		if tan.ID != "" {
+			posted = append(posted, tan)
+		}
+	}
+	return posted, errors.Join(errs...)
+}
+
+func (svc *InterestService) postAccruals(accruals []Accrual, year int, month time.Month) (transactions.Transaction, error) {
+	total := 0.0
+	for _, a := range accruals {
+		total += a.Amount
+	}
+	total = accruals[0].Currency.Round(total)
+	if total <= 0 {
+		return transactions.Transaction{}, nil
+	}
+	ref := fmt.Sprintf("Interest %04d-%02d", year, month)
+	tan, err := svc.ledger.PostInterest(accruals[0].AccountNumber, total, ref)
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+	for _, a := range accruals {
+		a.TransactionID = tan.ID
+		err = svc.accrualStore.Put(a)
+		if err != nil {
+			return transactions.Transaction{}, fmt.Errorf("error saving accrual %w", err)
+		}
+	}
+	return tan, nil
+}
+
+func (svc *InterestService) ListAccruals(acctNum accounts.AccountNumber) ([]Accrual, error) {
+	accruals, err := svc.accrualStore.GetByAcctNum(acctNum)
+	if err != nil {
+		return nil, fmt.Errorf("error listing accruals %w", err)
+	}
+	return accruals, nil
+}
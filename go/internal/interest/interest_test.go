@@ -0,0 +1,150 @@
+package interest_test
+
+import (
+	"eaglebank/internal/accounts"
+	adapters2 "eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/interest"
+	"eaglebank/internal/interest/adapters"
+	"eaglebank/internal/transactions"
+	adapters3 "eaglebank/internal/transactions/adapters"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	config := interest.Config{
+		Rates: map[accounts.AccountType][]interest.Tier{
+			accounts.SavingsAcct: {{From: 0, AnnualRate: 0.02}, {From: 1000, AnnualRate: 0.04}},
+		},
+		DayCount: interest.Actual365,
+	}
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should pay each band's rate on the part of the balance in it", func(t *testing.T) {
+		amt, rate, ok := config.DailyInterest(accounts.SavingsAcct, 2000, day)
+		require.True(t, ok)
+		assert.InDelta(t, (1000*0.02+1000*0.04)/365, amt, 1e-12)
+		assert.InDelta(t, 0.03, rate, 1e-12)
+
+		amt, rate, ok = config.DailyInterest(accounts.SavingsAcct, 500, day)
+		require.True(t, ok)
+		assert.InDelta(t, 500*0.02/365, amt, 1e-12)
+		assert.InDelta(t, 0.02, rate, 1e-12)
+	})
+	t.Run("should use the day count convention", func(t *testing.T) {
+		for dayCount, days := range map[interest.DayCount]float64{interest.Actual365: 365, interest.Actual360: 360, interest.ActualActual: 365} {
+			config.DayCount = dayCount
+			amt, _, _ := config.DailyInterest(accounts.SavingsAcct, 500, day)
+			assert.InDelta(t, 10/days, amt, 1e-12, dayCount)
+		}
+		config.DayCount = interest.ActualActual
+		amt, _, _ := config.DailyInterest(accounts.SavingsAcct, 500, time.Date(2028, 3, 1, 0, 0, 0, 0, time.UTC))
+		assert.InDelta(t, 10.0/366, amt, 1e-12)
+	})
+	t.Run("should not pay account types without rates", func(t *testing.T) {
+		_, _, ok := config.DailyInterest(accounts.CurrentAcct, 500, day)
+		assert.False(t, ok)
+	})
+	t.Run("should reject invalid config", func(t *testing.T) {
+		for _, invalid := range []interest.Config{
+			{DayCount: "30/360"},
+			{DayCount: interest.Actual365, Rates: map[accounts.AccountType][]interest.Tier{accounts.SavingsAcct: {}}},
+			{DayCount: interest.Actual365, Rates: map[accounts.AccountType][]interest.Tier{accounts.SavingsAcct: {{From: 100, AnnualRate: 0.02}}}},
+			{DayCount: interest.Actual365, Rates: map[accounts.AccountType][]interest.Tier{accounts.SavingsAcct: {{From: 0, AnnualRate: 0.02}, {From: 0, AnnualRate: 0.03}}}},
+			{DayCount: interest.Actual365, Rates: map[accounts.AccountType][]interest.Tier{accounts.SavingsAcct: {{From: 0, AnnualRate: 1}}}},
+		} {
+			assert.False(t, invalid.IsValid(), invalid)
+		}
+	})
+}
+
+func TestInterestService(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	tanStore := adapters3.NewInMemoryTransactionStore()
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+	accrualStore := adapters.NewInMemoryAccrualStore()
+	svc, err := interest.NewInterestService(interest.Config{
+		Rates:    map[accounts.AccountType][]interest.Tier{accounts.SavingsAcct: {{From: 0, AnnualRate: 0.0365}}},
+		DayCount: interest.Actual365,
+	}, accrualStore, acctStore, tanSvc)
+	require.NoError(t, err)
+
+	newAccount := func(t *testing.T, acctNum accounts.AccountNumber, acctType accounts.AccountType, balance float64) accounts.BankAccount {
+		t.Helper()
+		acct, err := accounts.NewBankAccount("usr-123", acctNum, "10-10-10", "Mr Foo", acctType, accounts.GBP)
+		require.NoError(t, err)
+		acct, err = acct.Deposit(balance)
+		require.NoError(t, err)
+		require.NoError(t, acctStore.Put(acct))
+		return acct
+	}
+	savings := newAccount(t, "01000004", accounts.SavingsAcct, 1000)
+	current := newAccount(t, "01000012", accounts.CurrentAcct, 1000)
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should accrue for accounts whose type earns interest", func(t *testing.T) {
+		accrued, err := svc.AccrueDay(march.Add(23 * time.Hour))
+		require.NoError(t, err)
+		require.Len(t, accrued, 1)
+		assert.Equal(t, savings.AccountNumber, accrued[0].AccountNumber)
+		assert.Equal(t, march, accrued[0].Date)
+		assert.Equal(t, 1000.0, accrued[0].Balance)
+		assert.Equal(t, 0.0365, accrued[0].Rate)
+		assert.Equal(t, interest.Actual365, accrued[0].DayCount)
+		assert.InDelta(t, 0.1, accrued[0].Amount, 1e-12)
+	})
+	t.Run("should not accrue the same day twice", func(t *testing.T) {
+		accrued, err := svc.AccrueDay(march)
+		require.NoError(t, err)
+		assert.Empty(t, accrued)
+
+		accruals, err := svc.ListAccruals(savings.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, accruals, 1)
+	})
+	t.Run("should post a month's accruals as one interest transaction", func(t *testing.T) {
+		for i := 1; i < 31; i++ {
+			_, err := svc.AccrueDay(march.AddDate(0, 0, i))
+			require.NoError(t, err)
+		}
+		_, err := svc.AccrueDay(march.AddDate(0, 1, 0))
+		require.NoError(t, err)
+
+		posted, err := svc.PostMonth(2026, time.March)
+		require.NoError(t, err)
+		require.Len(t, posted, 1)
+		assert.Equal(t, transactions.Interest, posted[0].Type)
+		assert.Equal(t, 3.1, posted[0].Amount)
+		assert.Equal(t, "Interest 2026-03", posted[0].Reference)
+
+		acct, err := acctStore.GetByAcctNum(savings.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 1003.1, acct.Balance())
+
+		accruals, err := svc.ListAccruals(savings.AccountNumber)
+		require.NoError(t, err)
+		require.Len(t, accruals, 32)
+		for _, a := range accruals[:31] {
+			assert.Equal(t, posted[0].ID, a.TransactionID)
+		}
+		assert.False(t, accruals[31].IsPosted(), "april's accrual should wait for april")
+	})
+	t.Run("should not post a month twice", func(t *testing.T) {
+		posted, err := svc.PostMonth(2026, time.March)
+		require.NoError(t, err)
+		assert.Empty(t, posted)
+
+		tans, err := tanStore.GetByAccountNumber(savings.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, tans, 1)
+		_, err = tanStore.GetByAccountNumber(current.AccountNumber)
+		assert.ErrorIs(t, err, transactions.ErrTransactionNotFound)
+	})
+	t.Run("should reject invalid config", func(t *testing.T) {
+		_, err := interest.NewInterestService(interest.Config{}, accrualStore, acctStore, tanSvc)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,190 @@
+package interest
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/transactions"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DayCount is the convention used to turn an annual rate into a daily one.
+type DayCount string
+
+const Actual365 DayCount = "actual/365"
+const Actual360 DayCount = "actual/360"
+
+// ActualActual divides by the number of days in the calendar year being accrued, 366 in a leap year.
+const ActualActual DayCount = "actual/actual"
+
+func (d DayCount) IsValid() bool {
+	switch d {
+	case Actual365, Actual360, ActualActual:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d DayCount) String() string {
+	return string(d)
+}
+
+func (d DayCount) daysInYear(day time.Time) int {
+	switch d {
+	case Actual360:
+		return 360
+	case ActualActual:
+		return time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	default:
+		return 365
+	}
+}
+
+// Tier is a balance band. The part of a balance from From up to the next tier's From earns
+// AnnualRate, so moving into a higher band only changes the rate on the money above it.
+type Tier struct {
+	From       float64
+	AnnualRate float64
+}
+
+type Config struct {
+	// Rates lists the tiers for each account type that earns interest, in ascending order of From
+	// starting at 0. Account types not listed earn nothing.
+	Rates    map[accounts.AccountType][]Tier
+	DayCount DayCount
+}
+
+func (c Config) IsValid() bool {
+	if !c.DayCount.IsValid() {
+		return false
+	}
+	for acctType, tiers := range c.Rates {
+		if !acctType.IsValid() || len(tiers) == 0 || tiers[0].From != 0 {
+			return false
+		}
+		for i, tier := range tiers {
+			if tier.AnnualRate < 0 || tier.AnnualRate >= 1 {
+				return false
+			}
+			if i > 0 && tier.From <= tiers[i-1].From {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DailyInterest is the unrounded interest earned on an end-of-day balance, along with the
+// effective annual rate across the tiers the balance falls in.
+func (c Config) DailyInterest(acctType accounts.AccountType, balance float64, day time.Time) (amt, rate float64, ok bool) {
+	tiers, ok := c.Rates[acctType]
+	if !ok || balance <= 0 {
+		return 0, 0, ok
+	}
+	annual := 0.0
+	for i, tier := range tiers {
+		if balance <= tier.From {
+			break
+		}
+		upper := balance
+		if i+1 < len(tiers) {
+			upper = min(balance, tiers[i+1].From)
+		}
+		annual += (upper - tier.From) * tier.AnnualRate
+	}
+	return annual / float64(c.DayCount.daysInYear(day)), annual / balance, true
+}
+
+type AccrualID string
+
+var accrualIDRegex = regexp.MustCompile(`^acr-[A-Za-z0-9]+$`)
+
+func (id AccrualID) IsValid() bool {
+	return accrualIDRegex.MatchString(id.String())
+}
+
+func (id AccrualID) String() string {
+	return string(id)
+}
+
+func NewAccrualID(s string) (AccrualID, error) {
+	id := AccrualID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid accrual ID %q: must match format acr-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandAccrualID() (AccrualID, error) {
+	return NewAccrualID("acr-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+// Accrual records one day's interest on one account and everything used to work it out, so the
+// amount can be checked after the fact. Amount is not rounded, rounding happens once when the
+// month's accruals are posted.
+type Accrual struct {
+	ID            AccrualID
+	AccountNumber accounts.AccountNumber
+	// Date is the day accrued for, at midnight UTC.
+	Date     time.Time
+	Balance  float64
+	Currency accounts.Currency
+	// Rate is the effective annual rate across the balance's tiers.
+	Rate     float64
+	DayCount DayCount
+	Amount   float64
+	// TransactionID is the interest transaction the accrual was paid in, empty until posted.
+	TransactionID    transactions.TransactionID
+	CreatedTimestamp time.Time
+}
+
+func (a Accrual) IsPosted() bool {
+	return a.TransactionID != ""
+}
+
+func NewAccrual(acct accounts.BankAccount, day time.Time, config Config) (Accrual, bool, error) {
+	day = dayOf(day)
+	amt, rate, ok := config.DailyInterest(acct.AccountType, acct.Balance(), day)
+	if !ok {
+		return Accrual{}, false, nil
+	}
+	id, err := NewRandAccrualID()
+	if err != nil {
+		return Accrual{}, false, err
+	}
+	return Accrual{
+		ID:               id,
+		AccountNumber:    acct.AccountNumber,
+		Date:             day,
+		Balance:          acct.Balance(),
+		Currency:         acct.Currency,
+		Rate:             rate,
+		DayCount:         config.DayCount,
+		Amount:           amt,
+		CreatedTimestamp: time.Now(),
+	}, true, nil
+}
+
+// monthAccruals groups the unposted accruals dated in the month by account.
+func monthAccruals(accruals []Accrual, year int, month time.Month) map[accounts.AccountNumber][]Accrual {
+	byAcct := make(map[accounts.AccountNumber][]Accrual)
+	for _, a := range accruals {
+		if a.IsPosted() || a.Date.Year() != year || a.Date.Month() != month {
+			continue
+		}
+		byAcct[a.AccountNumber] = append(byAcct[a.AccountNumber], a)
+	}
+	for acctNum := range byAcct {
+		slices.SortFunc(byAcct[acctNum], func(a, b Accrual) int { return a.Date.Compare(b.Date) })
+	}
+	return byAcct
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
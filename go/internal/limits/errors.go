@@ -0,0 +1,24 @@
+package limits
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// ExceededError names the limit that was hit, its value and the amount or total that would have
+// broken it. It matches ErrLimitExceeded with errors.Is.
+type ExceededError struct {
+	Limit     Kind
+	Max       float64
+	Attempted float64
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s limit of %v exceeded: %v", e.Limit, e.Max, e.Attempted)
+}
+
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
@@ -0,0 +1,78 @@
+package limits
+
+import "math"
+
+// Kind names a limit, it is reported in ExceededError so callers can tell which limit was hit.
+type Kind string
+
+const MaxBalance Kind = "max_balance"
+const MaxTransaction Kind = "max_transaction"
+const DailyWithdrawal Kind = "daily_withdrawal"
+const MonthlyWithdrawal Kind = "monthly_withdrawal"
+
+func Kinds() []Kind {
+	return []Kind{MaxBalance, MaxTransaction, DailyWithdrawal, MonthlyWithdrawal}
+}
+
+func (k Kind) String() string {
+	return string(k)
+}
+
+// Profile is the set of limits applied to one account. Withdrawal totals only count customer
+// withdrawals, not staff adjustments or charges made by the bank.
+type Profile struct {
+	MaxBalance        float64
+	MaxTransaction    float64
+	DailyWithdrawal   float64
+	MonthlyWithdrawal float64
+}
+
+func (p Profile) IsZero() bool {
+	return p == Profile{}
+}
+
+func (p Profile) IsValid() bool {
+	return p.MaxBalance > 0 && p.MaxTransaction > 0 && p.DailyWithdrawal > 0 && p.MonthlyWithdrawal > 0
+}
+
+// Tighten returns the stricter of each limit in p and caps. A zero limit in caps leaves the limit
+// in p as it is.
+func (p Profile) Tighten(caps Profile) Profile {
+	return Profile{
+		MaxBalance:        tighter(p.MaxBalance, caps.MaxBalance),
+		MaxTransaction:    tighter(p.MaxTransaction, caps.MaxTransaction),
+		DailyWithdrawal:   tighter(p.DailyWithdrawal, caps.DailyWithdrawal),
+		MonthlyWithdrawal: tighter(p.MonthlyWithdrawal, caps.MonthlyWithdrawal),
+	}
+}
+
+func tighter(limit, limitCap float64) float64 {
+	if limitCap <= 0 {
+		return limit
+	}
+	return math.Min(limit, limitCap)
+}
+
+func (p Profile) Limit(kind Kind) float64 {
+	switch kind {
+	case MaxBalance:
+		return p.MaxBalance
+	case MaxTransaction:
+		return p.MaxTransaction
+	case DailyWithdrawal:
+		return p.DailyWithdrawal
+	case MonthlyWithdrawal:
+		return p.MonthlyWithdrawal
+	default:
+		return 0
+	}
+}
+
+// Check returns an ExceededError if attempted is over the profile's limit of the given kind.
+func (p Profile) Check(kind Kind, attempted float64) error {
+	limit := p.Limit(kind)
+	if attempted > limit {
+		return &ExceededError{Limit: kind, Max: limit, Attempted: attempted}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package limits_test
+
+import (
+	"eaglebank/internal/limits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	profile := limits.Profile{MaxBalance: 1000, MaxTransaction: 500, DailyWithdrawal: 200, MonthlyWithdrawal: 800}
+
+	t.Run("should require every limit", func(t *testing.T) {
+		assert.True(t, profile.IsValid())
+		assert.False(t, limits.Profile{MaxBalance: 1000}.IsValid())
+		assert.True(t, limits.Profile{}.IsZero())
+	})
+	t.Run("should keep the stricter limit when tightened", func(t *testing.T) {
+		tightened := profile.Tighten(limits.Profile{MaxBalance: 100, DailyWithdrawal: 300})
+		assert.Equal(t, limits.Profile{MaxBalance: 100, MaxTransaction: 500, DailyWithdrawal: 200, MonthlyWithdrawal: 800}, tightened)
+	})
+	t.Run("should allow amounts up to the limit", func(t *testing.T) {
+		assert.NoError(t, profile.Check(limits.MaxTransaction, 500))
+	})
+	t.Run("should name the limit exceeded", func(t *testing.T) {
+		err := profile.Check(limits.DailyWithdrawal, 200.01)
+		assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+
+		var exceeded *limits.ExceededError
+		require.ErrorAs(t, err, &exceeded)
+		assert.Equal(t, limits.DailyWithdrawal, exceeded.Limit)
+		assert.Equal(t, 200.0, exceeded.Max)
+		assert.Equal(t, 200.01, exceeded.Attempted)
+	})
+}
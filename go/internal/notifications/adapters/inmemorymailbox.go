@@ -0,0 +1,36 @@
+package adapters
+
+import (
+	"eaglebank/internal/notifications"
+	"sync"
+)
+
+type InMemoryMailbox struct {
+	mu       sync.RWMutex
+	messages []notifications.Message
+}
+
+func NewInMemoryMailbox() *InMemoryMailbox {
+	return &InMemoryMailbox{}
+}
+
+func (m *InMemoryMailbox) Notify(msg notifications.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *InMemoryMailbox) MessagesTo(to string) []notifications.Message {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]notifications.Message, 0)
+	for _, msg := range m.messages {
+		if msg.To == to {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,30 @@
+package adapters
+
+import (
+	"eaglebank/internal/notifications"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryMailbox(t *testing.T) {
+	mailbox := NewInMemoryMailbox()
+
+	t.Run("should return no messages for empty mailbox", func(t *testing.T) {
+		assert.Empty(t, mailbox.MessagesTo("foo@bar.com"))
+	})
+	t.Run("should return messages for recipient in order", func(t *testing.T) {
+		msg1, err := notifications.NewMessage("foo@bar.com", "first", "body")
+		require.NoError(t, err)
+		msg2, err := notifications.NewMessage("baz@bar.com", "other", "body")
+		require.NoError(t, err)
+		msg3, err := notifications.NewMessage("foo@bar.com", "second", "body")
+		require.NoError(t, err)
+		for _, msg := range []notifications.Message{msg1, msg2, msg3} {
+			require.NoError(t, mailbox.Notify(msg))
+		}
+
+		assert.Equal(t, []notifications.Message{msg1, msg3}, mailbox.MessagesTo("foo@bar.com"))
+	})
+}
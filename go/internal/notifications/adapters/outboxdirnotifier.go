@@ -0,0 +1,60 @@
+package adapters
+
+import (
+	"eaglebank/internal/notifications"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// OutboxDirNotifier writes each message as a JSON file to a local directory instead of sending it,
+// so messages can be inspected during local development.
+type OutboxDirNotifier struct {
+	dir string
+}
+
+func NewOutboxDirNotifier(dir string) (*OutboxDirNotifier, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating outbox directory %w", err)
+	}
+	return &OutboxDirNotifier{dir: dir}, nil
+}
+
+func (n *OutboxDirNotifier) Notify(msg notifications.Message) error {
+	by, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding message %w", err)
+	}
+	name := fmt.Sprintf("%d-%s.json", msg.CreatedTimestamp.UnixNano(), msg.ID)
+	tmp := filepath.Join(n.dir, "."+name)
+	err = os.WriteFile(tmp, by, 0o600)
+	if err != nil {
+		return fmt.Errorf("error writing message %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(n.dir, name))
+}
+
+func (n *OutboxDirNotifier) Messages() ([]notifications.Message, error) {
+	paths, err := filepath.Glob(filepath.Join(n.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	msgs := make([]notifications.Message, 0, len(paths))
+	for _, path := range paths {
+		by, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading message %w", err)
+		}
+		var msg notifications.Message
+		err = json.Unmarshal(by, &msg)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding message %q %w", path, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
@@ -0,0 +1,33 @@
+package adapters
+
+import (
+	"eaglebank/internal/notifications"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxDirNotifier(t *testing.T) {
+	notifier, err := NewOutboxDirNotifier(filepath.Join(t.TempDir(), "outbox"))
+	require.NoError(t, err)
+
+	t.Run("should return no messages for empty outbox", func(t *testing.T) {
+		msgs, err := notifier.Messages()
+		require.NoError(t, err)
+		assert.Empty(t, msgs)
+	})
+	t.Run("should write messages which can be read back", func(t *testing.T) {
+		msg, err := notifications.NewMessage("foo@bar.com", "subject", "body")
+		require.NoError(t, err)
+		require.NoError(t, notifier.Notify(msg))
+
+		msgs, err := notifier.Messages()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, msg.ID, msgs[0].ID)
+		assert.Equal(t, msg.Body, msgs[0].Body)
+		assert.WithinDuration(t, msg.CreatedTimestamp, msgs[0].CreatedTimestamp, 0)
+	})
+}
@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MessageID string
+
+func (id MessageID) String() string {
+	return string(id)
+}
+
+func NewRandMessageID() MessageID {
+	clean := strings.ReplaceAll(uuid.New().String(), "-", "")
+	return MessageID("msg-" + clean)
+}
+
+type Message struct {
+	ID               MessageID
+	To               string
+	Subject          string
+	Body             string
+	CreatedTimestamp time.Time
+}
+
+func (m Message) IsValid() bool {
+	return m.ID != "" && m.To != "" && m.Subject != ""
+}
+
+func NewMessage(to, subject, body string) (Message, error) {
+	msg := Message{
+		ID:               NewRandMessageID(),
+		To:               to,
+		Subject:          subject,
+		Body:             body,
+		CreatedTimestamp: time.Now(),
+	}
+	if !msg.IsValid() {
+		return Message{}, fmt.Errorf("invalid message %+v", msg)
+	}
+	return msg, nil
+}
@@ -0,0 +1,70 @@
+package adapters
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/transactions"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type InMemoryBalanceSnapshotStore struct {
+	mu             sync.RWMutex
+	snapsByAcctNum map[accounts.AccountNumber][]transactions.BalanceSnapshot
+}
+
+func NewInMemoryBalanceSnapshotStore() *InMemoryBalanceSnapshotStore {
+	return &InMemoryBalanceSnapshotStore{
+		snapsByAcctNum: make(map[accounts.AccountNumber][]transactions.BalanceSnapshot),
+	}
+}
+
+func (s *InMemoryBalanceSnapshotStore) Latest(acctNum accounts.AccountNumber, before time.Time) (transactions.BalanceSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snaps := s.snapsByAcctNum[acctNum]
+	i := sort.Search(len(snaps), func(i int) bool { return !snaps[i].Date.Before(before) })
+	if i == 0 {
+		return transactions.BalanceSnapshot{}, transactions.ErrSnapshotNotFound
+	}
+	return snaps[i-1], nil
+}
+
+func (s *InMemoryBalanceSnapshotStore) GetRange(acctNum accounts.AccountNumber, from, to time.Time) ([]transactions.BalanceSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snaps := s.snapsByAcctNum[acctNum]
+	i := sort.Search(len(snaps), func(i int) bool { return !snaps[i].Date.Before(from) })
+	j := sort.Search(len(snaps), func(i int) bool { return snaps[i].Date.After(to) })
+	if j < i {
+		j = i
+	}
+	return slices.Clone(snaps[i:j]), nil
+}
+
+func (s *InMemoryBalanceSnapshotStore) PutAll(snaps ...transactions.BalanceSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := make(map[accounts.AccountNumber]time.Time)
+	for _, snap := range snaps {
+		last, ok := latest[snap.AccountNumber]
+		if !ok {
+			if stored := s.snapsByAcctNum[snap.AccountNumber]; len(stored) > 0 {
+				last, ok = stored[len(stored)-1].Date, true
+			}
+		}
+		if ok && !snap.Date.After(last) {
+			return fmt.Errorf("cannot modify balance snapshot for %s on %s", snap.AccountNumber, snap.Date.Format(time.DateOnly))
+		}
+		latest[snap.AccountNumber] = snap.Date
+	}
+	for _, snap := range snaps {
+		s.snapsByAcctNum[snap.AccountNumber] = append(s.snapsByAcctNum[snap.AccountNumber], snap)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package adapters
+
+import (
+	"eaglebank/internal/transactions"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryBalanceSnapshotStore(t *testing.T) {
+	store := NewInMemoryBalanceSnapshotStore()
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	snaps := make([]transactions.BalanceSnapshot, 3)
+	for i := range snaps {
+		snaps[i] = transactions.BalanceSnapshot{AccountNumber: "01000020", Date: day.AddDate(0, 0, i), Closing: float64(i * 10)}
+	}
+
+	t.Run("should error when there are no snapshots", func(t *testing.T) {
+		_, err := store.Latest("01000020", day)
+		assert.ErrorIs(t, err, transactions.ErrSnapshotNotFound)
+	})
+	t.Run("should put snapshots and get them back", func(t *testing.T) {
+		require.NoError(t, store.PutAll(snaps...))
+
+		latest, err := store.Latest("01000020", day.AddDate(0, 0, 2))
+		require.NoError(t, err)
+		assert.Equal(t, snaps[1], latest)
+
+		gotSnaps, err := store.GetRange("01000020", day.AddDate(0, 0, 1), day.AddDate(0, 0, 5))
+		require.NoError(t, err)
+		assert.Equal(t, snaps[1:], gotSnaps)
+	})
+	t.Run("should not replace or backfill snapshots", func(t *testing.T) {
+		err := store.PutAll(snaps[2])
+		assert.Error(t, err)
+		err = store.PutAll(transactions.BalanceSnapshot{AccountNumber: "01000020", Date: day.AddDate(0, 0, -1)})
+		assert.Error(t, err)
+	})
+}
@@ -2,22 +2,45 @@ package adapters
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/events"
 	"eaglebank/internal/transactions"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 )
 
 type InMemoryTransactionStore struct {
-	mu            sync.RWMutex
-	tansByTanID   map[transactions.TransactionID]transactions.Transaction
+	mu          sync.RWMutex
+	tansByTanID map[transactions.TransactionID]transactions.Transaction
+	// tansByAcctNum is in sequence order, so transaction n is at index n-1.
 	tansByAcctNum map[accounts.AccountNumber][]transactions.Transaction
+	// tansByTime is in time order so ranges can be found by binary search. Transactions are nearly
+	// always posted in time order, but concurrent postings can be numbered slightly out of it.
+	tansByTime map[accounts.AccountNumber][]transactions.Transaction
+	outbox     events.Recorder
 }
 
-func NewInMemoryTransactionStore() *InMemoryTransactionStore {
-	return &InMemoryTransactionStore{
+type TransactionStoreOption func(*InMemoryTransactionStore)
+
+// WithOutbox records the events passed to Put in the outbox, without one they are dropped.
+func WithOutbox(outbox events.Recorder) TransactionStoreOption {
+	return func(s *InMemoryTransactionStore) {
+		s.outbox = outbox
+	}
+}
+
+func NewInMemoryTransactionStore(opts ...TransactionStoreOption) *InMemoryTransactionStore {
+	s := &InMemoryTransactionStore{
 		tansByTanID:   make(map[transactions.TransactionID]transactions.Transaction),
 		tansByAcctNum: make(map[accounts.AccountNumber][]transactions.Transaction),
+		tansByTime:    make(map[accounts.AccountNumber][]transactions.Transaction),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *InMemoryTransactionStore) GetByTransactionID(tanID transactions.TransactionID) (transactions.Transaction, error) {
@@ -44,20 +67,83 @@ func (s *InMemoryTransactionStore) GetByAccountNumber(acctNum accounts.AccountNu
 	return result, nil
 }
 
-func (s *InMemoryTransactionStore) Put(tan transactions.Transaction) error {
+func (s *InMemoryTransactionStore) GetByAccountNumberBetween(acctNum accounts.AccountNumber, from, to time.Time) ([]transactions.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tans := s.tansByTime[acctNum]
+	i := sort.Search(len(tans), func(i int) bool { return !tans[i].CreatedTimestamp.Before(from) })
+	j := sort.Search(len(tans), func(i int) bool { return !tans[i].CreatedTimestamp.Before(to) })
+	if j < i {
+		j = i
+	}
+	return slices.Clone(tans[i:j]), nil
+}
+
+func (s *InMemoryTransactionStore) GetBySequenceRange(acctNum accounts.AccountNumber, from, to uint64) ([]transactions.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tans := s.tansByAcctNum[acctNum]
+	last := uint64(len(tans))
+	if from == 0 {
+		from = 1
+	}
+	if to > last {
+		to = last
+	}
+	if from > to {
+		return []transactions.Transaction{}, nil
+	}
+	return slices.Clone(tans[from-1 : to]), nil
+}
+
+func (s *InMemoryTransactionStore) LastSequence(acctNum accounts.AccountNumber) (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return uint64(len(s.tansByAcctNum[acctNum])), nil
+}
+
+func (s *InMemoryTransactionStore) Put(tan transactions.Transaction, evts ...events.Event) error {
+	return s.PutAll([]transactions.Transaction{tan}, evts...)
+}
+
+// PutAll stores every transaction and records the events under one lock, so readers see either none
+// or all of them. If any transaction already exists or does not have its account's next sequence
+// number none are stored.
+func (s *InMemoryTransactionStore) PutAll(tans []transactions.Transaction, evts ...events.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.tansByTanID[tan.ID]
-	if exists {
-		return fmt.Errorf("cannot modify transaction")
-	}
-	for _, t := range s.tansByAcctNum[tan.AccountNumber] {
-		if t.ID == tan.ID {
+	next := make(map[accounts.AccountNumber]uint64)
+	for _, tan := range tans {
+		_, exists := s.tansByTanID[tan.ID]
+		if exists {
 			return fmt.Errorf("cannot modify transaction")
 		}
+		seq, ok := next[tan.AccountNumber]
+		if !ok {
+			seq = uint64(len(s.tansByAcctNum[tan.AccountNumber])) + 1
+		}
+		if tan.Sequence != seq {
+			return fmt.Errorf("%w: got %d, next is %d", transactions.ErrSequenceConflict, tan.Sequence, seq)
+		}
+		next[tan.AccountNumber] = seq + 1
+	}
+	if s.outbox != nil && len(evts) > 0 {
+		s.outbox.Record(evts...)
+	}
+	for _, tan := range tans {
+		s.put(tan)
 	}
+	return nil
+}
+
+func (s *InMemoryTransactionStore) put(tan transactions.Transaction) {
 	s.tansByTanID[tan.ID] = tan
 	s.tansByAcctNum[tan.AccountNumber] = append(s.tansByAcctNum[tan.AccountNumber], tan)
-	return nil
+	byTime := s.tansByTime[tan.AccountNumber]
+	i := sort.Search(len(byTime), func(i int) bool { return byTime[i].CreatedTimestamp.After(tan.CreatedTimestamp) })
+	s.tansByTime[tan.AccountNumber] = slices.Insert(byTime, i, tan)
 }
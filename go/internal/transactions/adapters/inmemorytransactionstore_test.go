@@ -2,6 +2,8 @@ package adapters
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/events"
+	adapters2 "eaglebank/internal/events/adapters"
 	"eaglebank/internal/transactions"
 	"testing"
 	"time"
@@ -19,8 +21,8 @@ func TestNewInMemoryTransactionStore(t *testing.T) {
 		assert.Error(t, err)
 	})
 	t.Run("should perform put-get without errors and fail to update", func(t *testing.T) {
-		tan1 := newTestTransaction(t, transactions.Deposit, 150)
-		tan2 := newTestTransaction(t, transactions.Deposit, 200)
+		tan1 := newTestTransaction(t, 1, transactions.Deposit, 150)
+		tan2 := newTestTransaction(t, 2, transactions.Deposit, 200)
 		t.Run("should create transaction that does not exist in store", func(t *testing.T) {
 			err := store.Put(tan1)
 			require.NoError(t, err)
@@ -41,6 +43,25 @@ func TestNewInMemoryTransactionStore(t *testing.T) {
 			require.Contains(t, gotTans, tan1)
 			require.Contains(t, gotTans, tan2)
 		})
+		t.Run("should get transactions by sequence range", func(t *testing.T) {
+			gotTans, err := store.GetBySequenceRange(tan1.AccountNumber, 2, 10)
+			require.NoError(t, err)
+			assert.Equal(t, []transactions.Transaction{tan2}, gotTans)
+
+			gotTans, err = store.GetBySequenceRange(tan1.AccountNumber, 3, 10)
+			require.NoError(t, err)
+			assert.Empty(t, gotTans)
+
+			last, err := store.LastSequence(tan1.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(2), last)
+		})
+		t.Run("should refuse a sequence number which is taken or skips ahead", func(t *testing.T) {
+			for _, seq := range []uint64{0, 2, 4} {
+				err := store.Put(newTestTransaction(t, seq, transactions.Deposit, 10))
+				assert.ErrorIs(t, err, transactions.ErrSequenceConflict, seq)
+			}
+		})
 		t.Run("should fail updating existing transaction", func(t *testing.T) {
 			updatedTan := tan1
 			updatedTan.Amount = 9000
@@ -50,10 +71,72 @@ func TestNewInMemoryTransactionStore(t *testing.T) {
 			require.Error(t, err)
 		})
 	})
+	t.Run("should record events only with transactions which are stored", func(t *testing.T) {
+		outbox := adapters2.NewInMemoryOutbox()
+		store := NewInMemoryTransactionStore(WithOutbox(outbox))
+		tan := newTestTransaction(t, 1, transactions.Deposit, 10)
+		evt, err := events.NewEvent(events.TransactionPosted, tan.AccountNumber.String(), nil, events.TransactionData{TransactionID: tan.ID.String()})
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(tan, evt))
+		assert.ErrorIs(t, store.Put(newTestTransaction(t, 1, transactions.Deposit, 10), evt), transactions.ErrSequenceConflict)
+		assert.Equal(t, []events.Event{evt}, outbox.Events())
+	})
+	t.Run("should put every transaction together or none of them", func(t *testing.T) {
+		outbox := adapters2.NewInMemoryOutbox()
+		store := NewInMemoryTransactionStore(WithOutbox(outbox))
+		debit := newTestTransaction(t, 1, transactions.ConversionDebit, 10)
+		credit := newTestTransaction(t, 1, transactions.ConversionCredit, 12)
+		credit.AccountNumber = "01000038"
+		evt, err := events.NewEvent(events.TransactionPosted, debit.AccountNumber.String(), nil, events.TransactionData{TransactionID: debit.ID.String()})
+		require.NoError(t, err)
 
+		stale := credit
+		stale.Sequence = 2
+		assert.ErrorIs(t, store.PutAll([]transactions.Transaction{debit, stale}, evt), transactions.ErrSequenceConflict)
+		_, err = store.GetByTransactionID(debit.ID)
+		assert.ErrorIs(t, err, transactions.ErrTransactionNotFound)
+		assert.Empty(t, outbox.Events())
+
+		require.NoError(t, store.PutAll([]transactions.Transaction{debit, credit}, evt))
+		for _, tan := range []transactions.Transaction{debit, credit} {
+			last, err := store.LastSequence(tan.AccountNumber)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), last)
+		}
+		assert.Equal(t, []events.Event{evt}, outbox.Events())
+	})
+	t.Run("should number several transactions to one account in order", func(t *testing.T) {
+		store := NewInMemoryTransactionStore()
+		tans := []transactions.Transaction{newTestTransaction(t, 1, transactions.Deposit, 10), newTestTransaction(t, 2, transactions.Deposit, 10)}
+		require.NoError(t, store.PutAll(tans))
+		assert.ErrorIs(t, store.PutAll([]transactions.Transaction{newTestTransaction(t, 3, transactions.Deposit, 10), newTestTransaction(t, 3, transactions.Deposit, 10)}), transactions.ErrSequenceConflict)
+
+		last, err := store.LastSequence(tans[0].AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), last)
+	})
+	t.Run("should get transactions between two times in time order", func(t *testing.T) {
+		store := NewInMemoryTransactionStore()
+		now := time.Now()
+		tans := make([]transactions.Transaction, 4)
+		for i, hour := range []int{0, 2, 1, 3} {
+			tans[i] = newTestTransaction(t, uint64(i+1), transactions.Deposit, 10)
+			tans[i].CreatedTimestamp = now.Add(time.Duration(hour) * time.Hour)
+			require.NoError(t, store.Put(tans[i]))
+		}
+
+		gotTans, err := store.GetByAccountNumberBetween(tans[0].AccountNumber, tans[2].CreatedTimestamp, tans[3].CreatedTimestamp)
+		require.NoError(t, err)
+		assert.Equal(t, []transactions.Transaction{tans[2], tans[1]}, gotTans)
+
+		gotTans, err = store.GetByAccountNumberBetween("01999990", now, now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, gotTans)
+	})
 }
 
-func newTestTransaction(t *testing.T, tanType transactions.TransactionType, amt float64) transactions.Transaction {
+func newTestTransaction(t *testing.T, seq uint64, tanType transactions.TransactionType, amt float64) transactions.Transaction {
 	t.Helper()
 
 	tanID, err := transactions.NewRandTransactionID()
@@ -62,7 +145,8 @@ func newTestTransaction(t *testing.T, tanType transactions.TransactionType, amt
 
 	return transactions.Transaction{
 		ID:               tanID,
-		AccountNumber:    "01000000",
+		AccountNumber:    "01000020",
+		Sequence:         seq,
 		UserID:           "usr-123",
 		Amount:           amt,
 		Currency:         accounts.GBP,
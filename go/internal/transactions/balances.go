@@ -0,0 +1,224 @@
+package transactions
+
+import (
+	"eaglebank/internal/accounts"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Interval is the length of the periods a balance history is split into. Periods follow calendar
+// days, weeks starting on Monday and months, with the first and last cut to the requested range.
+type Interval string
+
+const DayInterval Interval = "day"
+const WeekInterval Interval = "week"
+const MonthInterval Interval = "month"
+
+func Intervals() []Interval {
+	return []Interval{DayInterval, WeekInterval, MonthInterval}
+}
+
+func (i Interval) String() string { return string(i) }
+
+func (i Interval) IsValid() bool {
+	switch i {
+	case DayInterval, WeekInterval, MonthInterval:
+		return true
+	default:
+		return false
+	}
+}
+
+// next is the first day of the period after the one containing day.
+func (i Interval) next(day time.Time) time.Time {
+	switch i {
+	case WeekInterval:
+		return day.AddDate(0, 0, 7-(int(day.Weekday())+6)%7)
+	case MonthInterval:
+		return time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day.AddDate(0, 0, 1)
+	}
+}
+
+// MaxBalanceIntervals is how many periods one balance history can return.
+const MaxBalanceIntervals = 366
+
+// BalanceInterval is the account's balance at the start of its first day and the end of its last.
+// Days are UTC days.
+type BalanceInterval struct {
+	Start   time.Time
+	End     time.Time
+	Opening float64
+	Closing float64
+}
+
+// BalanceSnapshot is an account's balance at the end of the UTC day starting at Date.
+type BalanceSnapshot struct {
+	AccountNumber accounts.AccountNumber
+	Date          time.Time
+	Closing       float64
+}
+
+type BalanceSnapshotStore interface {
+	// Latest returns the account's most recent snapshot dated before the given day, or
+	// ErrSnapshotNotFound.
+	Latest(acctNum accounts.AccountNumber, before time.Time) (BalanceSnapshot, error)
+	// GetRange returns the account's snapshots dated from the first day to the last inclusive.
+	GetRange(acctNum accounts.AccountNumber, from, to time.Time) ([]BalanceSnapshot, error)
+	// PutAll adds snapshots, which must be later than any already stored for their account.
+	PutAll(snaps ...BalanceSnapshot) error
+}
+
+// BalanceChange is how much the transaction changed the account's balance. Pot transfers only move
+// money within the balance and corrections only record fixing the stored balance, so they don't
+// change it.
+func (t Transaction) BalanceChange() float64 {
+	switch t.Type {
+	case Deposit, AdjustmentCredit, ConversionCredit, Interest:
+		return t.Amount
+	case Withdrawal, AdjustmentDebit, ConversionDebit, OverdraftInterest:
+		return -t.Amount
+	default:
+		return 0
+	}
+}
+
+// BalanceHistory returns the account's opening and closing balance for each period between the
+// from and to days inclusive. Days after today are left out. Balances are worked out from the
+// transactions, starting from the latest daily snapshot when there is one, so the cost depends on
+// the length of the range rather than the number of transactions on the account.
+func (svc *TransactionService) BalanceHistory(acctNum accounts.AccountNumber, from, to time.Time, interval Interval) ([]BalanceInterval, error) {
+	if !interval.IsValid() {
+		return nil, fmt.Errorf("%w: unknown interval %q", ErrInvalidBalanceRange, interval)
+	}
+	from, to = startOfDay(from), startOfDay(to)
+	if today := startOfDay(time.Now()); to.After(today) {
+		to = today
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: %s is after %s", ErrInvalidBalanceRange, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	var periods []BalanceInterval
+	for start := from; !start.After(to); start = interval.next(start) {
+		if len(periods) == MaxBalanceIntervals {
+			return nil, fmt.Errorf("%w: more than %d intervals", ErrInvalidBalanceRange, MaxBalanceIntervals)
+		}
+		end := interval.next(start).AddDate(0, 0, -1)
+		if end.After(to) {
+			end = to
+		}
+		periods = append(periods, BalanceInterval{Start: start, End: end})
+	}
+
+	acct, err := svc.fetchAccount(acctNum)
+	if err != nil {
+		return nil, err
+	}
+	// closings starts with the day before from, which gives the first period's opening balance.
+	first := from.AddDate(0, 0, -1)
+	closings, err := svc.dailyClosings(acct, first, to)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range periods {
+		periods[i].Opening = closings[daysBetween(first, p.Start)-1]
+		periods[i].Closing = closings[daysBetween(first, p.End)]
+	}
+	return periods, nil
+}
+
+// TakeBalanceSnapshots records each account's closing balance for every day up to yesterday that
+// has no snapshot yet, starting from the day it was opened. It is run daily and returns how many
+// snapshots it took.
+func (svc *TransactionService) TakeBalanceSnapshots(now time.Time) (int, error) {
+	if svc.snapshotStore == nil {
+		return 0, nil
+	}
+	yesterday := startOfDay(now).AddDate(0, 0, -1)
+	accts, err := svc.acctStore.List()
+	if err != nil {
+		return 0, fmt.Errorf("error listing accounts %w", err)
+	}
+	taken := 0
+	for _, acct := range accts {
+		first := startOfDay(acct.CreatedTimestamp)
+		latest, err := svc.snapshotStore.Latest(acct.AccountNumber, yesterday.AddDate(0, 0, 1))
+		switch {
+		case err == nil:
+			first = latest.Date.AddDate(0, 0, 1)
+		case !errors.Is(err, ErrSnapshotNotFound):
+			return taken, fmt.Errorf("error fetching balance snapshot %w", err)
+		}
+		if first.After(yesterday) {
+			continue
+		}
+		closings, err := svc.dailyClosings(acct, first, yesterday)
+		if err != nil {
+			return taken, err
+		}
+		snaps := make([]BalanceSnapshot, 0, len(closings))
+		for i, closing := range closings {
+			snaps = append(snaps, BalanceSnapshot{AccountNumber: acct.AccountNumber, Date: first.AddDate(0, 0, i), Closing: closing})
+		}
+		err = svc.snapshotStore.PutAll(snaps...)
+		if err != nil {
+			return taken, fmt.Errorf("error storing balance snapshots %w", err)
+		}
+		taken += len(snaps)
+	}
+	return taken, nil
+}
+
+// dailyClosings returns the account's closing balance for each day from first to last inclusive.
+// Days up to the latest snapshot are read from the snapshots, later days are worked out from the
+// transactions since it.
+func (svc *TransactionService) dailyClosings(acct accounts.BankAccount, first, last time.Time) ([]float64, error) {
+	closings := make([]float64, daysBetween(first, last)+1)
+	balance, since := 0.0, time.Time{}
+	if svc.snapshotStore != nil {
+		latest, err := svc.snapshotStore.Latest(acct.AccountNumber, last.AddDate(0, 0, 1))
+		switch {
+		case err == nil:
+			balance, since = latest.Closing, latest.Date.AddDate(0, 0, 1)
+		case !errors.Is(err, ErrSnapshotNotFound):
+			return nil, fmt.Errorf("error fetching balance snapshot %w", err)
+		}
+		snaps, err := svc.snapshotStore.GetRange(acct.AccountNumber, first, last)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching balance snapshots %w", err)
+		}
+		for _, snap := range snaps {
+			closings[daysBetween(first, snap.Date)] = snap.Closing
+		}
+	}
+
+	tans, err := svc.transactionStore.GetByAccountNumberBetween(acct.AccountNumber, since, last.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("error listing transactions %w", err)
+	}
+	j := 0
+	for i := range closings {
+		day := first.AddDate(0, 0, i)
+		// Days before the first snapshot are before the account was opened, so stay at zero.
+		if day.Before(since) {
+			continue
+		}
+		end := day.AddDate(0, 0, 1)
+		for ; j < len(tans) && tans[j].CreatedTimestamp.Before(end); j++ {
+			balance += tans[j].BalanceChange()
+		}
+		closings[i] = acct.Currency.Round(balance)
+	}
+	return closings, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -2,4 +2,11 @@ package transactions
 
 import "errors"
 
-var ErrTransactionNotFound = errors.New("transaction not found")
\ No newline at end of file
+var ErrTransactionNotFound = errors.New("transaction not found")
+var ErrCurrencyMismatch = errors.New("transaction currency does not match account currency")
+var ErrConversionUnavailable = errors.New("currency conversion unavailable")
+var ErrInvalidBalanceRange = errors.New("invalid balance history range")
+var ErrSnapshotNotFound = errors.New("balance snapshot not found")
+var ErrSequenceConflict = errors.New("transaction sequence number already taken")
+var ErrInvalidSequenceRange = errors.New("invalid transaction sequence range")
+var ErrInvalidTransactionType = errors.New("transaction type cannot be created directly")
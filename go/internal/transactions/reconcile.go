@@ -0,0 +1,155 @@
+package transactions
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxReconcileAttempts is how many times an account is checked before reconcile gives up on it
+// because postings keep getting in first.
+const maxReconcileAttempts = 5
+
+// Discrepancy is an account whose stored balance differs from the balance worked out from its
+// transactions. Correction is set when the stored balance was repaired.
+type Discrepancy struct {
+	AccountNumber accounts.AccountNumber
+	StoredBalance float64
+	LedgerBalance float64
+	// Difference is what has to be added to the stored balance to match the transactions.
+	Difference float64
+	Correction *Transaction
+}
+
+// ReconciliationReport lists the accounts whose balances did not match their transactions when
+// Reconcile was run.
+type ReconciliationReport struct {
+	StartedTimestamp  time.Time
+	FinishedTimestamp time.Time
+	AccountsChecked   int
+	Repaired          bool
+	Discrepancies     []Discrepancy
+}
+
+// Reconcile works out every account's balance from its transactions and compares it with the
+// stored balance. Partial failures can leave the two out of step, for example when a transaction
+// was stored but the account it changed was not. With repair set, the stored balance is set to the
+// one worked out from the transactions and a CorrectionCredit or CorrectionDebit transaction made
+// by userID records the change. A repair which fails stops the run, as does an account which keeps
+// changing while it is checked.
+func (svc *TransactionService) Reconcile(repair bool, userID users.UserID) (ReconciliationReport, error) {
+	report := ReconciliationReport{StartedTimestamp: time.Now(), Repaired: repair, Discrepancies: []Discrepancy{}}
+	accts, err := svc.acctStore.List()
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("error listing accounts %w", err)
+	}
+	for _, listed := range accts {
+		// With an executor each account is checked as a command of its own. Without one,
+		// reconcile relies on the account's version instead, see reconcile.
+		var d *Discrepancy
+		err = svc.executor.Do(listed.AccountNumber, func() error {
+			var err error
+			d, err = svc.reconcile(listed.AccountNumber, repair, userID)
+			return err
+		})
+		if err != nil {
+			return ReconciliationReport{}, err
+		}
+		report.AccountsChecked++
+		if d != nil {
+			report.Discrepancies = append(report.Discrepancies, *d)
+		}
+	}
+	report.FinishedTimestamp = time.Now()
+	return report, nil
+}
+
+// reconcile checks one account, returning its discrepancy if it has one. A posting stores its
+// transactions before its account becomes readable again, so the account is read again after its
+// transactions and the check starts over if its version has moved, rather than mistaking the
+// posting for drift. The correction is written at the next version of the account that was
+// checked, so if a posting gets in first the store refuses it and the account is checked again, up
+// to maxReconcileAttempts times before giving up with ErrConflict.
+func (svc *TransactionService) reconcile(acctNum accounts.AccountNumber, repair bool, userID users.UserID) (*Discrepancy, error) {
+	for range maxReconcileAttempts {
+		acct, err := svc.fetchAccount(acctNum)
+		if err != nil {
+			return nil, err
+		}
+		ledger, err := svc.ledgerBalance(acct)
+		if err != nil {
+			return nil, err
+		}
+		again, err := svc.fetchAccount(acctNum)
+		if err != nil {
+			return nil, err
+		}
+		if again.Version != acct.Version {
+			continue
+		}
+		stored := acct.Currency.Round(acct.Balance())
+		if acct.Currency.Round(ledger) == stored {
+			return nil, nil
+		}
+		d := Discrepancy{
+			AccountNumber: acct.AccountNumber,
+			StoredBalance: acct.Balance(),
+			LedgerBalance: ledger,
+			Difference:    acct.Currency.Round(ledger - acct.Balance()),
+		}
+		if repair {
+			correction, err := svc.correctBalance(acct, ledger, userID)
+			if errors.Is(err, accounts.ErrConflict) || errors.Is(err, ErrSequenceConflict) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			d.Correction = &correction
+		}
+		return &d, nil
+	}
+	return nil, fmt.Errorf("%w: account %s kept changing while it was reconciled", accounts.ErrConflict, acctNum)
+}
+
+// ledgerBalance is the account's balance worked out from all of its transactions.
+func (svc *TransactionService) ledgerBalance(acct accounts.BankAccount) (float64, error) {
+	tans, err := svc.transactionStore.GetByAccountNumber(acct.AccountNumber)
+	if err != nil && !errors.Is(err, ErrTransactionNotFound) {
+		return 0, fmt.Errorf("error listing transactions %w", err)
+	}
+	balance := 0.0
+	for _, tan := range tans {
+		balance += tan.BalanceChange()
+	}
+	return acct.Currency.Round(balance), nil
+}
+
+func (svc *TransactionService) correctBalance(acct accounts.BankAccount, ledger float64, userID users.UserID) (Transaction, error) {
+	tanType, amt := CorrectionCredit, acct.Currency.Round(ledger-acct.Balance())
+	if amt < 0 {
+		tanType, amt = CorrectionDebit, -amt
+	}
+	tanID, err := NewRandTransactionID()
+	if err != nil {
+		return Transaction{}, fmt.Errorf("error generating transactionID %w", err)
+	}
+	ref := fmt.Sprintf("Balance corrected from %v to %v to match transactions", acct.Balance(), ledger)
+	tan, err := NewTransaction(tanID, acct.AccountNumber, userID, amt, acct.Currency, tanType, ref)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid transaction details %w", err)
+	}
+	acct = acct.CorrectBalance(ledger)
+	tan, err = svc.sequence(tan, acct)
+	if err != nil {
+		return Transaction{}, err
+	}
+	acct.Version++
+	err = svc.post([]Transaction{tan}, acct)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("error correcting balance %w", err)
+	}
+	return tan, nil
+}
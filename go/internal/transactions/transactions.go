@@ -2,67 +2,459 @@ package transactions
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/events"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 )
 
 type TransactionStore interface {
 	GetByTransactionID(tanID TransactionID) (Transaction, error)
 	GetByAccountNumber(acctNum accounts.AccountNumber) ([]Transaction, error)
-	Put(tan Transaction) error
+	// GetByAccountNumberBetween returns the account's transactions created at or after from and
+	// before to, oldest first. It returns an empty slice rather than ErrTransactionNotFound.
+	GetByAccountNumberBetween(acctNum accounts.AccountNumber, from, to time.Time) ([]Transaction, error)
+	// GetBySequenceRange returns the account's transactions numbered from to to inclusive, in
+	// sequence order. It returns an empty slice rather than ErrTransactionNotFound.
+	GetBySequenceRange(acctNum accounts.AccountNumber, from, to uint64) ([]Transaction, error)
+	// LastSequence is the sequence number of the account's latest transaction, zero if it has none.
+	LastSequence(acctNum accounts.AccountNumber) (uint64, error)
+	// Put stores a new transaction along with the events announcing it, all or nothing. It must have
+	// the account's next sequence number, otherwise Put returns ErrSequenceConflict.
+	Put(tan Transaction, evts ...events.Event) error
+	// PutAll is Put for several transactions at once, storing all of them or none.
+	PutAll(tans []Transaction, evts ...events.Event) error
 }
 
 type accountStore interface {
 	GetByAcctNum(acctNum accounts.AccountNumber) (accounts.BankAccount, error)
-	Put(acct accounts.BankAccount) error
+	List() ([]accounts.BankAccount, error)
+	Put(acct accounts.BankAccount, evts ...events.Event) error
+	PutAll(accts ...accounts.BankAccount) error
+	// PutAllWith checks the accounts' versions, then calls write and stores the accounts only if
+	// it succeeds, all as one unit.
+	PutAllWith(write func() error, accts ...accounts.BankAccount) error
+}
+
+// Converter converts an amount between currencies, returning the converted amount rounded to the
+// target currency's minor unit, the rate applied and the spread taken from it.
+type Converter interface {
+	Convert(amt float64, from, to accounts.Currency) (converted, rate, spread float64, err error)
 }
 
 type TransactionService struct {
 	transactionStore TransactionStore
 	acctStore        accountStore
+	converter        Converter
+	snapshotStore    BalanceSnapshotStore
+	executor         *accounts.Executor
+}
+
+type TransactionServiceOption func(*TransactionService)
+
+// WithConverter enables converting transactions requested in another currency. Without one they
+// fail with ErrConversionUnavailable.
+func WithConverter(converter Converter) TransactionServiceOption {
+	return func(svc *TransactionService) {
+		svc.converter = converter
+	}
+}
+
+// WithBalanceSnapshots stores daily closing balances taken by TakeBalanceSnapshots, so balance
+// histories only read the transactions since the last snapshot. Without it they are computed from
+// the whole transaction history.
+func WithBalanceSnapshots(store BalanceSnapshotStore) TransactionServiceOption {
+	return func(svc *TransactionService) {
+		svc.snapshotStore = store
+	}
+}
+
+// WithExecutor posts transactions as commands on the account's shard of the executor, so postings
+// to one account are applied one at a time in order while other accounts post in parallel. It
+// should be the executor the account service uses.
+func WithExecutor(exec *accounts.Executor) TransactionServiceOption {
+	return func(svc *TransactionService) {
+		svc.executor = exec
+	}
 }
 
-func NewTransactionService(tanStore TransactionStore, acctStore accountStore) *TransactionService {
-	return &TransactionService{transactionStore: tanStore, acctStore: acctStore}
+func NewTransactionService(tanStore TransactionStore, acctStore accountStore, opts ...TransactionServiceOption) *TransactionService {
+	svc := &TransactionService{transactionStore: tanStore, acctStore: acctStore}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+// CreateTransaction applies the transaction to the account. Frozen and dormant accounts refuse
+// debits, closed accounts refuse everything.
+func (svc *TransactionService) CreateTransaction(req CreateTransactionRequest, conds ...accounts.Precondition) (Transaction, error) {
+	return accounts.Submit(svc.executor, req.AccountNumber, func() (Transaction, error) {
+		acct, err := svc.fetchAccount(req.AccountNumber)
+		if err != nil {
+			return Transaction{}, err
+		}
+		err = accounts.CheckPreconditions(acct, conds...)
+		if err != nil {
+			return Transaction{}, err
+		}
+		tanID, err := NewRandTransactionID()
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error generating transactionID %w", err)
+		}
+		amt, opts, err := svc.convertToAccountCurrency(req, acct.Currency)
+		if err != nil {
+			return Transaction{}, err
+		}
+		tan, err := NewTransaction(tanID, req.AccountNumber, req.UserID, amt, acct.Currency, req.Type, req.Reference, opts...)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid transaction details %w", err)
+		}
+
+		newAcct := acct
+		switch tan.Type {
+		case Deposit, AdjustmentCredit:
+			newAcct, err = newAcct.Deposit(tan.Amount)
+		case Withdrawal:
+			newAcct, err = newAcct.Withdraw(tan.Amount)
+		case AdjustmentDebit:
+			newAcct, err = newAcct.Debit(tan.Amount)
+		default:
+			// Conversions, interest, pot transfers and corrections are posted by their own methods.
+			err = fmt.Errorf("%w: %s", ErrInvalidTransactionType, tan.Type)
+		}
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error processing transaction %w", err)
+		}
+		if tan.Type.isCustomer() {
+			newAcct = newAcct.RecordCustomerActivity(tan.CreatedTimestamp)
+		}
+
+		tan, err = svc.sequence(tan, newAcct)
+		if err != nil {
+			return Transaction{}, err
+		}
+		newAcct.Version++
+		err = preconditionError(svc.post([]Transaction{tan}, newAcct), conds)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error processing transaction %w", err)
+		}
+		return tan, nil
+	})
 }
 
-func (svc *TransactionService) CreateTransaction(req CreateTransactionRequest) (Transaction, error) {
-	acct, err := svc.acctStore.GetByAcctNum(req.AccountNumber)
+func (svc *TransactionService) convertToAccountCurrency(req CreateTransactionRequest, acctCurr accounts.Currency) (float64, []TransactionOption, error) {
+	if req.Currency == acctCurr {
+		return req.Amount, nil, nil
+	}
+	if !req.Convert {
+		return 0, nil, ErrCurrencyMismatch
+	}
+	if svc.converter == nil {
+		return 0, nil, ErrConversionUnavailable
+	}
+	amt, rate, spread, err := svc.converter.Convert(req.Amount, req.Currency, acctCurr)
 	if err != nil {
-		if errors.Is(err, accounts.ErrAccountNotFound) {
+		return 0, nil, fmt.Errorf("error converting %s to %s %w", req.Currency, acctCurr, err)
+	}
+	conversion := Conversion{Amount: req.Amount, Currency: req.Currency, Rate: rate, Spread: spread}
+	return amt, []TransactionOption{WithConversion(conversion)}, nil
+}
+
+// CreateConversion moves money between two accounts in different currencies as a debit from one
+// and a credit to the other. Both legs are checked before anything is stored, and both legs and
+// account balances are written as one unit so neither leg is applied without the other.
+func (svc *TransactionService) CreateConversion(req CreateConversionRequest) (Transaction, Transaction, error) {
+	if !req.IsValid() {
+		return Transaction{}, Transaction{}, fmt.Errorf("invalid create conversion request %+v", req)
+	}
+	var debit, credit Transaction
+	err := svc.executor.DoAll([]accounts.AccountNumber{req.FromAccount, req.ToAccount}, func() error {
+		var err error
+		debit, credit, err = svc.createConversion(req)
+		return err
+	})
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+	return debit, credit, nil
+}
+
+func (svc *TransactionService) createConversion(req CreateConversionRequest) (Transaction, Transaction, error) {
+	fromAcct, err := svc.fetchAccount(req.FromAccount)
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+	toAcct, err := svc.fetchAccount(req.ToAccount)
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+	if fromAcct.Currency != req.FromCurrency || toAcct.Currency != req.ToCurrency {
+		return Transaction{}, Transaction{}, ErrCurrencyMismatch
+	}
+
+	debit, err := svc.newConversionLeg(req.FromAccount, req, ConversionDebit, req.FromAmount, req.FromCurrency, Conversion{
+		Amount: req.ToAmount, Currency: req.ToCurrency, Rate: req.Rate, Spread: req.Spread,
+	})
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+	credit, err := svc.newConversionLeg(req.ToAccount, req, ConversionCredit, req.ToAmount, req.ToCurrency, Conversion{
+		Amount: req.FromAmount, Currency: req.FromCurrency, Rate: req.Rate, Spread: req.Spread,
+	})
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+
+	newFromAcct, err := fromAcct.Withdraw(req.FromAmount)
+	if err != nil {
+		return Transaction{}, Transaction{}, fmt.Errorf("error processing conversion %w", err)
+	}
+	newToAcct, err := toAcct.Deposit(req.ToAmount)
+	if err != nil {
+		return Transaction{}, Transaction{}, fmt.Errorf("error processing conversion %w", err)
+	}
+	newFromAcct = newFromAcct.RecordCustomerActivity(debit.CreatedTimestamp)
+	newToAcct = newToAcct.RecordCustomerActivity(credit.CreatedTimestamp)
+	debit, err = svc.sequence(debit, newFromAcct)
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+	credit, err = svc.sequence(credit, newToAcct)
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+
+	newFromAcct.Version++
+	newToAcct.Version++
+	err = svc.post([]Transaction{debit, credit}, newFromAcct, newToAcct)
+	if err != nil {
+		return Transaction{}, Transaction{}, fmt.Errorf("error processing conversion %w", err)
+	}
+	return debit, credit, nil
+}
+
+// ChargeOverdraftInterest charges the overdraft interest accrued on every account as an
+// OverdraftInterest transaction. It is run once a month, accounts with nothing accrued since the
+// last run are skipped so running it twice does not charge twice.
+func (svc *TransactionService) ChargeOverdraftInterest() ([]Transaction, error) {
+	accts, err := svc.acctStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("error listing accounts %w", err)
+	}
+	var charged []Transaction
+	for _, listed := range accts {
+		err = svc.executor.Do(listed.AccountNumber, func() error {
+			acct, err := svc.fetchAccount(listed.AccountNumber)
+			if err != nil || acct.AccruedOverdraftInterest() == 0 {
+				return err
+			}
+			acct, amt := acct.ChargeOverdraftInterest()
+			var tans []Transaction
+			if amt > 0 {
+				tanID, err := NewRandTransactionID()
+				if err != nil {
+					return fmt.Errorf("error generating transactionID %w", err)
+				}
+				tan, err := NewTransaction(tanID, acct.AccountNumber, SystemUserID, amt, acct.Currency, OverdraftInterest, "Overdraft interest")
+				if err != nil {
+					return fmt.Errorf("invalid transaction details %w", err)
+				}
+				tan, err = svc.sequence(tan, acct)
+				if err != nil {
+					return err
+				}
+				tans = append(tans, tan)
+			}
+			acct.Version++
+			err = svc.post(tans, acct)
+			if err != nil {
+				return fmt.Errorf("error charging overdraft interest %w", err)
+			}
+			charged = append(charged, tans...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return charged, nil
+}
+
+// PostInterest pays credit interest into the account as an Interest transaction.
+func (svc *TransactionService) PostInterest(acctNum accounts.AccountNumber, amt float64, ref string) (Transaction, error) {
+	return accounts.Submit(svc.executor, acctNum, func() (Transaction, error) {
+		acct, err := svc.fetchAccount(acctNum)
+		if err != nil {
+			return Transaction{}, err
+		}
+		tanID, err := NewRandTransactionID()
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error generating transactionID %w", err)
+		}
+		tan, err := NewTransaction(tanID, acctNum, SystemUserID, amt, acct.Currency, Interest, ref)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid transaction details %w", err)
+		}
+		acct, err = acct.Deposit(amt)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error posting interest %w", err)
+		}
+		tan, err = svc.sequence(tan, acct)
+		if err != nil {
+			return Transaction{}, err
+		}
+		acct.Version++
+		err = svc.post([]Transaction{tan}, acct)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error posting interest %w", err)
+		}
+		return tan, nil
+	})
+}
+
+// TransferPot moves money into or out of one of the account's pots, recording it as a ToPot or
+// FromPot transaction. The account's balance is unchanged.
+func (svc *TransactionService) TransferPot(req PotTransferRequest, conds ...accounts.Precondition) (Transaction, error) {
+	return accounts.Submit(svc.executor, req.AccountNumber, func() (Transaction, error) {
+		if !req.IsValid() {
+			return Transaction{}, fmt.Errorf("invalid pot transfer request %+v", req)
+		}
+		acct, err := svc.fetchAccount(req.AccountNumber)
+		if err != nil {
+			return Transaction{}, err
+		}
+		err = accounts.CheckPreconditions(acct, conds...)
+		if err != nil {
+			return Transaction{}, err
+		}
+		tanID, err := NewRandTransactionID()
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error generating transactionID %w", err)
+		}
+		tan, err := NewTransaction(tanID, req.AccountNumber, req.UserID, req.Amount, acct.Currency, req.Type, req.Reference, WithPot(req.PotID))
+		if err != nil {
+			return Transaction{}, fmt.Errorf("invalid transaction details %w", err)
+		}
+		if req.Type == ToPot {
+			acct, _, err = acct.MoveToPot(req.PotID, req.Amount)
+		} else {
+			acct, _, err = acct.MoveFromPot(req.PotID, req.Amount)
+		}
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error processing pot transfer %w", err)
+		}
+		acct = acct.RecordCustomerActivity(tan.CreatedTimestamp)
+
+		tan, err = svc.sequence(tan, acct)
+		if err != nil {
 			return Transaction{}, err
 		}
-		return Transaction{}, fmt.Errorf("error fetching account %w", err)
+		acct.Version++
+		err = preconditionError(svc.post([]Transaction{tan}, acct), conds)
+		if err != nil {
+			return Transaction{}, fmt.Errorf("error processing pot transfer %w", err)
+		}
+		return tan, nil
+	})
+}
+
+// ListPotTransactions returns the transfers into and out of one pot.
+func (svc *TransactionService) ListPotTransactions(acctNum accounts.AccountNumber, potID accounts.PotID) ([]Transaction, error) {
+	tans, err := svc.ListTransactions(acctNum)
+	if err != nil {
+		return nil, err
+	}
+	potTans := []Transaction{}
+	for _, tan := range tans {
+		if tan.PotID == potID {
+			potTans = append(potTans, tan)
+		}
 	}
+	return potTans, nil
+}
+
+func (svc *TransactionService) newConversionLeg(acctNum accounts.AccountNumber, req CreateConversionRequest, tanType TransactionType, amt float64, curr accounts.Currency, conversion Conversion) (Transaction, error) {
 	tanID, err := NewRandTransactionID()
 	if err != nil {
 		return Transaction{}, fmt.Errorf("error generating transactionID %w", err)
 	}
-	tan, err := NewTransaction(tanID, req.AccountNumber, req.UserID, req.Amount, req.Currency, req.Type, req.Reference)
+	tan, err := NewTransaction(tanID, acctNum, req.UserID, amt, curr, tanType, req.Reference, WithConversion(conversion))
 	if err != nil {
 		return Transaction{}, fmt.Errorf("invalid transaction details %w", err)
 	}
+	return tan, nil
+}
 
-	newAcct := acct
-	switch tan.Type {
-	case Deposit:
-		newAcct, err = newAcct.Deposit(tan.Amount)
-	case Withdrawal:
-		newAcct, err = newAcct.Withdraw(tan.Amount)
-	}
+// sequence gives the transaction the account's next sequence number and the balance the account has
+// once it is applied. The store refuses a sequence number which has already been taken, so of two
+// postings racing on the same account only one is stored and the other fails with
+// ErrSequenceConflict.
+func (svc *TransactionService) sequence(tan Transaction, acct accounts.BankAccount) (Transaction, error) {
+	last, err := svc.transactionStore.LastSequence(tan.AccountNumber)
 	if err != nil {
-		return Transaction{}, fmt.Errorf("error processing transaction %w", err)
+		return Transaction{}, fmt.Errorf("error fetching sequence number %w", err)
 	}
+	tan.Sequence = last + 1
+	tan.BalanceAfter = acct.Balance()
+	return tan, nil
+}
 
-	err = svc.transactionStore.Put(tan)
-	if err != nil {
-		return Transaction{}, fmt.Errorf("error processing transaction %w", err)
+// post stores the transactions and the accounts they were applied to as one unit, along with a
+// TransactionPosted event for each transaction. The accounts' versions are checked before anything
+// is written, and the transactions and their events are stored under the account store's lock, so
+// either all of it is stored or none of it is.
+func (svc *TransactionService) post(tans []Transaction, accts ...accounts.BankAccount) error {
+	evts := make([]events.Event, 0, len(tans))
+	for _, tan := range tans {
+		i := slices.IndexFunc(accts, func(acct accounts.BankAccount) bool { return acct.AccountNumber == tan.AccountNumber })
+		evt, err := postedEvent(tan, accts[i])
+		if err != nil {
+			return err
+		}
+		evts = append(evts, evt)
+	}
+	return svc.acctStore.PutAllWith(func() error {
+		return svc.transactionStore.PutAll(tans, evts...)
+	}, accts...)
+}
+
+// postedEvent announces the transaction to the holders of the account it was applied to.
+func postedEvent(tan Transaction, acct accounts.BankAccount) (events.Event, error) {
+	var holders []string
+	for _, id := range acct.HolderIDs() {
+		holders = append(holders, id.String())
 	}
-	err = svc.acctStore.Put(newAcct)
+	return events.NewEvent(events.TransactionPosted, tan.AccountNumber.String(), holders, events.TransactionData{
+		TransactionID: tan.ID.String(),
+		AccountNumber: tan.AccountNumber.String(),
+		Type:          tan.Type.String(),
+		Amount:        tan.Amount,
+		Currency:      tan.Currency.String(),
+		Sequence:      tan.Sequence,
+		BalanceAfter:  tan.BalanceAfter,
+	})
+}
+
+// preconditionError is accounts.PreconditionError, also counting a transaction which lost the race
+// for its sequence number as the account having moved on.
+func preconditionError(err error, conds []accounts.Precondition) error {
+	if len(conds) > 0 && errors.Is(err, ErrSequenceConflict) {
+		return accounts.ErrPreconditionFailed
+	}
+	return accounts.PreconditionError(err, conds...)
+}
+
+func (svc *TransactionService) fetchAccount(acctNum accounts.AccountNumber) (accounts.BankAccount, error) {
+	acct, err := svc.acctStore.GetByAcctNum(acctNum)
 	if err != nil {
-		return Transaction{}, fmt.Errorf("error processing transaction %w", err)
+		if errors.Is(err, accounts.ErrAccountNotFound) {
+			return accounts.BankAccount{}, err
+		}
+		return accounts.BankAccount{}, fmt.Errorf("error fetching account %w", err)
 	}
-	return tan, nil
+	return acct, nil
 }
 
 func (svc *TransactionService) ListTransactions(acctNum accounts.AccountNumber) ([]Transaction, error) {
@@ -76,6 +468,19 @@ func (svc *TransactionService) ListTransactions(acctNum accounts.AccountNumber)
 	return tans, nil
 }
 
+// ListTransactionsBySequence returns the account's transactions numbered from to to inclusive, so
+// clients can fetch only what they have not seen and notice gaps.
+func (svc *TransactionService) ListTransactionsBySequence(acctNum accounts.AccountNumber, from, to uint64) ([]Transaction, error) {
+	if from == 0 || to < from {
+		return nil, fmt.Errorf("%w: %d to %d", ErrInvalidSequenceRange, from, to)
+	}
+	tans, err := svc.transactionStore.GetBySequenceRange(acctNum, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error listing transactions %w", err)
+	}
+	return tans, nil
+}
+
 func (svc *TransactionService) FetchTransaction(acctNum accounts.AccountNumber, tanID TransactionID) (Transaction, error) {
 	tan, err := svc.transactionStore.GetByTransactionID(tanID)
 	if err != nil {
@@ -3,10 +3,20 @@ package transactions_test
 import (
 	"eaglebank/internal/accounts"
 	adapters2 "eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/events"
+	adapters4 "eaglebank/internal/events/adapters"
+	"eaglebank/internal/fx"
+	"eaglebank/internal/limits"
 	"eaglebank/internal/transactions"
 	"eaglebank/internal/transactions/adapters"
 	"eaglebank/internal/users"
+	adapters3 "eaglebank/internal/users/adapters"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,7 +24,7 @@ import (
 
 func TestCreateTransaction(t *testing.T) {
 	acctStore := adapters2.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123", "usr-1234"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
 
 	tanStore := adapters.NewInMemoryTransactionStore()
 	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
@@ -59,7 +69,7 @@ func TestCreateTransaction(t *testing.T) {
 	})
 	t.Run("should fail if account doesn't exist", func(t *testing.T) {
 		_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
-			AccountNumber: "01000000",
+			AccountNumber: "01000020",
 			UserID:        userID,
 			Amount:        10,
 			Currency:      accounts.GBP,
@@ -74,7 +84,7 @@ func TestCreateTransaction(t *testing.T) {
 
 		_, err = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
 			AccountNumber: preWithdrawAcct.AccountNumber,
-			UserID:        preWithdrawAcct.UserID,
+			UserID:        preWithdrawAcct.PrimaryHolder(),
 			Amount:        preWithdrawAcct.Balance() * 2,
 			Currency:      accounts.GBP,
 			Type:          transactions.Withdrawal,
@@ -89,27 +99,885 @@ func TestCreateTransaction(t *testing.T) {
 	t.Run("should fail deposit if account above limit", func(t *testing.T) {
 		preDepositAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
 		require.NoError(t, err)
-		require.Greater(t, preDepositAcct.Balance()+accounts.BalanceMax, accounts.BalanceMax)
+		maxBalance := preDepositAcct.Limits.MaxBalance
+		require.Greater(t, preDepositAcct.Balance()+maxBalance, maxBalance)
 
 		_, err = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
 			AccountNumber: preDepositAcct.AccountNumber,
-			UserID:        preDepositAcct.UserID,
-			Amount:        accounts.BalanceMax,
+			UserID:        preDepositAcct.PrimaryHolder(),
+			Amount:        maxBalance,
 			Currency:      accounts.GBP,
 			Type:          transactions.Deposit,
 			Reference:     "too much money",
 		})
-		assert.ErrorIs(t, err, accounts.ErrTooManyFunds)
+		assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+		var exceeded *limits.ExceededError
+		require.ErrorAs(t, err, &exceeded)
+		assert.Equal(t, limits.MaxBalance, exceeded.Limit)
 
 		postDepositAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
 		require.NoError(t, err)
 		assert.Equal(t, preDepositAcct, postDepositAcct)
 	})
+	t.Run("should fail withdraw into held funds", func(t *testing.T) {
+		preHoldAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		require.Greater(t, preHoldAcct.Balance(), 1.0)
+		_, err = acctSvc.PlaceHold(acct.AccountNumber, preHoldAcct.Balance()-1, accounts.LegalHold, time.Now().Add(time.Hour), "usr-teller")
+		require.NoError(t, err)
+
+		_, err = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber,
+			UserID:        userID,
+			Amount:        1.01,
+			Currency:      accounts.GBP,
+			Type:          transactions.Withdrawal,
+			Reference:     "held",
+		})
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+	})
+	t.Run("should fail for a type which is posted some other way", func(t *testing.T) {
+		preAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+
+		for _, tanType := range []transactions.TransactionType{transactions.Interest, transactions.OverdraftInterest, transactions.CorrectionCredit} {
+			_, err = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+				AccountNumber: acct.AccountNumber,
+				UserID:        userID,
+				Amount:        1,
+				Currency:      accounts.GBP,
+				Type:          tanType,
+				Reference:     "unposted",
+			})
+			assert.ErrorIs(t, err, transactions.ErrInvalidTransactionType, tanType)
+		}
+
+		postAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, preAcct, postAcct)
+	})
+}
+
+func TestCreateTransactionStatus(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters.NewInMemoryTransactionStore(), acctStore)
+
+	userID := users.MustNewUserID("usr-123")
+	newAccount := func(t *testing.T, status accounts.AccountStatus) accounts.BankAccount {
+		t.Helper()
+		acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: userID, Name: "Mr Foo", AccountType: accounts.CurrentAcct})
+		require.NoError(t, err)
+		if status != accounts.ActiveStatus {
+			acct, err = acctSvc.ChangeAccountStatus(acct.AccountNumber, status, accounts.LegalOrderReason, "usr-teller")
+			require.NoError(t, err)
+		}
+		return acct
+	}
+	transact := func(acctNum accounts.AccountNumber, tanType transactions.TransactionType) error {
+		_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acctNum, UserID: userID, Amount: 10, Currency: accounts.GBP, Type: tanType,
+		})
+		return err
+	}
+
+	t.Run("should take deposits but refuse withdrawals on a frozen account", func(t *testing.T) {
+		acct := newAccount(t, accounts.FrozenStatus)
+		require.NoError(t, transact(acct.AccountNumber, transactions.Deposit))
+		assert.ErrorIs(t, transact(acct.AccountNumber, transactions.Withdrawal), accounts.ErrAccountFrozen)
+		assert.ErrorIs(t, transact(acct.AccountNumber, transactions.AdjustmentDebit), accounts.ErrAccountFrozen)
+	})
+	t.Run("should refuse withdrawals on a dormant account", func(t *testing.T) {
+		acct := newAccount(t, accounts.DormantStatus)
+		require.NoError(t, transact(acct.AccountNumber, transactions.Deposit))
+		assert.ErrorIs(t, transact(acct.AccountNumber, transactions.Withdrawal), accounts.ErrAccountDormant)
+	})
+	t.Run("should refuse everything on a closed account", func(t *testing.T) {
+		acct := newAccount(t, accounts.ClosedStatus)
+		assert.ErrorIs(t, transact(acct.AccountNumber, transactions.Deposit), accounts.ErrAccountClosed)
+		assert.ErrorIs(t, transact(acct.AccountNumber, transactions.AdjustmentCredit), accounts.ErrAccountClosed)
+	})
+	t.Run("should only count customer transactions as activity", func(t *testing.T) {
+		acct := newAccount(t, accounts.ActiveStatus)
+		require.NoError(t, transact(acct.AccountNumber, transactions.AdjustmentCredit))
+		gotAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, acct.CreatedTimestamp, gotAcct.LastCustomerActivity())
+
+		require.NoError(t, transact(acct.AccountNumber, transactions.Withdrawal))
+		gotAcct, err = acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.True(t, gotAcct.LastCustomerActivity().After(acct.CreatedTimestamp))
+	})
+}
+
+func TestCreateTransactionCurrency(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	converter := fx.NewConverter(fx.RateTable{Base: accounts.GBP, Rates: map[accounts.Currency]float64{accounts.EUR: 1.25}}, 0)
+	tanSvc := transactions.NewTransactionService(adapters.NewInMemoryTransactionStore(), acctStore, transactions.WithConverter(converter))
+
+	userID := users.MustNewUserID("usr-123")
+	acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{
+		UserID:      userID,
+		Name:        "Mr Foo",
+		AccountType: accounts.PersonalAcct,
+		Currency:    accounts.EUR,
+	})
+	require.NoError(t, err)
+	require.Equal(t, accounts.EUR, acct.Currency)
+	deposit := func(amt float64, curr accounts.Currency, convert bool) transactions.CreateTransactionRequest {
+		return transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber,
+			UserID:        userID,
+			Amount:        amt,
+			Currency:      curr,
+			Type:          transactions.Deposit,
+			Convert:       convert,
+		}
+	}
+
+	t.Run("should accept transaction in the account currency", func(t *testing.T) {
+		tan, err := tanSvc.CreateTransaction(deposit(10, accounts.EUR, false))
+		require.NoError(t, err)
+		assert.Equal(t, accounts.EUR, tan.Currency)
+		assert.True(t, tan.Conversion.IsZero())
+	})
+	t.Run("should reject transaction in another currency without conversion", func(t *testing.T) {
+		_, err := tanSvc.CreateTransaction(deposit(10, accounts.GBP, false))
+		assert.ErrorIs(t, err, transactions.ErrCurrencyMismatch)
+	})
+	t.Run("should convert transaction in another currency when requested", func(t *testing.T) {
+		tan, err := tanSvc.CreateTransaction(deposit(10, accounts.GBP, true))
+		require.NoError(t, err)
+		assert.Equal(t, 12.5, tan.Amount)
+		assert.Equal(t, accounts.EUR, tan.Currency)
+		assert.Equal(t, transactions.Conversion{Amount: 10, Currency: accounts.GBP, Rate: 1.25}, tan.Conversion)
+
+		gotAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 22.5, gotAcct.Balance())
+	})
+	t.Run("should reject conversion without a rate", func(t *testing.T) {
+		_, err := tanSvc.CreateTransaction(deposit(10, accounts.USD, true))
+		assert.ErrorIs(t, err, transactions.ErrConversionUnavailable)
+	})
+	t.Run("should reject conversion without a converter", func(t *testing.T) {
+		noConvSvc := transactions.NewTransactionService(adapters.NewInMemoryTransactionStore(), acctStore)
+		_, err := noConvSvc.CreateTransaction(deposit(10, accounts.GBP, true))
+		assert.ErrorIs(t, err, transactions.ErrConversionUnavailable)
+	})
+	t.Run("should reject amounts smaller than the currency's minor unit", func(t *testing.T) {
+		_, err := tanSvc.CreateTransaction(deposit(10.001, accounts.EUR, false))
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateConversion(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanStore := adapters.NewInMemoryTransactionStore()
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+
+	userID := users.MustNewUserID("usr-123")
+	newAccount := func(t *testing.T, curr accounts.Currency) accounts.BankAccount {
+		t.Helper()
+		acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: userID, Name: "Mr Foo", AccountType: accounts.PersonalAcct, Currency: curr})
+		require.NoError(t, err)
+		return acct
+	}
+	gbpAcct := newAccount(t, accounts.GBP)
+	eurAcct := newAccount(t, accounts.EUR)
+	_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+		AccountNumber: gbpAcct.AccountNumber, UserID: userID, Amount: 100, Currency: accounts.GBP, Type: transactions.Deposit,
+	})
+	require.NoError(t, err)
+	conversion := func(fromAmt float64) transactions.CreateConversionRequest {
+		return transactions.CreateConversionRequest{
+			UserID:       userID,
+			FromAccount:  gbpAcct.AccountNumber,
+			ToAccount:    eurAcct.AccountNumber,
+			FromAmount:   fromAmt,
+			FromCurrency: accounts.GBP,
+			ToAmount:     eurAcct.Currency.Round(fromAmt * 1.2),
+			ToCurrency:   accounts.EUR,
+			Rate:         1.2,
+			Spread:       0.01,
+			Reference:    "holiday money",
+		}
+	}
+
+	t.Run("should debit one account and credit the other", func(t *testing.T) {
+		debit, credit, err := tanSvc.CreateConversion(conversion(50))
+		require.NoError(t, err)
+
+		assert.Equal(t, transactions.ConversionDebit, debit.Type)
+		assert.Equal(t, gbpAcct.AccountNumber, debit.AccountNumber)
+		assert.Equal(t, 50.0, debit.Amount)
+		assert.Equal(t, accounts.GBP, debit.Currency)
+		assert.Equal(t, transactions.Conversion{Amount: 60, Currency: accounts.EUR, Rate: 1.2, Spread: 0.01}, debit.Conversion)
+
+		assert.Equal(t, transactions.ConversionCredit, credit.Type)
+		assert.Equal(t, eurAcct.AccountNumber, credit.AccountNumber)
+		assert.Equal(t, 60.0, credit.Amount)
+		assert.Equal(t, accounts.EUR, credit.Currency)
+		assert.Equal(t, transactions.Conversion{Amount: 50, Currency: accounts.GBP, Rate: 1.2, Spread: 0.01}, credit.Conversion)
+
+		gotGBP, err := acctSvc.FetchAccount(gbpAcct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, gotGBP.Balance())
+		gotEUR, err := acctSvc.FetchAccount(eurAcct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 60.0, gotEUR.Balance())
+
+		for _, tan := range []transactions.Transaction{debit, credit} {
+			gotTan, err := tanStore.GetByTransactionID(tan.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tan, gotTan)
+		}
+	})
+	t.Run("should apply neither leg if the debit fails", func(t *testing.T) {
+		_, _, err := tanSvc.CreateConversion(conversion(1000))
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+
+		gotEUR, err := acctSvc.FetchAccount(eurAcct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 60.0, gotEUR.Balance())
+		tans, err := tanSvc.ListTransactions(eurAcct.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, tans, 1)
+	})
+	t.Run("should store neither leg if storing the credit fails", func(t *testing.T) {
+		failing := transactions.NewTransactionService(failingCreditStore{tanStore}, acctStore)
+		before := make(map[accounts.AccountNumber]accounts.BankAccount)
+		for _, acctNum := range []accounts.AccountNumber{gbpAcct.AccountNumber, eurAcct.AccountNumber} {
+			before[acctNum], err = acctSvc.FetchAccount(acctNum)
+			require.NoError(t, err)
+		}
+		beforeGBPTans, err := tanSvc.ListTransactions(gbpAcct.AccountNumber)
+		require.NoError(t, err)
+
+		_, _, err = failing.CreateConversion(conversion(10))
+		assert.ErrorIs(t, err, errCreditFailed)
+
+		for acctNum, acct := range before {
+			got, err := acctSvc.FetchAccount(acctNum)
+			require.NoError(t, err)
+			assert.Equal(t, acct, got)
+		}
+		tans, err := tanSvc.ListTransactions(gbpAcct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, beforeGBPTans, tans)
+		tans, err = tanSvc.ListTransactions(eurAcct.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, tans, 1)
+	})
+	t.Run("should reject currencies that do not match the accounts", func(t *testing.T) {
+		req := conversion(10)
+		req.FromAccount, req.ToAccount = req.ToAccount, req.FromAccount
+		_, _, err := tanSvc.CreateConversion(req)
+		assert.ErrorIs(t, err, transactions.ErrCurrencyMismatch)
+	})
+	t.Run("should reject conversion into the same account", func(t *testing.T) {
+		req := conversion(10)
+		req.ToAccount = req.FromAccount
+		_, _, err := tanSvc.CreateConversion(req)
+		assert.Error(t, err)
+	})
+}
+
+func TestChargeOverdraftInterest(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanStore := adapters.NewInMemoryTransactionStore()
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+
+	userID := users.MustNewUserID("usr-123")
+	acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: userID, Name: "Mr Foo", AccountType: accounts.CurrentAcct})
+	require.NoError(t, err)
+	app, err := acctSvc.ApplyForOverdraft(acct.AccountNumber, userID, 1000)
+	require.NoError(t, err)
+	_, err = acctSvc.ApproveOverdraft(app.ID, "usr-teller")
+	require.NoError(t, err)
+	_, err = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+		AccountNumber: acct.AccountNumber, UserID: userID, Amount: 365, Currency: accounts.GBP, Type: transactions.Withdrawal,
+	})
+	require.NoError(t, err)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 31 {
+		require.NoError(t, acctSvc.AccrueOverdraftInterest(day.AddDate(0, 0, i)))
+	}
+
+	t.Run("should charge accrued interest as a transaction", func(t *testing.T) {
+		charged, err := tanSvc.ChargeOverdraftInterest()
+		require.NoError(t, err)
+		require.Len(t, charged, 1)
+		want := accounts.GBP.Round(31 * 365 * accounts.DefaultOverdraftRate / 365)
+		assert.Equal(t, transactions.OverdraftInterest, charged[0].Type)
+		assert.Equal(t, transactions.SystemUserID, charged[0].UserID)
+		assert.Equal(t, want, charged[0].Amount)
+
+		gotAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, accounts.GBP.Round(-365-want), gotAcct.Balance())
+		assert.Equal(t, 0.0, gotAcct.AccruedOverdraftInterest())
+	})
+	t.Run("should not charge twice for the same accrual", func(t *testing.T) {
+		charged, err := tanSvc.ChargeOverdraftInterest()
+		require.NoError(t, err)
+		assert.Empty(t, charged)
+
+		tans, err := tanSvc.ListTransactions(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, tans, 2)
+	})
+}
+
+func TestPostInterest(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters.NewInMemoryTransactionStore(), acctStore)
+
+	acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.SavingsAcct})
+	require.NoError(t, err)
+
+	t.Run("should credit interest from the bank", func(t *testing.T) {
+		tan, err := tanSvc.PostInterest(acct.AccountNumber, 1.23, "Interest 2026-03")
+		require.NoError(t, err)
+		assert.Equal(t, transactions.Interest, tan.Type)
+		assert.Equal(t, transactions.SystemUserID, tan.UserID)
+		assert.Equal(t, "Interest 2026-03", tan.Reference)
+
+		gotAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 1.23, gotAcct.Balance())
+	})
+	t.Run("should error for an unknown account", func(t *testing.T) {
+		_, err := tanSvc.PostInterest("01999990", 1.23, "Interest 2026-03")
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+	})
+}
+
+func TestTransferPot(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters.NewInMemoryTransactionStore(), acctStore)
+
+	acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+	require.NoError(t, err)
+	_, err = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+		AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 100, Currency: accounts.GBP, Type: transactions.Deposit,
+	})
+	require.NoError(t, err)
+	holiday, err := acctSvc.CreatePot(acct.AccountNumber, "Holiday", 500, time.Time{})
+	require.NoError(t, err)
+	rainyDay, err := acctSvc.CreatePot(acct.AccountNumber, "Rainy day", 0, time.Time{})
+	require.NoError(t, err)
+
+	transfer := func(potID accounts.PotID, amt float64, tanType transactions.TransactionType) (transactions.Transaction, error) {
+		return tanSvc.TransferPot(transactions.PotTransferRequest{
+			AccountNumber: acct.AccountNumber, PotID: potID, UserID: "usr-123", Amount: amt, Type: tanType,
+		})
+	}
+
+	t.Run("should move money into a pot without changing the balance", func(t *testing.T) {
+		tan, err := transfer(holiday.ID, 60, transactions.ToPot)
+		require.NoError(t, err)
+		assert.Equal(t, holiday.ID, tan.PotID)
+
+		gotAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, gotAcct.Balance())
+		assert.Equal(t, 40.0, gotAcct.MainBalance())
+		assert.Equal(t, 40.0, gotAcct.AvailableBalance())
+	})
+	t.Run("should refuse to move more than the main balance", func(t *testing.T) {
+		_, err := transfer(rainyDay.ID, 40.01, transactions.ToPot)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+	})
+	t.Run("should move money back out of a pot", func(t *testing.T) {
+		_, err := transfer(holiday.ID, 60.01, transactions.FromPot)
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+		_, err = transfer(holiday.ID, 10, transactions.FromPot)
+		require.NoError(t, err)
+
+		pot, err := acctSvc.FetchPot(acct.AccountNumber, holiday.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, pot.Balance)
+	})
+	t.Run("should list each pot's transfers", func(t *testing.T) {
+		_, err := transfer(rainyDay.ID, 5, transactions.ToPot)
+		require.NoError(t, err)
+
+		tans, err := tanSvc.ListPotTransactions(acct.AccountNumber, holiday.ID)
+		require.NoError(t, err)
+		require.Len(t, tans, 2)
+		assert.Equal(t, transactions.ToPot, tans[0].Type)
+		assert.Equal(t, transactions.FromPot, tans[1].Type)
+
+		all, err := tanSvc.ListTransactions(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Len(t, all, 4)
+	})
+	t.Run("should not withdraw money in pots", func(t *testing.T) {
+		_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 50, Currency: accounts.GBP, Type: transactions.Withdrawal,
+		})
+		assert.ErrorIs(t, err, accounts.ErrInsufficientFunds)
+	})
+	t.Run("should reject pot transfers through CreateTransaction", func(t *testing.T) {
+		_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 1, Currency: accounts.GBP, Type: transactions.ToPot,
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestBalanceHistory(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanStore := adapters.NewInMemoryTransactionStore()
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	daysAgo := func(n int) time.Time { return today.AddDate(0, 0, -n) }
+	acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+	require.NoError(t, err)
+	acct.CreatedTimestamp = daysAgo(10)
+	acct.Version++
+	require.NoError(t, acctStore.Put(acct))
+
+	var seq uint64
+	post := func(t *testing.T, created time.Time, tanType transactions.TransactionType, amt float64) {
+		t.Helper()
+		seq++
+		tanID, err := transactions.NewRandTransactionID()
+		require.NoError(t, err)
+		var opts []transactions.TransactionOption
+		if tanType == transactions.ToPot {
+			opts = append(opts, transactions.WithPot("pot-123"))
+		}
+		tan, err := transactions.NewTransaction(tanID, acct.AccountNumber, "usr-123", amt, accounts.GBP, tanType, "", opts...)
+		require.NoError(t, err)
+		tan.CreatedTimestamp = created
+		tan.Sequence = seq
+		require.NoError(t, tanStore.Put(tan))
+	}
+	post(t, daysAgo(5).Add(9*time.Hour), transactions.Deposit, 100)
+	post(t, daysAgo(5).Add(17*time.Hour), transactions.Withdrawal, 30)
+	post(t, daysAgo(3).Add(12*time.Hour), transactions.Deposit, 50)
+	post(t, daysAgo(3).Add(13*time.Hour), transactions.ToPot, 20)
+	post(t, daysAgo(2).Add(8*time.Hour), transactions.OverdraftInterest, 0.5)
+	post(t, time.Now(), transactions.Interest, 10.25)
+
+	t.Run("should return daily opening and closing balances", func(t *testing.T) {
+		history, err := tanSvc.BalanceHistory(acct.AccountNumber, daysAgo(6), today, transactions.DayInterval)
+		require.NoError(t, err)
+		require.Len(t, history, 7)
+		want := [][2]float64{{0, 0}, {0, 70}, {70, 70}, {70, 120}, {120, 119.5}, {119.5, 119.5}, {119.5, 129.75}}
+		for i, b := range history {
+			assert.Equal(t, daysAgo(6-i), b.Start)
+			assert.Equal(t, b.Start, b.End)
+			assert.Equal(t, want[i], [2]float64{b.Opening, b.Closing}, b.Start)
+		}
+	})
+	t.Run("should split longer intervals on calendar boundaries", func(t *testing.T) {
+		for _, interval := range []transactions.Interval{transactions.WeekInterval, transactions.MonthInterval} {
+			history, err := tanSvc.BalanceHistory(acct.AccountNumber, daysAgo(40), today, interval)
+			require.NoError(t, err)
+			require.NotEmpty(t, history)
+			assert.Equal(t, daysAgo(40), history[0].Start)
+			assert.Equal(t, today, history[len(history)-1].End)
+			assert.Equal(t, 0.0, history[0].Opening)
+			assert.Equal(t, 129.75, history[len(history)-1].Closing)
+			for i := 1; i < len(history); i++ {
+				assert.Equal(t, history[i-1].End.AddDate(0, 0, 1), history[i].Start)
+				assert.Equal(t, history[i-1].Closing, history[i].Opening)
+			}
+		}
+	})
+	t.Run("should leave out days after today", func(t *testing.T) {
+		history, err := tanSvc.BalanceHistory(acct.AccountNumber, daysAgo(1), today.AddDate(0, 0, 5), transactions.DayInterval)
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+	})
+	t.Run("should give the same history from snapshots", func(t *testing.T) {
+		snapStore := adapters.NewInMemoryBalanceSnapshotStore()
+		snapSvc := transactions.NewTransactionService(tanStore, acctStore, transactions.WithBalanceSnapshots(snapStore))
+
+		taken, err := snapSvc.TakeBalanceSnapshots(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 10, taken)
+		taken, err = snapSvc.TakeBalanceSnapshots(time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, taken)
+
+		snap, err := snapStore.Latest(acct.AccountNumber, today)
+		require.NoError(t, err)
+		assert.Equal(t, daysAgo(1), snap.Date)
+		assert.Equal(t, 119.5, snap.Closing)
+
+		want, err := tanSvc.BalanceHistory(acct.AccountNumber, daysAgo(12), today, transactions.DayInterval)
+		require.NoError(t, err)
+		got, err := snapSvc.BalanceHistory(acct.AccountNumber, daysAgo(12), today, transactions.DayInterval)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+	t.Run("should reject invalid ranges", func(t *testing.T) {
+		_, err := tanSvc.BalanceHistory(acct.AccountNumber, today, daysAgo(1), transactions.DayInterval)
+		assert.ErrorIs(t, err, transactions.ErrInvalidBalanceRange)
+		_, err = tanSvc.BalanceHistory(acct.AccountNumber, daysAgo(transactions.MaxBalanceIntervals), today, transactions.DayInterval)
+		assert.ErrorIs(t, err, transactions.ErrInvalidBalanceRange)
+		_, err = tanSvc.BalanceHistory(acct.AccountNumber, daysAgo(1), today, "year")
+		assert.ErrorIs(t, err, transactions.ErrInvalidBalanceRange)
+	})
+	t.Run("should error for an unknown account", func(t *testing.T) {
+		_, err := tanSvc.BalanceHistory("01999990", daysAgo(1), today, transactions.DayInterval)
+		assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+	})
+}
+
+func TestTransactionSequence(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanStore := adapters.NewInMemoryTransactionStore()
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+
+	acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+	require.NoError(t, err)
+	post := func(t *testing.T, tanType transactions.TransactionType, amt float64) transactions.Transaction {
+		t.Helper()
+		tan, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: amt, Currency: accounts.GBP, Type: tanType,
+		})
+		require.NoError(t, err)
+		return tan
+	}
+
+	t.Run("should number transactions from 1 and record the balance after each", func(t *testing.T) {
+		tans := []transactions.Transaction{
+			post(t, transactions.Deposit, 100),
+			post(t, transactions.Withdrawal, 30.5),
+		}
+		interest, err := tanSvc.PostInterest(acct.AccountNumber, 1.25, "Interest")
+		require.NoError(t, err)
+		tans = append(tans, interest)
+
+		for i, want := range []float64{100, 69.5, 70.75} {
+			assert.Equal(t, uint64(i+1), tans[i].Sequence)
+			assert.Equal(t, want, tans[i].BalanceAfter)
+		}
+		gotAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, gotAcct.Balance(), tans[2].BalanceAfter)
+	})
+	t.Run("should list transactions by sequence range", func(t *testing.T) {
+		tans, err := tanSvc.ListTransactionsBySequence(acct.AccountNumber, 2, 100)
+		require.NoError(t, err)
+		require.Len(t, tans, 2)
+		assert.Equal(t, uint64(2), tans[0].Sequence)
+		assert.Equal(t, uint64(3), tans[1].Sequence)
+
+		_, err = tanSvc.ListTransactionsBySequence(acct.AccountNumber, 0, 1)
+		assert.ErrorIs(t, err, transactions.ErrInvalidSequenceRange)
+		_, err = tanSvc.ListTransactionsBySequence(acct.AccountNumber, 3, 2)
+		assert.ErrorIs(t, err, transactions.ErrInvalidSequenceRange)
+	})
+	t.Run("should refuse a posting which loses a race for the next sequence number", func(t *testing.T) {
+		before, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		racingSvc := transactions.NewTransactionService(staleSequenceStore{tanStore}, acctStore)
+		_, err = racingSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 10, Currency: accounts.GBP, Type: transactions.Deposit,
+		})
+		assert.ErrorIs(t, err, transactions.ErrSequenceConflict)
+
+		after, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, before.Balance(), after.Balance())
+	})
+}
+
+func TestConcurrentPostings(t *testing.T) {
+	post := func(t *testing.T, exec *accounts.Executor) (accounts.BankAccount, []error) {
+		t.Helper()
+		acctStore := adapters2.NewInMemoryAccountStore()
+		acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore(), accounts.WithExecutor(exec))
+		tanSvc := transactions.NewTransactionService(adapters.NewInMemoryTransactionStore(), acctStore, transactions.WithExecutor(exec))
+		acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 50)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+					AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 10, Currency: accounts.GBP, Type: transactions.Deposit,
+				})
+			}()
+		}
+		wg.Wait()
+		got, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		return got, errs
+	}
+
+	t.Run("should refuse racing postings rather than lose them without an executor", func(t *testing.T) {
+		got, errs := post(t, nil)
+		posted := 0
+		for _, err := range errs {
+			if err == nil {
+				posted++
+				continue
+			}
+			assert.True(t, errors.Is(err, transactions.ErrSequenceConflict) || errors.Is(err, accounts.ErrConflict), err)
+		}
+		require.NotZero(t, posted)
+		assert.Equal(t, float64(posted*10), got.Balance())
+		assert.Equal(t, uint64(posted+1), got.Version)
+	})
+	t.Run("should apply every posting in turn with an executor", func(t *testing.T) {
+		exec := accounts.NewExecutor(4)
+		defer exec.Close()
+		got, errs := post(t, exec)
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 500.0, got.Balance())
+		assert.Equal(t, uint64(51), got.Version)
+	})
+}
+
+// BenchmarkPostings posts deposits from parallel goroutines. Run it with -cpu 1,2,4,8: with each
+// goroutine posting to its own account throughput grows with the cores available, while postings
+// to one account are applied one at a time however many cores there are.
+func BenchmarkPostings(b *testing.B) {
+	for _, bm := range []struct {
+		name     string
+		accounts int
+	}{
+		{name: "one account", accounts: 1},
+		{name: "many accounts", accounts: 256},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			exec := accounts.NewExecutor(runtime.GOMAXPROCS(0))
+			defer exec.Close()
+			acctStore := adapters2.NewInMemoryAccountStore()
+			acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(b, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore(), accounts.WithExecutor(exec))
+			tanSvc := transactions.NewTransactionService(adapters.NewInMemoryTransactionStore(), acctStore, transactions.WithExecutor(exec))
+			acctNums := make([]accounts.AccountNumber, bm.accounts)
+			for i := range acctNums {
+				acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+				require.NoError(b, err)
+				acctNums[i] = acct.AccountNumber
+			}
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				acctNum := acctNums[int(next.Add(1))%len(acctNums)]
+				for pb.Next() {
+					_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+						AccountNumber: acctNum, UserID: "usr-123", Amount: 1, Currency: accounts.GBP, Type: transactions.Deposit,
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+var errCreditFailed = errors.New("credit leg failed")
+
+// failingCreditStore fails to store any conversion credit, as if the write failed part way through
+// a conversion.
+type failingCreditStore struct {
+	*adapters.InMemoryTransactionStore
+}
+
+func (s failingCreditStore) Put(tan transactions.Transaction, evts ...events.Event) error {
+	return s.PutAll([]transactions.Transaction{tan}, evts...)
+}
+
+func (s failingCreditStore) PutAll(tans []transactions.Transaction, evts ...events.Event) error {
+	for _, tan := range tans {
+		if tan.Type == transactions.ConversionCredit {
+			return errCreditFailed
+		}
+	}
+	return s.InMemoryTransactionStore.PutAll(tans, evts...)
+}
+
+// staleSequenceStore reports the sequence number from before other postings, as a posting racing
+// with them would see it.
+type staleSequenceStore struct {
+	*adapters.InMemoryTransactionStore
+}
+
+func (s staleSequenceStore) LastSequence(acctNum accounts.AccountNumber) (uint64, error) {
+	return 0, nil
+}
+
+func TestReconcile(t *testing.T) {
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanStore := adapters.NewInMemoryTransactionStore()
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+
+	newAcct := func(t *testing.T) accounts.BankAccount {
+		t.Helper()
+		acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+		require.NoError(t, err)
+		_, err = tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 100, Currency: accounts.GBP, Type: transactions.Deposit,
+		})
+		require.NoError(t, err)
+		return acct
+	}
+	// storeOnly stores a transaction without applying it to the account, as a posting which failed
+	// after storing its transaction would.
+	storeOnly := func(t *testing.T, acctNum accounts.AccountNumber, tanType transactions.TransactionType, amt float64) {
+		t.Helper()
+		tanID, err := transactions.NewRandTransactionID()
+		require.NoError(t, err)
+		tan, err := transactions.NewTransaction(tanID, acctNum, "usr-123", amt, accounts.GBP, tanType, "")
+		require.NoError(t, err)
+		tan.Sequence, err = tanStore.LastSequence(acctNum)
+		require.NoError(t, err)
+		tan.Sequence++
+		require.NoError(t, tanStore.Put(tan))
+	}
+	balanced := newAcct(t)
+	drifted := newAcct(t)
+	storeOnly(t, drifted.AccountNumber, transactions.Deposit, 50)
+
+	t.Run("should report accounts whose balance does not match their transactions", func(t *testing.T) {
+		report, err := tanSvc.Reconcile(false, "usr-staff")
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.AccountsChecked)
+		assert.False(t, report.Repaired)
+		require.Len(t, report.Discrepancies, 1)
+		d := report.Discrepancies[0]
+		assert.Equal(t, drifted.AccountNumber, d.AccountNumber)
+		assert.Equal(t, 100.0, d.StoredBalance)
+		assert.Equal(t, 150.0, d.LedgerBalance)
+		assert.Equal(t, 50.0, d.Difference)
+		assert.Nil(t, d.Correction)
+
+		gotAcct, err := acctSvc.FetchAccount(drifted.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, gotAcct.Balance())
+	})
+	t.Run("should repair with a correction which records the change", func(t *testing.T) {
+		report, err := tanSvc.Reconcile(true, "usr-staff")
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 1)
+		correction := report.Discrepancies[0].Correction
+		require.NotNil(t, correction)
+		assert.Equal(t, transactions.CorrectionCredit, correction.Type)
+		assert.Equal(t, 50.0, correction.Amount)
+		assert.Equal(t, users.UserID("usr-staff"), correction.UserID)
+		assert.Equal(t, uint64(3), correction.Sequence)
+		assert.Equal(t, 150.0, correction.BalanceAfter)
+
+		gotAcct, err := acctSvc.FetchAccount(drifted.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 150.0, gotAcct.Balance())
+
+		report, err = tanSvc.Reconcile(false, "usr-staff")
+		require.NoError(t, err)
+		assert.Empty(t, report.Discrepancies)
+	})
+	t.Run("should correct a balance which is too high with a debit", func(t *testing.T) {
+		storeOnly(t, balanced.AccountNumber, transactions.Withdrawal, 80)
+		report, err := tanSvc.Reconcile(true, "usr-staff")
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 1)
+		assert.Equal(t, -80.0, report.Discrepancies[0].Difference)
+		assert.Equal(t, transactions.CorrectionDebit, report.Discrepancies[0].Correction.Type)
+		assert.Equal(t, 80.0, report.Discrepancies[0].Correction.Amount)
+
+		gotAcct, err := acctSvc.FetchAccount(balanced.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 20.0, gotAcct.Balance())
+	})
+	t.Run("should check again rather than overwrite a posting made during the repair", func(t *testing.T) {
+		acct := newAcct(t)
+		storeOnly(t, acct.AccountNumber, transactions.Deposit, 50)
+		interrupting := &interruptingAccountStore{InMemoryAccountStore: acctStore, before: func() {
+			_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+				AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 10, Currency: accounts.GBP, Type: transactions.Deposit,
+			})
+			require.NoError(t, err)
+		}}
+		repairSvc := transactions.NewTransactionService(tanStore, interrupting)
+
+		report, err := repairSvc.Reconcile(true, "usr-staff")
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 1)
+		correction := report.Discrepancies[0].Correction
+		require.NotNil(t, correction)
+		assert.Equal(t, 50.0, correction.Amount)
+		assert.Equal(t, uint64(4), correction.Sequence)
+		assert.Equal(t, 160.0, correction.BalanceAfter)
+
+		gotAcct, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, 160.0, gotAcct.Balance())
+		report, err = tanSvc.Reconcile(false, "usr-staff")
+		require.NoError(t, err)
+		assert.Empty(t, report.Discrepancies)
+	})
+	t.Run("should give up on an account which keeps changing", func(t *testing.T) {
+		acct := newAcct(t)
+		storeOnly(t, acct.AccountNumber, transactions.Deposit, 50)
+		interrupting := &interruptingAccountStore{InMemoryAccountStore: acctStore}
+		var interrupt func()
+		interrupt = func() {
+			_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+				AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 10, Currency: accounts.GBP, Type: transactions.Deposit,
+			})
+			require.NoError(t, err)
+			interrupting.before = interrupt
+		}
+		interrupting.before = interrupt
+		repairSvc := transactions.NewTransactionService(tanStore, interrupting)
+
+		_, err := repairSvc.Reconcile(true, "usr-staff")
+		assert.ErrorIs(t, err, accounts.ErrConflict)
+
+		interrupting.before = nil
+		report, err := tanSvc.Reconcile(false, "usr-staff")
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 1)
+		assert.Equal(t, 50.0, report.Discrepancies[0].Difference)
+	})
+}
+
+// interruptingAccountStore runs before ahead of the first write made through it, as another
+// posting getting in between a read and a write would.
+type interruptingAccountStore struct {
+	*adapters2.InMemoryAccountStore
+	before func()
+}
+
+func (s *interruptingAccountStore) PutAllWith(write func() error, accts ...accounts.BankAccount) error {
+	if s.before != nil {
+		before := s.before
+		s.before = nil
+		before()
+	}
+	return s.InMemoryAccountStore.PutAllWith(write, accts...)
 }
 
 func TestListTransaction(t *testing.T) {
 	acctStore := adapters2.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123", "usr-1234"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
 
 	tanStore := adapters.NewInMemoryTransactionStore()
 	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
@@ -157,7 +1025,7 @@ func TestListTransaction(t *testing.T) {
 
 func TestFetchTransaction(t *testing.T) {
 	acctStore := adapters2.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123", "usr-1234"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
 
 	tanStore := adapters.NewInMemoryTransactionStore()
 	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
@@ -184,7 +1052,7 @@ func TestFetchTransaction(t *testing.T) {
 		assert.Equal(t, tan1, gotTan)
 	})
 	t.Run("should error if account not found", func(t *testing.T) {
-		_, err := tanSvc.FetchTransaction("01111111", tan1.ID)
+		_, err := tanSvc.FetchTransaction("01111116", tan1.ID)
 		assert.ErrorIs(t, err, transactions.ErrTransactionNotFound)
 	})
 	t.Run("should error if transaction not found", func(t *testing.T) {
@@ -206,3 +1074,105 @@ func TestFetchTransaction(t *testing.T) {
 		assert.ErrorIs(t, err, transactions.ErrTransactionNotFound)
 	})
 }
+
+func newVerifiedUserStore(t testing.TB, ids ...users.UserID) *adapters3.InMemoryUserStore {
+	t.Helper()
+	store := adapters3.NewInMemoryUserStore()
+	for _, id := range ids {
+		usr := users.MustNewUser(
+			id,
+			"Mr Foo",
+			users.MustNewAddress("line1", "town", "county", "postcode"),
+			users.MustNewPhoneNumber("+440000000000"),
+			users.MustNewEmail("foo@bar.com"),
+		)
+		usr.EmailVerified = true
+		require.NoError(t, store.Put(usr))
+	}
+	return store
+}
+
+func TestTransactionEvents(t *testing.T) {
+	outbox := adapters4.NewInMemoryOutbox()
+	acctStore := adapters2.NewInMemoryAccountStore()
+	acctSvc := accounts.NewAccountService(acctStore, newVerifiedUserStore(t, "usr-123"), adapters2.NewInMemoryHolderChangeStore(), adapters2.NewInMemoryOverdraftApplicationStore())
+	tanStore := adapters.NewInMemoryTransactionStore(adapters.WithOutbox(outbox))
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+	acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: "usr-123", Name: "Mr Foo", AccountType: accounts.PersonalAcct})
+	require.NoError(t, err)
+
+	t.Run("should record TransactionPosted with the transaction", func(t *testing.T) {
+		tan, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 25, Currency: accounts.GBP, Type: transactions.Deposit,
+		})
+		require.NoError(t, err)
+
+		recorded := outbox.Events()
+		require.Len(t, recorded, 1)
+		assert.Equal(t, events.TransactionPosted, recorded[0].Type)
+		assert.Equal(t, acct.AccountNumber.String(), recorded[0].AggregateID)
+		assert.Equal(t, []string{"usr-123"}, recorded[0].UserIDs)
+		var data events.TransactionData
+		require.NoError(t, recorded[0].Decode(&data))
+		assert.Equal(t, events.TransactionData{
+			TransactionID: tan.ID.String(),
+			AccountNumber: acct.AccountNumber.String(),
+			Type:          "deposit",
+			Amount:        25,
+			Currency:      "GBP",
+			Sequence:      1,
+			BalanceAfter:  25,
+		}, data)
+	})
+	t.Run("should record nothing for a posting which is refused", func(t *testing.T) {
+		_, err := tanSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 1000, Currency: accounts.GBP, Type: transactions.Withdrawal,
+		})
+		require.Error(t, err)
+		staleSvc := transactions.NewTransactionService(staleSequenceStore{tanStore}, acctStore)
+		_, err = staleSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 10, Currency: accounts.GBP, Type: transactions.Deposit,
+		})
+		require.ErrorIs(t, err, transactions.ErrSequenceConflict)
+		assert.Len(t, outbox.Events(), 1)
+	})
+	t.Run("should record nothing for a posting whose account write is refused", func(t *testing.T) {
+		pot, err := acctSvc.CreatePot(acct.AccountNumber, "Holiday", 0, time.Time{})
+		require.NoError(t, err)
+		stale, err := acctSvc.FetchAccount(acct.AccountNumber)
+		require.NoError(t, err)
+		_, err = tanSvc.PostInterest(acct.AccountNumber, 1, "Interest")
+		require.NoError(t, err)
+		recorded := len(outbox.Events())
+		last, err := tanStore.LastSequence(acct.AccountNumber)
+		require.NoError(t, err)
+
+		staleSvc := transactions.NewTransactionService(tanStore, staleAccountStore{acctStore, stale})
+		_, err = staleSvc.CreateTransaction(transactions.CreateTransactionRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", Amount: 10, Currency: accounts.GBP, Type: transactions.Deposit,
+		})
+		assert.ErrorIs(t, err, accounts.ErrConflict)
+		_, err = staleSvc.PostInterest(acct.AccountNumber, 1, "Interest")
+		assert.ErrorIs(t, err, accounts.ErrConflict)
+		_, err = staleSvc.TransferPot(transactions.PotTransferRequest{
+			AccountNumber: acct.AccountNumber, UserID: "usr-123", PotID: pot.ID, Amount: 5, Type: transactions.ToPot,
+		})
+		assert.ErrorIs(t, err, accounts.ErrConflict)
+
+		assert.Len(t, outbox.Events(), recorded)
+		got, err := tanStore.LastSequence(acct.AccountNumber)
+		require.NoError(t, err)
+		assert.Equal(t, last, got)
+	})
+}
+
+// staleAccountStore returns the account as it was before other writes, as a posting racing with
+// them would read it.
+type staleAccountStore struct {
+	*adapters2.InMemoryAccountStore
+	stale accounts.BankAccount
+}
+
+func (s staleAccountStore) GetByAcctNum(acctNum accounts.AccountNumber) (accounts.BankAccount, error) {
+	return s.stale, nil
+}
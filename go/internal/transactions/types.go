@@ -16,17 +16,61 @@ type TransactionType string
 const Deposit TransactionType = "deposit"
 const Withdrawal TransactionType = "withdrawal"
 
+// Adjustments are manual corrections posted by staff rather than the account holder.
+const AdjustmentCredit TransactionType = "adjustment_credit"
+const AdjustmentDebit TransactionType = "adjustment_debit"
+
+// Conversions are the two legs of moving money between accounts in different currencies.
+const ConversionDebit TransactionType = "conversion_debit"
+const ConversionCredit TransactionType = "conversion_credit"
+
+// OverdraftInterest is the monthly charge of debit interest accrued on an overdrawn balance.
+const OverdraftInterest TransactionType = "overdraft_interest"
+
+// Interest is credit interest paid by the bank, posted monthly from daily accruals.
+const Interest TransactionType = "interest"
+
+// Pot transfers move money between the account's main balance and one of its pots. They don't
+// change the account's balance, only how much of it is ring-fenced.
+const ToPot TransactionType = "to_pot"
+const FromPot TransactionType = "from_pot"
+
+// Corrections record bringing an account's stored balance back in line with its transactions after
+// a partial failure. The transactions already include the money that moved, so corrections don't
+// count towards the balance worked out from them.
+const CorrectionCredit TransactionType = "correction_credit"
+const CorrectionDebit TransactionType = "correction_debit"
+
+// SystemUserID is recorded as the user on transactions the bank posts itself, such as interest.
+const SystemUserID users.UserID = "usr-eaglebank"
+
 func (t TransactionType) String() string { return string(t) }
 
 func (t TransactionType) IsValid() bool {
 	switch t {
-	case Deposit, Withdrawal:
+	case Deposit, Withdrawal, AdjustmentCredit, AdjustmentDebit, ConversionDebit, ConversionCredit, OverdraftInterest, Interest, ToPot, FromPot,
+		CorrectionCredit, CorrectionDebit:
 		return true
 	default:
 		return false
 	}
 }
 
+// isCustomer reports whether transactions of this type are made by the account holder, which
+// keeps the account from going dormant.
+func (t TransactionType) isCustomer() bool {
+	switch t {
+	case Deposit, Withdrawal, ConversionDebit, ConversionCredit, ToPot, FromPot:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t TransactionType) isPotTransfer() bool {
+	return t == ToPot || t == FromPot
+}
+
 type TransactionID string
 
 var transactionIDRegex = regexp.MustCompile(`^tan-[A-Za-z0-9]+$`)
@@ -49,22 +93,46 @@ func NewRandTransactionID() (TransactionID, error) {
 	return NewTransactionID("tan-" + clean)
 }
 
-const TransactionMax float64 = 10000
-const TransactionMin float64 = 0
+// Conversion records the other side of a converted transaction: the amount the customer asked for
+// when it was in a different currency to the account, or the opposite leg of a currency conversion.
+// The transaction's Amount and Currency are always in the account's currency. Rate is units of the
+// destination currency per unit of the source currency, after Spread has been taken.
+type Conversion struct {
+	Amount   float64
+	Currency accounts.Currency
+	Rate     float64
+	Spread   float64
+}
+
+func (c Conversion) IsZero() bool {
+	return c == Conversion{}
+}
+
+func (c Conversion) IsValid() bool {
+	return c.Currency.IsValidAmount(c.Amount) && c.Rate > 0 && c.Spread >= 0 && c.Spread < 1
+}
 
 type Transaction struct {
-	ID               TransactionID
-	AccountNumber    accounts.AccountNumber
+	ID            TransactionID
+	AccountNumber accounts.AccountNumber
+	// Sequence numbers the account's transactions from 1 in the order they were posted, with no
+	// gaps. It is assigned when the transaction is posted.
+	Sequence uint64
+	// BalanceAfter is the account's balance once the transaction was applied.
+	BalanceAfter     float64
 	UserID           users.UserID
 	Amount           float64
 	Currency         accounts.Currency
 	Type             TransactionType
 	Reference        string
+	Conversion       Conversion
+	PotID            accounts.PotID
 	CreatedTimestamp time.Time
 }
 
 func (t Transaction) IsValid() bool {
-	if t.Amount < TransactionMin || t.Amount > TransactionMax {
+	// Amount limits depend on the account, they are enforced by the account's limit profile.
+	if t.Amount < 0 {
 		return false
 	}
 	if !t.ID.IsValid() {
@@ -76,16 +144,36 @@ func (t Transaction) IsValid() bool {
 	if !t.UserID.IsValid() {
 		return false
 	}
-	if !t.Currency.IsValid() {
+	if !t.Currency.IsValidAmount(t.Amount) {
+		return false
+	}
+	if !t.Conversion.IsZero() && !t.Conversion.IsValid() {
 		return false
 	}
 	if !t.Type.IsValid() {
 		return false
 	}
+	if t.Type.isPotTransfer() != t.PotID.IsValid() {
+		return false
+	}
 	return true
 }
 
-func NewTransaction(id TransactionID, acctNum accounts.AccountNumber, userID users.UserID, amt float64, curr accounts.Currency, tanType TransactionType, ref string) (Transaction, error) {
+type TransactionOption func(*Transaction)
+
+func WithConversion(conversion Conversion) TransactionOption {
+	return func(t *Transaction) {
+		t.Conversion = conversion
+	}
+}
+
+func WithPot(id accounts.PotID) TransactionOption {
+	return func(t *Transaction) {
+		t.PotID = id
+	}
+}
+
+func NewTransaction(id TransactionID, acctNum accounts.AccountNumber, userID users.UserID, amt float64, curr accounts.Currency, tanType TransactionType, ref string, opts ...TransactionOption) (Transaction, error) {
 	now := time.Now()
 	tan := Transaction{
 		ID:               id,
@@ -97,6 +185,9 @@ func NewTransaction(id TransactionID, acctNum accounts.AccountNumber, userID use
 		Reference:        ref,
 		CreatedTimestamp: now,
 	}
+	for _, opt := range opts {
+		opt(&tan)
+	}
 	if !tan.IsValid() {
 		return Transaction{}, fmt.Errorf("invalid transaction %+v", tan)
 	}
@@ -110,10 +201,13 @@ type CreateTransactionRequest struct {
 	Currency      accounts.Currency
 	Type          TransactionType
 	Reference     string
+	// Convert allows an amount in another currency to be converted into the account's currency,
+	// otherwise it is rejected.
+	Convert bool
 }
 
 func (r CreateTransactionRequest) IsValid() bool {
-	if r.Amount < TransactionMin || r.Amount > TransactionMax {
+	if r.Amount < 0 {
 		return false
 	}
 	if !r.AccountNumber.IsValid() {
@@ -122,7 +216,7 @@ func (r CreateTransactionRequest) IsValid() bool {
 	if !r.UserID.IsValid() {
 		return false
 	}
-	if !r.Currency.IsValid() {
+	if !r.Currency.IsValidAmount(r.Amount) {
 		return false
 	}
 	if !r.Type.IsValid() {
@@ -145,3 +239,61 @@ func NewCreateTransactionRequest(acctNum accounts.AccountNumber, userID users.Us
 	}
 	return req, nil
 }
+
+// CreateConversionRequest moves FromAmount out of one account and ToAmount into another, at a rate
+// already agreed with the customer.
+type CreateConversionRequest struct {
+	UserID       users.UserID
+	FromAccount  accounts.AccountNumber
+	ToAccount    accounts.AccountNumber
+	FromAmount   float64
+	FromCurrency accounts.Currency
+	ToAmount     float64
+	ToCurrency   accounts.Currency
+	Rate         float64
+	Spread       float64
+	Reference    string
+}
+
+func (r CreateConversionRequest) IsValid() bool {
+	if !r.UserID.IsValid() {
+		return false
+	}
+	if !r.FromAccount.IsValid() || !r.ToAccount.IsValid() || r.FromAccount == r.ToAccount {
+		return false
+	}
+	if r.FromAmount <= 0 || !r.FromCurrency.IsValidAmount(r.FromAmount) {
+		return false
+	}
+	if r.ToAmount <= 0 || !r.ToCurrency.IsValidAmount(r.ToAmount) {
+		return false
+	}
+	if r.FromCurrency == r.ToCurrency {
+		return false
+	}
+	if r.Rate <= 0 || r.Spread < 0 || r.Spread >= 1 {
+		return false
+	}
+	return true
+}
+
+// PotTransferRequest moves Amount between the account's main balance and a pot, Type is ToPot or
+// FromPot. The amount is in the account's currency.
+type PotTransferRequest struct {
+	AccountNumber accounts.AccountNumber
+	PotID         accounts.PotID
+	UserID        users.UserID
+	Amount        float64
+	Type          TransactionType
+	Reference     string
+}
+
+func (r PotTransferRequest) IsValid() bool {
+	if r.Amount <= 0 {
+		return false
+	}
+	if !r.AccountNumber.IsValid() || !r.PotID.IsValid() || !r.UserID.IsValid() {
+		return false
+	}
+	return r.Type.isPotTransfer()
+}
@@ -0,0 +1,35 @@
+package adapters
+
+import (
+	"eaglebank/internal/users"
+	"sync"
+	"time"
+)
+
+type InMemoryTokenStore struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func NewInMemoryTokenStore() *InMemoryTokenStore {
+	return &InMemoryTokenStore{used: make(map[string]time.Time)}
+}
+
+// MarkUsed records the token as spent. Entries are kept until the token expires, after which the
+// signer would reject it anyway.
+func (s *InMemoryTokenStore) MarkUsed(claims users.TokenClaims) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range s.used {
+		if !now.Before(exp) {
+			delete(s.used, id)
+		}
+	}
+	if _, ok := s.used[claims.ID]; ok {
+		return users.ErrTokenUsed
+	}
+	s.used[claims.ID] = claims.Expires
+	return nil
+}
@@ -0,0 +1,25 @@
+package adapters
+
+import (
+	"eaglebank/internal/users"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryTokenStore(t *testing.T) {
+	store := NewInMemoryTokenStore()
+
+	t.Run("should only allow a token to be used once", func(t *testing.T) {
+		claims := users.TokenClaims{ID: "tok-1", Expires: time.Now().Add(time.Minute)}
+		assert.NoError(t, store.MarkUsed(claims))
+		assert.ErrorIs(t, store.MarkUsed(claims), users.ErrTokenUsed)
+	})
+	t.Run("should prune expired tokens", func(t *testing.T) {
+		claims := users.TokenClaims{ID: "tok-2", Expires: time.Now().Add(-time.Minute)}
+		assert.NoError(t, store.MarkUsed(claims))
+		assert.NoError(t, store.MarkUsed(users.TokenClaims{ID: "tok-3", Expires: time.Now().Add(time.Minute)}))
+		assert.NotContains(t, store.used, "tok-2")
+	})
+}
@@ -1,17 +1,34 @@
 package adapters
 
 import (
+	"eaglebank/internal/events"
 	"eaglebank/internal/users"
+	"fmt"
+	"strings"
 	"sync"
 )
 
 type InMemoryUserStore struct {
-	mu    sync.RWMutex
-	store map[users.UserID]users.User
+	mu     sync.RWMutex
+	store  map[users.UserID]users.User
+	outbox events.Recorder
 }
 
-func NewInMemoryUserStore() *InMemoryUserStore {
-	return &InMemoryUserStore{store: map[users.UserID]users.User{}}
+type UserStoreOption func(*InMemoryUserStore)
+
+// WithOutbox records the events passed to Put in the outbox, without one they are dropped.
+func WithOutbox(outbox events.Recorder) UserStoreOption {
+	return func(s *InMemoryUserStore) {
+		s.outbox = outbox
+	}
+}
+
+func NewInMemoryUserStore(opts ...UserStoreOption) *InMemoryUserStore {
+	s := &InMemoryUserStore{store: map[users.UserID]users.User{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *InMemoryUserStore) Get(id users.UserID) (users.User, error) {
@@ -25,10 +42,34 @@ func (s *InMemoryUserStore) Get(id users.UserID) (users.User, error) {
 	return user, nil
 }
 
-func (s *InMemoryUserStore) Put(user users.User) error {
+func (s *InMemoryUserStore) GetByEmail(email users.Email) ([]users.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []users.User
+	for _, user := range s.store {
+		if strings.EqualFold(user.Email.String(), email.String()) {
+			result = append(result, user)
+		}
+	}
+	if len(result) == 0 {
+		return nil, users.ErrUserNotFound
+	}
+	return result, nil
+}
+
+// Put stores a new user, or replaces a stored one when the user's Version is the next one after
+// it, otherwise it fails with ErrConflict. The events are recorded along with the user.
+func (s *InMemoryUserStore) Put(user users.User, evts ...events.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if old, ok := s.store[user.ID]; ok && user.Version != old.Version+1 {
+		return fmt.Errorf("%w: user %s is at version %d, got %d", users.ErrConflict, user.ID, old.Version, user.Version)
+	}
+	if s.outbox != nil && len(evts) > 0 {
+		s.outbox.Record(evts...)
+	}
 	s.store[user.ID] = user
 	return nil
 }
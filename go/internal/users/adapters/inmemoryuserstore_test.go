@@ -1,6 +1,8 @@
 package adapters
 
 import (
+	"eaglebank/internal/events"
+	adapters2 "eaglebank/internal/events/adapters"
 	"eaglebank/internal/users"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,9 +33,19 @@ func TestNewInMemoryUserStore(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, usr, gotUsr)
 		})
+		t.Run("should get an existing user by email", func(t *testing.T) {
+			gotUsrs, err := store.GetByEmail("FOO@bar.com")
+			require.NoError(t, err)
+			require.Equal(t, []users.User{usr}, gotUsrs)
+		})
+		t.Run("should error not found getting users by unknown email", func(t *testing.T) {
+			_, err := store.GetByEmail("missing@bar.com")
+			require.ErrorIs(t, err, users.ErrUserNotFound)
+		})
 		t.Run("should update existing user", func(t *testing.T) {
 			updatedUsr := usr
 			updatedUsr.Name = "new name"
+			updatedUsr.Version++
 			require.NotEqual(t, usr.Name, updatedUsr.Name)
 
 			err := store.Put(updatedUsr)
@@ -43,6 +55,28 @@ func TestNewInMemoryUserStore(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, updatedUsr, gotUsr)
 		})
+		t.Run("should not overwrite a change made since the user was read", func(t *testing.T) {
+			staleUsr := usr
+			staleUsr.Name = "stale name"
+			staleUsr.Version++
+
+			err := store.Put(staleUsr)
+			require.ErrorIs(t, err, users.ErrConflict)
+
+			gotUsr, err := store.Get(usr.ID)
+			require.NoError(t, err)
+			require.Equal(t, "new name", gotUsr.Name)
+		})
+		t.Run("should record events only with users which are stored", func(t *testing.T) {
+			outbox := adapters2.NewInMemoryOutbox()
+			store := NewInMemoryUserStore(WithOutbox(outbox))
+			evt, err := events.NewEvent(events.UserCreated, usr.ID.String(), nil, events.UserData{UserID: usr.ID.String()})
+			require.NoError(t, err)
+
+			require.NoError(t, store.Put(usr, evt))
+			require.ErrorIs(t, store.Put(usr, evt), users.ErrConflict)
+			assert.Equal(t, []events.Event{evt}, outbox.Events())
+		})
 		t.Run("should delete existing user", func(t *testing.T) {
 			err := store.Delete(usr.ID)
 			require.NoError(t, err)
@@ -3,3 +3,10 @@ package users
 import "errors"
 
 var ErrUserNotFound = errors.New("user not found")
+var ErrInvalidToken = errors.New("invalid or expired token")
+var ErrTokenUsed = errors.New("token has already been used")
+var ErrEmailNotVerified = errors.New("email address has not been verified")
+var ErrInvalidCredentials = errors.New("invalid credentials")
+var ErrPasswordNotSet = errors.New("password has not been set")
+var ErrConflict = errors.New("user has been changed since it was read")
+var ErrPreconditionFailed = errors.New("user is no longer at the version the request was made against")
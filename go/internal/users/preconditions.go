@@ -0,0 +1,34 @@
+package users
+
+import (
+	"errors"
+	"slices"
+)
+
+// Precondition makes a command conditional on the user being at one of the given versions, as with
+// accounts.Precondition. The command writes the next version, so the store's compare-and-swap
+// refuses it if the user has moved on since it was fetched.
+type Precondition struct {
+	versions []uint64
+}
+
+// IfVersion is met by a user at any of the versions. With none it is never met.
+func IfVersion(versions ...uint64) Precondition {
+	return Precondition{versions: versions}
+}
+
+func checkPreconditions(usr User, conds []Precondition) error {
+	for _, cond := range conds {
+		if !slices.Contains(cond.versions, usr.Version) {
+			return ErrPreconditionFailed
+		}
+	}
+	return nil
+}
+
+func preconditionError(err error, conds []Precondition) error {
+	if len(conds) > 0 && errors.Is(err, ErrConflict) {
+		return ErrPreconditionFailed
+	}
+	return err
+}
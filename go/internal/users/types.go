@@ -144,14 +144,95 @@ func MustNewAddress(line1, town, county, postcode string, opts ...AddressOption)
 	return addr
 }
 
+type Role string
+
+const CustomerRole Role = "customer"
+const TellerRole Role = "teller"
+const SupportRole Role = "support"
+const AuditorRole Role = "auditor"
+
+func (r Role) IsValid() bool {
+	switch r {
+	case CustomerRole, TellerRole, SupportRole, AuditorRole:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r Role) String() string {
+	return string(r)
+}
+
+func NewRole(s string) (Role, error) {
+	role := Role(s)
+	if !role.IsValid() {
+		return "", fmt.Errorf("invalid role %q", s)
+	}
+	return role, nil
+}
+
+// Tier is the customer's service tier, which picks the limits applied to the accounts they hold.
+type Tier string
+
+const StandardTier Tier = "standard"
+const PremiumTier Tier = "premium"
+
+func Tiers() []Tier {
+	return []Tier{StandardTier, PremiumTier}
+}
+
+func (t Tier) IsValid() bool {
+	switch t {
+	case StandardTier, PremiumTier:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t Tier) String() string {
+	return string(t)
+}
+
+func NewTier(s string) (Tier, error) {
+	tier := Tier(s)
+	if !tier.IsValid() {
+		return "", fmt.Errorf("invalid tier %q", s)
+	}
+	return tier, nil
+}
+
+type Password string
+
+func NewPassword(s string) (Password, error) {
+	err := validation.Get().Var(s, "required,min=8,max=72")
+	if err != nil {
+		return "", err
+	}
+	return Password(s), nil
+}
+
 type User struct {
-	ID          UserID      `validate:"required,userID"`
-	Name        string      `validate:"required"`
-	Address     Address     `validate:"required"`
-	PhoneNumber PhoneNumber `validate:"required,phone"`
-	Email       Email       `validate:"required,email"`
-	Created     time.Time   `validate:"required"`
-	Updated     time.Time   `validate:"required"`
+	ID            UserID      `validate:"required,userID"`
+	Name          string      `validate:"required"`
+	Address       Address     `validate:"required"`
+	PhoneNumber   PhoneNumber `validate:"required,phone"`
+	Email         Email       `validate:"required,email"`
+	EmailVerified bool
+	PasswordHash  []byte
+	Role          Role      `validate:"required,oneof=customer teller support auditor"`
+	Tier          Tier      `validate:"required,oneof=standard premium"`
+	Created       time.Time `validate:"required"`
+	Updated       time.Time `validate:"required"`
+	// Version counts the writes to the user, starting from 1. Each write must carry the next
+	// version, so a writer working from an out of date copy fails with ErrConflict rather than
+	// overwriting a change it has not seen.
+	Version uint64
+}
+
+func (u User) HasPassword() bool {
+	return len(u.PasswordHash) != 0
 }
 
 func NewUser(id UserID, name string, address Address, phone PhoneNumber, email Email) (User, error) {
@@ -162,8 +243,11 @@ func NewUser(id UserID, name string, address Address, phone PhoneNumber, email E
 		Address:     address,
 		PhoneNumber: phone,
 		Email:       email,
+		Role:        CustomerRole,
+		Tier:        StandardTier,
 		Created:     now,
 		Updated:     now,
+		Version:     1,
 	}
 	err := validation.Get().Struct(usr)
 	if err != nil {
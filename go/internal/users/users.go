@@ -1,20 +1,54 @@
 package users
 
-import "fmt"
+import (
+	"eaglebank/internal/events"
+	"eaglebank/internal/notifications"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 type UserStore interface {
 	Get(id UserID) (User, error)
-	Put(user User) error
+	GetByEmail(email Email) ([]User, error)
+	// Put stores the user together with the events describing the change, all or nothing.
+	Put(user User, evts ...events.Event) error
 	Delete(id UserID) error
 }
 
+type TokenStore interface {
+	MarkUsed(claims TokenClaims) error
+}
+
+type Notifier interface {
+	Notify(msg notifications.Message) error
+}
+
+type Config struct {
+	TokenSecret     []byte
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+	BaseURL         string
+}
+
 type UserService struct {
-	userStore UserStore
+	userStore  UserStore
+	tokenStore TokenStore
+	notifier   Notifier
+	signer     TokenSigner
+	cfg        Config
 }
 
-func NewUserService(userStore UserStore) UserService {
+func NewUserService(userStore UserStore, tokenStore TokenStore, notifier Notifier, cfg Config) UserService {
 	return UserService{
-		userStore: userStore,
+		userStore:  userStore,
+		tokenStore: tokenStore,
+		notifier:   notifier,
+		signer:     NewTokenSigner(cfg.TokenSecret),
+		cfg:        cfg,
 	}
 }
 
@@ -27,7 +61,11 @@ func (svc UserService) CreateUser(req CreateUserRequest) (User, error) {
 	if err != nil {
 		return User{}, err
 	}
-	err = svc.userStore.Put(usr)
+	err = svc.put(usr, events.UserCreated)
+	if err != nil {
+		return User{}, err
+	}
+	err = svc.sendVerificationEmail(usr)
 	if err != nil {
 		return User{}, err
 	}
@@ -41,3 +79,198 @@ func (svc UserService) GetUser(userID UserID) (User, error) {
 	}
 	return user, nil
 }
+
+func (svc UserService) AssignRole(userID UserID, role Role) (User, error) {
+	if !role.IsValid() {
+		return User{}, fmt.Errorf("invalid role %q", role)
+	}
+	usr, err := svc.GetUser(userID)
+	if err != nil {
+		return User{}, err
+	}
+	usr.Role = role
+	usr.Updated = time.Now()
+	usr.Version++
+	err = svc.put(usr, events.UserUpdated)
+	if err != nil {
+		return User{}, fmt.Errorf("error updating user %q: %w", usr.ID, err)
+	}
+	return usr, nil
+}
+
+func (svc UserService) AssignTier(userID UserID, tier Tier, conds ...Precondition) (User, error) {
+	if !tier.IsValid() {
+		return User{}, fmt.Errorf("invalid tier %q", tier)
+	}
+	usr, err := svc.GetUser(userID)
+	if err != nil {
+		return User{}, err
+	}
+	err = checkPreconditions(usr, conds)
+	if err != nil {
+		return User{}, err
+	}
+	usr.Tier = tier
+	usr.Updated = time.Now()
+	usr.Version++
+	err = preconditionError(svc.put(usr, events.UserUpdated), conds)
+	if err != nil {
+		return User{}, fmt.Errorf("error updating user %q: %w", usr.ID, err)
+	}
+	return usr, nil
+}
+
+func (svc UserService) SendVerificationEmail(userID UserID) error {
+	usr, err := svc.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if usr.EmailVerified {
+		return nil
+	}
+	return svc.sendVerificationEmail(usr)
+}
+
+func (svc UserService) VerifyEmail(token string) (User, error) {
+	claims, err := svc.useToken(token, EmailVerificationPurpose)
+	if err != nil {
+		return User{}, err
+	}
+	usr, err := svc.GetUser(claims.UserID)
+	if err != nil {
+		return User{}, err
+	}
+	usr.EmailVerified = true
+	usr.Updated = time.Now()
+	usr.Version++
+	err = svc.put(usr, events.UserUpdated)
+	if err != nil {
+		return User{}, fmt.Errorf("error updating user %q: %w", usr.ID, err)
+	}
+	return usr, nil
+}
+
+// RequestPasswordReset emails a reset link to every user registered with the address. Unknown
+// addresses are ignored so callers cannot use this to discover which emails are registered.
+func (svc UserService) RequestPasswordReset(email Email) error {
+	usrs, err := svc.userStore.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("error fetching users by email: %w", err)
+	}
+	for _, usr := range usrs {
+		token, _, err := svc.signer.Sign(PasswordResetPurpose, usr.ID, svc.cfg.ResetTTL)
+		if err != nil {
+			return fmt.Errorf("error signing password reset token: %w", err)
+		}
+		body := fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to reset your Eagle Bank password. It expires in %s.\n\n%s\n\nIf you did not request this you can ignore this email.\n",
+			usr.Name, svc.cfg.ResetTTL, svc.link("/reset-password", token),
+		)
+		err = svc.notify(usr.Email, "Reset your Eagle Bank password", body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetPassword sets a new password. Completing a reset proves control of the mailbox, so the
+// user's email is marked as verified as well.
+func (svc UserService) ResetPassword(token string, password Password) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+	claims, err := svc.useToken(token, PasswordResetPurpose)
+	if err != nil {
+		return err
+	}
+	usr, err := svc.GetUser(claims.UserID)
+	if err != nil {
+		return err
+	}
+	usr.PasswordHash = hash
+	usr.EmailVerified = true
+	usr.Updated = time.Now()
+	usr.Version++
+	err = svc.put(usr, events.UserUpdated)
+	if err != nil {
+		return fmt.Errorf("error updating user %q: %w", usr.ID, err)
+	}
+	return nil
+}
+
+func (svc UserService) VerifyPassword(userID UserID, password string) error {
+	usr, err := svc.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if !usr.HasPassword() {
+		return ErrPasswordNotSet
+	}
+	err = bcrypt.CompareHashAndPassword(usr.PasswordHash, []byte(password))
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// put stores the user along with an event of the given type announcing the change.
+func (svc UserService) put(usr User, typ events.EventType) error {
+	evt, err := events.NewEvent(typ, usr.ID.String(), []string{usr.ID.String()}, events.UserData{
+		UserID:        usr.ID.String(),
+		Role:          usr.Role.String(),
+		Tier:          usr.Tier.String(),
+		EmailVerified: usr.EmailVerified,
+	})
+	if err != nil {
+		return err
+	}
+	return svc.userStore.Put(usr, evt)
+}
+
+func (svc UserService) sendVerificationEmail(usr User) error {
+	token, _, err := svc.signer.Sign(EmailVerificationPurpose, usr.ID, svc.cfg.VerificationTTL)
+	if err != nil {
+		return fmt.Errorf("error signing verification token: %w", err)
+	}
+	body := fmt.Sprintf(
+		"Hi %s,\n\nUse the link below to verify your email address. It expires in %s.\n\n%s\n",
+		usr.Name, svc.cfg.VerificationTTL, svc.link("/verify-email", token),
+	)
+	return svc.notify(usr.Email, "Verify your Eagle Bank email address", body)
+}
+
+func (svc UserService) notify(to Email, subject, body string) error {
+	msg, err := notifications.NewMessage(to.String(), subject, body)
+	if err != nil {
+		return err
+	}
+	err = svc.notifier.Notify(msg)
+	if err != nil {
+		return fmt.Errorf("error sending %q email: %w", subject, err)
+	}
+	return nil
+}
+
+func (svc UserService) useToken(token string, purpose TokenPurpose) (TokenClaims, error) {
+	claims, err := svc.signer.Verify(token, purpose)
+	if err != nil {
+		return TokenClaims{}, err
+	}
+	err = svc.tokenStore.MarkUsed(claims)
+	if err != nil {
+		if errors.Is(err, ErrTokenUsed) {
+			return TokenClaims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+		}
+		return TokenClaims{}, fmt.Errorf("error recording token use: %w", err)
+	}
+	return claims, nil
+}
+
+func (svc UserService) link(path, token string) string {
+	return svc.cfg.BaseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -1,17 +1,31 @@
 package users_test
 
 import (
+	"eaglebank/internal/events"
+	adapters3 "eaglebank/internal/events/adapters"
+	adapters2 "eaglebank/internal/notifications/adapters"
 	"eaglebank/internal/users"
 	"eaglebank/internal/users/adapters"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/url"
+	"regexp"
 	"testing"
+	"time"
 )
 
+var testConfig = users.Config{
+	TokenSecret:     []byte("test-secret"),
+	VerificationTTL: time.Hour,
+	ResetTTL:        time.Hour,
+	BaseURL:         "http://localhost:8080",
+}
+
 func TestUserService(t *testing.T) {
 	store := adapters.NewInMemoryUserStore()
-	svc := users.NewUserService(store)
+	mailbox := adapters2.NewInMemoryMailbox()
+	svc := users.NewUserService(store, adapters.NewInMemoryTokenStore(), mailbox, testConfig)
 	t.Run("create user", func(t *testing.T) {
 		t.Run("should successfully create user", func(t *testing.T) {
 			usr, err := svc.CreateUser(newTestCreateUserRequest(t))
@@ -30,7 +44,7 @@ func TestUserService(t *testing.T) {
 	})
 	t.Run("should fail if put fails", func(t *testing.T) {
 		usrStore := newFailingUserStore(t)
-		failSvc := users.NewUserService(usrStore)
+		failSvc := users.NewUserService(usrStore, adapters.NewInMemoryTokenStore(), mailbox, testConfig)
 		_, err := failSvc.CreateUser(newTestCreateUserRequest(t))
 		assert.Error(t, err)
 	})
@@ -49,6 +63,125 @@ func TestUserService(t *testing.T) {
 			assert.ErrorIs(t, err, users.ErrUserNotFound)
 		})
 	})
+	t.Run("assign role", func(t *testing.T) {
+		usr, err := svc.CreateUser(newTestCreateUserRequest(t))
+		require.NoError(t, err)
+		t.Run("should default new users to customer", func(t *testing.T) {
+			assert.Equal(t, users.CustomerRole, usr.Role)
+		})
+		t.Run("should persist assigned role", func(t *testing.T) {
+			_, err := svc.AssignRole(usr.ID, users.TellerRole)
+			require.NoError(t, err)
+
+			gotUser, err := svc.GetUser(usr.ID)
+			require.NoError(t, err)
+			assert.Equal(t, users.TellerRole, gotUser.Role)
+		})
+		t.Run("should reject invalid role", func(t *testing.T) {
+			_, err := svc.AssignRole(usr.ID, users.Role("admin"))
+			assert.Error(t, err)
+		})
+	})
+	t.Run("assign tier", func(t *testing.T) {
+		usr, err := svc.CreateUser(newTestCreateUserRequest(t))
+		require.NoError(t, err)
+		t.Run("should default new users to standard", func(t *testing.T) {
+			assert.Equal(t, users.StandardTier, usr.Tier)
+		})
+		t.Run("should persist assigned tier", func(t *testing.T) {
+			_, err := svc.AssignTier(usr.ID, users.PremiumTier)
+			require.NoError(t, err)
+
+			gotUser, err := svc.GetUser(usr.ID)
+			require.NoError(t, err)
+			assert.Equal(t, users.PremiumTier, gotUser.Tier)
+		})
+		t.Run("should reject invalid tier", func(t *testing.T) {
+			_, err := svc.AssignTier(usr.ID, users.Tier("gold"))
+			assert.Error(t, err)
+		})
+	})
+	t.Run("email verification", func(t *testing.T) {
+		req := newTestCreateUserRequest(t)
+		req.Email = "verify@bar.com"
+		usr, err := svc.CreateUser(req)
+		require.NoError(t, err)
+		require.False(t, usr.EmailVerified)
+
+		t.Run("should send verification email on create", func(t *testing.T) {
+			msgs := mailbox.MessagesTo("verify@bar.com")
+			require.Len(t, msgs, 1)
+			assert.Contains(t, msgs[0].Body, testConfig.BaseURL+"/verify-email?token=")
+		})
+		t.Run("should verify email with emailed token", func(t *testing.T) {
+			gotUsr, err := svc.VerifyEmail(lastEmailedToken(t, mailbox, "verify@bar.com"))
+			require.NoError(t, err)
+			assert.True(t, gotUsr.EmailVerified)
+
+			storeUsr, err := store.Get(usr.ID)
+			require.NoError(t, err)
+			assert.True(t, storeUsr.EmailVerified)
+		})
+		t.Run("should reject reused token", func(t *testing.T) {
+			_, err := svc.VerifyEmail(lastEmailedToken(t, mailbox, "verify@bar.com"))
+			assert.ErrorIs(t, err, users.ErrInvalidToken)
+		})
+		t.Run("should not resend to verified user", func(t *testing.T) {
+			require.NoError(t, svc.SendVerificationEmail(usr.ID))
+			assert.Len(t, mailbox.MessagesTo("verify@bar.com"), 1)
+		})
+		t.Run("should reject token signed with another secret", func(t *testing.T) {
+			token, _, err := users.NewTokenSigner([]byte("other")).Sign(users.EmailVerificationPurpose, usr.ID, time.Hour)
+			require.NoError(t, err)
+			_, err = svc.VerifyEmail(token)
+			assert.ErrorIs(t, err, users.ErrInvalidToken)
+		})
+	})
+	t.Run("password reset", func(t *testing.T) {
+		req := newTestCreateUserRequest(t)
+		req.Email = "reset@bar.com"
+		usr, err := svc.CreateUser(req)
+		require.NoError(t, err)
+
+		t.Run("should report password not set before reset", func(t *testing.T) {
+			err := svc.VerifyPassword(usr.ID, "anything")
+			assert.ErrorIs(t, err, users.ErrPasswordNotSet)
+		})
+		t.Run("should ignore unknown email", func(t *testing.T) {
+			err := svc.RequestPasswordReset("nobody@bar.com")
+			require.NoError(t, err)
+			assert.Empty(t, mailbox.MessagesTo("nobody@bar.com"))
+		})
+		t.Run("should reset password with emailed token", func(t *testing.T) {
+			require.NoError(t, svc.RequestPasswordReset("reset@bar.com"))
+			token := lastEmailedToken(t, mailbox, "reset@bar.com")
+
+			err := svc.ResetPassword(token, "new-password")
+			require.NoError(t, err)
+			assert.NoError(t, svc.VerifyPassword(usr.ID, "new-password"))
+			assert.ErrorIs(t, svc.VerifyPassword(usr.ID, "wrong-password"), users.ErrInvalidCredentials)
+
+			storeUsr, err := store.Get(usr.ID)
+			require.NoError(t, err)
+			assert.True(t, storeUsr.EmailVerified)
+		})
+		t.Run("should reject reused token", func(t *testing.T) {
+			err := svc.ResetPassword(lastEmailedToken(t, mailbox, "reset@bar.com"), "other-password")
+			assert.ErrorIs(t, err, users.ErrInvalidToken)
+		})
+		t.Run("should reject expired token", func(t *testing.T) {
+			token, _, err := users.NewTokenSigner(testConfig.TokenSecret).Sign(users.PasswordResetPurpose, usr.ID, -time.Second)
+			require.NoError(t, err)
+			err = svc.ResetPassword(token, "other-password")
+			assert.ErrorIs(t, err, users.ErrInvalidToken)
+		})
+		t.Run("should reject token issued for another purpose", func(t *testing.T) {
+			token, _, err := users.NewTokenSigner(testConfig.TokenSecret).Sign(users.EmailVerificationPurpose, usr.ID, time.Hour)
+			require.NoError(t, err)
+			err = svc.ResetPassword(token, "other-password")
+			assert.ErrorIs(t, err, users.ErrInvalidToken)
+		})
+	})
 
 }
 
@@ -79,7 +212,11 @@ func (f failingUserStore) Get(id users.UserID) (users.User, error) {
 	panic("implement me")
 }
 
-func (f failingUserStore) Put(user users.User) error {
+func (f failingUserStore) GetByEmail(email users.Email) ([]users.User, error) {
+	return nil, errors.New("error")
+}
+
+func (f failingUserStore) Put(user users.User, evts ...events.Event) error {
 	return errors.New("error")
 }
 
@@ -87,3 +224,39 @@ func (f failingUserStore) Delete(id users.UserID) error {
 	//TODO implement me
 	panic("implement me")
 }
+
+var tokenLinkRegex = regexp.MustCompile(`token=(\S+)`)
+
+func lastEmailedToken(t *testing.T, mailbox *adapters2.InMemoryMailbox, to string) string {
+	t.Helper()
+	msgs := mailbox.MessagesTo(to)
+	require.NotEmpty(t, msgs)
+	match := tokenLinkRegex.FindStringSubmatch(msgs[len(msgs)-1].Body)
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestUserEvents(t *testing.T) {
+	outbox := adapters3.NewInMemoryOutbox()
+	store := adapters.NewInMemoryUserStore(adapters.WithOutbox(outbox))
+	svc := users.NewUserService(store, adapters.NewInMemoryTokenStore(), adapters2.NewInMemoryMailbox(), testConfig)
+
+	usr, err := svc.CreateUser(newTestCreateUserRequest(t))
+	require.NoError(t, err)
+	_, err = svc.AssignTier(usr.ID, users.PremiumTier)
+	require.NoError(t, err)
+
+	recorded := outbox.Events()
+	require.Len(t, recorded, 2)
+	assert.Equal(t, events.UserCreated, recorded[0].Type)
+	assert.Equal(t, events.UserUpdated, recorded[1].Type)
+	for _, e := range recorded {
+		assert.Equal(t, usr.ID.String(), e.AggregateID)
+		assert.Equal(t, []string{usr.ID.String()}, e.UserIDs)
+	}
+	var data events.UserData
+	require.NoError(t, recorded[1].Decode(&data))
+	assert.Equal(t, users.PremiumTier.String(), data.Tier)
+}
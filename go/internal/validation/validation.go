@@ -44,15 +44,6 @@ func userIDValidation(fl validator.FieldLevel) bool {
 	return matched
 }
 
-func accountNumberValidation(fl validator.FieldLevel) bool {
-	field := fl.Field().String()
-	matched, err := regexp.MatchString(`^01\d{6}$`, field)
-	if err != nil {
-		return false
-	}
-	return matched
-}
-
 func transactionIDValidation(fl validator.FieldLevel) bool {
 	field := fl.Field().String()
 	matched, err := regexp.MatchString(`^tan-[A-Za-z0-9]$`, field)
@@ -76,10 +67,6 @@ func newValidator() (*validator.Validate, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = validate.RegisterValidation("acctNum", accountNumberValidation)
-	if err != nil {
-		return nil, err
-	}
 	err = validate.RegisterValidation("tanID", transactionIDValidation)
 	if err != nil {
 		return nil, err
@@ -2,6 +2,7 @@ package web
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
 	"eaglebank/internal/users"
 	"eaglebank/internal/validation"
 	"encoding/json"
@@ -9,7 +10,7 @@ import (
 	"net/http"
 )
 
-func handleCreateAccount(svc AccountService) http.HandlerFunc {
+func handleCreateAccount(svc AccountService, policy authz.Policy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateBankAccountRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -17,14 +18,17 @@ func handleCreateAccount(svc AccountService) http.HandlerFunc {
 			return
 		}
 
-		err := validation.Get().Struct(req)
+		err := validation.Get().StructCtx(withBranches(r.Context(), svc.ListBranches()), req)
 		if err != nil {
 			writeBadRequestErrorResponse(w, err)
 			return
 		}
 
 		userID := GetAuthenticatedUserID(r.Context())
-		domReq, err := accounts.NewCreateAccountRequest(users.UserID(userID), req.Name, accounts.AccountType(req.AccountType))
+		if !authorize(w, r, policy, authz.CreateAccount, authz.OwnedBy(users.UserID(userID))) {
+			return
+		}
+		domReq, err := req.toDomain(users.UserID(userID))
 		if err != nil {
 			writeBadRequestErrorResponse(w, err)
 			return
@@ -32,6 +36,14 @@ func handleCreateAccount(svc AccountService) http.HandlerFunc {
 
 		acct, err := svc.CreateAccount(domReq)
 		if err != nil {
+			if errors.Is(err, users.ErrEmailNotVerified) || errors.Is(err, users.ErrUserNotFound) {
+				writeErrorResponse(w, http.StatusForbidden, err)
+				return
+			}
+			if errors.Is(err, accounts.ErrBranchNotFound) {
+				writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
 			writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -43,12 +55,26 @@ func handleCreateAccount(svc AccountService) http.HandlerFunc {
 	}
 }
 
-func handleListAccounts(svc AccountService) http.HandlerFunc {
+// handleListAccounts lists the caller's own accounts, staff can pass ?userId= to list a customer's.
+func handleListAccounts(svc AccountService, policy authz.Policy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userID := GetAuthenticatedUserID(r.Context())
-		accts, err := svc.ListAccounts(users.UserID(userID))
+		userID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		if q := r.URL.Query().Get("userId"); q != "" {
+			var err error
+			userID, err = users.NewUserID(q)
+			if err != nil {
+				writeBadRequestErrorResponse(w, err)
+				return
+			}
+		}
+		if !authorize(w, r, policy, authz.ListAccounts, authz.OwnedBy(userID)) {
+			return
+		}
+
+		accts, err := svc.ListAccounts(userID)
 		if err != nil {
 			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
 		}
 
 		acctResps := make([]BankAccountResponse, 0, len(accts))
@@ -63,7 +89,7 @@ func handleListAccounts(svc AccountService) http.HandlerFunc {
 	}
 }
 
-func handleFetchAccount(svc AccountService) http.HandlerFunc {
+func handleFetchAccount(svc AccountService, access accountAccess) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
 		if err != nil {
@@ -75,13 +101,53 @@ func handleFetchAccount(svc AccountService) http.HandlerFunc {
 		if err != nil {
 			if errors.Is(err, accounts.ErrAccountNotFound) {
 				writeErrorResponse(w, http.StatusNotFound, err)
+				return
 			}
 			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
 		}
 
-		userID := GetAuthenticatedUserID(r.Context())
-		if acct.UserID.String() != userID {
-			writeErrorResponse(w, http.StatusForbidden, errors.New("forbidden"))
+		if !access.authorize(w, r, authz.ReadAccount, acct) {
+			return
+		}
+		if writeETag(w, r, acct.Version) {
+			return
+		}
+
+		resp := newBankAccountResponseFromDomain(acct)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// handleFetchAccountByBankDetails fetches an account by sort code and account number, as they
+// would be given for a payment.
+func handleFetchAccountByBankDetails(svc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sortCode, err := accounts.NewSortCode(r.PathValue("sortCode"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+		acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := svc.FetchAccountByBankDetails(sortCode, acctNum)
+		if err != nil {
+			if errors.Is(err, accounts.ErrAccountNotFound) || errors.Is(err, accounts.ErrBranchNotFound) {
+				writeErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !access.authorize(w, r, authz.ReadAccount, acct) {
+			return
 		}
 
 		resp := newBankAccountResponseFromDomain(acct)
@@ -90,3 +156,16 @@ func handleFetchAccount(svc AccountService) http.HandlerFunc {
 		json.NewEncoder(w).Encode(resp)
 	}
 }
+
+func handleListBranches(svc AccountService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		branches := svc.ListBranches()
+		resp := ListBranchesResponse{BIC: svc.Bank().BIC.String(), Branches: make([]BranchResponse, 0, len(branches))}
+		for _, b := range branches {
+			resp.Branches = append(resp.Branches, newBranchResponseFromDomain(b))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
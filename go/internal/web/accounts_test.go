@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,8 +21,9 @@ import (
 func TestCreateAccount(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	acctStore := adapters.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
-	srv := NewServer(ServerArgs{Logger: logger, AcctSvc: acctSvc})
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc})
 
 	token := login(t, srv, "usr-testuser")
 
@@ -58,6 +60,47 @@ func TestCreateAccount(t *testing.T) {
 
 			assert.Equal(t, http.StatusBadRequest, rr.Code)
 		})
+		t.Run("business account with business details should 201", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateBankAccountRequest{
+				Name:        "Foo Ltd",
+				AccountType: accounts.BusinessAcct.String(),
+				Business:    &BusinessDetails{CompanyName: "Foo Ltd", RegistrationNumber: "01234567"},
+			}
+			req := createAccountRequest(t, reqObj, token)
+			srv.ServeHTTP(rr, req)
+
+			var resp BankAccountResponse
+			err := json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, reqObj.AccountType, resp.AccountType)
+			assert.Equal(t, reqObj.Business, resp.Business)
+		})
+		t.Run("business account without business details should 400", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateBankAccountRequest{
+				Name:        "Foo Ltd",
+				AccountType: accounts.BusinessAcct.String(),
+			}
+			req := createAccountRequest(t, reqObj, token)
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("savings account with business details should 400", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateBankAccountRequest{
+				Name:        "Mr Foo",
+				AccountType: accounts.SavingsAcct.String(),
+				Business:    &BusinessDetails{CompanyName: "Foo Ltd", RegistrationNumber: "01234567"},
+			}
+			req := createAccountRequest(t, reqObj, token)
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
 		t.Run("without authentication should 401", func(t *testing.T) {
 			rr := httptest.NewRecorder()
 			reqObj := CreateBankAccountRequest{
@@ -91,14 +134,28 @@ func TestCreateAccount(t *testing.T) {
 
 			assert.Equal(t, http.StatusInternalServerError, rr.Code)
 		})
+		t.Run("a branch the service does not know should 400", func(t *testing.T) {
+			branchSrv := NewServer(ServerArgs{Logger: logger, AcctSvc: unknownBranchAccountService{}})
+
+			rr := httptest.NewRecorder()
+			reqObj := CreateBankAccountRequest{
+				Name:        "Mr Foo",
+				AccountType: accounts.PersonalAcct.String(),
+			}
+			req := createAccountRequest(t, reqObj, token)
+			branchSrv.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
 	})
 }
 
 func TestListAccounts(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	acctStore := adapters.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
-	srv := NewServer(ServerArgs{Logger: logger, AcctSvc: acctSvc})
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc})
 
 	token := login(t, srv, "usr-testuser")
 
@@ -168,8 +225,9 @@ func TestListAccounts(t *testing.T) {
 func TestFetchAccount(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	acctStore := adapters.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
-	srv := NewServer(ServerArgs{Logger: logger, AcctSvc: acctSvc})
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc})
 
 	reqObj := CreateBankAccountRequest{
 		Name:        "Mr Foo",
@@ -298,12 +356,126 @@ func fetchAccountRequest(t *testing.T, acctNum string, token ...string) *http.Re
 	return req
 }
 
+func TestBranches(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	manchester := accounts.Branch{
+		SortCode: "10-20-30",
+		Name:     "Eagle Bank Manchester",
+		Address:  users.MustNewAddress("20 Market Street", "Manchester", "Greater Manchester", "M1 1PT"),
+	}
+	branches, err := accounts.NewBranchRegistry(accounts.HeadOffice, manchester)
+	require.NoError(t, err)
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore(), accounts.WithBranches(branches))
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc})
+
+	token := login(t, srv, "usr-testuser")
+	createAtBranch := func(t *testing.T, sortCode string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, createAccountRequest(t, CreateBankAccountRequest{
+			Name:        "Mr Foo",
+			AccountType: accounts.PersonalAcct.String(),
+			SortCode:    &sortCode,
+		}, token))
+		return rr
+	}
+	fetchByBankDetails := func(t *testing.T, sortCode, acctNum, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/branches/"+sortCode+"/accounts/"+acctNum, nil, token))
+		return rr
+	}
+
+	t.Run("GET from /v1/branches should list the registry", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/branches", nil, token))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp ListBranchesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		require.Len(t, resp.Branches, 2)
+		assert.Equal(t, "EAGLGB2L", resp.BIC)
+		assert.Equal(t, "10-10-10", resp.Branches[0].SortCode)
+		assert.Equal(t, "Eagle Bank Manchester", resp.Branches[1].Name)
+		assert.Equal(t, "M1 1PT", resp.Branches[1].Address.Postcode)
+	})
+	t.Run("POST to /v1/accounts", func(t *testing.T) {
+		t.Run("without a sort code should open at the default branch", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			assert.Equal(t, "10-10-10", acct.SortCode)
+		})
+		t.Run("with a registered sort code should 201", func(t *testing.T) {
+			rr := createAtBranch(t, "10-20-30")
+			require.Equal(t, http.StatusCreated, rr.Code)
+
+			var resp BankAccountResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, "10-20-30", resp.SortCode)
+			assert.True(t, accounts.IBAN(resp.IBAN).IsValid())
+			assert.Equal(t, "EAGL102030"+resp.AccountNumber, resp.IBAN[4:])
+		})
+		t.Run("with an unknown sort code should 400", func(t *testing.T) {
+			rr := createAtBranch(t, "99-99-99")
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("with a malformed sort code should 400", func(t *testing.T) {
+			rr := createAtBranch(t, "102030")
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+	t.Run("GET from /v1/branches/{sortCode}/accounts/{accountNumber}", func(t *testing.T) {
+		rr := createAtBranch(t, "10-20-30")
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var acct BankAccountResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&acct))
+
+		t.Run("with matching bank details should 200", func(t *testing.T) {
+			rr := fetchByBankDetails(t, "10-20-30", acct.AccountNumber, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp BankAccountResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, acct.AccountNumber, resp.AccountNumber)
+		})
+		t.Run("under another branch's sort code should 404", func(t *testing.T) {
+			rr := fetchByBankDetails(t, "10-10-10", acct.AccountNumber, token)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+		t.Run("with an unknown sort code should 404", func(t *testing.T) {
+			rr := fetchByBankDetails(t, "99-99-99", acct.AccountNumber, token)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+		t.Run("with a malformed sort code should 400", func(t *testing.T) {
+			rr := fetchByBankDetails(t, "102030", acct.AccountNumber, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("for another user's account should 403", func(t *testing.T) {
+			rr := fetchByBankDetails(t, "10-20-30", acct.AccountNumber, login(t, srv, "usr-testuser2"))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	})
+}
+
 type erroringAccountService struct{}
 
 func (e erroringAccountService) FetchAccount(acctNum accounts.AccountNumber) (accounts.BankAccount, error) {
 	return accounts.BankAccount{}, errors.New("some error")
 }
 
+func (e erroringAccountService) FetchAccountByBankDetails(sortCode accounts.SortCode, acctNum accounts.AccountNumber) (accounts.BankAccount, error) {
+	return accounts.BankAccount{}, errors.New("some error")
+}
+
+func (e erroringAccountService) Bank() accounts.Bank {
+	return accounts.Bank{}
+}
+
+func (e erroringAccountService) ListBranches() []accounts.Branch {
+	return nil
+}
+
 func (e erroringAccountService) ListAccounts(id users.UserID) ([]accounts.BankAccount, error) {
 	return nil, errors.New("some error")
 }
@@ -312,7 +484,91 @@ func (e erroringAccountService) CreateAccount(req accounts.CreateAccountRequest)
 	return accounts.BankAccount{}, errors.New("some error")
 }
 
+func (e erroringAccountService) RequestAddHolder(acctNum accounts.AccountNumber, requestedBy, userID users.UserID, conds ...accounts.Precondition) (accounts.HolderChange, error) {
+	return accounts.HolderChange{}, errors.New("some error")
+}
+
+func (e erroringAccountService) RequestRemoveHolder(acctNum accounts.AccountNumber, requestedBy, userID users.UserID, conds ...accounts.Precondition) (accounts.HolderChange, error) {
+	return accounts.HolderChange{}, errors.New("some error")
+}
+
+func (e erroringAccountService) ApproveHolderChange(id accounts.HolderChangeID, userID users.UserID) (accounts.HolderChange, error) {
+	return accounts.HolderChange{}, errors.New("some error")
+}
+
+func (e erroringAccountService) RejectHolderChange(id accounts.HolderChangeID, userID users.UserID) (accounts.HolderChange, error) {
+	return accounts.HolderChange{}, errors.New("some error")
+}
+
+func (e erroringAccountService) ListPendingHolderChanges(userID users.UserID) ([]accounts.HolderChange, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringAccountService) ApplyForOverdraft(acctNum accounts.AccountNumber, userID users.UserID, limit float64, conds ...accounts.Precondition) (accounts.OverdraftApplication, error) {
+	return accounts.OverdraftApplication{}, errors.New("some error")
+}
+
+func (e erroringAccountService) ApproveOverdraft(id accounts.OverdraftApplicationID, staffID users.UserID) (accounts.OverdraftApplication, error) {
+	return accounts.OverdraftApplication{}, errors.New("some error")
+}
+
+func (e erroringAccountService) RejectOverdraft(id accounts.OverdraftApplicationID, staffID users.UserID) (accounts.OverdraftApplication, error) {
+	return accounts.OverdraftApplication{}, errors.New("some error")
+}
+
+func (e erroringAccountService) ListPendingOverdraftApplications() ([]accounts.OverdraftApplication, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringAccountService) ApplyTier(userID users.UserID, tier users.Tier) ([]accounts.BankAccount, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringAccountService) ChangeAccountStatus(acctNum accounts.AccountNumber, to accounts.AccountStatus, reason accounts.StatusReason, staffID users.UserID, conds ...accounts.Precondition) (accounts.BankAccount, error) {
+	return accounts.BankAccount{}, errors.New("some error")
+}
+
+func (e erroringAccountService) PlaceHold(acctNum accounts.AccountNumber, amt float64, reason accounts.HoldReason, expires time.Time, staffID users.UserID, conds ...accounts.Precondition) (accounts.Hold, error) {
+	return accounts.Hold{}, errors.New("some error")
+}
+
+func (e erroringAccountService) ReleaseHold(acctNum accounts.AccountNumber, id accounts.HoldID, staffID users.UserID, conds ...accounts.Precondition) (accounts.Hold, error) {
+	return accounts.Hold{}, errors.New("some error")
+}
+
+func (e erroringAccountService) ListHolds(acctNum accounts.AccountNumber) ([]accounts.Hold, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringAccountService) CreatePot(acctNum accounts.AccountNumber, name string, goal float64, targetDate time.Time, conds ...accounts.Precondition) (accounts.Pot, error) {
+	return accounts.Pot{}, errors.New("some error")
+}
+
+func (e erroringAccountService) ListPots(acctNum accounts.AccountNumber) ([]accounts.Pot, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringAccountService) FetchPot(acctNum accounts.AccountNumber, id accounts.PotID) (accounts.Pot, error) {
+	return accounts.Pot{}, errors.New("some error")
+}
+
+func (e erroringAccountService) UpdatePot(acctNum accounts.AccountNumber, id accounts.PotID, update accounts.PotUpdate, conds ...accounts.Precondition) (accounts.Pot, error) {
+	return accounts.Pot{}, errors.New("some error")
+}
+
+func (e erroringAccountService) DeletePot(acctNum accounts.AccountNumber, id accounts.PotID, conds ...accounts.Precondition) error {
+	return errors.New("some error")
+}
+
 func newErroringAccountService(t *testing.T) erroringAccountService {
 	t.Helper()
 	return erroringAccountService{}
 }
+
+type unknownBranchAccountService struct {
+	erroringAccountService
+}
+
+func (u unknownBranchAccountService) CreateAccount(req accounts.CreateAccountRequest) (accounts.BankAccount, error) {
+	return accounts.BankAccount{}, accounts.ErrBranchNotFound
+}
@@ -0,0 +1,428 @@
+package web
+
+import (
+	"bytes"
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/fx"
+	adapters4 "eaglebank/internal/fx/adapters"
+	"eaglebank/internal/grants"
+	adapters3 "eaglebank/internal/grants/adapters"
+	"eaglebank/internal/interest"
+	adapters5 "eaglebank/internal/interest/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
+	"eaglebank/internal/webauthn"
+	adapters6 "eaglebank/internal/webauthn/adapters"
+	"eaglebank/internal/webhooks"
+	adapters7 "eaglebank/internal/webhooks/adapters"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleMatrix(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-owner", "usr-other", "usr-teller", "usr-support", "usr-auditor")
+	for id, role := range map[string]users.Role{"usr-teller": users.TellerRole, "usr-support": users.SupportRole, "usr-auditor": users.AuditorRole} {
+		mustAssignStaffRole(t, usrSvc, usrStore, users.UserID(id), role)
+	}
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	grantSvc := grants.NewGrantService(adapters3.NewInMemoryGrantStore(), adapters3.NewInMemoryAccessLog(), acctStore, usrStore)
+	converter := fx.NewConverter(fx.RateTable{Base: accounts.GBP, Rates: map[accounts.Currency]float64{accounts.EUR: 1.25}}, 0)
+	fxSvc := fx.NewFXService(converter, adapters4.NewInMemoryQuoteStore(), tanSvc, time.Minute)
+	interestSvc, err := interest.NewInterestService(interest.Config{
+		Rates:    map[accounts.AccountType][]interest.Tier{accounts.SavingsAcct: {{From: 0, AnnualRate: 0.0365}}},
+		DayCount: interest.Actual365,
+	}, adapters5.NewInMemoryAccrualStore(), acctStore, tanSvc)
+	require.NoError(t, err)
+	waCfg := webauthn.Config{RPID: "localhost", RPName: "Eagle Bank", Origin: "http://localhost:8080", ChallengeTTL: time.Minute}
+	waSvc := webauthn.NewWebAuthnService(waCfg, adapters6.NewInMemoryCredentialStore(), adapters6.NewInMemorySessionStore())
+	webhookSvc := webhooks.NewWebhookService(adapters7.NewInMemorySubscriptionStore(), adapters7.NewInMemoryDeliveryStore(), webhooks.DefaultConfig)
+	args := ServerArgs{
+		Logger:      logger,
+		UserSvc:     usrSvc,
+		AcctSvc:     acctSvc,
+		TanSvc:      tanSvc,
+		WebAuthnSvc: waSvc,
+		GrantSvc:    grantSvc,
+		FXSvc:       fxSvc,
+		InterestSvc: interestSvc,
+		WebhookSvc:  webhookSvc,
+	}
+	srv := NewServer(args)
+
+	do := func(t *testing.T, req *http.Request, want int) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		require.Equal(t, want, rr.Code, rr.Body.String())
+		return rr
+	}
+	body := func(t *testing.T, v any) []byte {
+		t.Helper()
+		by, err := json.Marshal(v)
+		require.NoError(t, err)
+		return by
+	}
+	id := func(t *testing.T, rr *httptest.ResponseRecorder) string {
+		t.Helper()
+		var resp struct {
+			ID string `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		return resp.ID
+	}
+
+	ownerToken := login(t, srv, "usr-owner")
+	tellerToken := login(t, srv, "usr-teller")
+	acct := mustCreateAccount(t, ownerToken, srv)
+	tan := mustCreateTransaction(t, srv, ownerToken, acct.AccountNumber)
+	otherAcct := mustCreateAccount(t, ownerToken, srv)
+	accountPath := "/v1/accounts/" + acct.AccountNumber
+	potAcct := mustCreateAccount(t, ownerToken, srv)
+	mustCreateTransaction(t, srv, ownerToken, potAcct.AccountNumber)
+	newPot := func(t *testing.T) string {
+		t.Helper()
+		return id(t, do(t, authedRequest(http.MethodPost, "/v1/accounts/"+potAcct.AccountNumber+"/pots", body(t, CreatePotRequest{Name: "pot"}), ownerToken), http.StatusCreated))
+	}
+	potPath := "/v1/accounts/" + potAcct.AccountNumber + "/pots/" + newPot(t)
+	// The grant is on an account of its own, so it gives the other customer no access to acct.
+	grantAcct := mustCreateAccount(t, ownerToken, srv)
+	grant := createGrant(t, srv, ownerToken, "/v1/accounts/"+grantAcct.AccountNumber+"/grants", CreateGrantRequest{GranteeID: "usr-other", Scopes: []string{"balance"}, Expires: time.Now().Add(time.Hour)})
+	newWebhook := func(t *testing.T) string {
+		t.Helper()
+		return id(t, do(t, authedRequest(http.MethodPost, "/v1/webhooks", body(t, CreateWebhookRequest{URL: "https://example.com/hooks", EventTypes: []string{"transaction.posted"}}), ownerToken), http.StatusCreated))
+	}
+	webhookPath := "/v1/webhooks/" + newWebhook(t)
+	newApplication := func(t *testing.T) string {
+		t.Helper()
+		acct := mustCreateAccount(t, ownerToken, srv)
+		return id(t, do(t, authedRequest(http.MethodPost, "/v1/accounts/"+acct.AccountNumber+"/overdraft-applications", body(t, ApplyForOverdraftRequest{Limit: 100}), ownerToken), http.StatusCreated))
+	}
+
+	tokens := make(map[string]string)
+	tokenUsers := make(map[string]users.UserID)
+	for _, userID := range []string{"usr-owner", "usr-other", "usr-teller", "usr-support", "usr-auditor"} {
+		tokens[userID] = login(t, srv, userID)
+		tokenUsers[tokens[userID]] = users.UserID(userID)
+	}
+	// self is the user a token belongs to, the owner for requests made without one.
+	self := func(token string) users.UserID {
+		userID, ok := tokenUsers[token]
+		if !ok {
+			return "usr-owner"
+		}
+		return userID
+	}
+	// ownAccount opens an account held by the caller. It goes through the service, as auditors
+	// cannot open accounts over the API.
+	ownAccount := func(t *testing.T, token string) string {
+		t.Helper()
+		acct, err := acctSvc.CreateAccount(accounts.CreateAccountRequest{UserID: self(token), Name: "own", AccountType: accounts.PersonalAcct})
+		require.NoError(t, err)
+		return acct.AccountNumber.String()
+	}
+	ownApplication := func(t *testing.T, token string) string {
+		t.Helper()
+		acctNum, err := accounts.NewAccountNumber(ownAccount(t, token))
+		require.NoError(t, err)
+		app, err := acctSvc.ApplyForOverdraft(acctNum, self(token), 100)
+		require.NoError(t, err)
+		return app.ID.String()
+	}
+
+	// statuses is what each test user should get from a request, by role: the account's owner,
+	// another customer, then each of the staff roles.
+	type statuses struct{ owner, other, teller, support, auditor int }
+	type routeCase struct {
+		pattern string
+		// name tells apart cases for the same pattern, it defaults to the pattern.
+		name string
+		req  func(t *testing.T, token string) *http.Request
+		want statuses
+	}
+	cases := []routeCase{
+		{pattern: "GET /v1/users/{userId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/users/usr-owner", nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "PUT /v1/users/{userId}/tier", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPut, "/v1/users/usr-owner/tier", body(t, ChangeUserTierRequest{Tier: users.StandardTier.String()}), token)
+		}, want: statuses{403, 403, 403, 200, 403}},
+		{pattern: "PUT /v1/users/{userId}/tier", name: "PUT /v1/users/{userId}/tier on self", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPut, "/v1/users/"+self(token).String()+"/tier", body(t, ChangeUserTierRequest{Tier: users.StandardTier.String()}), token)
+		}, want: statuses{403, 403, 403, 403, 403}},
+		{pattern: "POST /v1/reconciliations", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/reconciliations", body(t, ReconcileRequest{}), token)
+		}, want: statuses{403, 403, 403, 200, 200}},
+		{pattern: "POST /v1/users/{userId}/verification-email", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/users/usr-owner/verification-email", nil, token)
+		}, want: statuses{202, 403, 403, 403, 403}},
+		{pattern: "POST /v1/webauthn/credentials/begin", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/webauthn/credentials/begin", nil, token)
+		}, want: statuses{200, 200, 200, 200, 200}},
+		{pattern: "POST /v1/webauthn/credentials/finish", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/webauthn/credentials/finish", []byte(`{}`), token)
+		}, want: statuses{400, 400, 400, 400, 400}},
+
+		{pattern: "POST /v1/accounts", req: func(t *testing.T, token string) *http.Request {
+			return createAccountRequest(t, CreateBankAccountRequest{Name: "acct", AccountType: accounts.PersonalAcct.String()}, token)
+		}, want: statuses{201, 201, 201, 201, 403}},
+		{pattern: "GET /v1/accounts", req: func(t *testing.T, token string) *http.Request {
+			return listAccountsRequest(t, token)
+		}, want: statuses{200, 200, 200, 200, 200}},
+		{pattern: "GET /v1/accounts", name: "GET /v1/accounts?userId=", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/accounts?userId=usr-owner", nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "GET /v1/accounts/{accountNumber}", req: func(t *testing.T, token string) *http.Request {
+			return fetchAccountRequest(t, acct.AccountNumber, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "GET /v1/branches", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/branches", nil, token)
+		}, want: statuses{200, 200, 200, 200, 200}},
+		{pattern: "GET /v1/branches/{sortCode}/accounts/{accountNumber}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/branches/"+acct.SortCode+"/accounts/"+acct.AccountNumber, nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "POST /v1/accounts/{accountNumber}/status", req: func(t *testing.T, token string) *http.Request {
+			acct := mustCreateAccount(t, ownerToken, srv)
+			return authedRequest(http.MethodPost, "/v1/accounts/"+acct.AccountNumber+"/status", body(t, ChangeAccountStatusRequest{Status: "frozen", Reason: "suspected_fraud"}), token)
+		}, want: statuses{403, 403, 200, 200, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/status", name: "POST /v1/accounts/{accountNumber}/status on own account", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/accounts/"+ownAccount(t, token)+"/status", body(t, ChangeAccountStatusRequest{Status: "frozen", Reason: "suspected_fraud"}), token)
+		}, want: statuses{403, 403, 403, 403, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/holds", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, accountPath+"/holds", body(t, PlaceHoldRequest{Amount: 1, Reason: "legal_hold", Expires: time.Now().Add(time.Hour)}), token)
+		}, want: statuses{403, 403, 201, 201, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/holds", name: "POST /v1/accounts/{accountNumber}/holds on own account", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/accounts/"+ownAccount(t, token)+"/holds", body(t, PlaceHoldRequest{Amount: 1, Reason: "legal_hold", Expires: time.Now().Add(time.Hour)}), token)
+		}, want: statuses{403, 403, 403, 403, 403}},
+		{pattern: "GET /v1/accounts/{accountNumber}/holds", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, accountPath+"/holds", nil, token)
+		}, want: statuses{403, 403, 200, 200, 403}},
+		{pattern: "DELETE /v1/accounts/{accountNumber}/holds/{holdId}", req: func(t *testing.T, token string) *http.Request {
+			holdID := id(t, do(t, authedRequest(http.MethodPost, accountPath+"/holds", body(t, PlaceHoldRequest{Amount: 1, Reason: "legal_hold", Expires: time.Now().Add(time.Hour)}), tellerToken), http.StatusCreated))
+			return authedRequest(http.MethodDelete, accountPath+"/holds/"+holdID, nil, token)
+		}, want: statuses{403, 403, 200, 200, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/holders", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, accountPath+"/holders", body(t, AddAccountHolderRequest{UserID: "usr-owner"}), token)
+		}, want: statuses{409, 403, 403, 403, 403}},
+		{pattern: "DELETE /v1/accounts/{accountNumber}/holders/{userId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodDelete, accountPath+"/holders/usr-nobody", nil, token)
+		}, want: statuses{404, 403, 403, 403, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/grants", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, accountPath+"/grants", body(t, CreateGrantRequest{GranteeID: "usr-missing", Scopes: []string{"balance"}, Expires: time.Now().Add(time.Hour)}), token)
+		}, want: statuses{404, 403, 403, 403, 403}},
+		{pattern: "GET /v1/accounts/{accountNumber}/grants", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, accountPath+"/grants", nil, token)
+		}, want: statuses{200, 403, 403, 403, 403}},
+		{pattern: "GET /v1/grants", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/grants", nil, token)
+		}, want: statuses{200, 200, 200, 200, 200}},
+		{pattern: "DELETE /v1/grants/{grantId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodDelete, "/v1/grants/"+grant.ID, nil, token)
+		}, want: statuses{200, 403, 403, 403, 403}},
+		{pattern: "GET /v1/grants/{grantId}/accesses", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/grants/"+grant.ID+"/accesses", nil, token)
+		}, want: statuses{200, 403, 403, 403, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/overdraft-applications", req: func(t *testing.T, token string) *http.Request {
+			acct := mustCreateAccount(t, ownerToken, srv)
+			return authedRequest(http.MethodPost, "/v1/accounts/"+acct.AccountNumber+"/overdraft-applications", body(t, ApplyForOverdraftRequest{Limit: 100}), token)
+		}, want: statuses{201, 403, 403, 403, 403}},
+		{pattern: "GET /v1/overdraft-applications", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/overdraft-applications", nil, token)
+		}, want: statuses{403, 403, 200, 200, 403}},
+		{pattern: "POST /v1/overdraft-applications/{applicationId}/approve", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/overdraft-applications/"+newApplication(t)+"/approve", nil, token)
+		}, want: statuses{403, 403, 200, 200, 403}},
+		{pattern: "POST /v1/overdraft-applications/{applicationId}/reject", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/overdraft-applications/"+newApplication(t)+"/reject", nil, token)
+		}, want: statuses{403, 403, 200, 200, 403}},
+		{pattern: "POST /v1/overdraft-applications/{applicationId}/approve", name: "POST /v1/overdraft-applications/{applicationId}/approve on own account", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/overdraft-applications/"+ownApplication(t, token)+"/approve", nil, token)
+		}, want: statuses{403, 403, 403, 403, 403}},
+		{pattern: "POST /v1/overdraft-applications/{applicationId}/reject", name: "POST /v1/overdraft-applications/{applicationId}/reject on own account", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/overdraft-applications/"+ownApplication(t, token)+"/reject", nil, token)
+		}, want: statuses{403, 403, 403, 403, 403}},
+		{pattern: "GET /v1/holder-changes", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/holder-changes", nil, token)
+		}, want: statuses{200, 200, 200, 200, 200}},
+		{pattern: "POST /v1/holder-changes/{changeId}/approve", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/holder-changes/hch-missing/approve", nil, token)
+		}, want: statuses{404, 404, 404, 404, 404}},
+		{pattern: "POST /v1/holder-changes/{changeId}/reject", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/holder-changes/hch-missing/reject", nil, token)
+		}, want: statuses{404, 404, 404, 404, 404}},
+
+		{pattern: "POST /v1/accounts/{accountNumber}/transactions", req: func(t *testing.T, token string) *http.Request {
+			return createTransactionRequest(t, CreateTransactionRequest{Amount: 1, Currency: "GBP", Type: "deposit"}, acct.AccountNumber, token)
+		}, want: statuses{201, 403, 403, 403, 403}},
+		{pattern: "GET /v1/accounts/{accountNumber}/transactions", req: func(t *testing.T, token string) *http.Request {
+			return listTransactionRequest(t, acct.AccountNumber, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "GET /v1/accounts/{accountNumber}/transactions/{transactionId}", req: func(t *testing.T, token string) *http.Request {
+			return fetchTransactionRequest(t, acct.AccountNumber, tan.ID, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "GET /v1/accounts/{accountNumber}/balances", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, accountPath+"/balances", nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "POST /v1/accounts/{accountNumber}/pots", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, accountPath+"/pots", body(t, CreatePotRequest{Name: "pot"}), token)
+		}, want: statuses{201, 403, 403, 403, 403}},
+		{pattern: "GET /v1/accounts/{accountNumber}/pots", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, accountPath+"/pots", nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "GET /v1/accounts/{accountNumber}/pots/{potId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, potPath, nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "PATCH /v1/accounts/{accountNumber}/pots/{potId}", req: func(t *testing.T, token string) *http.Request {
+			name := "renamed"
+			return authedRequest(http.MethodPatch, potPath, body(t, UpdatePotRequest{Name: &name}), token)
+		}, want: statuses{200, 403, 403, 403, 403}},
+		{pattern: "DELETE /v1/accounts/{accountNumber}/pots/{potId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodDelete, "/v1/accounts/"+potAcct.AccountNumber+"/pots/"+newPot(t), nil, token)
+		}, want: statuses{204, 403, 403, 403, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/pots/{potId}/transactions", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, potPath+"/transactions", body(t, CreatePotTransactionRequest{Amount: 1, Type: "to_pot"}), token)
+		}, want: statuses{201, 403, 403, 403, 403}},
+		{pattern: "GET /v1/accounts/{accountNumber}/pots/{potId}/transactions", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, potPath+"/transactions", nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "POST /v1/accounts/{accountNumber}/adjustments", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, accountPath+"/adjustments", body(t, CreateAdjustmentRequest{Amount: 1, Currency: "GBP", Direction: "credit", Reason: "goodwill"}), token)
+		}, want: statuses{403, 403, 201, 201, 403}},
+		{pattern: "POST /v1/accounts/{accountNumber}/adjustments", name: "POST /v1/accounts/{accountNumber}/adjustments on own account", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/accounts/"+ownAccount(t, token)+"/adjustments", body(t, CreateAdjustmentRequest{Amount: 1, Currency: "GBP", Direction: "credit", Reason: "goodwill"}), token)
+		}, want: statuses{403, 403, 403, 403, 403}},
+		{pattern: "GET /v1/accounts/{accountNumber}/interest-accruals", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, accountPath+"/interest-accruals", nil, token)
+		}, want: statuses{200, 403, 200, 200, 200}},
+		{pattern: "POST /v1/fx/quotes", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/fx/quotes", body(t, CreateQuoteRequest{FromCurrency: "GBP", ToCurrency: "EUR", Amount: 10}), token)
+		}, want: statuses{201, 201, 201, 201, 201}},
+		{pattern: "POST /v1/fx/conversions", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/fx/conversions", body(t, CreateFXConversionRequest{QuoteID: "fxq-missing", FromAccountNumber: acct.AccountNumber, ToAccountNumber: otherAcct.AccountNumber}), token)
+		}, want: statuses{404, 403, 403, 403, 403}},
+
+		{pattern: "POST /v1/webhooks", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, "/v1/webhooks", body(t, CreateWebhookRequest{URL: "https://example.com/hooks", EventTypes: []string{"transaction.posted"}}), token)
+		}, want: statuses{201, 201, 201, 201, 201}},
+		{pattern: "GET /v1/webhooks", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, "/v1/webhooks", nil, token)
+		}, want: statuses{200, 200, 200, 200, 200}},
+		{pattern: "GET /v1/webhooks/{webhookId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, webhookPath, nil, token)
+		}, want: statuses{200, 403, 403, 403, 403}},
+		{pattern: "DELETE /v1/webhooks/{webhookId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodDelete, "/v1/webhooks/"+newWebhook(t), nil, token)
+		}, want: statuses{204, 403, 403, 403, 403}},
+		{pattern: "GET /v1/webhooks/{webhookId}/deliveries", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, webhookPath+"/deliveries", nil, token)
+		}, want: statuses{200, 403, 403, 403, 403}},
+		{pattern: "GET /v1/webhooks/{webhookId}/deliveries/{deliveryId}", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodGet, webhookPath+"/deliveries/dlv-missing", nil, token)
+		}, want: statuses{404, 403, 403, 403, 403}},
+		{pattern: "POST /v1/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", req: func(t *testing.T, token string) *http.Request {
+			return authedRequest(http.MethodPost, webhookPath+"/deliveries/dlv-missing/redeliver", nil, token)
+		}, want: statuses{404, 403, 403, 403, 403}},
+	}
+	// public routes are reachable without a token, so have no rules to check.
+	public := []string{
+		"/health",
+		"POST /login",
+		"POST /v1/users",
+		"POST /v1/users/verify-email",
+		"POST /v1/password-reset",
+		"POST /v1/password-reset/confirm",
+		"POST /login/webauthn/begin",
+		"POST /login/webauthn/finish",
+	}
+
+	t.Run("every route should be in the matrix", func(t *testing.T) {
+		covered := make(map[string]bool)
+		for _, c := range cases {
+			covered[c.pattern] = true
+		}
+		for _, rt := range routes(args) {
+			assert.True(t, covered[rt.pattern] || slices.Contains(public, rt.pattern), "no role matrix entry for %s", rt.pattern)
+		}
+	})
+	t.Run("protected routes should 401 without a token", func(t *testing.T) {
+		for _, c := range cases {
+			req := c.req(t, "")
+			req.Header.Del("Authorization")
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, c.pattern)
+		}
+	})
+	for _, c := range cases {
+		name := c.name
+		if name == "" {
+			name = c.pattern
+		}
+		for userID, want := range map[string]int{
+			"usr-owner":   c.want.owner,
+			"usr-other":   c.want.other,
+			"usr-teller":  c.want.teller,
+			"usr-support": c.want.support,
+			"usr-auditor": c.want.auditor,
+		} {
+			t.Run(userID+" "+name, func(t *testing.T) {
+				rr := httptest.NewRecorder()
+				srv.ServeHTTP(rr, c.req(t, tokens[userID]))
+				assert.Equal(t, want, rr.Code, rr.Body.String())
+			})
+		}
+	}
+
+	t.Run("adjustment should be recorded against the staff member", func(t *testing.T) {
+		by, err := json.Marshal(CreateAdjustmentRequest{Amount: 5, Currency: "GBP", Direction: "debit", Reason: "fee refund reversal"})
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/accounts/"+acct.AccountNumber+"/adjustments", by, login(t, srv, "usr-teller")))
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var resp TransactionResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, transactions.AdjustmentDebit.String(), resp.Type)
+		assert.Equal(t, "usr-teller", *resp.UserID)
+		assert.Equal(t, "fee refund reversal", *resp.Reference)
+	})
+	t.Run("adjustment with invalid direction should 400", func(t *testing.T) {
+		by, err := json.Marshal(CreateAdjustmentRequest{Amount: 5, Currency: "GBP", Direction: "sideways", Reason: "oops"})
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/accounts/"+acct.AccountNumber+"/adjustments", by, login(t, srv, "usr-teller")))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func authedRequest(method, path string, body []byte, token string) *http.Request {
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func mustCreateTransaction(t *testing.T, srv http.Handler, token, acctNum string) TransactionResponse {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	reqObj := CreateTransactionRequest{Amount: 100, Currency: accounts.GBP.String(), Type: transactions.Deposit.String()}
+	srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acctNum, token))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var resp TransactionResponse
+	err := json.NewDecoder(rr.Body).Decode(&resp)
+	require.NoError(t, err)
+	return resp
+}
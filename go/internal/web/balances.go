@@ -0,0 +1,82 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/transactions"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// defaultBalanceHistoryDays is how far back a balance history goes when no from date is given.
+const defaultBalanceHistoryDays = 30
+
+// handleBalanceHistory returns the account's opening and closing balances for each day, week or
+// month between ?from= and ?to=, which are dates and default to the last 30 days.
+func handleBalanceHistory(svc TransactionService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		to, err := parseDateParam(q.Get("to"), time.Now())
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+		from, err := parseDateParam(q.Get("from"), to.AddDate(0, 0, -defaultBalanceHistoryDays))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+		interval := transactions.DayInterval
+		if i := q.Get("interval"); i != "" {
+			interval = transactions.Interval(i)
+		}
+		if !interval.IsValid() {
+			writeBadRequestErrorResponse(w, fmt.Errorf("invalid interval %q", interval))
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.ReadTransactions)
+		if err != nil {
+			return
+		}
+
+		balances, err := svc.BalanceHistory(acct.AccountNumber, from, to, interval)
+		if err != nil {
+			switch {
+			case errors.Is(err, transactions.ErrInvalidBalanceRange):
+				writeBadRequestErrorResponse(w, err)
+			case errors.Is(err, accounts.ErrAccountNotFound):
+				writeErrorResponse(w, http.StatusNotFound, err)
+			default:
+				writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		resp := BalanceHistoryResponse{
+			Currency: acct.Currency.String(),
+			Interval: interval.String(),
+			Balances: make([]BalanceIntervalResponse, 0, len(balances)),
+		}
+		for _, b := range balances {
+			resp.Balances = append(resp.Balances, newBalanceIntervalResponseFromDomain(b))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func parseDateParam(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: must be YYYY-MM-DD", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceHistory(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-other")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	otherToken := login(t, srv, "usr-other")
+	acct := mustCreateAccount(t, token, srv)
+	mustCreateTransaction(t, srv, token, acct.AccountNumber)
+	balancesPath := "/v1/accounts/" + acct.AccountNumber + "/balances"
+
+	get := func(t *testing.T, query, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodGet, balancesPath+query, nil, token))
+		return rr
+	}
+
+	t.Run("GET from /v1/accounts/{accountNumber}/balances", func(t *testing.T) {
+		t.Run("without a range should return the last 30 days", func(t *testing.T) {
+			rr := get(t, "", token)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp BalanceHistoryResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.NoError(t, validation.Get().Struct(resp))
+			assert.Equal(t, "GBP", resp.Currency)
+			assert.Equal(t, "day", resp.Interval)
+			require.Len(t, resp.Balances, 31)
+			today := resp.Balances[30]
+			assert.Equal(t, time.Now().UTC().Format(time.DateOnly), today.Start)
+			assert.Equal(t, 0.0, today.OpeningBalance)
+			assert.Equal(t, 100.0, today.ClosingBalance)
+		})
+		t.Run("with a range and interval should 200", func(t *testing.T) {
+			now := time.Now().UTC()
+			from := time.Date(now.Year(), now.Month()-2, 1, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+			rr := get(t, "?from="+from+"&interval=month", token)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp BalanceHistoryResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.Len(t, resp.Balances, 3)
+			assert.Equal(t, from, resp.Balances[0].Start)
+			assert.Equal(t, 100.0, resp.Balances[2].ClosingBalance)
+		})
+		t.Run("with a bad date, interval or range should 400", func(t *testing.T) {
+			for _, query := range []string{"?from=yesterday", "?interval=year", "?from=2025-02-01&to=2025-01-01", "?from=2020-01-01&to=2025-01-01"} {
+				rr := get(t, query, token)
+				assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+			}
+		})
+		t.Run("by a non-holder should 403", func(t *testing.T) {
+			rr := get(t, "", otherToken)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("for an unknown account should 404", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/accounts/01999990/balances", nil, token))
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+}
@@ -1,6 +1,10 @@
 package web
 
-import "context"
+import (
+	"context"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/users"
+)
 
 type contextKey string
 
@@ -23,3 +27,25 @@ func GetAuthenticatedUserID(ctx context.Context) string {
 	}
 	return userID
 }
+
+const RoleKey contextKey = "role"
+
+// GetAuthenticatedRole defaults to the customer role so tokens issued before roles existed keep
+// their original, least-privileged access.
+func GetAuthenticatedRole(ctx context.Context) string {
+	role := users.CustomerRole.String()
+	if r, ok := ctx.Value(RoleKey).(string); ok && r != "" {
+		role = r
+	}
+	return role
+}
+
+// IfMatchKey holds the versions listed in a request's If-Match header, see ifMatch.
+const IfMatchKey contextKey = "ifMatch"
+
+func getAuthenticatedSubject(ctx context.Context) authz.Subject {
+	return authz.Subject{
+		UserID: users.UserID(GetAuthenticatedUserID(ctx)),
+		Role:   users.Role(GetAuthenticatedRole(ctx)),
+	}
+}
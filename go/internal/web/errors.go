@@ -1,7 +1,9 @@
 package web
 
 import (
+	"eaglebank/internal/limits"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -16,3 +18,15 @@ func writeBadRequestErrorResponse(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusBadRequest)
 	json.NewEncoder(w).Encode(resp)
 }
+
+// writeLimitExceededResponse writes a 422 naming the limit hit, it returns false if err is not a
+// limit breach.
+func writeLimitExceededResponse(w http.ResponseWriter, err error) bool {
+	var exceeded *limits.ExceededError
+	if !errors.As(err, &exceeded) {
+		return false
+	}
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(newLimitExceededResponse(exceeded))
+	return true
+}
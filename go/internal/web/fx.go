@@ -0,0 +1,118 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/fx"
+	"eaglebank/internal/transactions"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func handleCreateQuote(svc FXService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateQuoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		domReq, err := req.toDomain(users.UserID(GetAuthenticatedUserID(r.Context())))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		quote, err := svc.CreateQuote(domReq)
+		if err != nil {
+			writeFXError(w, err)
+			return
+		}
+
+		resp := newQuoteResponseFromDomain(quote)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// handleExecuteQuote converts between two accounts at a previously quoted rate. The caller needs
+// permission to transact on both accounts.
+func handleExecuteQuote(svc FXService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateFXConversionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		domReq, err := req.toDomain(users.UserID(GetAuthenticatedUserID(r.Context())))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		for _, acctNum := range []accounts.AccountNumber{domReq.FromAccount, domReq.ToAccount} {
+			acct, err := acctSvc.FetchAccount(acctNum)
+			if err != nil {
+				if errors.Is(err, accounts.ErrAccountNotFound) {
+					writeErrorResponse(w, http.StatusNotFound, err)
+					return
+				}
+				writeErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+			if !access.authorize(w, r, authz.CreateTransaction, acct) {
+				return
+			}
+		}
+
+		debit, credit, err := svc.ExecuteQuote(domReq)
+		if err != nil {
+			writeFXError(w, err)
+			return
+		}
+
+		resp := FXConversionResponse{
+			Debit:  newTransactionResponseFromDomain(debit),
+			Credit: newTransactionResponseFromDomain(credit),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func writeFXError(w http.ResponseWriter, err error) {
+	if writeLimitExceededResponse(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, fx.ErrQuoteNotFound), errors.Is(err, accounts.ErrAccountNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, fx.ErrQuoteExpired), errors.Is(err, fx.ErrRateUnavailable),
+		errors.Is(err, transactions.ErrConversionUnavailable), errors.Is(err, transactions.ErrCurrencyMismatch),
+		errors.Is(err, accounts.ErrInsufficientFunds), errors.Is(err, accounts.ErrWithdrawalLimitReached):
+		writeErrorResponse(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, accounts.ErrAccountFrozen), errors.Is(err, accounts.ErrAccountDormant), errors.Is(err, accounts.ErrAccountClosed),
+		errors.Is(err, transactions.ErrSequenceConflict), errors.Is(err, accounts.ErrConflict):
+		writeErrorResponse(w, http.StatusConflict, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
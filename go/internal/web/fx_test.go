@@ -0,0 +1,157 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/fx"
+	adapters3 "eaglebank/internal/fx/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFX(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	converter := fx.NewConverter(fx.RateTable{Base: accounts.GBP, Rates: map[accounts.Currency]float64{accounts.EUR: 1.25}}, 0.02)
+	fxSvc := fx.NewFXService(converter, adapters3.NewInMemoryQuoteStore(), tanSvc, time.Minute)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc, FXSvc: fxSvc})
+
+	token := login(t, srv, "usr-testuser")
+	gbpAcct := mustCreateAccount(t, token, srv)
+	mustCreateTransaction(t, srv, token, gbpAcct.AccountNumber)
+	eurAcct := mustCreateCurrencyAccount(t, token, srv, accounts.EUR)
+	otherAcct := mustCreateCurrencyAccount(t, login(t, srv, "usr-testuser2"), srv, accounts.EUR)
+
+	createQuote := func(t *testing.T, reqObj CreateQuoteRequest, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		by, err := json.Marshal(reqObj)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/fx/quotes", by, token))
+		return rr
+	}
+	mustCreateQuote := func(t *testing.T, amt float64) QuoteResponse {
+		t.Helper()
+		rr := createQuote(t, CreateQuoteRequest{FromCurrency: "GBP", ToCurrency: "EUR", Amount: amt}, token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var resp QuoteResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		return resp
+	}
+	convert := func(t *testing.T, reqObj CreateFXConversionRequest, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		by, err := json.Marshal(reqObj)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/fx/conversions", by, token))
+		return rr
+	}
+
+	t.Run("POST to /v1/fx/quotes", func(t *testing.T) {
+		t.Run("with required data should 201", func(t *testing.T) {
+			quote := mustCreateQuote(t, 100)
+			assert.Equal(t, 122.5, quote.ToAmount)
+			assert.InDelta(t, 1.225, quote.Rate, 1e-9)
+			assert.Equal(t, 0.02, quote.Spread)
+			assert.True(t, quote.ExpiresTimestamp.After(time.Now()))
+		})
+		t.Run("for the same currency should 400", func(t *testing.T) {
+			rr := createQuote(t, CreateQuoteRequest{FromCurrency: "GBP", ToCurrency: "GBP", Amount: 10}, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("without a rate should 422", func(t *testing.T) {
+			rr := createQuote(t, CreateQuoteRequest{FromCurrency: "GBP", ToCurrency: "USD", Amount: 10}, token)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("without authentication should 401", func(t *testing.T) {
+			rr := createQuote(t, CreateQuoteRequest{FromCurrency: "GBP", ToCurrency: "EUR", Amount: 10}, "")
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+	})
+	t.Run("POST to /v1/fx/conversions", func(t *testing.T) {
+		t.Run("with valid quote should 201 and record both legs", func(t *testing.T) {
+			quote := mustCreateQuote(t, 40)
+			ref := "holiday money"
+			rr := convert(t, CreateFXConversionRequest{QuoteID: quote.ID, FromAccountNumber: gbpAcct.AccountNumber, ToAccountNumber: eurAcct.AccountNumber, Reference: &ref}, token)
+			require.Equal(t, http.StatusCreated, rr.Code)
+
+			var resp FXConversionResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, transactions.ConversionDebit.String(), resp.Debit.Type)
+			assert.Equal(t, 40.0, resp.Debit.Amount)
+			assert.Equal(t, "GBP", resp.Debit.Currency)
+			assert.Equal(t, &ConversionResponse{Amount: 49, Currency: "EUR", Rate: quote.Rate, Spread: 0.02}, resp.Debit.Conversion)
+			assert.Equal(t, transactions.ConversionCredit.String(), resp.Credit.Type)
+			assert.Equal(t, 49.0, resp.Credit.Amount)
+			assert.Equal(t, "EUR", resp.Credit.Currency)
+			assert.Equal(t, &ConversionResponse{Amount: 40, Currency: "GBP", Rate: quote.Rate, Spread: 0.02}, resp.Credit.Conversion)
+			assert.Equal(t, ref, *resp.Credit.Reference)
+
+			for acctNum, want := range map[string]float64{gbpAcct.AccountNumber: 60, eurAcct.AccountNumber: 49} {
+				acctRR := httptest.NewRecorder()
+				srv.ServeHTTP(acctRR, fetchAccountRequest(t, acctNum, token))
+				var acct BankAccountResponse
+				require.NoError(t, json.NewDecoder(acctRR.Body).Decode(&acct))
+				assert.Equal(t, want, acct.Balance)
+			}
+
+			rr = convert(t, CreateFXConversionRequest{QuoteID: quote.ID, FromAccountNumber: gbpAcct.AccountNumber, ToAccountNumber: eurAcct.AccountNumber}, token)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+		t.Run("into another user's account should 403", func(t *testing.T) {
+			quote := mustCreateQuote(t, 10)
+			rr := convert(t, CreateFXConversionRequest{QuoteID: quote.ID, FromAccountNumber: gbpAcct.AccountNumber, ToAccountNumber: otherAcct.AccountNumber}, token)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("with accounts in the wrong currencies should 422", func(t *testing.T) {
+			quote := mustCreateQuote(t, 10)
+			rr := convert(t, CreateFXConversionRequest{QuoteID: quote.ID, FromAccountNumber: eurAcct.AccountNumber, ToAccountNumber: gbpAcct.AccountNumber}, token)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("with insufficient funds should 422", func(t *testing.T) {
+			quote := mustCreateQuote(t, 1000)
+			rr := convert(t, CreateFXConversionRequest{QuoteID: quote.ID, FromAccountNumber: gbpAcct.AccountNumber, ToAccountNumber: eurAcct.AccountNumber}, token)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("with another user's quote should 404", func(t *testing.T) {
+			quote := mustCreateQuote(t, 10)
+			otherToken := login(t, srv, "usr-testuser2")
+			otherGBP := mustCreateAccount(t, otherToken, srv)
+			rr := convert(t, CreateFXConversionRequest{QuoteID: quote.ID, FromAccountNumber: otherGBP.AccountNumber, ToAccountNumber: otherAcct.AccountNumber}, otherToken)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+		t.Run("with invalid quote ID should 400", func(t *testing.T) {
+			rr := convert(t, CreateFXConversionRequest{QuoteID: "bad", FromAccountNumber: gbpAcct.AccountNumber, ToAccountNumber: eurAcct.AccountNumber}, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+}
+
+func mustCreateCurrencyAccount(t *testing.T, token string, srv http.Handler, curr accounts.Currency) BankAccountResponse {
+	t.Helper()
+	currStr := curr.String()
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, createAccountRequest(t, CreateBankAccountRequest{
+		Name:        "Mr Foo's " + currStr + " Account",
+		AccountType: accounts.CurrentAcct.String(),
+		Currency:    &currStr,
+	}, token))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var resp BankAccountResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	return resp
+}
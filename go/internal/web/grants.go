@@ -0,0 +1,182 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/grants"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func handleCreateGrant(svc GrantService, acctSvc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateGrantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, ok := fetchAccountForGrants(w, r, acctSvc, policy)
+		if !ok {
+			return
+		}
+
+		userID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		domReq, err := req.toDomain(acct.AccountNumber, userID)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		grant, err := svc.CreateGrant(domReq, accountPreconditions(r)...)
+		if err != nil {
+			writeGrantError(w, err)
+			return
+		}
+
+		resp := newGrantResponseFromDomain(grant)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleListAccountGrants(svc GrantService, acctSvc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acct, ok := fetchAccountForGrants(w, r, acctSvc, policy)
+		if !ok {
+			return
+		}
+
+		grnts, err := svc.ListAccountGrants(acct.AccountNumber)
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeGrantsResponse(w, grnts)
+	}
+}
+
+func handleListReceivedGrants(svc GrantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetAuthenticatedUserID(r.Context())
+		grnts, err := svc.ListReceivedGrants(users.UserID(userID))
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeGrantsResponse(w, grnts)
+	}
+}
+
+func handleRevokeGrant(svc GrantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		grantID, err := grants.NewGrantID(r.PathValue("grantId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		grant, err := svc.RevokeGrant(grantID, users.UserID(userID))
+		if err != nil {
+			writeGrantError(w, err)
+			return
+		}
+
+		resp := newGrantResponseFromDomain(grant)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleListGrantAccesses(svc GrantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		grantID, err := grants.NewGrantID(r.PathValue("grantId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		accesses, err := svc.ListAccesses(grantID, users.UserID(userID))
+		if err != nil {
+			writeGrantError(w, err)
+			return
+		}
+
+		accessResps := make([]GrantAccessResponse, 0, len(accesses))
+		for _, a := range accesses {
+			accessResps = append(accessResps, GrantAccessResponse{
+				GranteeID: a.GranteeID.String(),
+				Scope:     a.Scope.String(),
+				Timestamp: a.Timestamp,
+			})
+		}
+
+		resp := ListGrantAccessesResponse{Accesses: accessResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func fetchAccountForGrants(w http.ResponseWriter, r *http.Request, svc AccountService, policy authz.Policy) (accounts.BankAccount, bool) {
+	acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+	if err != nil {
+		writeBadRequestErrorResponse(w, err)
+		return accounts.BankAccount{}, false
+	}
+
+	acct, err := svc.FetchAccount(acctNum)
+	if err != nil {
+		if errors.Is(err, accounts.ErrAccountNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, err)
+			return accounts.BankAccount{}, false
+		}
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+		return accounts.BankAccount{}, false
+	}
+
+	if !authorize(w, r, policy, authz.ManageGrants, authz.OwnedBy(acct.HolderIDs()...)) {
+		return accounts.BankAccount{}, false
+	}
+	return acct, true
+}
+
+func writeGrantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, accounts.ErrPreconditionFailed):
+		writeErrorResponse(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, grants.ErrGrantNotFound), errors.Is(err, users.ErrUserNotFound), errors.Is(err, accounts.ErrAccountNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, grants.ErrNotGrantor), errors.Is(err, accounts.ErrNotHolder):
+		writeErrorResponse(w, http.StatusForbidden, err)
+	case errors.Is(err, grants.ErrInvalidGrant):
+		writeBadRequestErrorResponse(w, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
+
+func writeGrantsResponse(w http.ResponseWriter, grnts []grants.Grant) {
+	grantResps := make([]GrantResponse, 0, len(grnts))
+	for _, grant := range grnts {
+		grantResps = append(grantResps, newGrantResponseFromDomain(grant))
+	}
+
+	resp := ListGrantsResponse{Grants: grantResps}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
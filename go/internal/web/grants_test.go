@@ -0,0 +1,150 @@
+package web
+
+import (
+	"bytes"
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/grants"
+	adapters3 "eaglebank/internal/grants/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrants(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-owner", "usr-accountant")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	grantSvc := grants.NewGrantService(adapters3.NewInMemoryGrantStore(), adapters3.NewInMemoryAccessLog(), acctStore, usrStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc, GrantSvc: grantSvc})
+
+	ownerToken := login(t, srv, "usr-owner")
+	accountantToken := login(t, srv, "usr-accountant")
+	acct := mustCreateAccount(t, ownerToken, srv)
+	tan := mustCreateTransaction(t, srv, ownerToken, acct.AccountNumber)
+	grantsPath := "/v1/accounts/" + acct.AccountNumber + "/grants"
+
+	var grant GrantResponse
+	t.Run("POST to /v1/accounts/{accountNumber}/grants", func(t *testing.T) {
+		t.Run("valid grant should 201", func(t *testing.T) {
+			grant = createGrant(t, srv, ownerToken, grantsPath, CreateGrantRequest{
+				GranteeID: "usr-accountant",
+				Scopes:    []string{"balance"},
+				Expires:   time.Now().Add(time.Hour),
+			})
+			assert.Equal(t, "usr-owner", grant.GrantorID)
+			assert.Nil(t, grant.RevokedTimestamp)
+		})
+		t.Run("unknown scope should 400", func(t *testing.T) {
+			by, err := json.Marshal(CreateGrantRequest{GranteeID: "usr-accountant", Scopes: []string{"withdraw"}, Expires: time.Now().Add(time.Hour)})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, grantsPath, by, ownerToken))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("expiry in the past should 400", func(t *testing.T) {
+			by, err := json.Marshal(CreateGrantRequest{GranteeID: "usr-accountant", Scopes: []string{"balance"}, Expires: time.Now().Add(-time.Hour)})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, grantsPath, by, ownerToken))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("non-holder should 403", func(t *testing.T) {
+			by, err := json.Marshal(CreateGrantRequest{GranteeID: "usr-accountant", Scopes: []string{"balance"}, Expires: time.Now().Add(time.Hour)})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, grantsPath, by, accountantToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	})
+	t.Run("access through grant", func(t *testing.T) {
+		t.Run("granted scope should 200 and be logged", func(t *testing.T) {
+			logs.Reset()
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, accountantToken))
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, logs.String(), `"msg":"account accessed through grant"`)
+			assert.Contains(t, logs.String(), grant.ID)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/grants/"+grant.ID+"/accesses", nil, ownerToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp ListGrantAccessesResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.Len(t, resp.Accesses, 1)
+			assert.Equal(t, "balance", resp.Accesses[0].Scope)
+		})
+		t.Run("ungranted scope should 403", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, listTransactionRequest(t, acct.AccountNumber, accountantToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("grant should never allow writes", func(t *testing.T) {
+			createGrant(t, srv, ownerToken, grantsPath, CreateGrantRequest{
+				GranteeID: "usr-accountant",
+				Scopes:    []string{"transactions"},
+				Expires:   time.Now().Add(time.Hour),
+			})
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchTransactionRequest(t, acct.AccountNumber, tan.ID, accountantToken))
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			rr = httptest.NewRecorder()
+			reqObj := CreateTransactionRequest{Amount: 1, Currency: "GBP", Type: "withdrawal"}
+			srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acct.AccountNumber, accountantToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("grantee should see received grants", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/grants", nil, accountantToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp ListGrantsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Len(t, resp.Grants, 2)
+		})
+	})
+	t.Run("DELETE to /v1/grants/{grantId}", func(t *testing.T) {
+		t.Run("grantee should 403", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodDelete, "/v1/grants/"+grant.ID, nil, accountantToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("grantor should 200 and access should stop", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodDelete, "/v1/grants/"+grant.ID, nil, ownerToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp GrantResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.NotNil(t, resp.RevokedTimestamp)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, accountantToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	})
+}
+
+func createGrant(t *testing.T, srv http.Handler, token, path string, reqObj CreateGrantRequest) GrantResponse {
+	t.Helper()
+	by, err := json.Marshal(reqObj)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, authedRequest(http.MethodPost, path, by, token))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var resp GrantResponse
+	err = json.NewDecoder(rr.Body).Decode(&resp)
+	require.NoError(t, err)
+	return resp
+}
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func handleRequestAddHolder(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AddAccountHolderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, ok := fetchAccountForHolderChange(w, r, svc, policy)
+		if !ok {
+			return
+		}
+
+		userID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		change, err := svc.RequestAddHolder(acct.AccountNumber, userID, users.UserID(req.UserID), accountPreconditions(r)...)
+		if err != nil {
+			writeHolderChangeError(w, err)
+			return
+		}
+
+		writeHolderChangeResponse(w, change)
+	}
+}
+
+// handleRequestRemoveHolder records the caller's consent to the removal. It responds 202 while
+// other holders still need to consent and 200 once the holder has been removed.
+func handleRequestRemoveHolder(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		holderID, err := users.NewUserID(r.PathValue("userId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, ok := fetchAccountForHolderChange(w, r, svc, policy)
+		if !ok {
+			return
+		}
+
+		userID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		change, err := svc.RequestRemoveHolder(acct.AccountNumber, userID, holderID, accountPreconditions(r)...)
+		if err != nil {
+			writeHolderChangeError(w, err)
+			return
+		}
+
+		writeHolderChangeResponse(w, change)
+	}
+}
+
+func handleListHolderChanges(svc AccountService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetAuthenticatedUserID(r.Context())
+		changes, err := svc.ListPendingHolderChanges(users.UserID(userID))
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		changeResps := make([]HolderChangeResponse, 0, len(changes))
+		for _, change := range changes {
+			changeResps = append(changeResps, newHolderChangeResponseFromDomain(change))
+		}
+
+		resp := ListHolderChangesResponse{Changes: changeResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleApproveHolderChange(svc AccountService) http.HandlerFunc {
+	return handleDecideHolderChange(func(id accounts.HolderChangeID, userID users.UserID) (accounts.HolderChange, error) {
+		return svc.ApproveHolderChange(id, userID)
+	})
+}
+
+func handleRejectHolderChange(svc AccountService) http.HandlerFunc {
+	return handleDecideHolderChange(func(id accounts.HolderChangeID, userID users.UserID) (accounts.HolderChange, error) {
+		return svc.RejectHolderChange(id, userID)
+	})
+}
+
+func handleDecideHolderChange(decide func(accounts.HolderChangeID, users.UserID) (accounts.HolderChange, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changeID, err := accounts.NewHolderChangeID(r.PathValue("changeId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		change, err := decide(changeID, users.UserID(userID))
+		if err != nil {
+			writeHolderChangeError(w, err)
+			return
+		}
+
+		writeHolderChangeResponse(w, change)
+	}
+}
+
+func fetchAccountForHolderChange(w http.ResponseWriter, r *http.Request, svc AccountService, policy authz.Policy) (accounts.BankAccount, bool) {
+	acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+	if err != nil {
+		writeBadRequestErrorResponse(w, err)
+		return accounts.BankAccount{}, false
+	}
+
+	acct, err := svc.FetchAccount(acctNum)
+	if err != nil {
+		if errors.Is(err, accounts.ErrAccountNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, err)
+			return accounts.BankAccount{}, false
+		}
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+		return accounts.BankAccount{}, false
+	}
+
+	if !authorize(w, r, policy, authz.ManageHolders, authz.OwnedBy(acct.HolderIDs()...)) {
+		return accounts.BankAccount{}, false
+	}
+	return acct, true
+}
+
+func writeHolderChangeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, accounts.ErrPreconditionFailed):
+		writeErrorResponse(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, accounts.ErrHolderChangeNotFound), errors.Is(err, accounts.ErrNotHolder), errors.Is(err, users.ErrUserNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, accounts.ErrNotApprover):
+		writeErrorResponse(w, http.StatusForbidden, err)
+	case errors.Is(err, accounts.ErrAlreadyHolder), errors.Is(err, accounts.ErrHolderChangePending),
+		errors.Is(err, accounts.ErrHolderChangeClosed), errors.Is(err, accounts.ErrLastHolder), errors.Is(err, accounts.ErrAccountClosed),
+		errors.Is(err, accounts.ErrConflict):
+		writeErrorResponse(w, http.StatusConflict, err)
+	case errors.Is(err, users.ErrEmailNotVerified):
+		writeErrorResponse(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
+
+func writeHolderChangeResponse(w http.ResponseWriter, change accounts.HolderChange) {
+	status := http.StatusOK
+	if change.Status == accounts.PendingChange {
+		status = http.StatusAccepted
+	}
+	resp := newHolderChangeResponseFromDomain(change)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,131 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJointAccounts(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-alice", "usr-bob", "usr-carol")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc})
+
+	aliceToken := login(t, srv, "usr-alice")
+	bobToken := login(t, srv, "usr-bob")
+	carolToken := login(t, srv, "usr-carol")
+	acct := mustCreateAccount(t, aliceToken, srv)
+	holdersPath := "/v1/accounts/" + acct.AccountNumber + "/holders"
+
+	t.Run("POST to /v1/accounts/{accountNumber}/holders", func(t *testing.T) {
+		t.Run("invite should 202 and be listed for the invitee", func(t *testing.T) {
+			by, err := json.Marshal(AddAccountHolderRequest{UserID: "usr-bob"})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, holdersPath, by, aliceToken))
+			require.Equal(t, http.StatusAccepted, rr.Code)
+
+			var resp HolderChangeResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, "add", resp.Kind)
+			assert.Equal(t, "pending", resp.Status)
+
+			changes := listHolderChanges(t, srv, bobToken)
+			require.Len(t, changes, 1)
+			assert.Equal(t, resp.ID, changes[0].ID)
+		})
+		t.Run("invitee should not see account before accepting", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, bobToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("non-holder invite should 403", func(t *testing.T) {
+			by, err := json.Marshal(AddAccountHolderRequest{UserID: "usr-carol"})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, holdersPath, by, carolToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("invalid user ID should 400", func(t *testing.T) {
+			by, err := json.Marshal(AddAccountHolderRequest{UserID: "bob"})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, holdersPath, by, aliceToken))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+	t.Run("POST to /v1/holder-changes/{changeId}/approve", func(t *testing.T) {
+		changeID := listHolderChanges(t, srv, bobToken)[0].ID
+		t.Run("other user should 403", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/holder-changes/"+changeID+"/approve", nil, carolToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("invitee should 200 and gain access", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/holder-changes/"+changeID+"/approve", nil, bobToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, listAccountsRequest(t, bobToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp ListBankAccountsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.Len(t, resp.Accounts, 1)
+			assert.Len(t, resp.Accounts[0].Holders, 2)
+
+			tan := mustCreateTransaction(t, srv, bobToken, acct.AccountNumber)
+			assert.Equal(t, "usr-bob", *tan.UserID)
+		})
+		t.Run("approving again should 409", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/holder-changes/"+changeID+"/approve", nil, bobToken))
+			assert.Equal(t, http.StatusConflict, rr.Code)
+		})
+	})
+	t.Run("DELETE to /v1/accounts/{accountNumber}/holders/{userId}", func(t *testing.T) {
+		t.Run("holder leaving should 202 until remaining holder consents", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodDelete, holdersPath+"/usr-bob", nil, bobToken))
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodDelete, holdersPath+"/usr-bob", nil, aliceToken))
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, bobToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("removing the last holder should 409", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodDelete, holdersPath+"/usr-alice", nil, aliceToken))
+			assert.Equal(t, http.StatusConflict, rr.Code)
+		})
+	})
+}
+
+func listHolderChanges(t *testing.T, srv http.Handler, token string) []HolderChangeResponse {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/holder-changes", nil, token))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp ListHolderChangesResponse
+	err := json.NewDecoder(rr.Body).Decode(&resp)
+	require.NoError(t, err)
+	return resp.Changes
+}
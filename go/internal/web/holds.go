@@ -0,0 +1,128 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// handlePlaceHold lets staff reserve funds on an account, for example for a card authorisation or
+// a legal hold. The held amount comes off the available balance but no money moves.
+func handlePlaceHold(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		var req PlaceHoldRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		if !authorizeStaff(w, r, svc, policy, authz.ManageHolds, acctNum) {
+			return
+		}
+
+		staffID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		hold, err := svc.PlaceHold(acctNum, req.Amount, accounts.HoldReason(req.Reason), req.Expires, staffID, accountPreconditions(r)...)
+		if err != nil {
+			writeHoldError(w, err)
+			return
+		}
+
+		resp := newHoldResponseFromDomain(hold)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleListHolds(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		if !authorizeStaff(w, r, svc, policy, authz.ManageHolds, acctNum) {
+			return
+		}
+
+		holds, err := svc.ListHolds(acctNum)
+		if err != nil {
+			writeHoldError(w, err)
+			return
+		}
+
+		holdResps := make([]HoldResponse, 0, len(holds))
+		for _, hold := range holds {
+			holdResps = append(holdResps, newHoldResponseFromDomain(hold))
+		}
+
+		resp := ListHoldsResponse{Holds: holdResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleReleaseHold(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+		holdID, err := accounts.NewHoldID(r.PathValue("holdId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		if !authorizeStaff(w, r, svc, policy, authz.ManageHolds, acctNum) {
+			return
+		}
+
+		staffID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		hold, err := svc.ReleaseHold(acctNum, holdID, staffID, accountPreconditions(r)...)
+		if err != nil {
+			writeHoldError(w, err)
+			return
+		}
+
+		resp := newHoldResponseFromDomain(hold)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func writeHoldError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, accounts.ErrPreconditionFailed):
+		writeErrorResponse(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, accounts.ErrAccountNotFound), errors.Is(err, accounts.ErrHoldNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, accounts.ErrHoldNotActive), errors.Is(err, accounts.ErrAccountClosed), errors.Is(err, accounts.ErrConflict):
+		writeErrorResponse(w, http.StatusConflict, err)
+	case errors.Is(err, accounts.ErrInsufficientFunds), errors.Is(err, accounts.ErrInvalidHoldAmount),
+		errors.Is(err, accounts.ErrHoldExpiryInPast):
+		writeErrorResponse(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHolds(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-teller")
+	mustAssignStaffRole(t, usrSvc, usrStore, "usr-teller", users.TellerRole)
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	tellerToken := login(t, srv, "usr-teller")
+
+	placeHold := func(t *testing.T, acctNum string, req PlaceHoldRequest, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		by, err := json.Marshal(req)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/accounts/"+acctNum+"/holds", by, token))
+		return rr
+	}
+	mustPlaceHold := func(t *testing.T, acctNum string, amt float64) HoldResponse {
+		t.Helper()
+		rr := placeHold(t, acctNum, PlaceHoldRequest{Amount: amt, Reason: "card_authorisation", Expires: time.Now().Add(time.Hour)}, tellerToken)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var resp HoldResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		return resp
+	}
+	releaseHold := func(t *testing.T, acctNum, holdID, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodDelete, "/v1/accounts/"+acctNum+"/holds/"+holdID, nil, token))
+		return rr
+	}
+	fetchAccount := func(t *testing.T, acctNum string) BankAccountResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, fetchAccountRequest(t, acctNum, token))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp BankAccountResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		return resp
+	}
+	withdraw := func(t *testing.T, acctNum string, amt float64) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		reqObj := CreateTransactionRequest{Amount: amt, Currency: accounts.GBP.String(), Type: transactions.Withdrawal.String()}
+		srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acctNum, token))
+		return rr
+	}
+
+	t.Run("POST to /v1/accounts/{accountNumber}/holds", func(t *testing.T) {
+		t.Run("by staff should 201 and reduce the available balance", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			hold := mustPlaceHold(t, acct.AccountNumber, 60)
+			require.NoError(t, validation.Get().Struct(hold))
+			assert.Equal(t, "active", hold.Status)
+			assert.Equal(t, "usr-teller", hold.CreatedBy)
+
+			got := fetchAccount(t, acct.AccountNumber)
+			assert.Equal(t, 100.0, got.Balance)
+			assert.Equal(t, 60.0, got.HeldAmount)
+			assert.Equal(t, 40.0, got.AvailableBalance)
+		})
+		t.Run("should refuse withdrawals into held funds", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			mustPlaceHold(t, acct.AccountNumber, 60)
+			assert.Equal(t, http.StatusUnprocessableEntity, withdraw(t, acct.AccountNumber, 50).Code)
+			assert.Equal(t, http.StatusCreated, withdraw(t, acct.AccountNumber, 40).Code)
+		})
+		t.Run("for more than the available balance should 422", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := placeHold(t, acct.AccountNumber, PlaceHoldRequest{Amount: 10, Reason: "legal_hold", Expires: time.Now().Add(time.Hour)}, tellerToken)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("with an expiry in the past should 422", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			rr := placeHold(t, acct.AccountNumber, PlaceHoldRequest{Amount: 10, Reason: "legal_hold", Expires: time.Now().Add(-time.Hour)}, tellerToken)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("with an unknown reason should 400", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := placeHold(t, acct.AccountNumber, PlaceHoldRequest{Amount: 10, Reason: "because", Expires: time.Now().Add(time.Hour)}, tellerToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("by the account holder should 403", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := placeHold(t, acct.AccountNumber, PlaceHoldRequest{Amount: 10, Reason: "legal_hold", Expires: time.Now().Add(time.Hour)}, token)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("for a missing account should 404", func(t *testing.T) {
+			rr := placeHold(t, "01999990", PlaceHoldRequest{Amount: 10, Reason: "legal_hold", Expires: time.Now().Add(time.Hour)}, tellerToken)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+
+	t.Run("GET to /v1/accounts/{accountNumber}/holds", func(t *testing.T) {
+		acct := mustCreateAccount(t, token, srv)
+		mustCreateTransaction(t, srv, token, acct.AccountNumber)
+		hold := mustPlaceHold(t, acct.AccountNumber, 10)
+
+		t.Run("by staff should 200", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/accounts/"+acct.AccountNumber+"/holds", nil, tellerToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp ListHoldsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.Len(t, resp.Holds, 1)
+			assert.Equal(t, hold.ID, resp.Holds[0].ID)
+		})
+		t.Run("by the account holder should 403", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/accounts/"+acct.AccountNumber+"/holds", nil, token))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	})
+
+	t.Run("DELETE to /v1/accounts/{accountNumber}/holds/{holdId}", func(t *testing.T) {
+		t.Run("by staff should 200 and free the funds", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			hold := mustPlaceHold(t, acct.AccountNumber, 60)
+
+			rr := releaseHold(t, acct.AccountNumber, hold.ID, tellerToken)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp HoldResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, "released", resp.Status)
+			assert.Equal(t, "usr-teller", *resp.ReleasedBy)
+			assert.Equal(t, 100.0, fetchAccount(t, acct.AccountNumber).AvailableBalance)
+		})
+		t.Run("twice should 409", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			hold := mustPlaceHold(t, acct.AccountNumber, 60)
+			releaseHold(t, acct.AccountNumber, hold.ID, tellerToken)
+			assert.Equal(t, http.StatusConflict, releaseHold(t, acct.AccountNumber, hold.ID, tellerToken).Code)
+		})
+		t.Run("for another account's hold should 404", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			hold := mustPlaceHold(t, acct.AccountNumber, 60)
+			other := mustCreateAccount(t, token, srv)
+			assert.Equal(t, http.StatusNotFound, releaseHold(t, other.AccountNumber, hold.ID, tellerToken).Code)
+		})
+		t.Run("by the account holder should 403", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			hold := mustPlaceHold(t, acct.AccountNumber, 60)
+			assert.Equal(t, http.StatusForbidden, releaseHold(t, acct.AccountNumber, hold.ID, token).Code)
+		})
+	})
+}
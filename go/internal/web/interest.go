@@ -0,0 +1,34 @@
+package web
+
+import (
+	"eaglebank/internal/authz"
+	"encoding/json"
+	"net/http"
+)
+
+// handleListAccruals lists an account's daily interest accruals so the interest paid can be
+// audited. Anyone who can read the account's transactions can read its accruals.
+func handleListAccruals(svc InterestService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.ReadTransactions)
+		if err != nil {
+			return
+		}
+
+		accruals, err := svc.ListAccruals(acct.AccountNumber)
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		accrualResps := make([]AccrualResponse, 0, len(accruals))
+		for _, accrual := range accruals {
+			accrualResps = append(accrualResps, newAccrualResponseFromDomain(accrual))
+		}
+
+		resp := ListAccrualsResponse{Accruals: accrualResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/interest"
+	adapters3 "eaglebank/internal/interest/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccruals(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	interestSvc, err := interest.NewInterestService(interest.Config{
+		Rates:    map[accounts.AccountType][]interest.Tier{accounts.SavingsAcct: {{From: 0, AnnualRate: 0.0365}}},
+		DayCount: interest.Actual365,
+	}, adapters3.NewInMemoryAccrualStore(), acctStore, tanSvc)
+	require.NoError(t, err)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc, InterestSvc: interestSvc})
+
+	token := login(t, srv, "usr-testuser")
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, createAccountRequest(t, CreateBankAccountRequest{Name: "Mr Foo's Savings", AccountType: accounts.SavingsAcct.String()}, token))
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var acct BankAccountResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&acct))
+	mustCreateTransaction(t, srv, token, acct.AccountNumber)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	_, err = interestSvc.AccrueDay(day)
+	require.NoError(t, err)
+
+	listAccruals := func(acctNum, token string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/accounts/"+acctNum+"/interest-accruals", nil, token))
+		return rr
+	}
+
+	t.Run("GET to /v1/accounts/{accountNumber}/interest-accruals", func(t *testing.T) {
+		t.Run("for the owner should 200", func(t *testing.T) {
+			rr := listAccruals(acct.AccountNumber, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp ListAccrualsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.NoError(t, validation.Get().Struct(resp))
+			require.Len(t, resp.Accruals, 1)
+			assert.Equal(t, "2026-03-01", resp.Accruals[0].Date)
+			assert.Equal(t, "actual/365", resp.Accruals[0].DayCount)
+			assert.InDelta(t, 0.01, resp.Accruals[0].Amount, 1e-12)
+			assert.Nil(t, resp.Accruals[0].TransactionID)
+		})
+		t.Run("for another user's account should 403", func(t *testing.T) {
+			rr := listAccruals(acct.AccountNumber, login(t, srv, "usr-testuser2"))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("for a missing account should 404", func(t *testing.T) {
+			rr := listAccruals("01999990", token)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+		t.Run("without a token should 401", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/accounts/"+acct.AccountNumber+"/interest-accruals", nil))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+	})
+}
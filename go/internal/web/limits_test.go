@@ -0,0 +1,88 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-support")
+	mustAssignStaffRole(t, usrSvc, usrStore, "usr-support", users.SupportRole)
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	supportToken := login(t, srv, "usr-support")
+
+	deposit := func(t *testing.T, acctNum string, amt float64) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		reqObj := CreateTransactionRequest{Amount: amt, Currency: accounts.GBP.String(), Type: transactions.Deposit.String()}
+		srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acctNum, token))
+		return rr
+	}
+	changeTier := func(t *testing.T, userID, tier, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		by, err := json.Marshal(ChangeUserTierRequest{Tier: tier})
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPut, "/v1/users/"+userID+"/tier", by, token))
+		return rr
+	}
+
+	acct := mustCreateAccount(t, token, srv)
+
+	t.Run("POST to /v1/accounts/{accountNumber}/transactions", func(t *testing.T) {
+		t.Run("should 422 naming the limit exceeded", func(t *testing.T) {
+			rr := deposit(t, acct.AccountNumber, 10000.01)
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+			var resp LimitExceededResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.NoError(t, validation.Get().Struct(resp))
+			assert.Equal(t, "max_transaction", resp.Limit)
+			assert.Equal(t, 10000.0, resp.Max)
+			assert.Equal(t, 10000.01, resp.Attempted)
+		})
+	})
+	t.Run("PUT to /v1/users/{userId}/tier", func(t *testing.T) {
+		t.Run("by a customer should 403", func(t *testing.T) {
+			rr := changeTier(t, "usr-testuser", "premium", token)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("with an unknown tier should 400", func(t *testing.T) {
+			rr := changeTier(t, "usr-testuser", "gold", supportToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("for an unknown user should 404", func(t *testing.T) {
+			rr := changeTier(t, "usr-nobody", "premium", supportToken)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+		t.Run("by support should 200 and raise the account limits", func(t *testing.T) {
+			rr := changeTier(t, "usr-testuser", "premium", supportToken)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp UserResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, "premium", resp.Tier)
+
+			rr = deposit(t, acct.AccountNumber, 20000)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+		})
+	})
+}
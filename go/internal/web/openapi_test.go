@@ -0,0 +1,216 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/events"
+	"eaglebank/internal/limits"
+	"eaglebank/internal/transactions"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type openAPISpec struct {
+	Components struct {
+		Schemas map[string]struct {
+			Enum       []string `yaml:"enum"`
+			Properties map[string]struct {
+				Enum []string `yaml:"enum"`
+			} `yaml:"properties"`
+		} `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+func readOpenAPISpec(t *testing.T) openAPISpec {
+	t.Helper()
+	by, err := os.ReadFile("../../../openapi.yaml")
+	require.NoError(t, err)
+
+	var spec openAPISpec
+	require.NoError(t, yaml.Unmarshal(by, &spec))
+	return spec
+}
+
+// TestOpenAPIAccountTypes keeps openapi.yaml and the acctType validator in step with the account
+// types defined in the accounts package.
+func TestOpenAPIAccountTypes(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var want []string
+	for _, acctType := range accounts.AccountTypes() {
+		want = append(want, acctType.String())
+	}
+
+	for _, schema := range []string{"CreateBankAccountRequest", "UpdateBankAccountRequest", "BankAccountResponse"} {
+		t.Run("should list every account type in "+schema, func(t *testing.T) {
+			require.Contains(t, spec.Components.Schemas, schema)
+			assert.Equal(t, want, spec.Components.Schemas[schema].Properties["accountType"].Enum)
+		})
+	}
+	t.Run("should validate every account type", func(t *testing.T) {
+		for _, acctType := range want {
+			assert.NoError(t, validation.Get().Var(acctType, "acctType"), acctType)
+		}
+		assert.Error(t, validation.Get().Var("cheque", "acctType"))
+	})
+}
+
+// TestOpenAPICurrencies keeps openapi.yaml and the currency validator in step with the currencies
+// defined in the accounts package.
+func TestOpenAPICurrencies(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var want []string
+	for _, curr := range accounts.Currencies() {
+		want = append(want, curr.String())
+	}
+
+	for _, schema := range []string{"CreateBankAccountRequest", "BankAccountResponse", "CreateTransactionRequest", "TransactionResponse", "ConversionResponse", "BalanceHistoryResponse"} {
+		t.Run("should list every currency in "+schema, func(t *testing.T) {
+			require.Contains(t, spec.Components.Schemas, schema)
+			assert.Equal(t, want, spec.Components.Schemas[schema].Properties["currency"].Enum)
+		})
+	}
+	t.Run("should validate every currency", func(t *testing.T) {
+		for _, curr := range want {
+			assert.NoError(t, validation.Get().Var(curr, "currency"), curr)
+		}
+		assert.Error(t, validation.Get().Var("XXX", "currency"))
+	})
+}
+
+// TestOpenAPIAccountStatuses keeps openapi.yaml and the acctStatus and statusReason validators in
+// step with the statuses and reason codes defined in the accounts package.
+func TestOpenAPIAccountStatuses(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var wantStatuses []string
+	for _, status := range accounts.AccountStatuses() {
+		wantStatuses = append(wantStatuses, status.String())
+	}
+	var wantReasons []string
+	for _, reason := range accounts.StatusReasons() {
+		wantReasons = append(wantReasons, reason.String())
+	}
+
+	for schema, props := range map[string][]string{
+		"BankAccountResponse":        {"status"},
+		"ChangeAccountStatusRequest": {"status"},
+		"AccountStatusResponse":      {"status"},
+		"StatusChangeResponse":       {"from", "to"},
+	} {
+		t.Run("should list every status in "+schema, func(t *testing.T) {
+			require.Contains(t, spec.Components.Schemas, schema)
+			for _, prop := range props {
+				assert.Equal(t, wantStatuses, spec.Components.Schemas[schema].Properties[prop].Enum, prop)
+			}
+		})
+	}
+	for _, schema := range []string{"ChangeAccountStatusRequest", "StatusChangeResponse"} {
+		t.Run("should list every reason in "+schema, func(t *testing.T) {
+			require.Contains(t, spec.Components.Schemas, schema)
+			assert.Equal(t, wantReasons, spec.Components.Schemas[schema].Properties["reason"].Enum)
+		})
+	}
+	t.Run("should validate every status and reason", func(t *testing.T) {
+		for _, status := range wantStatuses {
+			assert.NoError(t, validation.Get().Var(status, "acctStatus"), status)
+		}
+		for _, reason := range wantReasons {
+			assert.NoError(t, validation.Get().Var(reason, "statusReason"), reason)
+		}
+		assert.Error(t, validation.Get().Var("suspended", "acctStatus"))
+		assert.Error(t, validation.Get().Var("because", "statusReason"))
+	})
+}
+
+// TestOpenAPIHoldReasons keeps openapi.yaml and the holdReason validator in step with the hold
+// reasons defined in the accounts package.
+func TestOpenAPIHoldReasons(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var want []string
+	for _, reason := range accounts.HoldReasons() {
+		want = append(want, reason.String())
+	}
+
+	for _, schema := range []string{"PlaceHoldRequest", "HoldResponse"} {
+		t.Run("should list every reason in "+schema, func(t *testing.T) {
+			require.Contains(t, spec.Components.Schemas, schema)
+			assert.Equal(t, want, spec.Components.Schemas[schema].Properties["reason"].Enum)
+		})
+	}
+	t.Run("should validate every reason", func(t *testing.T) {
+		for _, reason := range want {
+			assert.NoError(t, validation.Get().Var(reason, "holdReason"), reason)
+		}
+		assert.Error(t, validation.Get().Var("because", "holdReason"))
+	})
+}
+
+func TestOpenAPITiers(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var want []string
+	for _, tier := range users.Tiers() {
+		want = append(want, tier.String())
+	}
+
+	for _, schema := range []string{"UserResponse", "ChangeUserTierRequest"} {
+		t.Run("should list every tier in "+schema, func(t *testing.T) {
+			require.Contains(t, spec.Components.Schemas, schema)
+			assert.Equal(t, want, spec.Components.Schemas[schema].Properties["tier"].Enum)
+		})
+	}
+	t.Run("should validate every tier", func(t *testing.T) {
+		for _, tier := range want {
+			assert.NoError(t, validation.Get().Var(tier, "tier"), tier)
+		}
+		assert.Error(t, validation.Get().Var("gold", "tier"))
+	})
+}
+
+func TestOpenAPIIntervals(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var want []string
+	for _, interval := range transactions.Intervals() {
+		want = append(want, interval.String())
+	}
+
+	t.Run("should list every interval in BalanceHistoryResponse", func(t *testing.T) {
+		require.Contains(t, spec.Components.Schemas, "BalanceHistoryResponse")
+		assert.Equal(t, want, spec.Components.Schemas["BalanceHistoryResponse"].Properties["interval"].Enum)
+	})
+}
+
+func TestOpenAPILimitKinds(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var want []string
+	for _, kind := range limits.Kinds() {
+		want = append(want, kind.String())
+	}
+
+	require.Contains(t, spec.Components.Schemas, "LimitExceededResponse")
+	assert.Equal(t, want, spec.Components.Schemas["LimitExceededResponse"].Properties["limit"].Enum)
+}
+
+// TestOpenAPIEventTypes keeps openapi.yaml in step with the event types webhooks can subscribe to,
+// which are checked by the webhooks package rather than a validator.
+func TestOpenAPIEventTypes(t *testing.T) {
+	spec := readOpenAPISpec(t)
+
+	var want []string
+	for _, typ := range events.EventTypes() {
+		want = append(want, typ.String())
+	}
+
+	require.Contains(t, spec.Components.Schemas, "WebhookEventType")
+	assert.Equal(t, want, spec.Components.Schemas["WebhookEventType"].Enum)
+}
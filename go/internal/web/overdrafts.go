@@ -0,0 +1,140 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func handleApplyForOverdraft(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		var req ApplyForOverdraftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := svc.FetchAccount(acctNum)
+		if err != nil {
+			if errors.Is(err, accounts.ErrAccountNotFound) {
+				writeErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !authorize(w, r, policy, authz.ApplyOverdraft, authz.OwnedBy(acct.HolderIDs()...)) {
+			return
+		}
+
+		userID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		app, err := svc.ApplyForOverdraft(acct.AccountNumber, userID, req.Limit, accountPreconditions(r)...)
+		if err != nil {
+			writeOverdraftError(w, err)
+			return
+		}
+
+		resp := newOverdraftApplicationResponseFromDomain(app)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// handleListOverdraftApplications lists the applications waiting on a decision, for staff.
+func handleListOverdraftApplications(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorize(w, r, policy, authz.DecideOverdraft, authz.OwnedBy()) {
+			return
+		}
+
+		apps, err := svc.ListPendingOverdraftApplications()
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		appResps := make([]OverdraftApplicationResponse, 0, len(apps))
+		for _, app := range apps {
+			appResps = append(appResps, newOverdraftApplicationResponseFromDomain(app))
+		}
+
+		resp := ListOverdraftApplicationsResponse{Applications: appResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleApproveOverdraft(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return handleDecideOverdraft(policy, func(id accounts.OverdraftApplicationID, staffID users.UserID) (accounts.OverdraftApplication, error) {
+		return svc.ApproveOverdraft(id, staffID)
+	})
+}
+
+func handleRejectOverdraft(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return handleDecideOverdraft(policy, func(id accounts.OverdraftApplicationID, staffID users.UserID) (accounts.OverdraftApplication, error) {
+		return svc.RejectOverdraft(id, staffID)
+	})
+}
+
+func handleDecideOverdraft(policy authz.Policy, decide func(accounts.OverdraftApplicationID, users.UserID) (accounts.OverdraftApplication, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appID, err := accounts.NewOverdraftApplicationID(r.PathValue("applicationId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		if !authorize(w, r, policy, authz.DecideOverdraft, authz.OwnedBy()) {
+			return
+		}
+
+		staffID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		app, err := decide(appID, staffID)
+		if err != nil {
+			writeOverdraftError(w, err)
+			return
+		}
+
+		resp := newOverdraftApplicationResponseFromDomain(app)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func writeOverdraftError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, accounts.ErrPreconditionFailed):
+		writeErrorResponse(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, accounts.ErrOverdraftApplicationNotFound), errors.Is(err, accounts.ErrAccountNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, accounts.ErrNotHolder), errors.Is(err, accounts.ErrOwnOverdraftApplication):
+		writeErrorResponse(w, http.StatusForbidden, err)
+	case errors.Is(err, accounts.ErrOverdraftApplicationPending), errors.Is(err, accounts.ErrOverdraftApplicationClosed),
+		errors.Is(err, accounts.ErrAccountClosed), errors.Is(err, accounts.ErrConflict):
+		writeErrorResponse(w, http.StatusConflict, err)
+	case errors.Is(err, accounts.ErrInvalidOverdraftLimit):
+		writeErrorResponse(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
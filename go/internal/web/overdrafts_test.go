@@ -0,0 +1,166 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverdrafts(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2", "usr-teller")
+	mustAssignStaffRole(t, usrSvc, usrStore, "usr-teller", users.TellerRole)
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	tellerToken := login(t, srv, "usr-teller")
+
+	apply := func(t *testing.T, acctNum string, limit float64, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		by, err := json.Marshal(ApplyForOverdraftRequest{Limit: limit})
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/accounts/"+acctNum+"/overdraft-applications", by, token))
+		return rr
+	}
+	mustApply := func(t *testing.T, acctNum string, limit float64) OverdraftApplicationResponse {
+		t.Helper()
+		rr := apply(t, acctNum, limit, token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var resp OverdraftApplicationResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		return resp
+	}
+	decide := func(t *testing.T, appID, decision, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/overdraft-applications/"+appID+"/"+decision, nil, token))
+		return rr
+	}
+
+	t.Run("POST to /v1/accounts/{accountNumber}/overdraft-applications", func(t *testing.T) {
+		t.Run("with a valid limit should 201", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			app := mustApply(t, acct.AccountNumber, 500)
+			assert.Equal(t, "pending", app.Status)
+			assert.Equal(t, 500.0, app.Limit)
+			assert.Equal(t, "usr-testuser", app.RequestedBy)
+		})
+		t.Run("while another application is pending should 409", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			mustApply(t, acct.AccountNumber, 500)
+			rr := apply(t, acct.AccountNumber, 200, token)
+			assert.Equal(t, http.StatusConflict, rr.Code)
+		})
+		t.Run("above the maximum limit should 400", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := apply(t, acct.AccountNumber, 5000.01, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("for another user's account should 403", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := apply(t, acct.AccountNumber, 500, login(t, srv, "usr-testuser2"))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("for a missing account should 404", func(t *testing.T) {
+			rr := apply(t, "01999990", 500, token)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+	t.Run("GET from /v1/overdraft-applications", func(t *testing.T) {
+		acct := mustCreateAccount(t, token, srv)
+		app := mustApply(t, acct.AccountNumber, 300)
+
+		t.Run("by staff should 200 with pending applications", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/overdraft-applications", nil, tellerToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp ListOverdraftApplicationsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Contains(t, resp.Applications, app)
+		})
+		t.Run("by a customer should 403", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/overdraft-applications", nil, token))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	})
+	t.Run("POST to /v1/overdraft-applications/{applicationId}/approve", func(t *testing.T) {
+		t.Run("by staff should 200 and let the account go overdrawn", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			app := mustApply(t, acct.AccountNumber, 250)
+
+			rr := decide(t, app.ID, "approve", tellerToken)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp OverdraftApplicationResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, "approved", resp.Status)
+			require.NotNil(t, resp.DecidedBy)
+			assert.Equal(t, "usr-teller", *resp.DecidedBy)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, createTransactionRequest(t, CreateTransactionRequest{Amount: 100, Currency: "GBP", Type: "withdrawal"}, acct.AccountNumber, token))
+			require.Equal(t, http.StatusCreated, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, token))
+			var acctResp BankAccountResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&acctResp))
+			assert.Equal(t, -100.0, acctResp.Balance)
+			assert.Equal(t, 150.0, acctResp.AvailableBalance)
+			assert.Equal(t, &OverdraftResponse{Limit: 250, Available: 150, AnnualRate: accounts.DefaultOverdraftRate}, acctResp.Overdraft)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, createTransactionRequest(t, CreateTransactionRequest{Amount: 150.01, Currency: "GBP", Type: "withdrawal"}, acct.AccountNumber, token))
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("twice should 409", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			app := mustApply(t, acct.AccountNumber, 250)
+			require.Equal(t, http.StatusOK, decide(t, app.ID, "approve", tellerToken).Code)
+			assert.Equal(t, http.StatusConflict, decide(t, app.ID, "approve", tellerToken).Code)
+		})
+		t.Run("by the applicant should 403", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			app := mustApply(t, acct.AccountNumber, 250)
+			assert.Equal(t, http.StatusForbidden, decide(t, app.ID, "approve", token).Code)
+		})
+		t.Run("for an unknown application should 404", func(t *testing.T) {
+			assert.Equal(t, http.StatusNotFound, decide(t, "ovd-missing", "approve", tellerToken).Code)
+		})
+		t.Run("with an invalid ID should 400", func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, decide(t, "missing", "approve", tellerToken).Code)
+		})
+	})
+	t.Run("POST to /v1/overdraft-applications/{applicationId}/reject by staff should 200", func(t *testing.T) {
+		acct := mustCreateAccount(t, token, srv)
+		app := mustApply(t, acct.AccountNumber, 250)
+
+		rr := decide(t, app.ID, "reject", tellerToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp OverdraftApplicationResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, "rejected", resp.Status)
+
+		rr = httptest.NewRecorder()
+		srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, token))
+		var acctResp BankAccountResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&acctResp))
+		assert.Nil(t, acctResp.Overdraft)
+	})
+}
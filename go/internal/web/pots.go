@@ -0,0 +1,252 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/transactions"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// handleCreatePot lets a holder ring-fence part of their balance under a name, optionally with a
+// goal and a target date.
+func handleCreatePot(svc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreatePotRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, svc, access, authz.ManagePots)
+		if err != nil {
+			return
+		}
+
+		goal, targetDate := req.toDomain()
+		pot, err := svc.CreatePot(acct.AccountNumber, req.Name, goal, targetDate, accountPreconditions(r)...)
+		if err != nil {
+			writePotError(w, err)
+			return
+		}
+
+		resp := newPotResponseFromDomain(pot)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleListPots(svc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acct, err := checkTransactionAccountAuth(w, r, svc, access, authz.ReadAccount)
+		if err != nil {
+			return
+		}
+
+		pots, err := svc.ListPots(acct.AccountNumber)
+		if err != nil {
+			writePotError(w, err)
+			return
+		}
+
+		potResps := make([]PotResponse, 0, len(pots))
+		for _, pot := range pots {
+			potResps = append(potResps, newPotResponseFromDomain(pot))
+		}
+
+		resp := ListPotsResponse{Pots: potResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleFetchPot(svc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		potID, err := accounts.NewPotID(r.PathValue("potId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, svc, access, authz.ReadAccount)
+		if err != nil {
+			return
+		}
+
+		pot, err := svc.FetchPot(acct.AccountNumber, potID)
+		if err != nil {
+			writePotError(w, err)
+			return
+		}
+
+		resp := newPotResponseFromDomain(pot)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleUpdatePot(svc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		potID, err := accounts.NewPotID(r.PathValue("potId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		var req UpdatePotRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, svc, access, authz.ManagePots)
+		if err != nil {
+			return
+		}
+
+		pot, err := svc.UpdatePot(acct.AccountNumber, potID, req.toDomain(), accountPreconditions(r)...)
+		if err != nil {
+			writePotError(w, err)
+			return
+		}
+
+		resp := newPotResponseFromDomain(pot)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleDeletePot(svc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		potID, err := accounts.NewPotID(r.PathValue("potId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, svc, access, authz.ManagePots)
+		if err != nil {
+			return
+		}
+
+		err = svc.DeletePot(acct.AccountNumber, potID, accountPreconditions(r)...)
+		if err != nil {
+			writePotError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleCreatePotTransaction moves money between the account's main balance and a pot.
+func handleCreatePotTransaction(svc TransactionService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		potID, err := accounts.NewPotID(r.PathValue("potId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		var req CreatePotTransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.ManagePots)
+		if err != nil {
+			return
+		}
+
+		userID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		tan, err := svc.TransferPot(req.toDomain(acct.AccountNumber, potID, userID), accountPreconditions(r)...)
+		if err != nil {
+			writePotError(w, err)
+			return
+		}
+
+		resp := newTransactionResponseFromDomain(tan)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleListPotTransactions(svc TransactionService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		potID, err := accounts.NewPotID(r.PathValue("potId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.ReadTransactions)
+		if err != nil {
+			return
+		}
+		if _, err := acctSvc.FetchPot(acct.AccountNumber, potID); err != nil {
+			writePotError(w, err)
+			return
+		}
+
+		tans, err := svc.ListPotTransactions(acct.AccountNumber, potID)
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		tanResps := make([]TransactionResponse, 0, len(tans))
+		for _, tan := range tans {
+			tanResps = append(tanResps, newTransactionResponseFromDomain(tan))
+		}
+
+		resp := ListTransactionsResponse{Transactions: tanResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func writePotError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, accounts.ErrPreconditionFailed):
+		writeErrorResponse(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, accounts.ErrAccountNotFound), errors.Is(err, accounts.ErrPotNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, accounts.ErrPotNotEmpty), errors.Is(err, accounts.ErrTooManyPots),
+		errors.Is(err, accounts.ErrAccountFrozen), errors.Is(err, accounts.ErrAccountDormant), errors.Is(err, accounts.ErrAccountClosed),
+		errors.Is(err, transactions.ErrSequenceConflict), errors.Is(err, accounts.ErrConflict):
+		writeErrorResponse(w, http.StatusConflict, err)
+	case errors.Is(err, accounts.ErrInsufficientFunds), errors.Is(err, accounts.ErrInvalidPotAmount),
+		errors.Is(err, accounts.ErrPotTargetInPast):
+		writeErrorResponse(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
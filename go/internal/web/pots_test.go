@@ -0,0 +1,156 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPots(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-other")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	otherToken := login(t, srv, "usr-other")
+
+	do := func(t *testing.T, method, path string, body any, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		var by []byte
+		if body != nil {
+			var err error
+			by, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(method, path, by, token))
+		return rr
+	}
+	mustCreatePot := func(t *testing.T, acctNum string, req CreatePotRequest) PotResponse {
+		t.Helper()
+		rr := do(t, http.MethodPost, "/v1/accounts/"+acctNum+"/pots", req, token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var resp PotResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		return resp
+	}
+
+	acct := mustCreateAccount(t, token, srv)
+	mustCreateTransaction(t, srv, token, acct.AccountNumber)
+	potsPath := "/v1/accounts/" + acct.AccountNumber + "/pots"
+
+	t.Run("POST to /v1/accounts/{accountNumber}/pots", func(t *testing.T) {
+		t.Run("by a holder should 201", func(t *testing.T) {
+			goal := 500.0
+			targetDate := time.Now().AddDate(0, 6, 0).UTC().Truncate(time.Second)
+			pot := mustCreatePot(t, acct.AccountNumber, CreatePotRequest{Name: "Holiday", Goal: &goal, TargetDate: &targetDate})
+			require.NoError(t, validation.Get().Struct(pot))
+			assert.Equal(t, "Holiday", pot.Name)
+			assert.Equal(t, 0.0, pot.Balance)
+			assert.Equal(t, &goal, pot.Goal)
+			assert.True(t, targetDate.Equal(*pot.TargetDate))
+		})
+		t.Run("by another customer should 403", func(t *testing.T) {
+			rr := do(t, http.MethodPost, potsPath, CreatePotRequest{Name: "Mine now"}, otherToken)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("without a name should 400", func(t *testing.T) {
+			rr := do(t, http.MethodPost, potsPath, CreatePotRequest{}, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("with a target date in the past should 422", func(t *testing.T) {
+			past := time.Now().Add(-time.Hour)
+			rr := do(t, http.MethodPost, potsPath, CreatePotRequest{Name: "Too late", TargetDate: &past}, token)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+	})
+	t.Run("GET and PATCH /v1/accounts/{accountNumber}/pots/{potId}", func(t *testing.T) {
+		pot := mustCreatePot(t, acct.AccountNumber, CreatePotRequest{Name: "Car"})
+
+		t.Run("should list the account's pots", func(t *testing.T) {
+			rr := do(t, http.MethodGet, potsPath, nil, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp ListPotsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.NoError(t, validation.Get().Struct(resp))
+			assert.NotEmpty(t, resp.Pots)
+		})
+		t.Run("should rename a pot", func(t *testing.T) {
+			name := "New car"
+			rr := do(t, http.MethodPatch, potsPath+"/"+pot.ID, UpdatePotRequest{Name: &name}, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			rr = do(t, http.MethodGet, potsPath+"/"+pot.ID, nil, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp PotResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, name, resp.Name)
+			assert.Nil(t, resp.Goal)
+		})
+		t.Run("should 404 for an unknown pot", func(t *testing.T) {
+			rr := do(t, http.MethodGet, potsPath+"/pot-unknown", nil, token)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+	t.Run("/v1/accounts/{accountNumber}/pots/{potId}/transactions", func(t *testing.T) {
+		pot := mustCreatePot(t, acct.AccountNumber, CreatePotRequest{Name: "Rainy day"})
+		tansPath := potsPath + "/" + pot.ID + "/transactions"
+
+		t.Run("should 201 and ring-fence the money", func(t *testing.T) {
+			rr := do(t, http.MethodPost, tansPath, CreatePotTransactionRequest{Amount: 40, Type: "to_pot"}, token)
+			require.Equal(t, http.StatusCreated, rr.Code)
+			var tan TransactionResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&tan))
+			assert.Equal(t, "to_pot", tan.Type)
+			assert.Equal(t, &pot.ID, tan.PotID)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, token))
+			var acctResp BankAccountResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&acctResp))
+			assert.Equal(t, 100.0, acctResp.Balance)
+			assert.Equal(t, 40.0, acctResp.PotsBalance)
+			assert.Equal(t, 60.0, acctResp.AvailableBalance)
+		})
+		t.Run("should 422 when the main balance is too low", func(t *testing.T) {
+			rr := do(t, http.MethodPost, tansPath, CreatePotTransactionRequest{Amount: 60.01, Type: "to_pot"}, token)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("by another customer should 403", func(t *testing.T) {
+			rr := do(t, http.MethodPost, tansPath, CreatePotTransactionRequest{Amount: 1, Type: "from_pot"}, otherToken)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("should list the pot's transactions", func(t *testing.T) {
+			rr := do(t, http.MethodGet, tansPath, nil, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp ListTransactionsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.Len(t, resp.Transactions, 1)
+			assert.Equal(t, "to_pot", resp.Transactions[0].Type)
+		})
+		t.Run("DELETE should 409 until the pot is empty", func(t *testing.T) {
+			rr := do(t, http.MethodDelete, potsPath+"/"+pot.ID, nil, token)
+			assert.Equal(t, http.StatusConflict, rr.Code)
+
+			rr = do(t, http.MethodPost, tansPath, CreatePotTransactionRequest{Amount: 40, Type: "from_pot"}, token)
+			require.Equal(t, http.StatusCreated, rr.Code)
+			rr = do(t, http.MethodDelete, potsPath+"/"+pot.ID, nil, token)
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+		})
+	})
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"context"
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag is the strong entity tag for a version of an account or user.
+func etag(version uint64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// matchesETag reports whether an If-None-Match header lists the tag, or is "*". If-None-Match uses
+// weak comparison, so a weak tag matches the strong one with the same value.
+func matchesETag(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeETag sets the ETag header for a GET response. If the request's If-None-Match already lists
+// it, it writes 304 Not Modified and returns true, so the handler should not write a body.
+func writeETag(w http.ResponseWriter, r *http.Request, version uint64) bool {
+	tag := etag(version)
+	w.Header().Set("ETag", tag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && matchesETag(ifNoneMatch, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ifMatch reads a mutation's If-Match header into the request's context as the versions it lists,
+// for the handler to pass to the service as a precondition. The service checks them against the
+// account or user it changes and the store's compare-and-swap enforces them, failing with 412
+// Precondition Failed if it has changed since the client read it. That happens after the handler
+// has authorised the caller, so the version is only revealed to those allowed to see it. If-Match
+// uses strong comparison, so weak tags never match, and "*" matches any version.
+func ifMatch(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("If-Match")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		versions := []uint64{}
+		for _, t := range strings.Split(header, ",") {
+			t = strings.TrimSpace(t)
+			if t == "*" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(t) < 2 || t[0] != '"' || t[len(t)-1] != '"' {
+				continue
+			}
+			version, err := strconv.ParseUint(t[1:len(t)-1], 10, 64)
+			if err == nil {
+				versions = append(versions, version)
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), IfMatchKey, versions)))
+	}
+}
+
+// accountPreconditions are the preconditions for the account a request changes, none without If-Match.
+func accountPreconditions(r *http.Request) []accounts.Precondition {
+	versions, ok := r.Context().Value(IfMatchKey).([]uint64)
+	if !ok {
+		return nil
+	}
+	return []accounts.Precondition{accounts.IfVersion(versions...)}
+}
+
+// userPreconditions is accountPreconditions for the user a request changes.
+func userPreconditions(r *http.Request) []users.Precondition {
+	versions, ok := r.Context().Value(IfMatchKey).([]uint64)
+	if !ok {
+		return nil
+	}
+	return []users.Precondition{users.IfVersion(versions...)}
+}
@@ -0,0 +1,178 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreconditions(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-other", "usr-support")
+	mustAssignStaffRole(t, usrSvc, usrStore, "usr-support", users.SupportRole)
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	racingStore := &racingAccountStore{InMemoryAccountStore: acctStore}
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), racingStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	otherToken := login(t, srv, "usr-other")
+	supportToken := login(t, srv, "usr-support")
+	acct := mustCreateAccount(t, token, srv)
+	acctPath := "/v1/accounts/" + acct.AccountNumber
+
+	get := func(t *testing.T, path, token string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := authedRequest(http.MethodGet, path, nil, token)
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+	deposit := func(t *testing.T, token, ifMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+		reqObj := CreateTransactionRequest{Amount: 10, Currency: accounts.GBP.String(), Type: transactions.Deposit.String()}
+		req := createTransactionRequest(t, reqObj, acct.AccountNumber, token)
+		req.Header.Set("If-Match", ifMatch)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("GET from /v1/accounts/{accountNumber}", func(t *testing.T) {
+		t.Run("should return an ETag which changes with the account", func(t *testing.T) {
+			rr := get(t, acctPath, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+			tag := rr.Header().Get("ETag")
+			require.NotEmpty(t, tag)
+
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+			rr = get(t, acctPath, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.NotEqual(t, tag, rr.Header().Get("ETag"))
+		})
+		t.Run("with a matching If-None-Match should 304", func(t *testing.T) {
+			tag := get(t, acctPath, token).Header().Get("ETag")
+			rr := get(t, acctPath, token, "If-None-Match", `"0", W/`+tag)
+			assert.Equal(t, http.StatusNotModified, rr.Code)
+			assert.Empty(t, rr.Body.String())
+			assert.Equal(t, tag, rr.Header().Get("ETag"))
+
+			rr = get(t, acctPath, token, "If-None-Match", `"0"`)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+		t.Run("with If-None-Match by a non-holder should still 403", func(t *testing.T) {
+			rr := get(t, acctPath, otherToken, "If-None-Match", "*")
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	})
+	t.Run("POST to /v1/accounts/{accountNumber}/transactions", func(t *testing.T) {
+		t.Run("with the current ETag in If-Match should 201", func(t *testing.T) {
+			tag := get(t, acctPath, token).Header().Get("ETag")
+			rr := deposit(t, token, tag)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			rr = deposit(t, token, "*")
+			assert.Equal(t, http.StatusCreated, rr.Code)
+		})
+		t.Run("with an out of date If-Match should 412 and change nothing", func(t *testing.T) {
+			rr := get(t, acctPath, token)
+			tag := rr.Header().Get("ETag")
+			var before BankAccountResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&before))
+			mustCreateTransaction(t, srv, token, acct.AccountNumber)
+
+			rr = deposit(t, token, tag)
+			assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+			rr = deposit(t, token, "W/"+get(t, acctPath, token).Header().Get("ETag"))
+			assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+			var after BankAccountResponse
+			require.NoError(t, json.NewDecoder(get(t, acctPath, token).Body).Decode(&after))
+			assert.Equal(t, before.Balance+100, after.Balance)
+		})
+		t.Run("racing another request with the same ETag should let only one through", func(t *testing.T) {
+			rr := get(t, acctPath, token)
+			tag := rr.Header().Get("ETag")
+			var before BankAccountResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&before))
+
+			racingStore.race = &sync.WaitGroup{}
+			racingStore.race.Add(2)
+			codes := make([]int, 2)
+			var wg sync.WaitGroup
+			for i := range codes {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					codes[i] = deposit(t, token, tag).Code
+				}()
+			}
+			wg.Wait()
+			racingStore.race = nil
+
+			assert.ElementsMatch(t, []int{http.StatusCreated, http.StatusPreconditionFailed}, codes)
+			var after BankAccountResponse
+			require.NoError(t, json.NewDecoder(get(t, acctPath, token).Body).Decode(&after))
+			assert.Equal(t, before.Balance+10, after.Balance)
+		})
+		t.Run("with If-Match by a non-holder should 403 rather than reveal the version", func(t *testing.T) {
+			rr := deposit(t, otherToken, `"0"`)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+	})
+	t.Run("/v1/users/{userId}", func(t *testing.T) {
+		userPath := "/v1/users/usr-testuser"
+		changeTier := func(t *testing.T, ifMatch string) *httptest.ResponseRecorder {
+			t.Helper()
+			by, err := json.Marshal(ChangeUserTierRequest{Tier: users.PremiumTier.String()})
+			require.NoError(t, err)
+			req := authedRequest(http.MethodPut, userPath+"/tier", by, supportToken)
+			req.Header.Set("If-Match", ifMatch)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := get(t, userPath, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		tag := rr.Header().Get("ETag")
+		require.NotEmpty(t, tag)
+		assert.Equal(t, http.StatusNotModified, get(t, userPath, token, "If-None-Match", tag).Code)
+
+		assert.Equal(t, http.StatusPreconditionFailed, changeTier(t, `"0"`).Code)
+		assert.Equal(t, http.StatusOK, changeTier(t, tag).Code)
+		assert.Equal(t, http.StatusPreconditionFailed, changeTier(t, tag).Code)
+		assert.Equal(t, http.StatusOK, get(t, userPath, token, "If-None-Match", tag).Code)
+	})
+}
+
+// racingAccountStore holds each read back until race has counted down, so racing requests all see
+// the same version of the account before any of them writes.
+type racingAccountStore struct {
+	*adapters.InMemoryAccountStore
+	race *sync.WaitGroup
+}
+
+func (s *racingAccountStore) GetByAcctNum(acctNum accounts.AccountNumber) (accounts.BankAccount, error) {
+	acct, err := s.InMemoryAccountStore.GetByAcctNum(acctNum)
+	if s.race != nil {
+		s.race.Done()
+		s.race.Wait()
+	}
+	return acct, err
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// handleReconcile compares every account's stored balance with its transactions and reports the
+// accounts which differ. With repair set, staff allowed to repair balances also correct them.
+func handleReconcile(svc TransactionService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReconcileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		action := authz.Reconcile
+		if req.Repair {
+			action = authz.RepairBalances
+		}
+		if !authorize(w, r, policy, action, authz.OwnedBy()) {
+			return
+		}
+
+		staffID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		report, err := svc.Reconcile(req.Repair, staffID)
+		if err != nil {
+			if errors.Is(err, accounts.ErrConflict) {
+				writeErrorResponse(w, http.StatusConflict, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp := newReconciliationReportResponseFromDomain(report)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-support", "usr-auditor")
+	for id, role := range map[users.UserID]users.Role{"usr-support": users.SupportRole, "usr-auditor": users.AuditorRole} {
+		mustAssignStaffRole(t, usrSvc, usrStore, id, role)
+	}
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanStore := adapters2.NewInMemoryTransactionStore()
+	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	supportToken := login(t, srv, "usr-support")
+	auditorToken := login(t, srv, "usr-auditor")
+
+	acct := mustCreateAccount(t, token, srv)
+	mustCreateTransaction(t, srv, token, acct.AccountNumber)
+	// Store a transaction without applying it to the account, as a posting which failed part way would.
+	tanID, err := transactions.NewRandTransactionID()
+	require.NoError(t, err)
+	tan, err := transactions.NewTransaction(tanID, accounts.AccountNumber(acct.AccountNumber), "usr-testuser", 25, accounts.GBP, transactions.Deposit, "")
+	require.NoError(t, err)
+	tan.Sequence = 2
+	require.NoError(t, tanStore.Put(tan))
+
+	reconcile := func(t *testing.T, repair bool, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		by, err := json.Marshal(ReconcileRequest{Repair: repair})
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/reconciliations", by, token))
+		return rr
+	}
+
+	t.Run("POST to /v1/reconciliations", func(t *testing.T) {
+		t.Run("by an auditor should 200 with the discrepancies", func(t *testing.T) {
+			rr := reconcile(t, false, auditorToken)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp ReconciliationReportResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.NoError(t, validation.Get().Struct(resp))
+			assert.Equal(t, 1, resp.AccountsChecked)
+			require.Len(t, resp.Discrepancies, 1)
+			assert.Equal(t, acct.AccountNumber, resp.Discrepancies[0].AccountNumber)
+			assert.Equal(t, 25.0, resp.Discrepancies[0].Difference)
+			assert.Nil(t, resp.Discrepancies[0].Correction)
+		})
+		t.Run("to repair by an auditor should 403", func(t *testing.T) {
+			rr := reconcile(t, true, auditorToken)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("by a customer should 403", func(t *testing.T) {
+			rr := reconcile(t, false, token)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("to repair by support should 200 and correct the balance", func(t *testing.T) {
+			rr := reconcile(t, true, supportToken)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp ReconciliationReportResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.True(t, resp.Repaired)
+			require.Len(t, resp.Discrepancies, 1)
+			correction := resp.Discrepancies[0].Correction
+			require.NotNil(t, correction)
+			assert.Equal(t, "correction_credit", correction.Type)
+			assert.Equal(t, 125.0, correction.BalanceAfter)
+
+			gotAcct, err := acctSvc.FetchAccount(accounts.AccountNumber(acct.AccountNumber))
+			require.NoError(t, err)
+			assert.Equal(t, 125.0, gotAcct.Balance())
+		})
+	})
+}
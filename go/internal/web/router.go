@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"eaglebank/internal/authz"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -15,30 +16,22 @@ import (
 )
 
 type ServerArgs struct {
-	Logger  *slog.Logger
-	UserSvc UserService
-	AcctSvc AccountService
-	TanSvc  TransactionService
+	Logger      *slog.Logger
+	UserSvc     UserService
+	AcctSvc     AccountService
+	TanSvc      TransactionService
+	WebAuthnSvc WebAuthnService
+	GrantSvc    GrantService
+	FXSvc       FXService
+	InterestSvc InterestService
+	WebhookSvc  WebhookService
 }
 
 func NewServer(args ServerArgs) http.Handler {
 	mux := http.NewServeMux()
-
-	// unprotected routes
-	mux.HandleFunc("/health", handleHealth())
-	mux.HandleFunc("POST /login", handleLogin())
-	mux.HandleFunc("POST /v1/users", handleCreateUser(args.UserSvc))
-
-	// protected routes
-	mux.HandleFunc("GET /v1/users/{userId}", authMiddleware(handleGetUser(args.UserSvc)))
-
-	mux.HandleFunc("POST /v1/accounts", authMiddleware(handleCreateAccount(args.AcctSvc)))
-	mux.HandleFunc("GET /v1/accounts", authMiddleware(handleListAccounts(args.AcctSvc)))
-	mux.HandleFunc("GET /v1/accounts/{accountNumber}", authMiddleware(handleFetchAccount(args.AcctSvc)))
-
-	mux.HandleFunc("POST /v1/accounts/{accountNumber}/transactions", authMiddleware(handleCreateTransaction(args.TanSvc, args.AcctSvc)))
-	mux.HandleFunc("GET /v1/accounts/{accountNumber}/transactions", authMiddleware(handleListTransactions(args.TanSvc, args.AcctSvc)))
-	mux.HandleFunc("GET /v1/accounts/{accountNumber}/transactions/{transactionId}", authMiddleware(handleFetchTransaction(args.TanSvc, args.AcctSvc)))
+	for _, rt := range routes(args) {
+		mux.Handle(rt.pattern, rt.handler)
+	}
 
 	handler := panicMiddleware(args.Logger)(mux)
 	handler = loggingMiddleware(args.Logger)(handler)
@@ -46,6 +39,85 @@ func NewServer(args ServerArgs) http.Handler {
 	return handler
 }
 
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// routes lists every route the server handles.
+func routes(args ServerArgs) []route {
+	policy := authz.NewPolicy(authz.DefaultRules)
+	access := accountAccess{policy: policy, grants: args.GrantSvc, logger: args.Logger}
+
+	return []route{
+		// unprotected routes
+		{"/health", handleHealth()},
+		{"POST /login", handleLogin(args.UserSvc)},
+		{"POST /v1/users", handleCreateUser(args.UserSvc)},
+		{"POST /v1/users/verify-email", handleVerifyEmail(args.UserSvc)},
+		{"POST /v1/password-reset", handleRequestPasswordReset(args.UserSvc)},
+		{"POST /v1/password-reset/confirm", handleResetPassword(args.UserSvc)},
+		{"POST /login/webauthn/begin", handleBeginWebAuthnLogin(args.WebAuthnSvc)},
+		{"POST /login/webauthn/finish", handleFinishWebAuthnLogin(args.WebAuthnSvc, args.UserSvc)},
+
+		// protected routes
+		{"GET /v1/users/{userId}", authMiddleware(handleGetUser(args.UserSvc, policy))},
+		{"PUT /v1/users/{userId}/tier", authMiddleware(ifMatch(handleChangeUserTier(args.UserSvc, args.AcctSvc, policy)))},
+		{"POST /v1/reconciliations", authMiddleware(handleReconcile(args.TanSvc, policy))},
+		{"POST /v1/users/{userId}/verification-email", authMiddleware(handleSendVerificationEmail(args.UserSvc, policy))},
+		{"POST /v1/webauthn/credentials/begin", authMiddleware(handleBeginWebAuthnRegistration(args.WebAuthnSvc))},
+		{"POST /v1/webauthn/credentials/finish", authMiddleware(handleFinishWebAuthnRegistration(args.WebAuthnSvc))},
+
+		{"POST /v1/accounts", authMiddleware(handleCreateAccount(args.AcctSvc, policy))},
+		{"GET /v1/accounts", authMiddleware(handleListAccounts(args.AcctSvc, policy))},
+		{"GET /v1/accounts/{accountNumber}", authMiddleware(handleFetchAccount(args.AcctSvc, access))},
+		{"GET /v1/branches", authMiddleware(handleListBranches(args.AcctSvc))},
+		{"GET /v1/branches/{sortCode}/accounts/{accountNumber}", authMiddleware(handleFetchAccountByBankDetails(args.AcctSvc, access))},
+		{"POST /v1/accounts/{accountNumber}/status", authMiddleware(ifMatch(handleChangeAccountStatus(args.AcctSvc, policy)))},
+		{"POST /v1/accounts/{accountNumber}/holds", authMiddleware(ifMatch(handlePlaceHold(args.AcctSvc, policy)))},
+		{"GET /v1/accounts/{accountNumber}/holds", authMiddleware(handleListHolds(args.AcctSvc, policy))},
+		{"DELETE /v1/accounts/{accountNumber}/holds/{holdId}", authMiddleware(ifMatch(handleReleaseHold(args.AcctSvc, policy)))},
+		{"POST /v1/accounts/{accountNumber}/holders", authMiddleware(ifMatch(handleRequestAddHolder(args.AcctSvc, policy)))},
+		{"DELETE /v1/accounts/{accountNumber}/holders/{userId}", authMiddleware(ifMatch(handleRequestRemoveHolder(args.AcctSvc, policy)))},
+		{"POST /v1/accounts/{accountNumber}/grants", authMiddleware(ifMatch(handleCreateGrant(args.GrantSvc, args.AcctSvc, policy)))},
+		{"GET /v1/accounts/{accountNumber}/grants", authMiddleware(handleListAccountGrants(args.GrantSvc, args.AcctSvc, policy))},
+		{"GET /v1/grants", authMiddleware(handleListReceivedGrants(args.GrantSvc))},
+		{"DELETE /v1/grants/{grantId}", authMiddleware(handleRevokeGrant(args.GrantSvc))},
+		{"GET /v1/grants/{grantId}/accesses", authMiddleware(handleListGrantAccesses(args.GrantSvc))},
+		{"POST /v1/accounts/{accountNumber}/overdraft-applications", authMiddleware(ifMatch(handleApplyForOverdraft(args.AcctSvc, policy)))},
+		{"GET /v1/overdraft-applications", authMiddleware(handleListOverdraftApplications(args.AcctSvc, policy))},
+		{"POST /v1/overdraft-applications/{applicationId}/approve", authMiddleware(handleApproveOverdraft(args.AcctSvc, policy))},
+		{"POST /v1/overdraft-applications/{applicationId}/reject", authMiddleware(handleRejectOverdraft(args.AcctSvc, policy))},
+		{"GET /v1/holder-changes", authMiddleware(handleListHolderChanges(args.AcctSvc))},
+		{"POST /v1/holder-changes/{changeId}/approve", authMiddleware(handleApproveHolderChange(args.AcctSvc))},
+		{"POST /v1/holder-changes/{changeId}/reject", authMiddleware(handleRejectHolderChange(args.AcctSvc))},
+
+		{"POST /v1/accounts/{accountNumber}/transactions", authMiddleware(ifMatch(handleCreateTransaction(args.TanSvc, args.AcctSvc, access)))},
+		{"GET /v1/accounts/{accountNumber}/transactions", authMiddleware(handleListTransactions(args.TanSvc, args.AcctSvc, access))},
+		{"GET /v1/accounts/{accountNumber}/transactions/{transactionId}", authMiddleware(handleFetchTransaction(args.TanSvc, args.AcctSvc, access))},
+		{"GET /v1/accounts/{accountNumber}/balances", authMiddleware(handleBalanceHistory(args.TanSvc, args.AcctSvc, access))},
+		{"POST /v1/accounts/{accountNumber}/pots", authMiddleware(ifMatch(handleCreatePot(args.AcctSvc, access)))},
+		{"GET /v1/accounts/{accountNumber}/pots", authMiddleware(handleListPots(args.AcctSvc, access))},
+		{"GET /v1/accounts/{accountNumber}/pots/{potId}", authMiddleware(handleFetchPot(args.AcctSvc, access))},
+		{"PATCH /v1/accounts/{accountNumber}/pots/{potId}", authMiddleware(ifMatch(handleUpdatePot(args.AcctSvc, access)))},
+		{"DELETE /v1/accounts/{accountNumber}/pots/{potId}", authMiddleware(ifMatch(handleDeletePot(args.AcctSvc, access)))},
+		{"POST /v1/accounts/{accountNumber}/pots/{potId}/transactions", authMiddleware(ifMatch(handleCreatePotTransaction(args.TanSvc, args.AcctSvc, access)))},
+		{"GET /v1/accounts/{accountNumber}/pots/{potId}/transactions", authMiddleware(handleListPotTransactions(args.TanSvc, args.AcctSvc, access))},
+		{"POST /v1/accounts/{accountNumber}/adjustments", authMiddleware(ifMatch(handleCreateAdjustment(args.TanSvc, args.AcctSvc, access)))},
+		{"GET /v1/accounts/{accountNumber}/interest-accruals", authMiddleware(handleListAccruals(args.InterestSvc, args.AcctSvc, access))},
+		{"POST /v1/fx/quotes", authMiddleware(handleCreateQuote(args.FXSvc))},
+		{"POST /v1/fx/conversions", authMiddleware(handleExecuteQuote(args.FXSvc, args.AcctSvc, access))},
+
+		{"POST /v1/webhooks", authMiddleware(handleCreateWebhook(args.WebhookSvc))},
+		{"GET /v1/webhooks", authMiddleware(handleListWebhooks(args.WebhookSvc))},
+		{"GET /v1/webhooks/{webhookId}", authMiddleware(handleFetchWebhook(args.WebhookSvc))},
+		{"DELETE /v1/webhooks/{webhookId}", authMiddleware(handleDeleteWebhook(args.WebhookSvc))},
+		{"GET /v1/webhooks/{webhookId}/deliveries", authMiddleware(handleListWebhookDeliveries(args.WebhookSvc))},
+		{"GET /v1/webhooks/{webhookId}/deliveries/{deliveryId}", authMiddleware(handleFetchWebhookDelivery(args.WebhookSvc))},
+		{"POST /v1/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", authMiddleware(handleRedeliverWebhook(args.WebhookSvc))},
+	}
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -170,7 +242,10 @@ func authMiddleware(next http.Handler) http.HandlerFunc {
 			return
 		}
 
+		role, _ := claims["role"].(string)
+
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
+		ctx = context.WithValue(ctx, RoleKey, role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
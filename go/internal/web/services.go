@@ -2,23 +2,97 @@ package web
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/fx"
+	"eaglebank/internal/grants"
+	"eaglebank/internal/interest"
 	"eaglebank/internal/transactions"
 	"eaglebank/internal/users"
+	"eaglebank/internal/webauthn"
+	"eaglebank/internal/webhooks"
+	"time"
 )
 
 type UserService interface {
 	CreateUser(req users.CreateUserRequest) (users.User, error)
 	GetUser(userID users.UserID) (users.User, error)
+	SendVerificationEmail(userID users.UserID) error
+	VerifyEmail(token string) (users.User, error)
+	RequestPasswordReset(email users.Email) error
+	ResetPassword(token string, password users.Password) error
+	VerifyPassword(userID users.UserID, password string) error
+	AssignTier(userID users.UserID, tier users.Tier, conds ...users.Precondition) (users.User, error)
 }
 
 type AccountService interface {
 	CreateAccount(req accounts.CreateAccountRequest) (accounts.BankAccount, error)
 	ListAccounts(id users.UserID) ([]accounts.BankAccount, error)
 	FetchAccount(acctNum accounts.AccountNumber) (accounts.BankAccount, error)
+	FetchAccountByBankDetails(sortCode accounts.SortCode, acctNum accounts.AccountNumber) (accounts.BankAccount, error)
+	ListBranches() []accounts.Branch
+	Bank() accounts.Bank
+	RequestAddHolder(acctNum accounts.AccountNumber, requestedBy, userID users.UserID, conds ...accounts.Precondition) (accounts.HolderChange, error)
+	RequestRemoveHolder(acctNum accounts.AccountNumber, requestedBy, userID users.UserID, conds ...accounts.Precondition) (accounts.HolderChange, error)
+	ApproveHolderChange(id accounts.HolderChangeID, userID users.UserID) (accounts.HolderChange, error)
+	RejectHolderChange(id accounts.HolderChangeID, userID users.UserID) (accounts.HolderChange, error)
+	ListPendingHolderChanges(userID users.UserID) ([]accounts.HolderChange, error)
+	ApplyForOverdraft(acctNum accounts.AccountNumber, userID users.UserID, limit float64, conds ...accounts.Precondition) (accounts.OverdraftApplication, error)
+	ApproveOverdraft(id accounts.OverdraftApplicationID, staffID users.UserID) (accounts.OverdraftApplication, error)
+	RejectOverdraft(id accounts.OverdraftApplicationID, staffID users.UserID) (accounts.OverdraftApplication, error)
+	ListPendingOverdraftApplications() ([]accounts.OverdraftApplication, error)
+	ApplyTier(userID users.UserID, tier users.Tier) ([]accounts.BankAccount, error)
+	ChangeAccountStatus(acctNum accounts.AccountNumber, to accounts.AccountStatus, reason accounts.StatusReason, staffID users.UserID, conds ...accounts.Precondition) (accounts.BankAccount, error)
+	PlaceHold(acctNum accounts.AccountNumber, amt float64, reason accounts.HoldReason, expires time.Time, staffID users.UserID, conds ...accounts.Precondition) (accounts.Hold, error)
+	ReleaseHold(acctNum accounts.AccountNumber, id accounts.HoldID, staffID users.UserID, conds ...accounts.Precondition) (accounts.Hold, error)
+	ListHolds(acctNum accounts.AccountNumber) ([]accounts.Hold, error)
+	CreatePot(acctNum accounts.AccountNumber, name string, goal float64, targetDate time.Time, conds ...accounts.Precondition) (accounts.Pot, error)
+	ListPots(acctNum accounts.AccountNumber) ([]accounts.Pot, error)
+	FetchPot(acctNum accounts.AccountNumber, id accounts.PotID) (accounts.Pot, error)
+	UpdatePot(acctNum accounts.AccountNumber, id accounts.PotID, update accounts.PotUpdate, conds ...accounts.Precondition) (accounts.Pot, error)
+	DeletePot(acctNum accounts.AccountNumber, id accounts.PotID, conds ...accounts.Precondition) error
 }
 
 type TransactionService interface {
-	CreateTransaction(req transactions.CreateTransactionRequest) (transactions.Transaction, error)
+	CreateTransaction(req transactions.CreateTransactionRequest, conds ...accounts.Precondition) (transactions.Transaction, error)
 	ListTransactions(acctNum accounts.AccountNumber) ([]transactions.Transaction, error)
+	ListTransactionsBySequence(acctNum accounts.AccountNumber, from, to uint64) ([]transactions.Transaction, error)
 	FetchTransaction(acctNum accounts.AccountNumber, tanID transactions.TransactionID) (transactions.Transaction, error)
+	TransferPot(req transactions.PotTransferRequest, conds ...accounts.Precondition) (transactions.Transaction, error)
+	ListPotTransactions(acctNum accounts.AccountNumber, potID accounts.PotID) ([]transactions.Transaction, error)
+	BalanceHistory(acctNum accounts.AccountNumber, from, to time.Time, interval transactions.Interval) ([]transactions.BalanceInterval, error)
+	Reconcile(repair bool, userID users.UserID) (transactions.ReconciliationReport, error)
+}
+
+type GrantService interface {
+	CreateGrant(req grants.CreateGrantRequest, conds ...accounts.Precondition) (grants.Grant, error)
+	ListAccountGrants(acctNum accounts.AccountNumber) ([]grants.Grant, error)
+	ListReceivedGrants(userID users.UserID) ([]grants.Grant, error)
+	RevokeGrant(id grants.GrantID, userID users.UserID) (grants.Grant, error)
+	ListAccesses(id grants.GrantID, userID users.UserID) ([]grants.Access, error)
+	UseGrant(userID users.UserID, acctNum accounts.AccountNumber, scope grants.Scope) (grants.Grant, error)
+}
+
+type FXService interface {
+	CreateQuote(req fx.CreateQuoteRequest) (fx.Quote, error)
+	ExecuteQuote(req fx.ExecuteQuoteRequest) (transactions.Transaction, transactions.Transaction, error)
+}
+
+type InterestService interface {
+	ListAccruals(acctNum accounts.AccountNumber) ([]interest.Accrual, error)
+}
+
+type WebAuthnService interface {
+	BeginRegistration(userID users.UserID) (webauthn.CreationOptions, error)
+	FinishRegistration(userID users.UserID, resp webauthn.RegistrationResponse) (webauthn.Credential, error)
+	BeginLogin(userID users.UserID) (webauthn.RequestOptions, error)
+	FinishLogin(resp webauthn.AssertionResponse) (users.UserID, error)
+}
+
+type WebhookService interface {
+	CreateSubscription(req webhooks.CreateSubscriptionRequest) (webhooks.Subscription, error)
+	ListSubscriptions(userID users.UserID) ([]webhooks.Subscription, error)
+	FetchSubscription(id webhooks.SubscriptionID, userID users.UserID) (webhooks.Subscription, error)
+	DeleteSubscription(id webhooks.SubscriptionID, userID users.UserID) error
+	ListDeliveries(id webhooks.SubscriptionID, userID users.UserID) ([]webhooks.Delivery, error)
+	FetchDelivery(id webhooks.SubscriptionID, dlvID webhooks.DeliveryID, userID users.UserID) (webhooks.Delivery, error)
+	Redeliver(id webhooks.SubscriptionID, dlvID webhooks.DeliveryID, userID users.UserID) (webhooks.Delivery, error)
 }
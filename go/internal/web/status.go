@@ -0,0 +1,60 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// handleChangeAccountStatus lets staff freeze, reactivate or close an account, giving a reason
+// code which is kept in the account's status history.
+func handleChangeAccountStatus(svc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		var req ChangeAccountStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		if !authorizeStaff(w, r, svc, policy, authz.ChangeStatus, acctNum) {
+			return
+		}
+
+		staffID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		acct, err := svc.ChangeAccountStatus(acctNum, accounts.AccountStatus(req.Status), accounts.StatusReason(req.Reason), staffID, accountPreconditions(r)...)
+		if err != nil {
+			switch {
+			case errors.Is(err, accounts.ErrAccountNotFound):
+				writeErrorResponse(w, http.StatusNotFound, err)
+			case errors.Is(err, accounts.ErrPreconditionFailed):
+				writeErrorResponse(w, http.StatusPreconditionFailed, err)
+			case errors.Is(err, accounts.ErrInvalidStatusTransition), errors.Is(err, accounts.ErrAccountNotEmpty), errors.Is(err, accounts.ErrConflict):
+				writeErrorResponse(w, http.StatusConflict, err)
+			default:
+				writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		resp := newAccountStatusResponseFromDomain(acct)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeAccountStatus(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-teller")
+	mustAssignStaffRole(t, usrSvc, usrStore, "usr-teller", users.TellerRole)
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore)
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, TanSvc: tanSvc})
+
+	token := login(t, srv, "usr-testuser")
+	tellerToken := login(t, srv, "usr-teller")
+
+	changeStatus := func(t *testing.T, acctNum string, req ChangeAccountStatusRequest, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		by, err := json.Marshal(req)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/accounts/"+acctNum+"/status", by, token))
+		return rr
+	}
+	transact := func(t *testing.T, acctNum, tanType string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		reqObj := CreateTransactionRequest{Amount: 10, Currency: accounts.GBP.String(), Type: tanType}
+		srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acctNum, token))
+		return rr
+	}
+	freeze := ChangeAccountStatusRequest{Status: "frozen", Reason: "suspected_fraud"}
+
+	t.Run("POST to /v1/accounts/{accountNumber}/status", func(t *testing.T) {
+		t.Run("by staff should 200 with the history", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := changeStatus(t, acct.AccountNumber, freeze, tellerToken)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp AccountStatusResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.NoError(t, validation.Get().Struct(resp))
+			assert.Equal(t, "frozen", resp.Status)
+			require.Len(t, resp.History, 1)
+			assert.Equal(t, "suspected_fraud", resp.History[0].Reason)
+			assert.Equal(t, "usr-teller", *resp.History[0].ChangedBy)
+		})
+		t.Run("should show the status on the account", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			assert.Equal(t, "active", acct.Status)
+			changeStatus(t, acct.AccountNumber, freeze, tellerToken)
+
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, fetchAccountRequest(t, acct.AccountNumber, token))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp BankAccountResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, "frozen", resp.Status)
+		})
+		t.Run("should refuse withdrawals but take deposits once frozen", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			changeStatus(t, acct.AccountNumber, freeze, tellerToken)
+			assert.Equal(t, http.StatusCreated, transact(t, acct.AccountNumber, "deposit").Code)
+			assert.Equal(t, http.StatusConflict, transact(t, acct.AccountNumber, "withdrawal").Code)
+		})
+		t.Run("for a transition that is not allowed should 409", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			changeStatus(t, acct.AccountNumber, freeze, tellerToken)
+			rr := changeStatus(t, acct.AccountNumber, ChangeAccountStatusRequest{Status: "dormant", Reason: "inactivity"}, tellerToken)
+			assert.Equal(t, http.StatusConflict, rr.Code)
+		})
+		t.Run("closing an account with money in it should 409", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			transact(t, acct.AccountNumber, "deposit")
+			rr := changeStatus(t, acct.AccountNumber, ChangeAccountStatusRequest{Status: "closed", Reason: "customer_request"}, tellerToken)
+			assert.Equal(t, http.StatusConflict, rr.Code)
+		})
+		t.Run("with an unknown reason should 400", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := changeStatus(t, acct.AccountNumber, ChangeAccountStatusRequest{Status: "frozen", Reason: "because"}, tellerToken)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("by the account holder should 403", func(t *testing.T) {
+			acct := mustCreateAccount(t, token, srv)
+			rr := changeStatus(t, acct.AccountNumber, freeze, token)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("for a missing account should 404", func(t *testing.T) {
+			rr := changeStatus(t, "01999990", freeze, tellerToken)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+}
@@ -2,14 +2,19 @@ package web
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
 	"eaglebank/internal/transactions"
+	"eaglebank/internal/users"
 	"eaglebank/internal/validation"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 )
 
-func handleCreateTransaction(tanSvc TransactionService, acctSvc AccountService) http.HandlerFunc {
+func handleCreateTransaction(tanSvc TransactionService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateTransactionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -23,28 +28,20 @@ func handleCreateTransaction(tanSvc TransactionService, acctSvc AccountService)
 			return
 		}
 
-		acct, err := checkTransactionAccountAuth(w, r, acctSvc)
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.CreateTransaction)
 		if err != nil {
 			return
 		}
 
-		ref := ""
-		if req.Reference != nil {
-			ref = *req.Reference
-		}
-		domReq, err := transactions.NewCreateTransactionRequest(acct.AccountNumber, acct.UserID, req.Amount, accounts.Currency(req.Currency), transactions.TransactionType(req.Type), ref)
+		domReq, err := req.toDomain(acct.AccountNumber, users.UserID(GetAuthenticatedUserID(r.Context())))
 		if err != nil {
 			writeBadRequestErrorResponse(w, err)
 			return
 		}
 
-		tan, err := tanSvc.CreateTransaction(domReq)
+		tan, err := tanSvc.CreateTransaction(domReq, accountPreconditions(r)...)
 		if err != nil {
-			if errors.Is(err, accounts.ErrInsufficientFunds) {
-				writeErrorResponse(w, http.StatusUnprocessableEntity, err)
-				return
-			}
-			writeErrorResponse(w, http.StatusInternalServerError, err)
+			writeCreateTransactionError(w, err)
 			return
 		}
 
@@ -55,16 +52,41 @@ func handleCreateTransaction(tanSvc TransactionService, acctSvc AccountService)
 	}
 }
 
-func handleListTransactions(svc TransactionService, acctSvc AccountService) http.HandlerFunc {
+// handleListTransactions lists the account's transactions in sequence order. ?fromSequence= and
+// ?toSequence= limit it to a range of sequence numbers, so clients can fetch only what is new.
+func handleListTransactions(svc TransactionService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		acct, err := checkTransactionAccountAuth(w, r, acctSvc)
+		q := r.URL.Query()
+		bySequence := q.Has("fromSequence") || q.Has("toSequence")
+		from, err := parseSequenceParam(q.Get("fromSequence"), 1)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+		to, err := parseSequenceParam(q.Get("toSequence"), math.MaxUint64)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.ReadTransactions)
 		if err != nil {
 			return
 		}
 
-		tans, err := svc.ListTransactions(acct.AccountNumber)
+		var tans []transactions.Transaction
+		if bySequence {
+			tans, err = svc.ListTransactionsBySequence(acct.AccountNumber, from, to)
+		} else {
+			tans, err = svc.ListTransactions(acct.AccountNumber)
+		}
 		if err != nil {
+			if errors.Is(err, transactions.ErrInvalidSequenceRange) {
+				writeBadRequestErrorResponse(w, err)
+				return
+			}
 			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
 		}
 
 		tanResps := make([]TransactionResponse, 0, len(tans))
@@ -79,7 +101,7 @@ func handleListTransactions(svc TransactionService, acctSvc AccountService) http
 	}
 }
 
-func handleFetchTransaction(svc TransactionService, acctSvc AccountService) http.HandlerFunc {
+func handleFetchTransaction(svc TransactionService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tanID, err := transactions.NewTransactionID(r.PathValue("transactionId"))
 		if err != nil {
@@ -87,7 +109,7 @@ func handleFetchTransaction(svc TransactionService, acctSvc AccountService) http
 			return
 		}
 
-		acct, err := checkTransactionAccountAuth(w, r, acctSvc)
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.ReadTransactions)
 		if err != nil {
 			return
 		}
@@ -96,8 +118,10 @@ func handleFetchTransaction(svc TransactionService, acctSvc AccountService) http
 		if err != nil {
 			if errors.Is(err, transactions.ErrTransactionNotFound) {
 				writeErrorResponse(w, http.StatusNotFound, err)
+				return
 			}
 			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
 		}
 
 		resp := newTransactionResponseFromDomain(tan)
@@ -108,7 +132,77 @@ func handleFetchTransaction(svc TransactionService, acctSvc AccountService) http
 	}
 }
 
-func checkTransactionAccountAuth(w http.ResponseWriter, r *http.Request, acctSvc AccountService) (accounts.BankAccount, error) {
+// handleCreateAdjustment posts a manual correction to a customer's account. The transaction is
+// recorded against the staff member who made it rather than the account holder.
+func handleCreateAdjustment(tanSvc TransactionService, acctSvc AccountService, access accountAccess) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateAdjustmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		acct, err := checkTransactionAccountAuth(w, r, acctSvc, access, authz.PostAdjustment)
+		if err != nil {
+			return
+		}
+
+		domReq, err := req.toDomain(acct.AccountNumber, users.UserID(GetAuthenticatedUserID(r.Context())))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		tan, err := tanSvc.CreateTransaction(domReq, accountPreconditions(r)...)
+		if err != nil {
+			writeCreateTransactionError(w, err)
+			return
+		}
+
+		resp := newTransactionResponseFromDomain(tan)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func parseSequenceParam(s string, def uint64) (uint64, error) {
+	if s == "" {
+		return def, nil
+	}
+	seq, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sequence number %q", s)
+	}
+	return seq, nil
+}
+
+func writeCreateTransactionError(w http.ResponseWriter, err error) {
+	if writeLimitExceededResponse(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, accounts.ErrPreconditionFailed):
+		writeErrorResponse(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, accounts.ErrInsufficientFunds), errors.Is(err, accounts.ErrWithdrawalLimitReached),
+		errors.Is(err, transactions.ErrCurrencyMismatch), errors.Is(err, transactions.ErrConversionUnavailable),
+		errors.Is(err, transactions.ErrInvalidTransactionType):
+		writeErrorResponse(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, accounts.ErrAccountFrozen), errors.Is(err, accounts.ErrAccountDormant), errors.Is(err, accounts.ErrAccountClosed),
+		errors.Is(err, transactions.ErrSequenceConflict), errors.Is(err, accounts.ErrConflict):
+		writeErrorResponse(w, http.StatusConflict, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
+
+func checkTransactionAccountAuth(w http.ResponseWriter, r *http.Request, acctSvc AccountService, access accountAccess, action authz.Action) (accounts.BankAccount, error) {
 	acctNum, err := accounts.NewAccountNumber(r.PathValue("accountNumber"))
 	if err != nil {
 		writeBadRequestErrorResponse(w, err)
@@ -124,11 +218,8 @@ func checkTransactionAccountAuth(w http.ResponseWriter, r *http.Request, acctSvc
 		writeErrorResponse(w, http.StatusInternalServerError, err)
 		return accounts.BankAccount{}, err
 	}
-	userID := GetAuthenticatedUserID(r.Context())
-	if acct.UserID.String() != userID {
-		err = errors.New("forbidden")
-		writeErrorResponse(w, http.StatusForbidden, err)
-		return accounts.BankAccount{}, err
+	if !access.authorize(w, r, action, acct) {
+		return accounts.BankAccount{}, errors.New("forbidden")
 	}
 	return acct, nil
 }
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"eaglebank/internal/accounts"
 	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/fx"
 	"eaglebank/internal/transactions"
 	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/users"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -13,6 +15,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,10 +24,11 @@ import (
 func TestCreateTransaction(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	acctStore := adapters.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 	tanStore := adapters2.NewInMemoryTransactionStore()
 	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
-	srv := NewServer(ServerArgs{Logger: logger, TanSvc: tanSvc, AcctSvc: acctSvc})
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc})
 
 	token := login(t, srv, "usr-testuser")
 
@@ -124,7 +128,7 @@ func TestCreateTransaction(t *testing.T) {
 				Type:      transactions.Deposit.String(),
 				Reference: &ref,
 			}
-			req := createTransactionRequest(t, reqObj, "01111111")
+			req := createTransactionRequest(t, reqObj, "01111116")
 			srv.ServeHTTP(rr, req)
 
 			var resp ErrorResponse
@@ -152,6 +156,32 @@ func TestCreateTransaction(t *testing.T) {
 
 			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
 		})
+		t.Run("savings withdrawal over monthly limit should 422", func(t *testing.T) {
+			acctRR := httptest.NewRecorder()
+			srv.ServeHTTP(acctRR, createAccountRequest(t, CreateBankAccountRequest{
+				Name:        "Mr Foo's Savings",
+				AccountType: accounts.SavingsAcct.String(),
+			}, token))
+			require.Equal(t, http.StatusCreated, acctRR.Code)
+			var savings BankAccountResponse
+			require.NoError(t, json.NewDecoder(acctRR.Body).Decode(&savings))
+			mustCreateTransaction(t, srv, token, savings.AccountNumber)
+
+			reqObj := CreateTransactionRequest{
+				Amount:   1,
+				Currency: accounts.GBP.String(),
+				Type:     transactions.Withdrawal.String(),
+			}
+			for range 3 {
+				rr := httptest.NewRecorder()
+				srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, savings.AccountNumber, token))
+				require.Equal(t, http.StatusCreated, rr.Code)
+			}
+
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, savings.AccountNumber, token))
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
 		t.Run("unexpected error should 500", func(t *testing.T) {
 			errTanSvc := newErroringTransactionService(t)
 			errSrv := NewServer(ServerArgs{Logger: logger, TanSvc: errTanSvc, AcctSvc: acctSvc})
@@ -177,13 +207,85 @@ func TestCreateTransaction(t *testing.T) {
 	})
 }
 
+func TestCreateTransactionCurrency(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	acctStore := adapters.NewInMemoryAccountStore()
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	converter := fx.NewConverter(fx.RateTable{Base: accounts.GBP, Rates: map[accounts.Currency]float64{accounts.EUR: 1.25}}, 0)
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(), acctStore, transactions.WithConverter(converter))
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc})
+
+	token := login(t, srv, "usr-testuser")
+
+	eur := accounts.EUR.String()
+	acctRR := httptest.NewRecorder()
+	srv.ServeHTTP(acctRR, createAccountRequest(t, CreateBankAccountRequest{
+		Name:        "Mr Foo's Euro Account",
+		AccountType: accounts.CurrentAcct.String(),
+		Currency:    &eur,
+	}, token))
+	require.Equal(t, http.StatusCreated, acctRR.Code)
+	var acct BankAccountResponse
+	require.NoError(t, json.NewDecoder(acctRR.Body).Decode(&acct))
+	require.Equal(t, eur, acct.Currency)
+
+	t.Run("POST to /v1/accounts/{accountNumber}/transactions", func(t *testing.T) {
+		t.Run("in account currency should 201", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateTransactionRequest{Amount: 10, Currency: eur, Type: transactions.Deposit.String()}
+			srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acct.AccountNumber, token))
+
+			var resp TransactionResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, eur, resp.Currency)
+			assert.Nil(t, resp.Conversion)
+		})
+		t.Run("in another currency should 422", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateTransactionRequest{Amount: 10, Currency: accounts.GBP.String(), Type: transactions.Deposit.String()}
+			srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acct.AccountNumber, token))
+
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+		t.Run("in another currency with conversion should 201", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateTransactionRequest{Amount: 10, Currency: accounts.GBP.String(), Type: transactions.Deposit.String(), Convert: true}
+			srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acct.AccountNumber, token))
+
+			var resp TransactionResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, 12.5, resp.Amount)
+			assert.Equal(t, eur, resp.Currency)
+			assert.Equal(t, &ConversionResponse{Amount: 10, Currency: accounts.GBP.String(), Rate: 1.25}, resp.Conversion)
+		})
+		t.Run("in unsupported currency should 400", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateTransactionRequest{Amount: 10, Currency: "XXX", Type: transactions.Deposit.String()}
+			srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acct.AccountNumber, token))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("with more decimal places than the currency should 400", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reqObj := CreateTransactionRequest{Amount: 10.005, Currency: eur, Type: transactions.Deposit.String()}
+			srv.ServeHTTP(rr, createTransactionRequest(t, reqObj, acct.AccountNumber, token))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+}
+
 func TestListTransactions(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	acctStore := adapters.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 	tanStore := adapters2.NewInMemoryTransactionStore()
 	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
-	srv := NewServer(ServerArgs{Logger: logger, TanSvc: tanSvc, AcctSvc: acctSvc})
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc})
 
 	token := login(t, srv, "usr-testuser")
 
@@ -223,6 +325,28 @@ func TestListTransactions(t *testing.T) {
 			assert.Contains(t, resp.Transactions, tan1)
 			assert.Contains(t, resp.Transactions, tan2)
 		})
+		t.Run("should number transactions and show the balance after each", func(t *testing.T) {
+			assert.Equal(t, uint64(1), tan1.Sequence)
+			assert.Equal(t, 100.0, tan1.BalanceAfter)
+			assert.Equal(t, uint64(2), tan2.Sequence)
+			assert.Equal(t, 200.0, tan2.BalanceAfter)
+		})
+		t.Run("with a sequence range should 200 with only that range", func(t *testing.T) {
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/accounts/"+validAcct.AccountNumber+"/transactions?fromSequence=2", nil, token))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp ListTransactionsResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, []TransactionResponse{tan2}, resp.Transactions)
+		})
+		t.Run("with an invalid sequence range should 400", func(t *testing.T) {
+			for _, query := range []string{"?fromSequence=first", "?fromSequence=0", "?fromSequence=3&toSequence=2"} {
+				rr = httptest.NewRecorder()
+				srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/accounts/"+validAcct.AccountNumber+"/transactions"+query, nil, token))
+				assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+			}
+		})
 		t.Run("invalid request should 400", func(t *testing.T) {
 			rr = httptest.NewRecorder()
 			req = listTransactionRequest(t, "invalid-acct-num", token)
@@ -260,7 +384,7 @@ func TestListTransactions(t *testing.T) {
 		})
 		t.Run("non-existent account should 404", func(t *testing.T) {
 			rr = httptest.NewRecorder()
-			req = listTransactionRequest(t, "01111111", token)
+			req = listTransactionRequest(t, "01111116", token)
 			srv.ServeHTTP(rr, req)
 
 			var resp ErrorResponse
@@ -289,10 +413,11 @@ func TestListTransactions(t *testing.T) {
 func TestFetchTransaction(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	acctStore := adapters.NewInMemoryAccountStore()
-	acctSvc := accounts.NewAccountService(acctStore)
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser", "usr-testuser2")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
 	tanStore := adapters2.NewInMemoryTransactionStore()
 	tanSvc := transactions.NewTransactionService(tanStore, acctStore)
-	srv := NewServer(ServerArgs{Logger: logger, TanSvc: tanSvc, AcctSvc: acctSvc})
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc})
 
 	token := login(t, srv, "usr-testuser")
 
@@ -360,7 +485,7 @@ func TestFetchTransaction(t *testing.T) {
 		})
 		t.Run("non-existent account should 404", func(t *testing.T) {
 			rr = httptest.NewRecorder()
-			req = fetchTransactionRequest(t, "01111111", tan1.ID, token)
+			req = fetchTransactionRequest(t, "01111116", tan1.ID, token)
 			srv.ServeHTTP(rr, req)
 
 			var resp ErrorResponse
@@ -468,10 +593,30 @@ func (e erroringTransactionService) ListTransactions(acctNum accounts.AccountNum
 	return []transactions.Transaction{}, errors.New("some error")
 }
 
-func (e erroringTransactionService) CreateTransaction(req transactions.CreateTransactionRequest) (transactions.Transaction, error) {
+func (e erroringTransactionService) ListTransactionsBySequence(acctNum accounts.AccountNumber, from, to uint64) ([]transactions.Transaction, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringTransactionService) CreateTransaction(req transactions.CreateTransactionRequest, conds ...accounts.Precondition) (transactions.Transaction, error) {
+	return transactions.Transaction{}, errors.New("some error")
+}
+
+func (e erroringTransactionService) TransferPot(req transactions.PotTransferRequest, conds ...accounts.Precondition) (transactions.Transaction, error) {
 	return transactions.Transaction{}, errors.New("some error")
 }
 
+func (e erroringTransactionService) ListPotTransactions(acctNum accounts.AccountNumber, potID accounts.PotID) ([]transactions.Transaction, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringTransactionService) BalanceHistory(acctNum accounts.AccountNumber, from, to time.Time, interval transactions.Interval) ([]transactions.BalanceInterval, error) {
+	return nil, errors.New("some error")
+}
+
+func (e erroringTransactionService) Reconcile(repair bool, userID users.UserID) (transactions.ReconciliationReport, error) {
+	return transactions.ReconciliationReport{}, errors.New("some error")
+}
+
 func newErroringTransactionService(t *testing.T) erroringTransactionService {
 	t.Helper()
 	return erroringTransactionService{}
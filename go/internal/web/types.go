@@ -2,9 +2,17 @@ package web
 
 import (
 	"eaglebank/internal/accounts"
+	"eaglebank/internal/events"
+	"eaglebank/internal/fx"
+	"eaglebank/internal/grants"
+	"eaglebank/internal/interest"
+	"eaglebank/internal/limits"
 	"eaglebank/internal/transactions"
 	"eaglebank/internal/users"
 	"eaglebank/internal/validation"
+	"eaglebank/internal/webauthn"
+	"eaglebank/internal/webhooks"
+	"encoding/base64"
 	"errors"
 	"time"
 
@@ -49,66 +57,660 @@ func newAddressFromDomain(adr users.Address) Address {
 	return a
 }
 
+type BusinessDetails struct {
+	CompanyName        string `json:"companyName" validate:"required"`
+	RegistrationNumber string `json:"registrationNumber" validate:"required"`
+}
+
+// CreateBankAccountRequest only checks the account type exists, whether business details are
+// required for it is decided by the accounts package.
 type CreateBankAccountRequest struct {
-	Name        string `json:"name" validate:"required"`
-	AccountType string `json:"accountType" validate:"required,oneof=personal"`
+	Name        string           `json:"name" validate:"required"`
+	AccountType string           `json:"accountType" validate:"required,acctType"`
+	Currency    *string          `json:"currency,omitempty" validate:"omitempty,currency"`
+	SortCode    *string          `json:"sortCode,omitempty" validate:"omitempty,sortCode"`
+	Business    *BusinessDetails `json:"business,omitempty"`
+}
+
+func (r CreateBankAccountRequest) toDomain(userID users.UserID) (accounts.CreateAccountRequest, error) {
+	var business accounts.BusinessDetails
+	if r.Business != nil {
+		regNum, err := accounts.NewCompanyNumber(r.Business.RegistrationNumber)
+		if err != nil {
+			return accounts.CreateAccountRequest{}, err
+		}
+		business = accounts.BusinessDetails{CompanyName: r.Business.CompanyName, RegistrationNumber: regNum}
+	}
+	var curr accounts.Currency
+	if r.Currency != nil {
+		curr = accounts.Currency(*r.Currency)
+	}
+	var sortCode accounts.SortCode
+	if r.SortCode != nil {
+		sortCode = accounts.SortCode(*r.SortCode)
+	}
+	return accounts.NewCreateAccountRequest(userID, r.Name, accounts.AccountType(r.AccountType), curr, sortCode, business)
+}
+
+type BranchResponse struct {
+	SortCode string  `json:"sortCode" validate:"required,sortCode"`
+	Name     string  `json:"name" validate:"required"`
+	Address  Address `json:"address" validate:"required"`
+}
+
+func newBranchResponseFromDomain(b accounts.Branch) BranchResponse {
+	return BranchResponse{
+		SortCode: b.SortCode.String(),
+		Name:     b.Name,
+		Address:  newAddressFromDomain(b.Address),
+	}
+}
+
+type ListBranchesResponse struct {
+	BIC      string           `json:"bic" validate:"required,bic"`
+	Branches []BranchResponse `json:"branches" validate:"required,dive"`
 }
 
 type UpdateBankAccountRequest struct {
 	Name        *string `json:"name,omitempty"`
-	AccountType *string `json:"accountType,omitempty" validate:"omitempty,oneof=personal"`
+	AccountType *string `json:"accountType,omitempty" validate:"omitempty,acctType"`
+}
+
+type AccountHolderResponse struct {
+	UserID         string    `json:"userId" validate:"required,userID"`
+	Role           string    `json:"role" validate:"required,oneof=primary secondary"`
+	AddedTimestamp time.Time `json:"addedTimestamp" validate:"required"`
 }
 
 type BankAccountResponse struct {
-	AccountNumber    string    `json:"accountNumber" validate:"required,acctNum"`
-	SortCode         string    `json:"sortCode" validate:"required,eq=10-10-10"`
-	Name             string    `json:"name" validate:"required"`
-	AccountType      string    `json:"accountType" validate:"required,oneof=personal"`
-	Balance          float64   `json:"balance" validate:"required,min=0,max=10000"`
-	Currency         string    `json:"currency" validate:"required,oneof=GBP"`
-	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
-	UpdatedTimestamp time.Time `json:"updatedTimestamp" validate:"required"`
+	AccountNumber    string                  `json:"accountNumber" validate:"required,acctNum"`
+	SortCode         string                  `json:"sortCode" validate:"required,sortCode"`
+	IBAN             string                  `json:"iban,omitempty" validate:"omitempty,iban"`
+	Name             string                  `json:"name" validate:"required"`
+	AccountType      string                  `json:"accountType" validate:"required,acctType"`
+	Balance          float64                 `json:"balance" validate:"required"`
+	AvailableBalance float64                 `json:"availableBalance" validate:"min=0"`
+	HeldAmount       float64                 `json:"heldAmount" validate:"min=0"`
+	PotsBalance      float64                 `json:"potsBalance" validate:"min=0"`
+	Currency         string                  `json:"currency" validate:"required,currency"`
+	Business         *BusinessDetails        `json:"business,omitempty"`
+	Overdraft        *OverdraftResponse      `json:"overdraft,omitempty"`
+	Status           string                  `json:"status" validate:"required,acctStatus"`
+	Holders          []AccountHolderResponse `json:"holders" validate:"required,dive"`
+	CreatedTimestamp time.Time               `json:"createdTimestamp" validate:"required"`
+	UpdatedTimestamp time.Time               `json:"updatedTimestamp" validate:"required"`
 }
 
 func newBankAccountResponseFromDomain(acct accounts.BankAccount) BankAccountResponse {
-	return BankAccountResponse{
+	holders := make([]AccountHolderResponse, 0, len(acct.Holders))
+	for _, h := range acct.Holders {
+		holders = append(holders, AccountHolderResponse{
+			UserID:         h.UserID.String(),
+			Role:           h.Role.String(),
+			AddedTimestamp: h.AddedTimestamp,
+		})
+	}
+	resp := BankAccountResponse{
 		AccountNumber:    acct.AccountNumber.String(),
 		SortCode:         acct.SortCode.String(),
+		IBAN:             acct.IBAN.String(),
 		Name:             acct.Name,
 		AccountType:      acct.AccountType.String(),
 		Balance:          acct.Balance(),
+		AvailableBalance: acct.AvailableBalance(),
+		HeldAmount:       acct.HeldAmount(),
+		PotsBalance:      acct.PotsBalance(),
 		Currency:         acct.Currency.String(),
+		Status:           acct.Status.String(),
+		Holders:          holders,
 		CreatedTimestamp: acct.CreatedTimestamp,
 		UpdatedTimestamp: acct.UpdatedTimestamp,
 	}
+	if !acct.Overdraft.IsZero() {
+		resp.Overdraft = &OverdraftResponse{
+			Limit:      acct.Overdraft.Limit,
+			Available:  acct.AvailableOverdraft(),
+			AnnualRate: acct.Overdraft.AnnualRate,
+		}
+	}
+	if !acct.Business.IsZero() {
+		resp.Business = &BusinessDetails{
+			CompanyName:        acct.Business.CompanyName,
+			RegistrationNumber: acct.Business.RegistrationNumber.String(),
+		}
+	}
+	return resp
 }
 
 type ListBankAccountsResponse struct {
 	Accounts []BankAccountResponse `json:"accounts" validate:"required"`
 }
 
+type AddAccountHolderRequest struct {
+	UserID string `json:"userId" validate:"required,userID"`
+}
+
+type HolderChangeResponse struct {
+	ID               string    `json:"id" validate:"required"`
+	AccountNumber    string    `json:"accountNumber" validate:"required,acctNum"`
+	Kind             string    `json:"kind" validate:"required,oneof=add remove"`
+	UserID           string    `json:"userId" validate:"required,userID"`
+	RequestedBy      string    `json:"requestedBy" validate:"required,userID"`
+	Approvals        []string  `json:"approvals" validate:"required"`
+	Status           string    `json:"status" validate:"required,oneof=pending completed rejected"`
+	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
+	UpdatedTimestamp time.Time `json:"updatedTimestamp" validate:"required"`
+}
+
+func newHolderChangeResponseFromDomain(change accounts.HolderChange) HolderChangeResponse {
+	approvals := make([]string, 0, len(change.Approvals))
+	for _, id := range change.Approvals {
+		approvals = append(approvals, id.String())
+	}
+	return HolderChangeResponse{
+		ID:               change.ID.String(),
+		AccountNumber:    change.AccountNumber.String(),
+		Kind:             string(change.Kind),
+		UserID:           change.UserID.String(),
+		RequestedBy:      change.RequestedBy.String(),
+		Approvals:        approvals,
+		Status:           string(change.Status),
+		CreatedTimestamp: change.CreatedTimestamp,
+		UpdatedTimestamp: change.UpdatedTimestamp,
+	}
+}
+
+type ListHolderChangesResponse struct {
+	Changes []HolderChangeResponse `json:"changes" validate:"required"`
+}
+
+// OverdraftResponse shows the arranged limit and how much of it is still available.
+type OverdraftResponse struct {
+	Limit      float64 `json:"limit" validate:"required,gt=0"`
+	Available  float64 `json:"available" validate:"min=0"`
+	AnnualRate float64 `json:"annualRate" validate:"min=0"`
+}
+
+type ApplyForOverdraftRequest struct {
+	Limit float64 `json:"limit" validate:"required,gt=0,overdraftLimit"`
+}
+
+type OverdraftApplicationResponse struct {
+	ID               string    `json:"id" validate:"required"`
+	AccountNumber    string    `json:"accountNumber" validate:"required,acctNum"`
+	Limit            float64   `json:"limit" validate:"required,gt=0"`
+	RequestedBy      string    `json:"requestedBy" validate:"required,userID"`
+	Status           string    `json:"status" validate:"required,oneof=pending approved rejected"`
+	DecidedBy        *string   `json:"decidedBy,omitempty" validate:"omitempty,userID"`
+	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
+	UpdatedTimestamp time.Time `json:"updatedTimestamp" validate:"required"`
+}
+
+func newOverdraftApplicationResponseFromDomain(app accounts.OverdraftApplication) OverdraftApplicationResponse {
+	resp := OverdraftApplicationResponse{
+		ID:               app.ID.String(),
+		AccountNumber:    app.AccountNumber.String(),
+		Limit:            app.Limit,
+		RequestedBy:      app.RequestedBy.String(),
+		Status:           string(app.Status),
+		CreatedTimestamp: app.CreatedTimestamp,
+		UpdatedTimestamp: app.UpdatedTimestamp,
+	}
+	if app.DecidedBy != "" {
+		decidedBy := app.DecidedBy.String()
+		resp.DecidedBy = &decidedBy
+	}
+	return resp
+}
+
+type ListOverdraftApplicationsResponse struct {
+	Applications []OverdraftApplicationResponse `json:"applications" validate:"required"`
+}
+
+type ChangeAccountStatusRequest struct {
+	Status string `json:"status" validate:"required,acctStatus"`
+	Reason string `json:"reason" validate:"required,statusReason"`
+}
+
+// StatusChangeResponse is one entry in an account's status history. ChangedBy is left out when
+// the bank made the change automatically.
+type StatusChangeResponse struct {
+	From      string    `json:"from" validate:"required,acctStatus"`
+	To        string    `json:"to" validate:"required,acctStatus"`
+	Reason    string    `json:"reason" validate:"required,statusReason"`
+	ChangedBy *string   `json:"changedBy,omitempty" validate:"omitempty,userID"`
+	Timestamp time.Time `json:"timestamp" validate:"required"`
+}
+
+// AccountStatusResponse is shown to staff only, customers see the status on the account but not
+// the reasons behind it.
+type AccountStatusResponse struct {
+	AccountNumber string                 `json:"accountNumber" validate:"required,acctNum"`
+	Status        string                 `json:"status" validate:"required,acctStatus"`
+	History       []StatusChangeResponse `json:"history" validate:"required,dive"`
+}
+
+func newAccountStatusResponseFromDomain(acct accounts.BankAccount) AccountStatusResponse {
+	history := make([]StatusChangeResponse, 0, len(acct.StatusHistory))
+	for _, change := range acct.StatusHistory {
+		changeResp := StatusChangeResponse{
+			From:      change.From.String(),
+			To:        change.To.String(),
+			Reason:    change.Reason.String(),
+			Timestamp: change.Timestamp,
+		}
+		if change.ChangedBy != "" {
+			changedBy := change.ChangedBy.String()
+			changeResp.ChangedBy = &changedBy
+		}
+		history = append(history, changeResp)
+	}
+	return AccountStatusResponse{
+		AccountNumber: acct.AccountNumber.String(),
+		Status:        acct.Status.String(),
+		History:       history,
+	}
+}
+
+type PlaceHoldRequest struct {
+	Amount  float64   `json:"amount" validate:"required,gt=0"`
+	Reason  string    `json:"reason" validate:"required,holdReason"`
+	Expires time.Time `json:"expiresTimestamp" validate:"required"`
+}
+
+type HoldResponse struct {
+	ID                string     `json:"id" validate:"required"`
+	Amount            float64    `json:"amount" validate:"required,gt=0"`
+	Reason            string     `json:"reason" validate:"required,holdReason"`
+	Status            string     `json:"status" validate:"required,oneof=active released expired"`
+	CreatedBy         string     `json:"createdBy" validate:"required,userID"`
+	CreatedTimestamp  time.Time  `json:"createdTimestamp" validate:"required"`
+	ExpiresTimestamp  time.Time  `json:"expiresTimestamp" validate:"required"`
+	ReleasedBy        *string    `json:"releasedBy,omitempty" validate:"omitempty,userID"`
+	ReleasedTimestamp *time.Time `json:"releasedTimestamp,omitempty"`
+}
+
+func newHoldResponseFromDomain(hold accounts.Hold) HoldResponse {
+	resp := HoldResponse{
+		ID:               hold.ID.String(),
+		Amount:           hold.Amount,
+		Reason:           hold.Reason.String(),
+		Status:           string(hold.Status),
+		CreatedBy:        hold.CreatedBy.String(),
+		CreatedTimestamp: hold.CreatedTimestamp,
+		ExpiresTimestamp: hold.ExpiresTimestamp,
+	}
+	if hold.ReleasedBy != "" {
+		releasedBy := hold.ReleasedBy.String()
+		resp.ReleasedBy = &releasedBy
+	}
+	if !hold.ReleasedTimestamp.IsZero() {
+		releasedTimestamp := hold.ReleasedTimestamp
+		resp.ReleasedTimestamp = &releasedTimestamp
+	}
+	return resp
+}
+
+type ListHoldsResponse struct {
+	Holds []HoldResponse `json:"holds" validate:"required"`
+}
+
+// AccrualResponse is one day's interest with the inputs used to work it out.
+type AccrualResponse struct {
+	ID               string    `json:"id" validate:"required"`
+	AccountNumber    string    `json:"accountNumber" validate:"required,acctNum"`
+	Date             string    `json:"date" validate:"required,datetime=2006-01-02"`
+	Balance          float64   `json:"balance"`
+	Currency         string    `json:"currency" validate:"required,currency"`
+	Rate             float64   `json:"rate" validate:"min=0"`
+	DayCount         string    `json:"dayCount" validate:"required,oneof=actual/365 actual/360 actual/actual"`
+	Amount           float64   `json:"amount" validate:"min=0"`
+	TransactionID    *string   `json:"transactionId,omitempty"`
+	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
+}
+
+func newAccrualResponseFromDomain(accrual interest.Accrual) AccrualResponse {
+	resp := AccrualResponse{
+		ID:               accrual.ID.String(),
+		AccountNumber:    accrual.AccountNumber.String(),
+		Date:             accrual.Date.Format(time.DateOnly),
+		Balance:          accrual.Balance,
+		Currency:         accrual.Currency.String(),
+		Rate:             accrual.Rate,
+		DayCount:         accrual.DayCount.String(),
+		Amount:           accrual.Amount,
+		CreatedTimestamp: accrual.CreatedTimestamp,
+	}
+	if accrual.IsPosted() {
+		tanID := accrual.TransactionID.String()
+		resp.TransactionID = &tanID
+	}
+	return resp
+}
+
+type ListAccrualsResponse struct {
+	Accruals []AccrualResponse `json:"accruals" validate:"required"`
+}
+
+type CreateGrantRequest struct {
+	GranteeID string    `json:"granteeId" validate:"required,userID"`
+	Scopes    []string  `json:"scopes" validate:"required,min=1,dive,oneof=balance transactions statements"`
+	Expires   time.Time `json:"expiresTimestamp" validate:"required"`
+}
+
+func (r CreateGrantRequest) toDomain(acctNum accounts.AccountNumber, grantorID users.UserID) (grants.CreateGrantRequest, error) {
+	scopes := make([]grants.Scope, 0, len(r.Scopes))
+	for _, s := range r.Scopes {
+		scope, err := grants.NewScope(s)
+		if err != nil {
+			return grants.CreateGrantRequest{}, err
+		}
+		scopes = append(scopes, scope)
+	}
+	granteeID, err := users.NewUserID(r.GranteeID)
+	if err != nil {
+		return grants.CreateGrantRequest{}, err
+	}
+	return grants.CreateGrantRequest{
+		AccountNumber: acctNum,
+		GrantorID:     grantorID,
+		GranteeID:     granteeID,
+		Scopes:        scopes,
+		Expires:       r.Expires,
+	}, nil
+}
+
+type GrantResponse struct {
+	ID               string     `json:"id" validate:"required"`
+	AccountNumber    string     `json:"accountNumber" validate:"required,acctNum"`
+	GrantorID        string     `json:"grantorId" validate:"required,userID"`
+	GranteeID        string     `json:"granteeId" validate:"required,userID"`
+	Scopes           []string   `json:"scopes" validate:"required"`
+	Expires          time.Time  `json:"expiresTimestamp" validate:"required"`
+	CreatedTimestamp time.Time  `json:"createdTimestamp" validate:"required"`
+	RevokedTimestamp *time.Time `json:"revokedTimestamp,omitempty"`
+}
+
+func newGrantResponseFromDomain(grant grants.Grant) GrantResponse {
+	scopes := make([]string, 0, len(grant.Scopes))
+	for _, s := range grant.Scopes {
+		scopes = append(scopes, s.String())
+	}
+	resp := GrantResponse{
+		ID:               grant.ID.String(),
+		AccountNumber:    grant.AccountNumber.String(),
+		GrantorID:        grant.GrantorID.String(),
+		GranteeID:        grant.GranteeID.String(),
+		Scopes:           scopes,
+		Expires:          grant.Expires,
+		CreatedTimestamp: grant.CreatedTimestamp,
+	}
+	if grant.IsRevoked() {
+		revoked := grant.RevokedTimestamp
+		resp.RevokedTimestamp = &revoked
+	}
+	return resp
+}
+
+type ListGrantsResponse struct {
+	Grants []GrantResponse `json:"grants" validate:"required"`
+}
+
+type GrantAccessResponse struct {
+	GranteeID string    `json:"granteeId" validate:"required,userID"`
+	Scope     string    `json:"scope" validate:"required"`
+	Timestamp time.Time `json:"timestamp" validate:"required"`
+}
+
+type ListGrantAccessesResponse struct {
+	Accesses []GrantAccessResponse `json:"accesses" validate:"required"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1"`
+}
+
+func (r CreateWebhookRequest) toDomain(userID users.UserID) webhooks.CreateSubscriptionRequest {
+	eventTypes := make([]events.EventType, 0, len(r.EventTypes))
+	for _, t := range r.EventTypes {
+		eventTypes = append(eventTypes, events.EventType(t))
+	}
+	return webhooks.CreateSubscriptionRequest{
+		UserID:     userID,
+		URL:        r.URL,
+		EventTypes: eventTypes,
+	}
+}
+
+type WebhookResponse struct {
+	ID         string   `json:"id" validate:"required"`
+	URL        string   `json:"url" validate:"required"`
+	EventTypes []string `json:"eventTypes" validate:"required"`
+	// Secret is only returned when the webhook is created.
+	Secret           *string   `json:"secret,omitempty"`
+	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
+}
+
+func newWebhookResponseFromDomain(sub webhooks.Subscription) WebhookResponse {
+	eventTypes := make([]string, 0, len(sub.EventTypes))
+	for _, t := range sub.EventTypes {
+		eventTypes = append(eventTypes, t.String())
+	}
+	return WebhookResponse{
+		ID:               sub.ID.String(),
+		URL:              sub.URL,
+		EventTypes:       eventTypes,
+		CreatedTimestamp: sub.CreatedTimestamp,
+	}
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks" validate:"required"`
+}
+
+type WebhookAttemptResponse struct {
+	Timestamp  time.Time `json:"timestamp" validate:"required"`
+	StatusCode *int      `json:"statusCode,omitempty"`
+	Error      *string   `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID                   string                   `json:"id" validate:"required"`
+	EventID              string                   `json:"eventId" validate:"required"`
+	EventType            string                   `json:"eventType" validate:"required"`
+	Status               string                   `json:"status" validate:"required,oneof=pending delivered dead_lettered"`
+	Attempts             []WebhookAttemptResponse `json:"attempts" validate:"required"`
+	NextAttemptTimestamp *time.Time               `json:"nextAttemptTimestamp,omitempty"`
+	CreatedTimestamp     time.Time                `json:"createdTimestamp" validate:"required"`
+	UpdatedTimestamp     time.Time                `json:"updatedTimestamp" validate:"required"`
+}
+
+func newWebhookDeliveryResponseFromDomain(dlv webhooks.Delivery) WebhookDeliveryResponse {
+	attempts := make([]WebhookAttemptResponse, 0, len(dlv.Attempts))
+	for _, a := range dlv.Attempts {
+		attempt := WebhookAttemptResponse{Timestamp: a.Timestamp, DurationMs: a.Duration.Milliseconds()}
+		if a.StatusCode != 0 {
+			statusCode := a.StatusCode
+			attempt.StatusCode = &statusCode
+		}
+		if !a.Succeeded() {
+			errMsg := a.Error
+			attempt.Error = &errMsg
+		}
+		attempts = append(attempts, attempt)
+	}
+	resp := WebhookDeliveryResponse{
+		ID:               dlv.ID.String(),
+		EventID:          dlv.Event.ID.String(),
+		EventType:        dlv.Event.Type.String(),
+		Status:           dlv.Status.String(),
+		Attempts:         attempts,
+		CreatedTimestamp: dlv.CreatedTimestamp,
+		UpdatedTimestamp: dlv.UpdatedTimestamp,
+	}
+	if dlv.Status == webhooks.DeliveryPending {
+		next := dlv.NextAttempt
+		resp.NextAttemptTimestamp = &next
+	}
+	return resp
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries" validate:"required"`
+}
+
 type CreateTransactionRequest struct {
-	Amount    float64 `json:"amount" validate:"required,min=0,max=10000"`
-	Currency  string  `json:"currency" validate:"required,oneof=GBP"`
+	Amount    float64 `json:"amount" validate:"required,min=0"`
+	Currency  string  `json:"currency" validate:"required,currency"`
 	Type      string  `json:"type" validate:"required,oneof=deposit withdrawal"`
 	Reference *string `json:"reference,omitempty"`
+	Convert   bool    `json:"convert,omitempty"`
+}
+
+func (r CreateTransactionRequest) toDomain(acctNum accounts.AccountNumber, userID users.UserID) (transactions.CreateTransactionRequest, error) {
+	ref := ""
+	if r.Reference != nil {
+		ref = *r.Reference
+	}
+	req, err := transactions.NewCreateTransactionRequest(acctNum, userID, r.Amount, accounts.Currency(r.Currency), transactions.TransactionType(r.Type), ref)
+	if err != nil {
+		return transactions.CreateTransactionRequest{}, err
+	}
+	req.Convert = r.Convert
+	return req, nil
+}
+
+type CreateAdjustmentRequest struct {
+	Amount    float64 `json:"amount" validate:"required,min=0"`
+	Currency  string  `json:"currency" validate:"required,currency"`
+	Direction string  `json:"direction" validate:"required,oneof=credit debit"`
+	Reason    string  `json:"reason" validate:"required"`
+}
+
+func (r CreateAdjustmentRequest) toDomain(acctNum accounts.AccountNumber, staffID users.UserID) (transactions.CreateTransactionRequest, error) {
+	tanType := transactions.AdjustmentCredit
+	if r.Direction == "debit" {
+		tanType = transactions.AdjustmentDebit
+	}
+	return transactions.NewCreateTransactionRequest(acctNum, staffID, r.Amount, accounts.Currency(r.Currency), tanType, r.Reason)
+}
+
+// ConversionResponse is the other side of a converted transaction: the amount and currency
+// originally requested, or the opposite leg of a currency conversion.
+type ConversionResponse struct {
+	Amount   float64 `json:"amount" validate:"required"`
+	Currency string  `json:"currency" validate:"required,currency"`
+	Rate     float64 `json:"rate" validate:"required,gt=0"`
+	Spread   float64 `json:"spread" validate:"min=0,lt=1"`
+}
+
+type CreatePotRequest struct {
+	Name       string     `json:"name" validate:"required"`
+	Goal       *float64   `json:"goal,omitempty" validate:"omitempty,gte=0"`
+	TargetDate *time.Time `json:"targetDate,omitempty"`
+}
+
+func (r CreatePotRequest) toDomain() (float64, time.Time) {
+	var goal float64
+	if r.Goal != nil {
+		goal = *r.Goal
+	}
+	var targetDate time.Time
+	if r.TargetDate != nil {
+		targetDate = *r.TargetDate
+	}
+	return goal, targetDate
+}
+
+type UpdatePotRequest struct {
+	Name       *string    `json:"name,omitempty" validate:"omitempty,min=1"`
+	Goal       *float64   `json:"goal,omitempty" validate:"omitempty,gte=0"`
+	TargetDate *time.Time `json:"targetDate,omitempty"`
+}
+
+func (r UpdatePotRequest) toDomain() accounts.PotUpdate {
+	return accounts.PotUpdate{Name: r.Name, Goal: r.Goal, TargetDate: r.TargetDate}
+}
+
+// PotResponse leaves out the goal and target date when the pot has none.
+type PotResponse struct {
+	ID               string     `json:"id" validate:"required,potID"`
+	Name             string     `json:"name" validate:"required"`
+	Balance          float64    `json:"balance" validate:"min=0"`
+	Goal             *float64   `json:"goal,omitempty" validate:"omitempty,gt=0"`
+	TargetDate       *time.Time `json:"targetDate,omitempty"`
+	CreatedTimestamp time.Time  `json:"createdTimestamp" validate:"required"`
+	UpdatedTimestamp time.Time  `json:"updatedTimestamp" validate:"required"`
+}
+
+func newPotResponseFromDomain(pot accounts.Pot) PotResponse {
+	resp := PotResponse{
+		ID:               pot.ID.String(),
+		Name:             pot.Name,
+		Balance:          pot.Balance,
+		CreatedTimestamp: pot.CreatedTimestamp,
+		UpdatedTimestamp: pot.UpdatedTimestamp,
+	}
+	if pot.Goal > 0 {
+		goal := pot.Goal
+		resp.Goal = &goal
+	}
+	if !pot.TargetDate.IsZero() {
+		targetDate := pot.TargetDate
+		resp.TargetDate = &targetDate
+	}
+	return resp
+}
+
+type ListPotsResponse struct {
+	Pots []PotResponse `json:"pots" validate:"required,dive"`
+}
+
+// CreatePotTransactionRequest moves money into the pot with to_pot or out of it with from_pot. The
+// amount is in the account's currency.
+type CreatePotTransactionRequest struct {
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+	Type      string  `json:"type" validate:"required,oneof=to_pot from_pot"`
+	Reference *string `json:"reference,omitempty"`
+}
+
+func (r CreatePotTransactionRequest) toDomain(acctNum accounts.AccountNumber, potID accounts.PotID, userID users.UserID) transactions.PotTransferRequest {
+	req := transactions.PotTransferRequest{
+		AccountNumber: acctNum,
+		PotID:         potID,
+		UserID:        userID,
+		Amount:        r.Amount,
+		Type:          transactions.TransactionType(r.Type),
+	}
+	if r.Reference != nil {
+		req.Reference = *r.Reference
+	}
+	return req
 }
 
 type TransactionResponse struct {
-	ID               string    `json:"id" validate:"required,tanID"`
-	Amount           float64   `json:"amount" validate:"required,min=0,max=10000"`
-	Currency         string    `json:"currency" validate:"required,oneof=GBP"`
-	Type             string    `json:"type" validate:"required,oneof=deposit withdrawal"`
-	Reference        *string   `json:"reference,omitempty"`
-	UserID           *string   `json:"userId,omitempty" validate:"omitempty,userID"`
-	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
+	ID               string              `json:"id" validate:"required,tanID"`
+	Sequence         uint64              `json:"sequence" validate:"required"`
+	Amount           float64             `json:"amount" validate:"required,min=0"`
+	BalanceAfter     float64             `json:"balanceAfter"`
+	Currency         string              `json:"currency" validate:"required,currency"`
+	Type             string              `json:"type" validate:"required,oneof=deposit withdrawal adjustment_credit adjustment_debit conversion_debit conversion_credit overdraft_interest interest to_pot from_pot correction_credit correction_debit"`
+	Reference        *string             `json:"reference,omitempty"`
+	UserID           *string             `json:"userId,omitempty" validate:"omitempty,userID"`
+	Conversion       *ConversionResponse `json:"conversion,omitempty"`
+	PotID            *string             `json:"potId,omitempty" validate:"omitempty,potID"`
+	CreatedTimestamp time.Time           `json:"createdTimestamp" validate:"required"`
 }
 
 func newTransactionResponseFromDomain(tan transactions.Transaction) TransactionResponse {
 	userID := tan.UserID.String()
 	resp := TransactionResponse{
 		ID:               tan.ID.String(),
+		Sequence:         tan.Sequence,
 		Amount:           tan.Amount,
+		BalanceAfter:     tan.BalanceAfter,
 		Currency:         tan.Currency.String(),
 		Type:             tan.Type.String(),
 		UserID:           &userID,
@@ -118,6 +720,18 @@ func newTransactionResponseFromDomain(tan transactions.Transaction) TransactionR
 		ref := &tan.Reference
 		resp.Reference = ref
 	}
+	if !tan.Conversion.IsZero() {
+		resp.Conversion = &ConversionResponse{
+			Amount:   tan.Conversion.Amount,
+			Currency: tan.Conversion.Currency.String(),
+			Rate:     tan.Conversion.Rate,
+			Spread:   tan.Conversion.Spread,
+		}
+	}
+	if tan.PotID != "" {
+		potID := tan.PotID.String()
+		resp.PotID = &potID
+	}
 	return resp
 }
 
@@ -125,6 +739,136 @@ type ListTransactionsResponse struct {
 	Transactions []TransactionResponse `json:"transactions" validate:"required"`
 }
 
+type ReconcileRequest struct {
+	Repair bool `json:"repair"`
+}
+
+type DiscrepancyResponse struct {
+	AccountNumber string               `json:"accountNumber" validate:"required,acctNum"`
+	StoredBalance float64              `json:"storedBalance"`
+	LedgerBalance float64              `json:"ledgerBalance"`
+	Difference    float64              `json:"difference" validate:"required"`
+	Correction    *TransactionResponse `json:"correction,omitempty"`
+}
+
+type ReconciliationReportResponse struct {
+	StartedTimestamp  time.Time             `json:"startedTimestamp" validate:"required"`
+	FinishedTimestamp time.Time             `json:"finishedTimestamp" validate:"required"`
+	AccountsChecked   int                   `json:"accountsChecked" validate:"min=0"`
+	Repaired          bool                  `json:"repaired"`
+	Discrepancies     []DiscrepancyResponse `json:"discrepancies" validate:"required"`
+}
+
+func newReconciliationReportResponseFromDomain(report transactions.ReconciliationReport) ReconciliationReportResponse {
+	resp := ReconciliationReportResponse{
+		StartedTimestamp:  report.StartedTimestamp,
+		FinishedTimestamp: report.FinishedTimestamp,
+		AccountsChecked:   report.AccountsChecked,
+		Repaired:          report.Repaired,
+		Discrepancies:     make([]DiscrepancyResponse, 0, len(report.Discrepancies)),
+	}
+	for _, d := range report.Discrepancies {
+		dResp := DiscrepancyResponse{
+			AccountNumber: d.AccountNumber.String(),
+			StoredBalance: d.StoredBalance,
+			LedgerBalance: d.LedgerBalance,
+			Difference:    d.Difference,
+		}
+		if d.Correction != nil {
+			correction := newTransactionResponseFromDomain(*d.Correction)
+			dResp.Correction = &correction
+		}
+		resp.Discrepancies = append(resp.Discrepancies, dResp)
+	}
+	return resp
+}
+
+type BalanceIntervalResponse struct {
+	Start          string  `json:"start" validate:"required,datetime=2006-01-02"`
+	End            string  `json:"end" validate:"required,datetime=2006-01-02"`
+	OpeningBalance float64 `json:"openingBalance"`
+	ClosingBalance float64 `json:"closingBalance"`
+}
+
+func newBalanceIntervalResponseFromDomain(b transactions.BalanceInterval) BalanceIntervalResponse {
+	return BalanceIntervalResponse{
+		Start:          b.Start.Format(time.DateOnly),
+		End:            b.End.Format(time.DateOnly),
+		OpeningBalance: b.Opening,
+		ClosingBalance: b.Closing,
+	}
+}
+
+type BalanceHistoryResponse struct {
+	Currency string                    `json:"currency" validate:"required,currency"`
+	Interval string                    `json:"interval" validate:"required,oneof=day week month"`
+	Balances []BalanceIntervalResponse `json:"balances" validate:"required,dive"`
+}
+
+type CreateQuoteRequest struct {
+	FromCurrency string  `json:"fromCurrency" validate:"required,currency"`
+	ToCurrency   string  `json:"toCurrency" validate:"required,currency,nefield=FromCurrency"`
+	Amount       float64 `json:"amount" validate:"required,gt=0"`
+}
+
+func (r CreateQuoteRequest) toDomain(userID users.UserID) (fx.CreateQuoteRequest, error) {
+	return fx.NewCreateQuoteRequest(userID, accounts.Currency(r.FromCurrency), accounts.Currency(r.ToCurrency), r.Amount)
+}
+
+type QuoteResponse struct {
+	ID               string    `json:"id" validate:"required"`
+	FromCurrency     string    `json:"fromCurrency" validate:"required,currency"`
+	FromAmount       float64   `json:"fromAmount" validate:"required"`
+	ToCurrency       string    `json:"toCurrency" validate:"required,currency"`
+	ToAmount         float64   `json:"toAmount" validate:"required"`
+	Rate             float64   `json:"rate" validate:"required,gt=0"`
+	Spread           float64   `json:"spread" validate:"min=0,lt=1"`
+	ExpiresTimestamp time.Time `json:"expiresTimestamp" validate:"required"`
+}
+
+func newQuoteResponseFromDomain(quote fx.Quote) QuoteResponse {
+	return QuoteResponse{
+		ID:               quote.ID.String(),
+		FromCurrency:     quote.FromCurrency.String(),
+		FromAmount:       quote.FromAmount,
+		ToCurrency:       quote.ToCurrency.String(),
+		ToAmount:         quote.ToAmount,
+		Rate:             quote.Rate,
+		Spread:           quote.Spread,
+		ExpiresTimestamp: quote.ExpiresTimestamp,
+	}
+}
+
+type CreateFXConversionRequest struct {
+	QuoteID           string  `json:"quoteId" validate:"required"`
+	FromAccountNumber string  `json:"fromAccountNumber" validate:"required,acctNum"`
+	ToAccountNumber   string  `json:"toAccountNumber" validate:"required,acctNum,nefield=FromAccountNumber"`
+	Reference         *string `json:"reference,omitempty"`
+}
+
+func (r CreateFXConversionRequest) toDomain(userID users.UserID) (fx.ExecuteQuoteRequest, error) {
+	quoteID, err := fx.NewQuoteID(r.QuoteID)
+	if err != nil {
+		return fx.ExecuteQuoteRequest{}, err
+	}
+	ref := ""
+	if r.Reference != nil {
+		ref = *r.Reference
+	}
+	return fx.ExecuteQuoteRequest{
+		QuoteID:     quoteID,
+		UserID:      userID,
+		FromAccount: accounts.AccountNumber(r.FromAccountNumber),
+		ToAccount:   accounts.AccountNumber(r.ToAccountNumber),
+		Reference:   ref,
+	}, nil
+}
+
+type FXConversionResponse struct {
+	Debit  TransactionResponse `json:"debit" validate:"required"`
+	Credit TransactionResponse `json:"credit" validate:"required"`
+}
+
 type CreateUserRequest struct {
 	Name        string  `json:"name" validate:"required"`
 	Address     Address `json:"address" validate:"required"`
@@ -162,6 +906,8 @@ type UserResponse struct {
 	Address          Address   `json:"address" validate:"required"`
 	PhoneNumber      string    `json:"phoneNumber" validate:"required,phone"`
 	Email            string    `json:"email" validate:"required,email"`
+	EmailVerified    bool      `json:"emailVerified"`
+	Tier             string    `json:"tier" validate:"required,tier"`
 	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
 	UpdatedTimestamp time.Time `json:"updatedTimestamp" validate:"required"`
 }
@@ -173,11 +919,17 @@ func newUserResponseFromDomain(user users.User) UserResponse {
 		Address:          newAddressFromDomain(user.Address),
 		PhoneNumber:      user.PhoneNumber.String(),
 		Email:            user.Email.String(),
+		EmailVerified:    user.EmailVerified,
+		Tier:             user.Tier.String(),
 		CreatedTimestamp: user.Created,
 		UpdatedTimestamp: user.Updated,
 	}
 }
 
+type ChangeUserTierRequest struct {
+	Tier string `json:"tier" validate:"required,tier"`
+}
+
 type ErrorResponse struct {
 	Message string `json:"message" validate:"required"`
 }
@@ -236,6 +988,23 @@ func newBadRequestErrorResponse(err error) BadRequestErrorResponse {
 	return resp
 }
 
+// LimitExceededResponse names the limit a transaction would have broken.
+type LimitExceededResponse struct {
+	Message   string  `json:"message" validate:"required"`
+	Limit     string  `json:"limit" validate:"required"`
+	Max       float64 `json:"max"`
+	Attempted float64 `json:"attempted"`
+}
+
+func newLimitExceededResponse(err *limits.ExceededError) LimitExceededResponse {
+	return LimitExceededResponse{
+		Message:   err.Error(),
+		Limit:     err.Limit.String(),
+		Max:       err.Max,
+		Attempted: err.Attempted,
+	}
+}
+
 type LoginRequest struct {
 	UserID       string `json:"userID" validate:"required,userID"`
 	PasswordHash string `json:"passwordhash" validate:"required"`
@@ -244,3 +1013,165 @@ type LoginRequest struct {
 type LoginResponse struct {
 	Token string `json:"token" validate:"required"`
 }
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token        string `json:"token" validate:"required"`
+	PasswordHash string `json:"passwordhash" validate:"required,min=8,max=72"`
+}
+
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id" validate:"required"`
+	Name string `json:"name" validate:"required"`
+}
+
+type WebAuthnUser struct {
+	ID          string `json:"id" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	DisplayName string `json:"displayName" validate:"required"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type" validate:"required,eq=public-key"`
+	Alg  int    `json:"alg" validate:"required"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type string `json:"type" validate:"required,eq=public-key"`
+	ID   string `json:"id" validate:"required,base64rawurl"`
+}
+
+func newWebAuthnCredentialDescriptorsFromDomain(descs []webauthn.CredentialDescriptor) []WebAuthnCredentialDescriptor {
+	resps := make([]WebAuthnCredentialDescriptor, 0, len(descs))
+	for _, desc := range descs {
+		resps = append(resps, WebAuthnCredentialDescriptor{Type: desc.Type, ID: desc.ID.String()})
+	}
+	return resps
+}
+
+type WebAuthnCreationOptionsResponse struct {
+	Challenge          string                         `json:"challenge" validate:"required"`
+	RelyingParty       WebAuthnRelyingParty           `json:"rp" validate:"required"`
+	User               WebAuthnUser                   `json:"user" validate:"required"`
+	PubKeyCredParams   []WebAuthnCredentialParameter  `json:"pubKeyCredParams" validate:"required"`
+	ExcludeCredentials []WebAuthnCredentialDescriptor `json:"excludeCredentials" validate:"required"`
+	Timeout            int64                          `json:"timeout" validate:"required"`
+	Attestation        string                         `json:"attestation" validate:"required,eq=none"`
+}
+
+func newWebAuthnCreationOptionsResponseFromDomain(opts webauthn.CreationOptions) WebAuthnCreationOptionsResponse {
+	params := make([]WebAuthnCredentialParameter, 0, len(opts.PubKeyCredParams))
+	for _, p := range opts.PubKeyCredParams {
+		params = append(params, WebAuthnCredentialParameter{Type: p.Type, Alg: p.Algorithm})
+	}
+	return WebAuthnCreationOptionsResponse{
+		Challenge:    opts.Challenge.String(),
+		RelyingParty: WebAuthnRelyingParty{ID: opts.RelyingParty.ID, Name: opts.RelyingParty.Name},
+		User: WebAuthnUser{
+			ID:          base64.RawURLEncoding.EncodeToString([]byte(opts.UserID)),
+			Name:        opts.UserID.String(),
+			DisplayName: opts.UserID.String(),
+		},
+		PubKeyCredParams:   params,
+		ExcludeCredentials: newWebAuthnCredentialDescriptorsFromDomain(opts.ExcludeCreds),
+		Timeout:            opts.Timeout.Milliseconds(),
+		Attestation:        "none",
+	}
+}
+
+type WebAuthnRequestOptionsResponse struct {
+	Challenge        string                         `json:"challenge" validate:"required"`
+	RPID             string                         `json:"rpId" validate:"required"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials" validate:"required"`
+	Timeout          int64                          `json:"timeout" validate:"required"`
+}
+
+func newWebAuthnRequestOptionsResponseFromDomain(opts webauthn.RequestOptions) WebAuthnRequestOptionsResponse {
+	return WebAuthnRequestOptionsResponse{
+		Challenge:        opts.Challenge.String(),
+		RPID:             opts.RelyingParty.ID,
+		AllowCredentials: newWebAuthnCredentialDescriptorsFromDomain(opts.AllowCreds),
+		Timeout:          opts.Timeout.Milliseconds(),
+	}
+}
+
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,base64rawurl"`
+	AttestationObject string `json:"attestationObject" validate:"required,base64rawurl"`
+}
+
+type WebAuthnRegistrationRequest struct {
+	ID       string                      `json:"id" validate:"required,base64rawurl"`
+	Type     string                      `json:"type" validate:"required,eq=public-key"`
+	Response WebAuthnAttestationResponse `json:"response" validate:"required"`
+}
+
+func (r WebAuthnRegistrationRequest) toDomain() (webauthn.RegistrationResponse, error) {
+	clientDataJSON, err := base64.RawURLEncoding.DecodeString(r.Response.ClientDataJSON)
+	if err != nil {
+		return webauthn.RegistrationResponse{}, err
+	}
+	attObj, err := base64.RawURLEncoding.DecodeString(r.Response.AttestationObject)
+	if err != nil {
+		return webauthn.RegistrationResponse{}, err
+	}
+	return webauthn.RegistrationResponse{ClientDataJSON: clientDataJSON, AttestationObject: attObj}, nil
+}
+
+type WebAuthnCredentialResponse struct {
+	ID               string    `json:"id" validate:"required,base64rawurl"`
+	UserID           string    `json:"userId" validate:"required,userID"`
+	CreatedTimestamp time.Time `json:"createdTimestamp" validate:"required"`
+}
+
+func newWebAuthnCredentialResponseFromDomain(cred webauthn.Credential) WebAuthnCredentialResponse {
+	return WebAuthnCredentialResponse{
+		ID:               cred.ID.String(),
+		UserID:           cred.UserID.String(),
+		CreatedTimestamp: cred.CreatedTimestamp,
+	}
+}
+
+type WebAuthnLoginBeginRequest struct {
+	UserID string `json:"userId" validate:"required,userID"`
+}
+
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,base64rawurl"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required,base64rawurl"`
+	Signature         string `json:"signature" validate:"required,base64rawurl"`
+}
+
+type WebAuthnLoginRequest struct {
+	ID       string                    `json:"id" validate:"required,base64rawurl"`
+	Type     string                    `json:"type" validate:"required,eq=public-key"`
+	Response WebAuthnAssertionResponse `json:"response" validate:"required"`
+}
+
+func (r WebAuthnLoginRequest) toDomain() (webauthn.AssertionResponse, error) {
+	clientDataJSON, err := base64.RawURLEncoding.DecodeString(r.Response.ClientDataJSON)
+	if err != nil {
+		return webauthn.AssertionResponse{}, err
+	}
+	authData, err := base64.RawURLEncoding.DecodeString(r.Response.AuthenticatorData)
+	if err != nil {
+		return webauthn.AssertionResponse{}, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(r.Response.Signature)
+	if err != nil {
+		return webauthn.AssertionResponse{}, err
+	}
+	return webauthn.AssertionResponse{
+		CredentialID:      webauthn.CredentialID(r.ID),
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         sig,
+	}, nil
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -36,4 +37,14 @@ func TestTypes(t *testing.T) {
 			assert.NotEmpty(t, resp.Details)
 		})
 	})
+	t.Run("acctNum", func(t *testing.T) {
+		t.Run("should accept an account number with a valid check digit", func(t *testing.T) {
+			req := CreateFXConversionRequest{QuoteID: "fxq-123", FromAccountNumber: "01234528", ToAccountNumber: "01000020"}
+			assert.NoError(t, validation.Get().Struct(req))
+		})
+		t.Run("should reject an account number failing the modulus check", func(t *testing.T) {
+			req := CreateFXConversionRequest{QuoteID: "fxq-123", FromAccountNumber: "01234527", ToAccountNumber: "01000020"}
+			assert.Error(t, validation.Get().Struct(req))
+		})
+	})
 }
@@ -1,6 +1,8 @@
 package web
 
 import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/authz"
 	"eaglebank/internal/users"
 	"eaglebank/internal/validation"
 	"encoding/json"
@@ -40,7 +42,7 @@ func handleCreateUser(usrSvc UserService) http.HandlerFunc {
 	}
 }
 
-func handleGetUser(usrSvc UserService) http.HandlerFunc {
+func handleGetUser(usrSvc UserService, policy authz.Policy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, err := users.NewUserID(r.PathValue("userId"))
 		if err != nil {
@@ -48,9 +50,8 @@ func handleGetUser(usrSvc UserService) http.HandlerFunc {
 			return
 		}
 
-		authenticatedUserID := GetAuthenticatedUserID(r.Context())
-		if authenticatedUserID != userID.String() {
-			writeErrorResponse(w, http.StatusForbidden, errors.New("forbidden"))
+		if !authorize(w, r, policy, authz.ReadUser, authz.OwnedBy(userID)) {
+			return
 		}
 
 		usr, err := usrSvc.GetUser(userID)
@@ -62,6 +63,9 @@ func handleGetUser(usrSvc UserService) http.HandlerFunc {
 			writeErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
+		if writeETag(w, r, usr.Version) {
+			return
+		}
 
 		resp := newUserResponseFromDomain(usr)
 		w.Header().Set("Content-Type", "application/json")
@@ -69,3 +73,176 @@ func handleGetUser(usrSvc UserService) http.HandlerFunc {
 		json.NewEncoder(w).Encode(resp)
 	}
 }
+
+// handleChangeUserTier lets staff move a customer to another tier, the limits on the accounts they
+// are the primary holder of are resolved again for the new tier.
+func handleChangeUserTier(usrSvc UserService, acctSvc AccountService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := users.NewUserID(r.PathValue("userId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		var req ChangeUserTierRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		if !authorize(w, r, policy, authz.ChangeTier, authz.OwnedBy(userID)) {
+			return
+		}
+
+		tier := users.Tier(req.Tier)
+		usr, err := usrSvc.AssignTier(userID, tier, userPreconditions(r)...)
+		if err != nil {
+			switch {
+			case errors.Is(err, users.ErrUserNotFound):
+				writeErrorResponse(w, http.StatusNotFound, err)
+			case errors.Is(err, users.ErrPreconditionFailed):
+				writeErrorResponse(w, http.StatusPreconditionFailed, err)
+			case errors.Is(err, users.ErrConflict):
+				writeErrorResponse(w, http.StatusConflict, err)
+			default:
+				writeErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		_, err = acctSvc.ApplyTier(userID, tier)
+		if err != nil {
+			if errors.Is(err, accounts.ErrConflict) {
+				writeErrorResponse(w, http.StatusConflict, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp := newUserResponseFromDomain(usr)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleSendVerificationEmail(usrSvc UserService, policy authz.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := users.NewUserID(r.PathValue("userId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		if !authorize(w, r, policy, authz.VerifyEmail, authz.OwnedBy(userID)) {
+			return
+		}
+
+		err = usrSvc.SendVerificationEmail(userID)
+		if err != nil {
+			if errors.Is(err, users.ErrUserNotFound) {
+				writeErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func handleVerifyEmail(usrSvc UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req VerifyEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		usr, err := usrSvc.VerifyEmail(req.Token)
+		if err != nil {
+			if errors.Is(err, users.ErrInvalidToken) {
+				writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp := newUserResponseFromDomain(usr)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleRequestPasswordReset(usrSvc UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PasswordResetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		err = usrSvc.RequestPasswordReset(users.Email(req.Email))
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func handleResetPassword(usrSvc UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ConfirmPasswordResetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		password, err := users.NewPassword(req.PasswordHash)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		err = usrSvc.ResetPassword(req.Token, password)
+		if err != nil {
+			if errors.Is(err, users.ErrInvalidToken) {
+				writeErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
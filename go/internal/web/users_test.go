@@ -2,16 +2,22 @@ package web
 
 import (
 	"bytes"
+	"eaglebank/internal/accounts"
+	adapters3 "eaglebank/internal/accounts/adapters"
+	adapters2 "eaglebank/internal/notifications/adapters"
 	"eaglebank/internal/users"
 	"eaglebank/internal/users/adapters"
 	"encoding/json"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"regexp"
 	"testing"
 	"time"
 )
@@ -19,8 +25,7 @@ import (
 func TestUsers(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-	usrStore := adapters.NewInMemoryUserStore()
-	usrSvc := users.NewUserService(usrStore)
+	usrSvc, usrStore, mailbox := newTestUserService(t)
 
 	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc})
 	t.Run("POST to /v1/users", func(t *testing.T) {
@@ -161,6 +166,143 @@ func TestUsers(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, rr.Code)
 		})
 	})
+	t.Run("POST /v1/users/verify-email", func(t *testing.T) {
+		user := mustCreateUser(t, srv, "verify@bar.com")
+		token := login(t, srv, user.ID)
+		assert.False(t, user.EmailVerified)
+
+		t.Run("unverified user should 403 creating account", func(t *testing.T) {
+			acctSvc := accounts.NewAccountService(adapters3.NewInMemoryAccountStore(), usrStore, adapters3.NewInMemoryHolderChangeStore(), adapters3.NewInMemoryOverdraftApplicationStore())
+			acctSrv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc})
+			rr := httptest.NewRecorder()
+			req := createAccountRequest(t, CreateBankAccountRequest{Name: "Mr Foo", AccountType: "personal"}, token)
+			acctSrv.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("resending verification email should 202", func(t *testing.T) {
+			before := len(mailbox.MessagesTo("verify@bar.com"))
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/v1/users/"+user.ID+"/verification-email", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			srv.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+			assert.Len(t, mailbox.MessagesTo("verify@bar.com"), before+1)
+		})
+		t.Run("valid token should 200 and verify email", func(t *testing.T) {
+			emailToken := lastEmailedToken(t, mailbox, "verify@bar.com")
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/users/verify-email", VerifyEmailRequest{Token: emailToken}))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp UserResponse
+			err := json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.True(t, resp.EmailVerified)
+		})
+		t.Run("reused token should 400", func(t *testing.T) {
+			emailToken := lastEmailedToken(t, mailbox, "verify@bar.com")
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/users/verify-email", VerifyEmailRequest{Token: emailToken}))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("forged token should 400", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/users/verify-email", VerifyEmailRequest{Token: "forged.token"}))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+	t.Run("POST /v1/password-reset", func(t *testing.T) {
+		user := mustCreateUser(t, srv, "reset@bar.com")
+
+		t.Run("unknown email should still 202", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/password-reset", PasswordResetRequest{Email: "nobody@bar.com"}))
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+			assert.Empty(t, mailbox.MessagesTo("nobody@bar.com"))
+		})
+		t.Run("reset flow should set password used by login", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/password-reset", PasswordResetRequest{Email: "reset@bar.com"}))
+			require.Equal(t, http.StatusAccepted, rr.Code)
+
+			resetToken := lastEmailedToken(t, mailbox, "reset@bar.com")
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/password-reset/confirm", ConfirmPasswordResetRequest{Token: resetToken, PasswordHash: "new-password"}))
+			require.Equal(t, http.StatusNoContent, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/login", LoginRequest{UserID: user.ID, PasswordHash: "wrong-password"}))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/login", LoginRequest{UserID: user.ID, PasswordHash: "new-password"}))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+		t.Run("reused reset token should 400", func(t *testing.T) {
+			resetToken := lastEmailedToken(t, mailbox, "reset@bar.com")
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/password-reset/confirm", ConfirmPasswordResetRequest{Token: resetToken, PasswordHash: "another-password"}))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("verification token should not reset password", func(t *testing.T) {
+			verifyToken := lastEmailedToken(t, mailbox, "verify@bar.com")
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/password-reset/confirm", ConfirmPasswordResetRequest{Token: verifyToken, PasswordHash: "another-password"}))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("short password should 400", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/v1/password-reset/confirm", ConfirmPasswordResetRequest{Token: "x.y", PasswordHash: "short"}))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+	t.Run("POST /login", func(t *testing.T) {
+		customer := mustCreateUser(t, srv, "customer@bar.com")
+		staff := mustCreateUser(t, srv, "staff@bar.com")
+		_, err := usrSvc.AssignRole(users.UserID(staff.ID), users.TellerRole)
+		require.NoError(t, err)
+
+		t.Run("customer without a password should 200", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/login", LoginRequest{UserID: customer.ID, PasswordHash: "anything"}))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+		t.Run("staff without a password should 401", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/login", LoginRequest{UserID: staff.ID, PasswordHash: "anything"}))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+		t.Run("staff with a password should need it", func(t *testing.T) {
+			mustAssignStaffRole(t, usrSvc, usrStore, users.UserID(staff.ID), users.TellerRole)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/login", LoginRequest{UserID: staff.ID, PasswordHash: "wrong-password"}))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, postJSONReq(t, "/login", LoginRequest{UserID: staff.ID, PasswordHash: testPassword}))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	})
+}
+
+func mustCreateUser(t *testing.T, srv http.Handler, email string) UserResponse {
+	t.Helper()
+	reqObj := validUserRequest
+	reqObj.Email = email
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, createUserReq(t, reqObj))
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var user UserResponse
+	err := json.NewDecoder(rr.Body).Decode(&user)
+	require.NoError(t, err)
+	return user
+}
+
+func postJSONReq(t *testing.T, path string, reqObj any) *http.Request {
+	t.Helper()
+	by, err := json.Marshal(reqObj)
+	require.NoError(t, err)
+	return httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(by))
 }
 
 func createUserReq(t *testing.T, reqObj CreateUserRequest) *http.Request {
@@ -183,7 +325,7 @@ func login(t *testing.T, srv http.Handler, userID string) string {
 	t.Helper()
 	loginBody := LoginRequest{
 		UserID:       userID,
-		PasswordHash: "123",
+		PasswordHash: testPassword,
 	}
 	by, err := json.Marshal(loginBody)
 	require.NoError(t, err)
@@ -238,3 +380,81 @@ func (e ErroringUserService) CreateUser(_ users.CreateUserRequest) (users.User,
 func (e ErroringUserService) GetUser(userID users.UserID) (users.User, error) {
 	return users.User{}, errors.New("some error")
 }
+
+func (e ErroringUserService) SendVerificationEmail(userID users.UserID) error {
+	return errors.New("some error")
+}
+
+func (e ErroringUserService) VerifyEmail(token string) (users.User, error) {
+	return users.User{}, errors.New("some error")
+}
+
+func (e ErroringUserService) RequestPasswordReset(email users.Email) error {
+	return errors.New("some error")
+}
+
+func (e ErroringUserService) ResetPassword(token string, password users.Password) error {
+	return errors.New("some error")
+}
+
+func (e ErroringUserService) VerifyPassword(userID users.UserID, password string) error {
+	return errors.New("some error")
+}
+
+func (e ErroringUserService) AssignTier(userID users.UserID, tier users.Tier, conds ...users.Precondition) (users.User, error) {
+	return users.User{}, errors.New("some error")
+}
+
+func newTestUserService(t *testing.T, verifiedUserIDs ...string) (users.UserService, *adapters.InMemoryUserStore, *adapters2.InMemoryMailbox) {
+	t.Helper()
+	usrStore := adapters.NewInMemoryUserStore()
+	mailbox := adapters2.NewInMemoryMailbox()
+	usrSvc := users.NewUserService(usrStore, adapters.NewInMemoryTokenStore(), mailbox, users.Config{
+		TokenSecret:     []byte("test-secret"),
+		VerificationTTL: time.Hour,
+		ResetTTL:        time.Hour,
+		BaseURL:         "http://localhost:8080",
+	})
+	for _, id := range verifiedUserIDs {
+		usr := users.MustNewUser(
+			users.MustNewUserID(id),
+			"Mr Foo",
+			users.MustNewAddress("line1", "town", "county", "postcode"),
+			users.MustNewPhoneNumber("+440000000000"),
+			users.MustNewEmail("foo@bar.com"),
+		)
+		usr.EmailVerified = true
+		require.NoError(t, usrStore.Put(usr))
+	}
+	return usrSvc, usrStore, mailbox
+}
+
+// testPassword is the password login signs in with. Customers without a password are let in
+// with anything, staff need to have set it.
+const testPassword = "test-password"
+
+// mustAssignStaffRole gives the user a staff role along with testPassword, as staff cannot log in
+// without a password.
+func mustAssignStaffRole(t *testing.T, usrSvc users.UserService, usrStore *adapters.InMemoryUserStore, id users.UserID, role users.Role) {
+	t.Helper()
+	usr, err := usrSvc.AssignRole(id, role)
+	require.NoError(t, err)
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	require.NoError(t, err)
+	usr.PasswordHash = hash
+	usr.Version++
+	require.NoError(t, usrStore.Put(usr))
+}
+
+var tokenLinkRegex = regexp.MustCompile(`token=(\S+)`)
+
+func lastEmailedToken(t *testing.T, mailbox *adapters2.InMemoryMailbox, to string) string {
+	t.Helper()
+	msgs := mailbox.MessagesTo(to)
+	require.NotEmpty(t, msgs)
+	match := tokenLinkRegex.FindStringSubmatch(msgs[len(msgs)-1].Body)
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
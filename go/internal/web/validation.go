@@ -0,0 +1,112 @@
+package web
+
+import (
+	"context"
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"fmt"
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Tags backed by domain definitions are registered here, the validation package cannot import
+// the domain packages because they import it.
+func init() {
+	err := validation.Get().RegisterValidation("acctType", func(fl validator.FieldLevel) bool {
+		return accounts.AccountType(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering acctType validation: %v", err))
+	}
+	// acctNum checks the modulus check digit as well as the format.
+	err = validation.Get().RegisterValidation("acctNum", func(fl validator.FieldLevel) bool {
+		_, err := accounts.NewAccountNumber(fl.Field().String())
+		return err == nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering acctNum validation: %v", err))
+	}
+	err = validation.Get().RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return accounts.Currency(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering currency validation: %v", err))
+	}
+	// sortCode also requires the bank to own the sort code when the context validated with carries
+	// its branches, see withBranches.
+	err = validation.Get().RegisterValidationCtx("sortCode", func(ctx context.Context, fl validator.FieldLevel) bool {
+		sortCode := accounts.SortCode(fl.Field().String())
+		if !sortCode.IsValid() {
+			return false
+		}
+		branches, ok := ctx.Value(branchesKey{}).([]accounts.Branch)
+		if !ok {
+			return true
+		}
+		return slices.ContainsFunc(branches, func(b accounts.Branch) bool { return b.SortCode == sortCode })
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering sortCode validation: %v", err))
+	}
+	err = validation.Get().RegisterValidation("acctStatus", func(fl validator.FieldLevel) bool {
+		return accounts.AccountStatus(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering acctStatus validation: %v", err))
+	}
+	err = validation.Get().RegisterValidation("statusReason", func(fl validator.FieldLevel) bool {
+		return accounts.StatusReason(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering statusReason validation: %v", err))
+	}
+	err = validation.Get().RegisterValidation("holdReason", func(fl validator.FieldLevel) bool {
+		return accounts.HoldReason(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering holdReason validation: %v", err))
+	}
+	err = validation.Get().RegisterValidation("tier", func(fl validator.FieldLevel) bool {
+		return users.Tier(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering tier validation: %v", err))
+	}
+	err = validation.Get().RegisterValidation("potID", func(fl validator.FieldLevel) bool {
+		return accounts.PotID(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering potID validation: %v", err))
+	}
+	// overdraftLimit leaves the largest overdraft to the accounts package.
+	err = validation.Get().RegisterValidation("overdraftLimit", func(fl validator.FieldLevel) bool {
+		return fl.Field().Float() <= accounts.MaxOverdraftLimit
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering overdraftLimit validation: %v", err))
+	}
+	// iban accepts IBANs from any country in the registry, not only GB, so it can be used for
+	// counterparty details on external payments.
+	err = validation.Get().RegisterValidation("iban", func(fl validator.FieldLevel) bool {
+		return accounts.IBAN(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering iban validation: %v", err))
+	}
+	err = validation.Get().RegisterValidation("bic", func(fl validator.FieldLevel) bool {
+		return accounts.BIC(fl.Field().String()).IsValid()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("error registering bic validation: %v", err))
+	}
+}
+
+type branchesKey struct{}
+
+// withBranches makes the sortCode tag check sort codes against the bank's branches when a request
+// is validated with the returned context.
+func withBranches(ctx context.Context, branches []accounts.Branch) context.Context {
+	return context.WithValue(ctx, branchesKey{}, branches)
+}
@@ -0,0 +1,136 @@
+package web
+
+import (
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"eaglebank/internal/webauthn"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func handleBeginWebAuthnRegistration(svc WebAuthnService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetAuthenticatedUserID(r.Context())
+		opts, err := svc.BeginRegistration(users.UserID(userID))
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp := newWebAuthnCreationOptionsResponseFromDomain(opts)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleFinishWebAuthnRegistration(svc WebAuthnService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req WebAuthnRegistrationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		domResp, err := req.toDomain()
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		cred, err := svc.FinishRegistration(users.UserID(userID), domResp)
+		if err != nil {
+			if errors.Is(err, webauthn.ErrVerificationFailed) || errors.Is(err, webauthn.ErrSessionNotFound) {
+				writeErrorResponse(w, http.StatusUnauthorized, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp := newWebAuthnCredentialResponseFromDomain(cred)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleBeginWebAuthnLogin(svc WebAuthnService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req WebAuthnLoginBeginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		opts, err := svc.BeginLogin(users.UserID(req.UserID))
+		if err != nil {
+			if errors.Is(err, webauthn.ErrCredentialNotFound) {
+				writeErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp := newWebAuthnRequestOptionsResponseFromDomain(opts)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleFinishWebAuthnLogin(svc WebAuthnService, usrSvc UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req WebAuthnLoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		domResp, err := req.toDomain()
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID, err := svc.FinishLogin(domResp)
+		if err != nil {
+			if errors.Is(err, webauthn.ErrVerificationFailed) || errors.Is(err, webauthn.ErrSessionNotFound) {
+				writeErrorResponse(w, http.StatusUnauthorized, errors.New("unauthorized"))
+				return
+			}
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// A passkey is a credential in its own right, so it signs in with the user's role whether or
+		// not they have set a password.
+		usr, err := usrSvc.GetUser(userID)
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, errors.New("authorization error"))
+			return
+		}
+		writeLoginResponse(w, userID.String(), usr.Role)
+	}
+}
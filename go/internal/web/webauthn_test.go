@@ -0,0 +1,204 @@
+package web
+
+import (
+	"bytes"
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/webauthn"
+	adapters2 "eaglebank/internal/webauthn/adapters"
+	"eaglebank/internal/webauthn/webauthntest"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebAuthn(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	cfg := webauthn.Config{RPID: "localhost", RPName: "Eagle Bank", Origin: "http://localhost:8080", ChallengeTTL: time.Minute}
+	waSvc := webauthn.NewWebAuthnService(cfg, adapters2.NewInMemoryCredentialStore(), adapters2.NewInMemorySessionStore())
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-testuser")
+	acctSvc := accounts.NewAccountService(adapters.NewInMemoryAccountStore(), usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	srv := NewServer(ServerArgs{Logger: logger, UserSvc: usrSvc, AcctSvc: acctSvc, WebAuthnSvc: waSvc})
+	auth := webauthntest.NewAuthenticator(cfg.RPID, cfg.Origin)
+
+	userID := "usr-testuser"
+	token := login(t, srv, userID)
+
+	var credID webauthn.CredentialID
+	t.Run("POST to /v1/webauthn/credentials", func(t *testing.T) {
+		t.Run("valid registration should 201", func(t *testing.T) {
+			opts := beginWebAuthnRegistration(t, srv, token)
+			assert.Equal(t, cfg.RPID, opts.RelyingParty.ID)
+			assert.Equal(t, userID, opts.User.Name)
+
+			id, domResp, err := auth.Register(webauthn.Challenge(opts.Challenge))
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, finishWebAuthnRegistrationRequest(t, newWebAuthnRegistrationRequest(id, domResp), token))
+			require.Equal(t, http.StatusCreated, rr.Code)
+
+			var resp WebAuthnCredentialResponse
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.Equal(t, id.String(), resp.ID)
+			assert.Equal(t, userID, resp.UserID)
+			credID = id
+		})
+		t.Run("without authentication should 401", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/webauthn/credentials/begin", nil))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+		t.Run("invalid body should 400", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, finishWebAuthnRegistrationRequest(t, WebAuthnRegistrationRequest{ID: "!!", Type: "public-key"}, token))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("failed verification should 401", func(t *testing.T) {
+			opts := beginWebAuthnRegistration(t, srv, token)
+			evil := webauthntest.NewAuthenticator(cfg.RPID, "https://evil.example")
+			id, domResp, err := evil.Register(webauthn.Challenge(opts.Challenge))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, finishWebAuthnRegistrationRequest(t, newWebAuthnRegistrationRequest(id, domResp), token))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+	})
+	t.Run("POST to /login/webauthn", func(t *testing.T) {
+		t.Run("valid assertion should 200 with usable token", func(t *testing.T) {
+			opts := beginWebAuthnLogin(t, srv, userID)
+			require.Len(t, opts.AllowCredentials, 1)
+			assert.Equal(t, credID.String(), opts.AllowCredentials[0].ID)
+
+			domResp, err := auth.Assert(credID, webauthn.Challenge(opts.Challenge))
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, finishWebAuthnLoginRequest(t, newWebAuthnLoginRequest(domResp)))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp LoginResponse
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			require.NotEmpty(t, resp.Token)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, listAccountsRequest(t, resp.Token))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+		t.Run("user without credentials should 404", func(t *testing.T) {
+			by, err := json.Marshal(WebAuthnLoginBeginRequest{UserID: "usr-nocreds"})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/login/webauthn/begin", bytes.NewBuffer(by)))
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+		t.Run("invalid user ID should 400", func(t *testing.T) {
+			by, err := json.Marshal(WebAuthnLoginBeginRequest{UserID: "not-a-user"})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/login/webauthn/begin", bytes.NewBuffer(by)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("replayed assertion should 401", func(t *testing.T) {
+			opts := beginWebAuthnLogin(t, srv, userID)
+			domResp, err := auth.Assert(credID, webauthn.Challenge(opts.Challenge))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, finishWebAuthnLoginRequest(t, newWebAuthnLoginRequest(domResp)))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, finishWebAuthnLoginRequest(t, newWebAuthnLoginRequest(domResp)))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+		t.Run("tampered signature should 401", func(t *testing.T) {
+			opts := beginWebAuthnLogin(t, srv, userID)
+			domResp, err := auth.Assert(credID, webauthn.Challenge(opts.Challenge))
+			require.NoError(t, err)
+			domResp.Signature[len(domResp.Signature)-1] ^= 0xff
+
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, finishWebAuthnLoginRequest(t, newWebAuthnLoginRequest(domResp)))
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+	})
+}
+
+func beginWebAuthnRegistration(t *testing.T, srv http.Handler, token string) WebAuthnCreationOptionsResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/webauthn/credentials/begin", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var opts WebAuthnCreationOptionsResponse
+	err := json.NewDecoder(rr.Body).Decode(&opts)
+	require.NoError(t, err)
+	return opts
+}
+
+func finishWebAuthnRegistrationRequest(t *testing.T, reqObj WebAuthnRegistrationRequest, token ...string) *http.Request {
+	t.Helper()
+	by, err := json.Marshal(reqObj)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/v1/webauthn/credentials/finish", bytes.NewBuffer(by))
+	if len(token) != 0 {
+		req.Header.Set("Authorization", "Bearer "+token[0])
+	}
+	return req
+}
+
+func newWebAuthnRegistrationRequest(id webauthn.CredentialID, resp webauthn.RegistrationResponse) WebAuthnRegistrationRequest {
+	return WebAuthnRegistrationRequest{
+		ID:   id.String(),
+		Type: "public-key",
+		Response: WebAuthnAttestationResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(resp.ClientDataJSON),
+			AttestationObject: base64.RawURLEncoding.EncodeToString(resp.AttestationObject),
+		},
+	}
+}
+
+func beginWebAuthnLogin(t *testing.T, srv http.Handler, userID string) WebAuthnRequestOptionsResponse {
+	t.Helper()
+	by, err := json.Marshal(WebAuthnLoginBeginRequest{UserID: userID})
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/login/webauthn/begin", bytes.NewBuffer(by)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var opts WebAuthnRequestOptionsResponse
+	err = json.NewDecoder(rr.Body).Decode(&opts)
+	require.NoError(t, err)
+	return opts
+}
+
+func finishWebAuthnLoginRequest(t *testing.T, reqObj WebAuthnLoginRequest) *http.Request {
+	t.Helper()
+	by, err := json.Marshal(reqObj)
+	require.NoError(t, err)
+	return httptest.NewRequest(http.MethodPost, "/login/webauthn/finish", bytes.NewBuffer(by))
+}
+
+func newWebAuthnLoginRequest(resp webauthn.AssertionResponse) WebAuthnLoginRequest {
+	return WebAuthnLoginRequest{
+		ID:   resp.CredentialID.String(),
+		Type: "public-key",
+		Response: WebAuthnAssertionResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(resp.ClientDataJSON),
+			AuthenticatorData: base64.RawURLEncoding.EncodeToString(resp.AuthenticatorData),
+			Signature:         base64.RawURLEncoding.EncodeToString(resp.Signature),
+		},
+	}
+}
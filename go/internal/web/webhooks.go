@@ -0,0 +1,198 @@
+package web
+
+import (
+	"eaglebank/internal/users"
+	"eaglebank/internal/validation"
+	"eaglebank/internal/webhooks"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func handleCreateWebhook(svc WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := validation.Get().Struct(req)
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := users.UserID(GetAuthenticatedUserID(r.Context()))
+		sub, err := svc.CreateSubscription(req.toDomain(userID))
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		resp := newWebhookResponseFromDomain(sub)
+		resp.Secret = &sub.Secret
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleListWebhooks(svc WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetAuthenticatedUserID(r.Context())
+		subs, err := svc.ListSubscriptions(users.UserID(userID))
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		webhookResps := make([]WebhookResponse, 0, len(subs))
+		for _, sub := range subs {
+			webhookResps = append(webhookResps, newWebhookResponseFromDomain(sub))
+		}
+
+		resp := ListWebhooksResponse{Webhooks: webhookResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleFetchWebhook(svc WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subID, err := webhooks.NewSubscriptionID(r.PathValue("webhookId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		sub, err := svc.FetchSubscription(subID, users.UserID(userID))
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		resp := newWebhookResponseFromDomain(sub)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleDeleteWebhook(svc WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subID, err := webhooks.NewSubscriptionID(r.PathValue("webhookId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		err = svc.DeleteSubscription(subID, users.UserID(userID))
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleListWebhookDeliveries(svc WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subID, err := webhooks.NewSubscriptionID(r.PathValue("webhookId"))
+		if err != nil {
+			writeBadRequestErrorResponse(w, err)
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		dlvs, err := svc.ListDeliveries(subID, users.UserID(userID))
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		deliveryResps := make([]WebhookDeliveryResponse, 0, len(dlvs))
+		for _, dlv := range dlvs {
+			deliveryResps = append(deliveryResps, newWebhookDeliveryResponseFromDomain(dlv))
+		}
+
+		resp := ListWebhookDeliveriesResponse{Deliveries: deliveryResps}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleFetchWebhookDelivery(svc WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subID, dlvID, ok := webhookDeliveryPath(w, r)
+		if !ok {
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		dlv, err := svc.FetchDelivery(subID, dlvID, users.UserID(userID))
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		resp := newWebhookDeliveryResponseFromDomain(dlv)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func handleRedeliverWebhook(svc WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subID, dlvID, ok := webhookDeliveryPath(w, r)
+		if !ok {
+			return
+		}
+
+		userID := GetAuthenticatedUserID(r.Context())
+		dlv, err := svc.Redeliver(subID, dlvID, users.UserID(userID))
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		resp := newWebhookDeliveryResponseFromDomain(dlv)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func webhookDeliveryPath(w http.ResponseWriter, r *http.Request) (webhooks.SubscriptionID, webhooks.DeliveryID, bool) {
+	subID, err := webhooks.NewSubscriptionID(r.PathValue("webhookId"))
+	if err != nil {
+		writeBadRequestErrorResponse(w, err)
+		return "", "", false
+	}
+	dlvID, err := webhooks.NewDeliveryID(r.PathValue("deliveryId"))
+	if err != nil {
+		writeBadRequestErrorResponse(w, err)
+		return "", "", false
+	}
+	return subID, dlvID, true
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, webhooks.ErrSubscriptionNotFound), errors.Is(err, webhooks.ErrDeliveryNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, webhooks.ErrNotSubscriber):
+		writeErrorResponse(w, http.StatusForbidden, err)
+	case errors.Is(err, webhooks.ErrDeliveryPending):
+		writeErrorResponse(w, http.StatusConflict, err)
+	case errors.Is(err, webhooks.ErrInvalidSubscription):
+		writeBadRequestErrorResponse(w, err)
+	default:
+		writeErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
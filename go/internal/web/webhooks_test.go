@@ -0,0 +1,226 @@
+package web
+
+import (
+	"eaglebank/internal/accounts"
+	"eaglebank/internal/accounts/adapters"
+	"eaglebank/internal/events"
+	adapters4 "eaglebank/internal/events/adapters"
+	"eaglebank/internal/transactions"
+	adapters2 "eaglebank/internal/transactions/adapters"
+	"eaglebank/internal/webhooks"
+	adapters3 "eaglebank/internal/webhooks/adapters"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	outbox := adapters4.NewInMemoryOutbox()
+	acctStore := adapters.NewInMemoryAccountStore(adapters.WithOutbox(outbox))
+	usrSvc, usrStore, _ := newTestUserService(t, "usr-owner", "usr-other")
+	acctSvc := accounts.NewAccountService(acctStore, usrStore, adapters.NewInMemoryHolderChangeStore(), adapters.NewInMemoryOverdraftApplicationStore())
+	tanSvc := transactions.NewTransactionService(adapters2.NewInMemoryTransactionStore(adapters2.WithOutbox(outbox)), acctStore)
+
+	// The receiver accepts only deliveries signed with the webhook's secret, recording their payloads.
+	var mu sync.Mutex
+	var secret string
+	var received []webhooks.Payload
+	status := http.StatusOK
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if webhooks.Verify(secret, r.Header, body, time.Now(), webhooks.DefaultTolerance) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if status == http.StatusOK {
+			var payload webhooks.Payload
+			require.NoError(t, json.Unmarshal(body, &payload))
+			received = append(received, payload)
+		}
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	webhookSvc := webhooks.NewWebhookService(adapters3.NewInMemorySubscriptionStore(), adapters3.NewInMemoryDeliveryStore(), webhooks.Config{
+		MaxAttempts: 2,
+		MinBackoff:  time.Minute,
+		MaxBackoff:  time.Minute,
+	}, webhooks.WithHTTPClient(receiver.Client()), webhooks.WithLocalReceivers())
+	dispatcher := events.NewDispatcher(outbox)
+	require.NoError(t, dispatcher.Subscribe("webhooks", webhookSvc.Handle))
+	srv := NewServer(ServerArgs{Logger: slog.New(slog.DiscardHandler), UserSvc: usrSvc, TanSvc: tanSvc, AcctSvc: acctSvc, WebhookSvc: webhookSvc})
+
+	ownerToken := login(t, srv, "usr-owner")
+	otherToken := login(t, srv, "usr-other")
+	acct := mustCreateAccount(t, ownerToken, srv)
+
+	deliver := func(t *testing.T, now time.Time) webhooks.DeliveryResult {
+		t.Helper()
+		_, err := dispatcher.Dispatch(now)
+		require.NoError(t, err)
+		result, err := webhookSvc.DeliverDue(now)
+		require.NoError(t, err)
+		return result
+	}
+
+	var webhook WebhookResponse
+	t.Run("POST to /v1/webhooks", func(t *testing.T) {
+		t.Run("valid webhook should 201 with its secret", func(t *testing.T) {
+			by, err := json.Marshal(CreateWebhookRequest{URL: receiver.URL, EventTypes: []string{"transaction.posted"}})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/webhooks", by, ownerToken))
+			require.Equal(t, http.StatusCreated, rr.Code)
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&webhook))
+			require.NotNil(t, webhook.Secret)
+			mu.Lock()
+			secret = *webhook.Secret
+			mu.Unlock()
+		})
+		t.Run("unknown event type should 400", func(t *testing.T) {
+			by, err := json.Marshal(CreateWebhookRequest{URL: receiver.URL, EventTypes: []string{"transaction.deleted"}})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/webhooks", by, ownerToken))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+		t.Run("plain http url to another host should 400", func(t *testing.T) {
+			by, err := json.Marshal(CreateWebhookRequest{URL: "http://example.com/hooks", EventTypes: []string{"transaction.posted"}})
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/webhooks", by, ownerToken))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+	t.Run("GET /v1/webhooks/{webhookId}", func(t *testing.T) {
+		t.Run("own webhook should 200 without its secret", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/webhooks/"+webhook.ID, nil, ownerToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var got WebhookResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+			assert.Equal(t, webhook.ID, got.ID)
+			assert.Nil(t, got.Secret)
+		})
+		t.Run("another user's webhook should 403", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/webhooks/"+webhook.ID, nil, otherToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("listing should only show own webhooks", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/webhooks", nil, otherToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var got ListWebhooksResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+			assert.Empty(t, got.Webhooks)
+		})
+	})
+	t.Run("delivery", func(t *testing.T) {
+		t.Run("posting a transaction should push a signed event to the receiver", func(t *testing.T) {
+			tan := mustCreateTransaction(t, srv, ownerToken, acct.AccountNumber)
+			result := deliver(t, time.Now())
+			require.Len(t, result.Delivered, 1)
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.Len(t, received, 1)
+			assert.Equal(t, events.TransactionPosted.String(), received[0].Type)
+			var data events.TransactionData
+			require.NoError(t, json.Unmarshal(received[0].Data, &data))
+			assert.Equal(t, tan.ID, data.TransactionID)
+			assert.Equal(t, acct.AccountNumber, data.AccountNumber)
+		})
+		t.Run("failing receiver should be dead-lettered and listed with its attempts", func(t *testing.T) {
+			mu.Lock()
+			status = http.StatusInternalServerError
+			mu.Unlock()
+			mustCreateTransaction(t, srv, ownerToken, acct.AccountNumber)
+			now := time.Now()
+			require.Len(t, deliver(t, now).Failed, 1)
+			require.Len(t, deliver(t, now.Add(time.Minute)).DeadLettered, 1)
+
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/webhooks/"+webhook.ID+"/deliveries", nil, ownerToken))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var got ListWebhookDeliveriesResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+			require.Len(t, got.Deliveries, 2)
+			assert.Equal(t, "delivered", got.Deliveries[0].Status)
+			dead := got.Deliveries[1]
+			assert.Equal(t, "dead_lettered", dead.Status)
+			require.Len(t, dead.Attempts, 2)
+			require.NotNil(t, dead.Attempts[0].StatusCode)
+			assert.Equal(t, http.StatusInternalServerError, *dead.Attempts[0].StatusCode)
+			assert.NotNil(t, dead.Attempts[0].Error)
+			assert.Nil(t, dead.NextAttemptTimestamp)
+
+			t.Run("redeliver should 202 and deliver it", func(t *testing.T) {
+				mu.Lock()
+				status = http.StatusOK
+				mu.Unlock()
+				path := "/v1/webhooks/" + webhook.ID + "/deliveries/" + dead.ID + "/redeliver"
+				rr := httptest.NewRecorder()
+				srv.ServeHTTP(rr, authedRequest(http.MethodPost, path, nil, ownerToken))
+				require.Equal(t, http.StatusAccepted, rr.Code)
+				var queued WebhookDeliveryResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&queued))
+				assert.Equal(t, "pending", queued.Status)
+				assert.NotNil(t, queued.NextAttemptTimestamp)
+
+				rr = httptest.NewRecorder()
+				srv.ServeHTTP(rr, authedRequest(http.MethodPost, path, nil, ownerToken))
+				assert.Equal(t, http.StatusConflict, rr.Code)
+
+				rr = httptest.NewRecorder()
+				srv.ServeHTTP(rr, authedRequest(http.MethodPost, path, nil, otherToken))
+				assert.Equal(t, http.StatusForbidden, rr.Code)
+
+				require.Len(t, deliver(t, time.Now()).Delivered, 1)
+				rr = httptest.NewRecorder()
+				srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/webhooks/"+webhook.ID+"/deliveries/"+dead.ID, nil, ownerToken))
+				require.Equal(t, http.StatusOK, rr.Code)
+				var got WebhookDeliveryResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+				assert.Equal(t, "delivered", got.Status)
+				assert.Len(t, got.Attempts, 3)
+			})
+		})
+		t.Run("unknown delivery should 404", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodPost, "/v1/webhooks/"+webhook.ID+"/deliveries/dlv-missing/redeliver", nil, ownerToken))
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+	t.Run("DELETE /v1/webhooks/{webhookId}", func(t *testing.T) {
+		t.Run("another user's webhook should 403", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodDelete, "/v1/webhooks/"+webhook.ID, nil, otherToken))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+		})
+		t.Run("own webhook should 204 and stop deliveries", func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodDelete, "/v1/webhooks/"+webhook.ID, nil, ownerToken))
+			require.Equal(t, http.StatusNoContent, rr.Code)
+
+			mustCreateTransaction(t, srv, ownerToken, acct.AccountNumber)
+			result := deliver(t, time.Now())
+			assert.Empty(t, result.Delivered)
+
+			rr = httptest.NewRecorder()
+			srv.ServeHTTP(rr, authedRequest(http.MethodGet, "/v1/webhooks/"+webhook.ID, nil, ownerToken))
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package adapters
+
+import (
+	"eaglebank/internal/users"
+	"eaglebank/internal/webauthn"
+	"sync"
+)
+
+type InMemoryCredentialStore struct {
+	mu            sync.RWMutex
+	credsByID     map[webauthn.CredentialID]webauthn.Credential
+	credsByUserID map[users.UserID][]webauthn.CredentialID
+}
+
+func NewInMemoryCredentialStore() *InMemoryCredentialStore {
+	return &InMemoryCredentialStore{
+		credsByID:     make(map[webauthn.CredentialID]webauthn.Credential),
+		credsByUserID: make(map[users.UserID][]webauthn.CredentialID),
+	}
+}
+
+func (s *InMemoryCredentialStore) Get(id webauthn.CredentialID) (webauthn.Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cred, ok := s.credsByID[id]
+	if !ok {
+		return webauthn.Credential{}, webauthn.ErrCredentialNotFound
+	}
+	return cred, nil
+}
+
+func (s *InMemoryCredentialStore) GetByUserID(userID users.UserID) ([]webauthn.Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids, ok := s.credsByUserID[userID]
+	if !ok {
+		return nil, webauthn.ErrCredentialNotFound
+	}
+	result := make([]webauthn.Credential, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, s.credsByID[id])
+	}
+	return result, nil
+}
+
+func (s *InMemoryCredentialStore) Put(cred webauthn.Credential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.credsByID[cred.ID]
+	s.credsByID[cred.ID] = cred
+	if !exists {
+		s.credsByUserID[cred.UserID] = append(s.credsByUserID[cred.UserID], cred.ID)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package adapters
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"eaglebank/internal/webauthn"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryCredentialStore(t *testing.T) {
+	store := NewInMemoryCredentialStore()
+
+	t.Run("should error not found getting credential which does not exist", func(t *testing.T) {
+		_, err := store.Get("missing")
+		assert.ErrorIs(t, err, webauthn.ErrCredentialNotFound)
+	})
+	t.Run("should error not found getting credentials for user without any", func(t *testing.T) {
+		_, err := store.GetByUserID("usr-missing")
+		assert.ErrorIs(t, err, webauthn.ErrCredentialNotFound)
+	})
+	t.Run("should perform put-get-update cycle without errors", func(t *testing.T) {
+		cred1 := newTestCredential(t)
+		cred2 := newTestCredential(t)
+		t.Run("should store credentials", func(t *testing.T) {
+			require.NoError(t, store.Put(cred1))
+			require.NoError(t, store.Put(cred2))
+		})
+		t.Run("should get credential by ID", func(t *testing.T) {
+			gotCred, err := store.Get(cred1.ID)
+			require.NoError(t, err)
+			assert.Equal(t, cred1, gotCred)
+		})
+		t.Run("should get both credentials by userID", func(t *testing.T) {
+			gotCreds, err := store.GetByUserID(cred1.UserID)
+			require.NoError(t, err)
+			assert.Len(t, gotCreds, 2)
+		})
+		t.Run("should update existing credential", func(t *testing.T) {
+			updated := cred1
+			updated.SignCount = 10
+			require.NoError(t, store.Put(updated))
+
+			gotCred, err := store.Get(cred1.ID)
+			require.NoError(t, err)
+			assert.Equal(t, updated, gotCred)
+
+			gotCreds, err := store.GetByUserID(cred1.UserID)
+			require.NoError(t, err)
+			assert.Len(t, gotCreds, 2)
+			assert.Contains(t, gotCreds, updated)
+		})
+	})
+}
+
+func newTestCredential(t *testing.T) webauthn.Credential {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rawID := make([]byte, 16)
+	_, err = rand.Read(rawID)
+	require.NoError(t, err)
+	id, err := webauthn.NewCredentialID(rawID)
+	require.NoError(t, err)
+	now := time.Now()
+
+	return webauthn.Credential{
+		ID:               id,
+		UserID:           "usr-123",
+		PublicKey:        key.PublicKey,
+		CreatedTimestamp: now,
+		LastUsed:         now,
+	}
+}
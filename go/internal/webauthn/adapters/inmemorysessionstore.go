@@ -0,0 +1,36 @@
+package adapters
+
+import (
+	"eaglebank/internal/webauthn"
+	"sync"
+)
+
+type InMemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[webauthn.Challenge]webauthn.Session
+}
+
+func NewInMemorySessionStore() *InMemorySessionStore {
+	return &InMemorySessionStore{sessions: make(map[webauthn.Challenge]webauthn.Session)}
+}
+
+func (s *InMemorySessionStore) Put(sess webauthn.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sess.Challenge] = sess
+	return nil
+}
+
+// Take returns the session for the challenge and removes it so each challenge can only be used once.
+func (s *InMemorySessionStore) Take(challenge webauthn.Challenge) (webauthn.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[challenge]
+	if !ok {
+		return webauthn.Session{}, webauthn.ErrSessionNotFound
+	}
+	delete(s.sessions, challenge)
+	return sess, nil
+}
@@ -0,0 +1,35 @@
+package adapters
+
+import (
+	"eaglebank/internal/webauthn"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemorySessionStore(t *testing.T) {
+	store := NewInMemorySessionStore()
+
+	t.Run("should error not found taking session which does not exist", func(t *testing.T) {
+		_, err := store.Take("missing")
+		assert.ErrorIs(t, err, webauthn.ErrSessionNotFound)
+	})
+	t.Run("should only allow a session to be taken once", func(t *testing.T) {
+		sess := webauthn.Session{
+			Challenge: "challenge",
+			UserID:    "usr-123",
+			Ceremony:  webauthn.LoginCeremony,
+			Expires:   time.Now().Add(time.Minute),
+		}
+		require.NoError(t, store.Put(sess))
+
+		gotSess, err := store.Take(sess.Challenge)
+		require.NoError(t, err)
+		assert.Equal(t, sess, gotSess)
+
+		_, err = store.Take(sess.Challenge)
+		assert.ErrorIs(t, err, webauthn.ErrSessionNotFound)
+	})
+}
@@ -0,0 +1,110 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// decodeCBOR decodes a single CBOR data item from the start of b and returns it along with the
+// number of bytes consumed. Only the subset of CBOR used by WebAuthn attestation objects and COSE
+// keys is supported: integers, byte & text strings, arrays, maps and simple values.
+func decodeCBOR(b []byte) (any, int, error) {
+	if len(b) == 0 {
+		return nil, 0, errors.New("cbor: unexpected end of input")
+	}
+	major := b[0] >> 5
+	arg, n, err := decodeCBORArg(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch major {
+	case 0:
+		return int64(arg), n, nil
+	case 1:
+		return -1 - int64(arg), n, nil
+	case 2, 3:
+		end := n + int(arg)
+		if arg > uint64(len(b)) || end > len(b) {
+			return nil, 0, errors.New("cbor: string exceeds input")
+		}
+		if major == 2 {
+			return append([]byte{}, b[n:end]...), end, nil
+		}
+		return string(b[n:end]), end, nil
+	case 4:
+		arr := make([]any, 0, min(arg, uint64(len(b))))
+		for i := uint64(0); i < arg; i++ {
+			item, m, err := decodeCBOR(b[n:])
+			if err != nil {
+				return nil, 0, err
+			}
+			arr = append(arr, item)
+			n += m
+		}
+		return arr, n, nil
+	case 5:
+		mp := make(map[any]any)
+		for i := uint64(0); i < arg; i++ {
+			key, m, err := decodeCBOR(b[n:])
+			if err != nil {
+				return nil, 0, err
+			}
+			n += m
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			val, m, err := decodeCBOR(b[n:])
+			if err != nil {
+				return nil, 0, err
+			}
+			n += m
+			mp[key] = val
+		}
+		return mp, n, nil
+	case 7:
+		switch arg {
+		case 20:
+			return false, n, nil
+		case 21:
+			return true, n, nil
+		case 22, 23:
+			return nil, n, nil
+		}
+		return nil, 0, fmt.Errorf("cbor: unsupported simple value %d", arg)
+	default:
+		return nil, 0, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+func decodeCBORArg(b []byte) (uint64, int, error) {
+	info := b[0] & 0x1f
+	switch {
+	case info < 24:
+		return uint64(info), 1, nil
+	case info == 24:
+		if len(b) < 2 {
+			return 0, 0, errors.New("cbor: unexpected end of input")
+		}
+		return uint64(b[1]), 2, nil
+	case info == 25:
+		if len(b) < 3 {
+			return 0, 0, errors.New("cbor: unexpected end of input")
+		}
+		return uint64(binary.BigEndian.Uint16(b[1:3])), 3, nil
+	case info == 26:
+		if len(b) < 5 {
+			return 0, 0, errors.New("cbor: unexpected end of input")
+		}
+		return uint64(binary.BigEndian.Uint32(b[1:5])), 5, nil
+	case info == 27:
+		if len(b) < 9 {
+			return 0, 0, errors.New("cbor: unexpected end of input")
+		}
+		return binary.BigEndian.Uint64(b[1:9]), 9, nil
+	default:
+		return 0, 0, fmt.Errorf("cbor: unsupported additional info %d", info)
+	}
+}
@@ -0,0 +1,59 @@
+package webauthn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	t.Run("should decode supported items", func(t *testing.T) {
+		cases := []struct {
+			name string
+			in   []byte
+			want any
+		}{
+			{"small uint", []byte{0x17}, int64(23)},
+			{"one byte uint", []byte{0x18, 0xff}, int64(255)},
+			{"two byte uint", []byte{0x19, 0x01, 0x00}, int64(256)},
+			{"negative int", []byte{0x26}, int64(-7)},
+			{"byte string", []byte{0x42, 0x01, 0x02}, []byte{0x01, 0x02}},
+			{"text string", []byte{0x63, 'f', 'm', 't'}, "fmt"},
+			{"array", []byte{0x82, 0x01, 0x20}, []any{int64(1), int64(-1)}},
+			{"map", []byte{0xa2, 0x01, 0x02, 0x61, 'a', 0xf5}, map[any]any{int64(1): int64(2), "a": true}},
+			{"null", []byte{0xf6}, nil},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				got, n, err := decodeCBOR(c.in)
+				require.NoError(t, err)
+				assert.Equal(t, c.want, got)
+				assert.Equal(t, len(c.in), n)
+			})
+		}
+	})
+	t.Run("should report bytes consumed for trailing data", func(t *testing.T) {
+		_, n, err := decodeCBOR([]byte{0x01, 0xff, 0xff})
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+	t.Run("should error on malformed input", func(t *testing.T) {
+		cases := map[string][]byte{
+			"empty":              {},
+			"truncated uint":     {0x19, 0x01},
+			"truncated string":   {0x45, 0x01},
+			"truncated map":      {0xa1, 0x01},
+			"indefinite length":  {0x5f},
+			"unsupported tag":    {0xc0, 0x01},
+			"unsupported key":    {0xa1, 0x40, 0x01},
+			"huge string length": {0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		}
+		for name, in := range cases {
+			t.Run(name, func(t *testing.T) {
+				_, _, err := decodeCBOR(in)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,7 @@
+package webauthn
+
+import "errors"
+
+var ErrCredentialNotFound = errors.New("credential not found")
+var ErrSessionNotFound = errors.New("webauthn session not found or expired")
+var ErrVerificationFailed = errors.New("webauthn verification failed")
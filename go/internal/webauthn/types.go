@@ -0,0 +1,131 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"eaglebank/internal/users"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+type CredentialID string
+
+func (id CredentialID) String() string {
+	return string(id)
+}
+
+func (id CredentialID) IsValid() bool {
+	raw, err := base64.RawURLEncoding.DecodeString(id.String())
+	return err == nil && len(raw) > 0
+}
+
+func NewCredentialID(raw []byte) (CredentialID, error) {
+	id := CredentialID(base64.RawURLEncoding.EncodeToString(raw))
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid credential ID %q", id)
+	}
+	return id, nil
+}
+
+type Credential struct {
+	ID               CredentialID
+	UserID           users.UserID
+	PublicKey        ecdsa.PublicKey
+	SignCount        uint32
+	CreatedTimestamp time.Time
+	LastUsed         time.Time
+}
+
+func (c Credential) IsValid() bool {
+	if !c.ID.IsValid() {
+		return false
+	}
+	if !c.UserID.IsValid() {
+		return false
+	}
+	if c.PublicKey.Curve == nil || c.PublicKey.X == nil || c.PublicKey.Y == nil {
+		return false
+	}
+	return true
+}
+
+type Challenge string
+
+func (c Challenge) String() string {
+	return string(c)
+}
+
+func NewRandChallenge() (Challenge, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating challenge %w", err)
+	}
+	return Challenge(base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+type Ceremony string
+
+const RegistrationCeremony Ceremony = "webauthn.create"
+const LoginCeremony Ceremony = "webauthn.get"
+
+type Session struct {
+	Challenge Challenge
+	UserID    users.UserID
+	Ceremony  Ceremony
+	Expires   time.Time
+}
+
+func (s Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.Expires)
+}
+
+type RelyingParty struct {
+	ID   string
+	Name string
+}
+
+type CredentialDescriptor struct {
+	Type string
+	ID   CredentialID
+}
+
+type CredentialParameter struct {
+	Type      string
+	Algorithm int
+}
+
+type CreationOptions struct {
+	Challenge        Challenge
+	RelyingParty     RelyingParty
+	UserID           users.UserID
+	PubKeyCredParams []CredentialParameter
+	ExcludeCreds     []CredentialDescriptor
+	Timeout          time.Duration
+}
+
+type RequestOptions struct {
+	Challenge    Challenge
+	RelyingParty RelyingParty
+	AllowCreds   []CredentialDescriptor
+	Timeout      time.Duration
+}
+
+type RegistrationResponse struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+type AssertionResponse struct {
+	CredentialID      CredentialID
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
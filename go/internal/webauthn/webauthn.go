@@ -0,0 +1,369 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"eaglebank/internal/users"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const publicKeyType = "public-key"
+const algES256 = -7
+
+const flagUserPresent byte = 0x01
+const flagAttestedCredentialData byte = 0x40
+
+type CredentialStore interface {
+	Get(id CredentialID) (Credential, error)
+	GetByUserID(userID users.UserID) ([]Credential, error)
+	Put(cred Credential) error
+}
+
+type SessionStore interface {
+	Put(sess Session) error
+	Take(challenge Challenge) (Session, error)
+}
+
+type Config struct {
+	RPID         string
+	RPName       string
+	Origin       string
+	ChallengeTTL time.Duration
+}
+
+type WebAuthnService struct {
+	cfg       Config
+	credStore CredentialStore
+	sessStore SessionStore
+	rpIDHash  [32]byte
+	rp        RelyingParty
+}
+
+func NewWebAuthnService(cfg Config, credStore CredentialStore, sessStore SessionStore) *WebAuthnService {
+	return &WebAuthnService{
+		cfg:       cfg,
+		credStore: credStore,
+		sessStore: sessStore,
+		rpIDHash:  sha256.Sum256([]byte(cfg.RPID)),
+		rp:        RelyingParty{ID: cfg.RPID, Name: cfg.RPName},
+	}
+}
+
+func (svc *WebAuthnService) BeginRegistration(userID users.UserID) (CreationOptions, error) {
+	if !userID.IsValid() {
+		return CreationOptions{}, fmt.Errorf("invalid user ID %q", userID)
+	}
+	existing, err := svc.listCredentials(userID)
+	if err != nil {
+		return CreationOptions{}, err
+	}
+	sess, err := svc.newSession(userID, RegistrationCeremony)
+	if err != nil {
+		return CreationOptions{}, err
+	}
+	return CreationOptions{
+		Challenge:        sess.Challenge,
+		RelyingParty:     svc.rp,
+		UserID:           userID,
+		PubKeyCredParams: []CredentialParameter{{Type: publicKeyType, Algorithm: algES256}},
+		ExcludeCreds:     descriptors(existing),
+		Timeout:          svc.cfg.ChallengeTTL,
+	}, nil
+}
+
+func (svc *WebAuthnService) FinishRegistration(userID users.UserID, resp RegistrationResponse) (Credential, error) {
+	cd, err := svc.verifyClientData(resp.ClientDataJSON, RegistrationCeremony)
+	if err != nil {
+		return Credential{}, err
+	}
+	sess, err := svc.takeSession(cd, RegistrationCeremony)
+	if err != nil {
+		return Credential{}, err
+	}
+	if sess.UserID != userID {
+		return Credential{}, fmt.Errorf("%w: session belongs to another user", ErrVerificationFailed)
+	}
+
+	attObj, err := parseAttestationObject(resp.AttestationObject)
+	if err != nil {
+		return Credential{}, err
+	}
+	authData, err := svc.verifyAuthenticatorData(attObj.authData, true)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	credID, err := NewCredentialID(authData.credentialID)
+	if err != nil {
+		return Credential{}, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+	_, err = svc.credStore.Get(credID)
+	if err == nil {
+		return Credential{}, fmt.Errorf("%w: credential already registered", ErrVerificationFailed)
+	}
+	if !errors.Is(err, ErrCredentialNotFound) {
+		return Credential{}, fmt.Errorf("error fetching credential %w", err)
+	}
+
+	now := time.Now()
+	cred := Credential{
+		ID:               credID,
+		UserID:           userID,
+		PublicKey:        *authData.publicKey,
+		SignCount:        authData.signCount,
+		CreatedTimestamp: now,
+		LastUsed:         now,
+	}
+	if !cred.IsValid() {
+		return Credential{}, fmt.Errorf("%w: invalid credential", ErrVerificationFailed)
+	}
+	err = svc.credStore.Put(cred)
+	if err != nil {
+		return Credential{}, fmt.Errorf("error storing credential %w", err)
+	}
+	return cred, nil
+}
+
+func (svc *WebAuthnService) BeginLogin(userID users.UserID) (RequestOptions, error) {
+	if !userID.IsValid() {
+		return RequestOptions{}, fmt.Errorf("invalid user ID %q", userID)
+	}
+	creds, err := svc.listCredentials(userID)
+	if err != nil {
+		return RequestOptions{}, err
+	}
+	if len(creds) == 0 {
+		return RequestOptions{}, ErrCredentialNotFound
+	}
+	sess, err := svc.newSession(userID, LoginCeremony)
+	if err != nil {
+		return RequestOptions{}, err
+	}
+	return RequestOptions{
+		Challenge:    sess.Challenge,
+		RelyingParty: svc.rp,
+		AllowCreds:   descriptors(creds),
+		Timeout:      svc.cfg.ChallengeTTL,
+	}, nil
+}
+
+func (svc *WebAuthnService) FinishLogin(resp AssertionResponse) (users.UserID, error) {
+	cd, err := svc.verifyClientData(resp.ClientDataJSON, LoginCeremony)
+	if err != nil {
+		return "", err
+	}
+	sess, err := svc.takeSession(cd, LoginCeremony)
+	if err != nil {
+		return "", err
+	}
+
+	cred, err := svc.credStore.Get(resp.CredentialID)
+	if err != nil {
+		if errors.Is(err, ErrCredentialNotFound) {
+			return "", fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+		}
+		return "", fmt.Errorf("error fetching credential %w", err)
+	}
+	if cred.UserID != sess.UserID {
+		return "", fmt.Errorf("%w: credential not allowed for session", ErrVerificationFailed)
+	}
+
+	authData, err := svc.verifyAuthenticatorData(resp.AuthenticatorData, false)
+	if err != nil {
+		return "", err
+	}
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	signed := sha256.Sum256(append(append([]byte{}, resp.AuthenticatorData...), clientDataHash[:]...))
+	if !ecdsa.VerifyASN1(&cred.PublicKey, signed[:], resp.Signature) {
+		return "", fmt.Errorf("%w: invalid signature", ErrVerificationFailed)
+	}
+	if (authData.signCount != 0 || cred.SignCount != 0) && authData.signCount <= cred.SignCount {
+		return "", fmt.Errorf("%w: signature counter did not increase", ErrVerificationFailed)
+	}
+
+	cred.SignCount = authData.signCount
+	cred.LastUsed = time.Now()
+	err = svc.credStore.Put(cred)
+	if err != nil {
+		return "", fmt.Errorf("error updating credential %w", err)
+	}
+	return cred.UserID, nil
+}
+
+func (svc *WebAuthnService) listCredentials(userID users.UserID) ([]Credential, error) {
+	creds, err := svc.credStore.GetByUserID(userID)
+	if err != nil {
+		if errors.Is(err, ErrCredentialNotFound) {
+			return []Credential{}, nil
+		}
+		return nil, fmt.Errorf("error listing credentials %w", err)
+	}
+	return creds, nil
+}
+
+func (svc *WebAuthnService) newSession(userID users.UserID, ceremony Ceremony) (Session, error) {
+	challenge, err := NewRandChallenge()
+	if err != nil {
+		return Session{}, err
+	}
+	sess := Session{
+		Challenge: challenge,
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Expires:   time.Now().Add(svc.cfg.ChallengeTTL),
+	}
+	err = svc.sessStore.Put(sess)
+	if err != nil {
+		return Session{}, fmt.Errorf("error storing webauthn session %w", err)
+	}
+	return sess, nil
+}
+
+func (svc *WebAuthnService) takeSession(cd clientData, ceremony Ceremony) (Session, error) {
+	sess, err := svc.sessStore.Take(Challenge(cd.Challenge))
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return Session{}, err
+		}
+		return Session{}, fmt.Errorf("error fetching webauthn session %w", err)
+	}
+	if sess.IsExpired(time.Now()) || sess.Ceremony != ceremony {
+		return Session{}, ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+func (svc *WebAuthnService) verifyClientData(raw []byte, ceremony Ceremony) (clientData, error) {
+	var cd clientData
+	err := json.Unmarshal(raw, &cd)
+	if err != nil {
+		return clientData{}, fmt.Errorf("%w: malformed client data: %w", ErrVerificationFailed, err)
+	}
+	if cd.Type != string(ceremony) {
+		return clientData{}, fmt.Errorf("%w: unexpected client data type %q", ErrVerificationFailed, cd.Type)
+	}
+	if cd.Origin != svc.cfg.Origin {
+		return clientData{}, fmt.Errorf("%w: unexpected origin %q", ErrVerificationFailed, cd.Origin)
+	}
+	return cd, nil
+}
+
+type attestationObject struct {
+	format   string
+	authData []byte
+}
+
+func parseAttestationObject(raw []byte) (attestationObject, error) {
+	item, _, err := decodeCBOR(raw)
+	if err != nil {
+		return attestationObject{}, fmt.Errorf("%w: malformed attestation object: %w", ErrVerificationFailed, err)
+	}
+	mp, ok := item.(map[any]any)
+	if !ok {
+		return attestationObject{}, fmt.Errorf("%w: attestation object is not a map", ErrVerificationFailed)
+	}
+	format, _ := mp["fmt"].(string)
+	authData, _ := mp["authData"].([]byte)
+	if format != "none" {
+		return attestationObject{}, fmt.Errorf("%w: unsupported attestation format %q", ErrVerificationFailed, format)
+	}
+	if len(authData) == 0 {
+		return attestationObject{}, fmt.Errorf("%w: missing authenticator data", ErrVerificationFailed)
+	}
+	return attestationObject{format: format, authData: authData}, nil
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    *ecdsa.PublicKey
+}
+
+func (svc *WebAuthnService) verifyAuthenticatorData(raw []byte, attested bool) (authenticatorData, error) {
+	if len(raw) < 37 {
+		return authenticatorData{}, fmt.Errorf("%w: authenticator data too short", ErrVerificationFailed)
+	}
+	if !bytes.Equal(raw[:32], svc.rpIDHash[:]) {
+		return authenticatorData{}, fmt.Errorf("%w: relying party ID mismatch", ErrVerificationFailed)
+	}
+	ad := authenticatorData{
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return authenticatorData{}, fmt.Errorf("%w: user not present", ErrVerificationFailed)
+	}
+	if !attested {
+		return ad, nil
+	}
+
+	if ad.flags&flagAttestedCredentialData == 0 {
+		return authenticatorData{}, fmt.Errorf("%w: missing attested credential data", ErrVerificationFailed)
+	}
+	rest := raw[37:]
+	// 16 byte AAGUID followed by a 2 byte credential ID length
+	if len(rest) < 18 {
+		return authenticatorData{}, fmt.Errorf("%w: attested credential data too short", ErrVerificationFailed)
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return authenticatorData{}, fmt.Errorf("%w: credential ID exceeds authenticator data", ErrVerificationFailed)
+	}
+	ad.credentialID = rest[:idLen]
+	pub, err := parseCOSEKey(rest[idLen:])
+	if err != nil {
+		return authenticatorData{}, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+	ad.publicKey = pub
+	return ad, nil
+}
+
+func parseCOSEKey(raw []byte) (*ecdsa.PublicKey, error) {
+	item, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed credential public key: %w", err)
+	}
+	mp, ok := item.(map[any]any)
+	if !ok {
+		return nil, errors.New("credential public key is not a map")
+	}
+	kty, _ := mp[int64(1)].(int64)
+	alg, _ := mp[int64(3)].(int64)
+	crv, _ := mp[int64(-1)].(int64)
+	x, _ := mp[int64(-2)].([]byte)
+	y, _ := mp[int64(-3)].([]byte)
+	// only EC2 keys on P-256 signing with ES256 are supported
+	if kty != 2 || alg != algES256 || crv != 1 {
+		return nil, fmt.Errorf("unsupported credential public key kty=%d alg=%d crv=%d", kty, alg, crv)
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("invalid credential public key coordinates")
+	}
+	_, err = ecdh.P256().NewPublicKey(append(append([]byte{0x04}, x...), y...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+func descriptors(creds []Credential) []CredentialDescriptor {
+	descs := make([]CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		descs = append(descs, CredentialDescriptor{Type: publicKeyType, ID: cred.ID})
+	}
+	return descs
+}
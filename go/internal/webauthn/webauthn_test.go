@@ -0,0 +1,183 @@
+package webauthn_test
+
+import (
+	"eaglebank/internal/users"
+	"eaglebank/internal/webauthn"
+	"eaglebank/internal/webauthn/adapters"
+	"eaglebank/internal/webauthn/webauthntest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = webauthn.Config{
+	RPID:         "localhost",
+	RPName:       "Eagle Bank",
+	Origin:       "http://localhost:8080",
+	ChallengeTTL: time.Minute,
+}
+
+func TestWebAuthnService(t *testing.T) {
+	credStore := adapters.NewInMemoryCredentialStore()
+	sessStore := adapters.NewInMemorySessionStore()
+	svc := webauthn.NewWebAuthnService(testConfig, credStore, sessStore)
+	auth := webauthntest.NewAuthenticator(testConfig.RPID, testConfig.Origin)
+	userID := users.MustNewUserID("usr-123")
+
+	var credID webauthn.CredentialID
+	t.Run("registration", func(t *testing.T) {
+		t.Run("should register credential from software authenticator", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			assert.Equal(t, testConfig.RPID, opts.RelyingParty.ID)
+			assert.Empty(t, opts.ExcludeCreds)
+
+			id, resp, err := auth.Register(opts.Challenge)
+			require.NoError(t, err)
+			cred, err := svc.FinishRegistration(userID, resp)
+			require.NoError(t, err)
+			assert.Equal(t, id, cred.ID)
+			assert.Equal(t, userID, cred.UserID)
+
+			gotCred, err := credStore.Get(id)
+			require.NoError(t, err)
+			assert.Equal(t, cred, gotCred)
+			credID = id
+		})
+		t.Run("should exclude existing credentials", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			require.Len(t, opts.ExcludeCreds, 1)
+			assert.Equal(t, credID, opts.ExcludeCreds[0].ID)
+		})
+		t.Run("should fail if challenge is reused", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			_, resp, err := auth.Register(opts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(userID, resp)
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(userID, resp)
+			assert.ErrorIs(t, err, webauthn.ErrSessionNotFound)
+		})
+		t.Run("should fail for unknown challenge", func(t *testing.T) {
+			_, resp, err := auth.Register("not-a-challenge")
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(userID, resp)
+			assert.ErrorIs(t, err, webauthn.ErrSessionNotFound)
+		})
+		t.Run("should fail for expired challenge", func(t *testing.T) {
+			err := sessStore.Put(webauthn.Session{
+				Challenge: "expired",
+				UserID:    userID,
+				Ceremony:  webauthn.RegistrationCeremony,
+				Expires:   time.Now().Add(-time.Second),
+			})
+			require.NoError(t, err)
+			_, resp, err := auth.Register("expired")
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(userID, resp)
+			assert.ErrorIs(t, err, webauthn.ErrSessionNotFound)
+		})
+		t.Run("should fail if challenge was issued to another user", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			_, resp, err := auth.Register(opts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(users.MustNewUserID("usr-other"), resp)
+			assert.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+		})
+		t.Run("should fail for wrong origin", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			evil := webauthntest.NewAuthenticator(testConfig.RPID, "https://evil.example")
+			_, resp, err := evil.Register(opts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(userID, resp)
+			assert.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+		})
+		t.Run("should fail for wrong relying party", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			evil := webauthntest.NewAuthenticator("evil.example", testConfig.Origin)
+			_, resp, err := evil.Register(opts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(userID, resp)
+			assert.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+		})
+		t.Run("should fail for malformed attestation object", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			_, resp, err := auth.Register(opts.Challenge)
+			require.NoError(t, err)
+			resp.AttestationObject = resp.AttestationObject[:len(resp.AttestationObject)/2]
+			_, err = svc.FinishRegistration(userID, resp)
+			assert.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+		})
+	})
+	t.Run("login", func(t *testing.T) {
+		t.Run("should authenticate with registered credential", func(t *testing.T) {
+			opts, err := svc.BeginLogin(userID)
+			require.NoError(t, err)
+			assert.NotEmpty(t, opts.AllowCreds)
+
+			resp, err := auth.Assert(credID, opts.Challenge)
+			require.NoError(t, err)
+			gotUserID, err := svc.FinishLogin(resp)
+			require.NoError(t, err)
+			assert.Equal(t, userID, gotUserID)
+		})
+		t.Run("should error beginning login for user without credentials", func(t *testing.T) {
+			_, err := svc.BeginLogin(users.MustNewUserID("usr-nocreds"))
+			assert.ErrorIs(t, err, webauthn.ErrCredentialNotFound)
+		})
+		t.Run("should fail for tampered signature", func(t *testing.T) {
+			opts, err := svc.BeginLogin(userID)
+			require.NoError(t, err)
+			resp, err := auth.Assert(credID, opts.Challenge)
+			require.NoError(t, err)
+			resp.Signature[len(resp.Signature)-1] ^= 0xff
+			_, err = svc.FinishLogin(resp)
+			assert.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+		})
+		t.Run("should fail for registration challenge", func(t *testing.T) {
+			opts, err := svc.BeginRegistration(userID)
+			require.NoError(t, err)
+			resp, err := auth.Assert(credID, opts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishLogin(resp)
+			assert.ErrorIs(t, err, webauthn.ErrSessionNotFound)
+		})
+		t.Run("should fail for another user's credential", func(t *testing.T) {
+			otherUserID := users.MustNewUserID("usr-other")
+			regOpts, err := svc.BeginRegistration(otherUserID)
+			require.NoError(t, err)
+			otherCredID, regResp, err := auth.Register(regOpts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishRegistration(otherUserID, regResp)
+			require.NoError(t, err)
+
+			opts, err := svc.BeginLogin(userID)
+			require.NoError(t, err)
+			resp, err := auth.Assert(otherCredID, opts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishLogin(resp)
+			assert.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+		})
+		t.Run("should fail if signature counter goes backwards", func(t *testing.T) {
+			cred, err := credStore.Get(credID)
+			require.NoError(t, err)
+			cred.SignCount = 1 << 30
+			require.NoError(t, credStore.Put(cred))
+
+			opts, err := svc.BeginLogin(userID)
+			require.NoError(t, err)
+			resp, err := auth.Assert(credID, opts.Challenge)
+			require.NoError(t, err)
+			_, err = svc.FinishLogin(resp)
+			assert.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+		})
+	})
+}
@@ -0,0 +1,166 @@
+// Package webauthntest provides a software authenticator for exercising WebAuthn ceremonies in
+// tests without a browser or hardware key.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"eaglebank/internal/webauthn"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+type Authenticator struct {
+	mu        sync.Mutex
+	rpID      string
+	origin    string
+	keys      map[webauthn.CredentialID]*ecdsa.PrivateKey
+	signCount uint32
+}
+
+func NewAuthenticator(rpID, origin string) *Authenticator {
+	return &Authenticator{
+		rpID:   rpID,
+		origin: origin,
+		keys:   make(map[webauthn.CredentialID]*ecdsa.PrivateKey),
+	}
+}
+
+// Register creates a new credential for the challenge, returning its ID and a "none" attestation.
+func (a *Authenticator) Register(challenge webauthn.Challenge) (webauthn.CredentialID, webauthn.RegistrationResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", webauthn.RegistrationResponse{}, err
+	}
+	rawID := make([]byte, 16)
+	_, err = rand.Read(rawID)
+	if err != nil {
+		return "", webauthn.RegistrationResponse{}, err
+	}
+	credID, err := webauthn.NewCredentialID(rawID)
+	if err != nil {
+		return "", webauthn.RegistrationResponse{}, err
+	}
+	a.keys[credID] = key
+
+	pubKey, err := key.PublicKey.ECDH()
+	if err != nil {
+		return "", webauthn.RegistrationResponse{}, err
+	}
+	point := pubKey.Bytes()
+	coseKey := cborMap(
+		cborInt(1), cborInt(2),
+		cborInt(3), cborInt(-7),
+		cborInt(-1), cborInt(1),
+		cborInt(-2), cborBytes(point[1:33]),
+		cborInt(-3), cborBytes(point[33:65]),
+	)
+
+	a.signCount++
+	authData := a.authData(0x41)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(rawID)))
+	authData = append(authData, rawID...)
+	authData = append(authData, coseKey...)
+
+	attObj := cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(authData),
+	)
+	clientDataJSON, err := a.clientData(webauthn.RegistrationCeremony, challenge)
+	if err != nil {
+		return "", webauthn.RegistrationResponse{}, err
+	}
+	return credID, webauthn.RegistrationResponse{
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: attObj,
+	}, nil
+}
+
+// Assert signs the challenge with the private key for a credential previously created by Register.
+func (a *Authenticator) Assert(credID webauthn.CredentialID, challenge webauthn.Challenge) (webauthn.AssertionResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key, ok := a.keys[credID]
+	if !ok {
+		return webauthn.AssertionResponse{}, fmt.Errorf("unknown credential %q", credID)
+	}
+	clientDataJSON, err := a.clientData(webauthn.LoginCeremony, challenge)
+	if err != nil {
+		return webauthn.AssertionResponse{}, err
+	}
+	a.signCount++
+	authData := a.authData(0x01)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return webauthn.AssertionResponse{}, err
+	}
+	return webauthn.AssertionResponse{
+		CredentialID:      credID,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         sig,
+	}, nil
+}
+
+func (a *Authenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	b := append([]byte{}, rpIDHash[:]...)
+	b = append(b, flags)
+	return binary.BigEndian.AppendUint32(b, a.signCount)
+}
+
+func (a *Authenticator) clientData(ceremony webauthn.Ceremony, challenge webauthn.Challenge) ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"type":      string(ceremony),
+		"challenge": challenge.String(),
+		"origin":    a.origin,
+	})
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+}
+
+func cborInt(i int64) []byte {
+	if i < 0 {
+		return cborHead(1, uint64(-1-i))
+	}
+	return cborHead(0, uint64(i))
+}
+
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, uint64(len(b))), b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, uint64(len(s))), s...)
+}
+
+func cborMap(kvs ...[]byte) []byte {
+	b := cborHead(5, uint64(len(kvs)/2))
+	for _, kv := range kvs {
+		b = append(b, kv...)
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package adapters
+
+import (
+	"eaglebank/internal/events"
+	"eaglebank/internal/webhooks"
+	"slices"
+	"sync"
+)
+
+type deliveryKey struct {
+	subID   webhooks.SubscriptionID
+	eventID events.EventID
+}
+
+type InMemoryDeliveryStore struct {
+	mu    sync.RWMutex
+	dlvs  map[webhooks.DeliveryID]webhooks.Delivery
+	order []webhooks.DeliveryID
+	// pending holds the pending deliveries in the order they were queued, a redelivery counting
+	// from when it went back to pending.
+	pending []webhooks.DeliveryID
+	// queued holds each event queued for each subscription, so none is queued twice.
+	queued map[deliveryKey]bool
+}
+
+func NewInMemoryDeliveryStore() *InMemoryDeliveryStore {
+	return &InMemoryDeliveryStore{
+		dlvs:   make(map[webhooks.DeliveryID]webhooks.Delivery),
+		queued: make(map[deliveryKey]bool),
+	}
+}
+
+func (s *InMemoryDeliveryStore) Get(id webhooks.DeliveryID) (webhooks.Delivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dlv, ok := s.dlvs[id]
+	if !ok {
+		return webhooks.Delivery{}, webhooks.ErrDeliveryNotFound
+	}
+	return dlv, nil
+}
+
+func (s *InMemoryDeliveryStore) GetBySubscriptionID(id webhooks.SubscriptionID) ([]webhooks.Delivery, error) {
+	return s.filter(func(dlv webhooks.Delivery) bool { return dlv.SubscriptionID == id }), nil
+}
+
+func (s *InMemoryDeliveryStore) GetPending() ([]webhooks.Delivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []webhooks.Delivery
+	for _, id := range s.pending {
+		result = append(result, s.dlvs[id])
+	}
+	return result, nil
+}
+
+func (s *InMemoryDeliveryStore) Create(dlv webhooks.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := deliveryKey{subID: dlv.SubscriptionID, eventID: dlv.Event.ID}
+	if s.queued[key] {
+		return webhooks.ErrDeliveryExists
+	}
+	s.queued[key] = true
+	s.dlvs[dlv.ID] = dlv
+	s.order = append(s.order, dlv.ID)
+	if dlv.Status == webhooks.DeliveryPending {
+		s.pending = append(s.pending, dlv.ID)
+	}
+	return nil
+}
+
+func (s *InMemoryDeliveryStore) Put(dlv webhooks.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.dlvs[dlv.ID]
+	if !ok {
+		return webhooks.ErrDeliveryNotFound
+	}
+	wasPending, isPending := prev.Status == webhooks.DeliveryPending, dlv.Status == webhooks.DeliveryPending
+	if wasPending && !isPending {
+		s.pending = slices.DeleteFunc(s.pending, func(id webhooks.DeliveryID) bool { return id == dlv.ID })
+	}
+	if !wasPending && isPending {
+		s.pending = append(s.pending, dlv.ID)
+	}
+	s.dlvs[dlv.ID] = dlv
+	return nil
+}
+
+func (s *InMemoryDeliveryStore) filter(keep func(webhooks.Delivery) bool) []webhooks.Delivery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []webhooks.Delivery
+	for _, id := range s.order {
+		if dlv := s.dlvs[id]; keep(dlv) {
+			result = append(result, dlv)
+		}
+	}
+	return result
+}
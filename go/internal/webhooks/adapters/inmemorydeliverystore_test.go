@@ -0,0 +1,70 @@
+package adapters
+
+import (
+	"eaglebank/internal/events"
+	"eaglebank/internal/webhooks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryDeliveryStore(t *testing.T) {
+	store := NewInMemoryDeliveryStore()
+	sub, err := webhooks.NewSubscription("whk-1", "usr-owner", "https://example.com/hooks", []events.EventType{events.TransactionPosted}, "whsec_secret")
+	require.NoError(t, err)
+	evt, err := events.NewEvent(events.TransactionPosted, "01000004", []string{"usr-owner"}, events.TransactionData{TransactionID: "tan-1"})
+	require.NoError(t, err)
+
+	t.Run("should error not found getting delivery which does not exist", func(t *testing.T) {
+		_, err := store.Get("dlv-missing")
+		assert.ErrorIs(t, err, webhooks.ErrDeliveryNotFound)
+	})
+	t.Run("should error not found putting delivery which was never created", func(t *testing.T) {
+		assert.ErrorIs(t, store.Put(webhooks.NewDelivery("dlv-missing", sub, evt)), webhooks.ErrDeliveryNotFound)
+	})
+	t.Run("should perform create-get-update cycle without errors", func(t *testing.T) {
+		dlv := webhooks.NewDelivery("dlv-1", sub, evt)
+		require.NoError(t, store.Create(dlv))
+
+		t.Run("should not queue the same event for the subscription twice", func(t *testing.T) {
+			assert.ErrorIs(t, store.Create(webhooks.NewDelivery("dlv-2", sub, evt)), webhooks.ErrDeliveryExists)
+		})
+		t.Run("should get an existing delivery", func(t *testing.T) {
+			got, err := store.Get(dlv.ID)
+			require.NoError(t, err)
+			assert.Equal(t, dlv, got)
+
+			got2, err := store.GetBySubscriptionID(sub.ID)
+			require.NoError(t, err)
+			assert.Equal(t, []webhooks.Delivery{dlv}, got2)
+		})
+		t.Run("should list only pending deliveries as pending", func(t *testing.T) {
+			got, err := store.GetPending()
+			require.NoError(t, err)
+			assert.Equal(t, []webhooks.Delivery{dlv}, got)
+
+			dlv.Status = webhooks.DeliveryDelivered
+			require.NoError(t, store.Put(dlv))
+			got, err = store.GetPending()
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+		t.Run("should list a delivery queued again behind those already pending", func(t *testing.T) {
+			later, err := events.NewEvent(events.TransactionPosted, "01000004", []string{"usr-owner"}, events.TransactionData{TransactionID: "tan-2"})
+			require.NoError(t, err)
+			pending := webhooks.NewDelivery("dlv-3", sub, later)
+			require.NoError(t, store.Create(pending))
+
+			dlv.Status = webhooks.DeliveryPending
+			require.NoError(t, store.Put(dlv))
+			got, err := store.GetPending()
+			require.NoError(t, err)
+			assert.Equal(t, []webhooks.Delivery{pending, dlv}, got)
+
+			got, err = store.GetBySubscriptionID(sub.ID)
+			require.NoError(t, err)
+			assert.Equal(t, []webhooks.Delivery{dlv, pending}, got)
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package adapters
+
+import (
+	"eaglebank/internal/users"
+	"eaglebank/internal/webhooks"
+	"slices"
+	"sync"
+)
+
+type InMemorySubscriptionStore struct {
+	mu    sync.RWMutex
+	subs  map[webhooks.SubscriptionID]webhooks.Subscription
+	order []webhooks.SubscriptionID
+}
+
+func NewInMemorySubscriptionStore() *InMemorySubscriptionStore {
+	return &InMemorySubscriptionStore{subs: make(map[webhooks.SubscriptionID]webhooks.Subscription)}
+}
+
+func (s *InMemorySubscriptionStore) Get(id webhooks.SubscriptionID) (webhooks.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subs[id]
+	if !ok {
+		return webhooks.Subscription{}, webhooks.ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *InMemorySubscriptionStore) GetByUserID(userID users.UserID) ([]webhooks.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []webhooks.Subscription
+	for _, id := range s.order {
+		if sub := s.subs[id]; sub.UserID == userID {
+			result = append(result, sub)
+		}
+	}
+	return result, nil
+}
+
+func (s *InMemorySubscriptionStore) Put(sub webhooks.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[sub.ID]; !ok {
+		s.order = append(s.order, sub.ID)
+	}
+	s.subs[sub.ID] = sub
+	return nil
+}
+
+func (s *InMemorySubscriptionStore) Delete(id webhooks.SubscriptionID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[id]; !ok {
+		return webhooks.ErrSubscriptionNotFound
+	}
+	delete(s.subs, id)
+	s.order = slices.DeleteFunc(s.order, func(other webhooks.SubscriptionID) bool { return other == id })
+	return nil
+}
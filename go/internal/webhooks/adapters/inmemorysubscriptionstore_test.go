@@ -0,0 +1,51 @@
+package adapters
+
+import (
+	"eaglebank/internal/events"
+	"eaglebank/internal/webhooks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemorySubscriptionStore(t *testing.T) {
+	store := NewInMemorySubscriptionStore()
+
+	t.Run("should error not found getting subscription which does not exist", func(t *testing.T) {
+		_, err := store.Get("whk-missing")
+		assert.ErrorIs(t, err, webhooks.ErrSubscriptionNotFound)
+	})
+	t.Run("should error not found deleting subscription which does not exist", func(t *testing.T) {
+		assert.ErrorIs(t, store.Delete("whk-missing"), webhooks.ErrSubscriptionNotFound)
+	})
+	t.Run("should perform put-get-delete cycle without errors", func(t *testing.T) {
+		sub, err := webhooks.NewSubscription("whk-1", "usr-owner", "https://example.com/hooks", []events.EventType{events.TransactionPosted}, "whsec_secret")
+		require.NoError(t, err)
+		require.NoError(t, store.Put(sub))
+
+		t.Run("should get an existing subscription", func(t *testing.T) {
+			got, err := store.Get(sub.ID)
+			require.NoError(t, err)
+			assert.Equal(t, sub, got)
+		})
+		t.Run("should get subscriptions by user", func(t *testing.T) {
+			got, err := store.GetByUserID("usr-owner")
+			require.NoError(t, err)
+			assert.Equal(t, []webhooks.Subscription{sub}, got)
+
+			got, err = store.GetByUserID("usr-other")
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+		t.Run("should delete existing subscription", func(t *testing.T) {
+			require.NoError(t, store.Delete(sub.ID))
+
+			_, err := store.Get(sub.ID)
+			assert.ErrorIs(t, err, webhooks.ErrSubscriptionNotFound)
+			got, err := store.GetByUserID("usr-owner")
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	})
+}
@@ -0,0 +1,13 @@
+package webhooks
+
+import "errors"
+
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+var ErrNotSubscriber = errors.New("only the user who created a webhook subscription can manage it")
+var ErrInvalidSubscription = errors.New("invalid webhook subscription")
+var ErrReceiverAddressNotAllowed = errors.New("webhook receiver resolves to a private address")
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+var ErrDeliveryExists = errors.New("event already queued for delivery to webhook subscription")
+var ErrDeliveryPending = errors.New("webhook delivery is already pending")
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+var ErrSignatureExpired = errors.New("webhook timestamp outside tolerance")
@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IDHeader carries the delivery ID, which stays the same when a delivery is retried or redelivered.
+const IDHeader = "Eagle-Webhook-Id"
+
+// TimestampHeader carries when the delivery was sent, in Unix seconds.
+const TimestampHeader = "Eagle-Webhook-Timestamp"
+
+// SignatureHeader carries "v1=" followed by the hex HMAC-SHA256, keyed by the subscription's
+// secret, of the timestamp, a full stop and the body.
+const SignatureHeader = "Eagle-Webhook-Signature"
+
+// DefaultTolerance is how old a delivery's timestamp may be before Verify rejects it as a replay.
+const DefaultTolerance = 5 * time.Minute
+
+// Sign returns the signature of a body sent at the given time. Signing the timestamp along with
+// the body stops a captured delivery being passed off as a new one later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a delivery's headers against its body, as a receiver should before trusting it:
+// the signature must match and the timestamp must be within tolerance of now.
+func Verify(secret string, header http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSignature, header.Get(TimestampHeader))
+	}
+	timestamp := time.Unix(unix, 0)
+	if timestamp.Before(now.Add(-tolerance)) || timestamp.After(now.Add(tolerance)) {
+		return fmt.Errorf("%w: sent at %s", ErrSignatureExpired, timestamp)
+	}
+	if !hmac.Equal([]byte(header.Get(SignatureHeader)), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
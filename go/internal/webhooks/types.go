@@ -0,0 +1,223 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"eaglebank/internal/events"
+	"eaglebank/internal/users"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SubscriptionID string
+
+var subscriptionIDRegex = regexp.MustCompile(`^whk-[A-Za-z0-9]+$`)
+
+func (id SubscriptionID) IsValid() bool {
+	return subscriptionIDRegex.MatchString(id.String())
+}
+
+func (id SubscriptionID) String() string {
+	return string(id)
+}
+
+func NewSubscriptionID(s string) (SubscriptionID, error) {
+	id := SubscriptionID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid webhook ID %q: must match format whk-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandSubscriptionID() (SubscriptionID, error) {
+	return NewSubscriptionID("whk-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+type DeliveryID string
+
+var deliveryIDRegex = regexp.MustCompile(`^dlv-[A-Za-z0-9]+$`)
+
+func (id DeliveryID) IsValid() bool {
+	return deliveryIDRegex.MatchString(id.String())
+}
+
+func (id DeliveryID) String() string {
+	return string(id)
+}
+
+func NewDeliveryID(s string) (DeliveryID, error) {
+	id := DeliveryID(s)
+	if !id.IsValid() {
+		return "", fmt.Errorf("invalid delivery ID %q: must match format dlv-XXXX", s)
+	}
+	return id, nil
+}
+
+func NewRandDeliveryID() (DeliveryID, error) {
+	return NewDeliveryID("dlv-" + strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+// newSecret generates the key a subscription's deliveries are signed with.
+func newSecret() (string, error) {
+	by := make([]byte, 32)
+	_, err := rand.Read(by)
+	if err != nil {
+		return "", fmt.Errorf("error generating webhook secret %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(by), nil
+}
+
+// Subscription has the events of the given types which concern its user pushed to its URL.
+type Subscription struct {
+	ID         SubscriptionID
+	UserID     users.UserID
+	URL        string
+	EventTypes []events.EventType
+	// Secret signs every delivery, so the receiver can tell they came from us.
+	Secret           string
+	CreatedTimestamp time.Time
+}
+
+func (s Subscription) IsValid() bool {
+	return s.isValid(false)
+}
+
+func (s Subscription) isValid(allowLocal bool) bool {
+	if !s.ID.IsValid() || !s.UserID.IsValid() || s.Secret == "" {
+		return false
+	}
+	if !isValidURL(s.URL, allowLocal) || len(s.EventTypes) == 0 {
+		return false
+	}
+	for _, t := range s.EventTypes {
+		if !t.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Subscription) Wants(t events.EventType) bool {
+	return slices.Contains(s.EventTypes, t)
+}
+
+// isValidURL allows only https URLs, so secrets and account details aren't sent in the clear.
+// Plain http to the local machine is allowed only when allowLocal is set, for tests and local
+// development.
+func isValidURL(s string, allowLocal bool) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || u.User != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		if !allowLocal {
+			return false
+		}
+		if u.Hostname() == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(u.Hostname())
+		return ip != nil && ip.IsLoopback()
+	default:
+		return false
+	}
+}
+
+func NewSubscription(id SubscriptionID, userID users.UserID, rawURL string, eventTypes []events.EventType, secret string) (Subscription, error) {
+	return newSubscription(id, userID, rawURL, eventTypes, secret, false)
+}
+
+func newSubscription(id SubscriptionID, userID users.UserID, rawURL string, eventTypes []events.EventType, secret string, allowLocal bool) (Subscription, error) {
+	sub := Subscription{
+		ID:               id,
+		UserID:           userID,
+		URL:              rawURL,
+		EventTypes:       eventTypes,
+		Secret:           secret,
+		CreatedTimestamp: time.Now(),
+	}
+	if !sub.isValid(allowLocal) {
+		return Subscription{}, fmt.Errorf("invalid webhook subscription for %s to %q with event types %v", userID, rawURL, eventTypes)
+	}
+	return sub, nil
+}
+
+type CreateSubscriptionRequest struct {
+	UserID     users.UserID
+	URL        string
+	EventTypes []events.EventType
+}
+
+type DeliveryStatus string
+
+const DeliveryPending DeliveryStatus = "pending"
+const DeliveryDelivered DeliveryStatus = "delivered"
+const DeliveryDeadLettered DeliveryStatus = "dead_lettered"
+
+func (s DeliveryStatus) String() string {
+	return string(s)
+}
+
+// Attempt is one try at delivering an event, successful or not. StatusCode is zero when no
+// response was received.
+type Attempt struct {
+	Timestamp  time.Time
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
+
+func (a Attempt) Succeeded() bool {
+	return a.Error == ""
+}
+
+// Delivery is an event on its way to a subscription. Attempts logs every try, including those
+// before a redelivery, while Failures counts the failures since the delivery was last queued.
+type Delivery struct {
+	ID               DeliveryID
+	SubscriptionID   SubscriptionID
+	UserID           users.UserID
+	Event            events.Event
+	Status           DeliveryStatus
+	Failures         int
+	Attempts         []Attempt
+	NextAttempt      time.Time
+	CreatedTimestamp time.Time
+	UpdatedTimestamp time.Time
+}
+
+// NewDelivery queues the event for the subscription, due since the event happened so that it is
+// attempted by the next delivery run whenever that run started.
+func NewDelivery(id DeliveryID, sub Subscription, e events.Event) Delivery {
+	now := time.Now()
+	return Delivery{
+		ID:               id,
+		SubscriptionID:   sub.ID,
+		UserID:           sub.UserID,
+		Event:            e,
+		Status:           DeliveryPending,
+		NextAttempt:      e.OccurredTimestamp,
+		CreatedTimestamp: now,
+		UpdatedTimestamp: now,
+	}
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	EventID           string          `json:"eventId"`
+	Type              string          `json:"type"`
+	AggregateID       string          `json:"aggregateId"`
+	OccurredTimestamp time.Time       `json:"occurredTimestamp"`
+	Data              json.RawMessage `json:"data"`
+}
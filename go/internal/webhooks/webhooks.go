@@ -0,0 +1,490 @@
+package webhooks
+
+import (
+	"bytes"
+	"eaglebank/internal/events"
+	"eaglebank/internal/users"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type SubscriptionStore interface {
+	Get(id SubscriptionID) (Subscription, error)
+	GetByUserID(userID users.UserID) ([]Subscription, error)
+	Put(sub Subscription) error
+	Delete(id SubscriptionID) error
+}
+
+type DeliveryStore interface {
+	Get(id DeliveryID) (Delivery, error)
+	GetBySubscriptionID(id SubscriptionID) ([]Delivery, error)
+	// GetPending returns the deliveries waiting to be attempted, in the order they were queued.
+	GetPending() ([]Delivery, error)
+	// Create fails with ErrDeliveryExists if the event is already queued for the subscription.
+	Create(dlv Delivery) error
+	// Put queues the delivery again, behind those already pending, when it goes back to pending.
+	// It keeps its place among the subscription's deliveries.
+	Put(dlv Delivery) error
+}
+
+type Config struct {
+	// MaxAttempts is how many times in a row a delivery may fail before it is dead-lettered.
+	MaxAttempts int
+	// MinBackoff is the wait after the first failure, doubling after each one up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultConfig = Config{MaxAttempts: 8, MinBackoff: 10 * time.Second, MaxBackoff: time.Hour}
+
+// maxResponseBytes is as much of a receiver's response as is read, to let the connection be reused.
+const maxResponseBytes = 64 << 10
+
+type WebhookService struct {
+	subStore SubscriptionStore
+	dlvStore DeliveryStore
+	cfg      Config
+	client   *http.Client
+	// allowLocal lets subscriptions use plain http to the local machine and deliveries reach
+	// private addresses, see WithLocalReceivers.
+	allowLocal bool
+
+	// mu guards claiming and updating deliveries, and is never held while one is sent.
+	mu sync.Mutex
+	// claimed holds the subscriptions a DeliverDue call is sending to, so no other call sends to
+	// them at the same time. Their deliveries are pending until attempted, so can't be redelivered
+	// mid-attempt either.
+	claimed map[SubscriptionID]bool
+}
+
+type WebhookServiceOption func(*WebhookService)
+
+// WithHTTPClient changes the client deliveries are sent with. The default client gives up on a
+// receiver after ten seconds, does not follow redirects and refuses to connect to private,
+// loopback or link-local addresses; a client given here is used as it is.
+func WithHTTPClient(client *http.Client) WebhookServiceOption {
+	return func(svc *WebhookService) {
+		svc.client = client
+	}
+}
+
+// WithLocalReceivers allows webhooks to plain http URLs on the local machine, and lets the default
+// client connect to private addresses. It is for tests and local development only.
+func WithLocalReceivers() WebhookServiceOption {
+	return func(svc *WebhookService) {
+		svc.allowLocal = true
+	}
+}
+
+func NewWebhookService(subStore SubscriptionStore, dlvStore DeliveryStore, cfg Config, opts ...WebhookServiceOption) *WebhookService {
+	svc := &WebhookService{
+		subStore: subStore,
+		dlvStore: dlvStore,
+		cfg:      cfg,
+		claimed:  make(map[SubscriptionID]bool),
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if svc.client == nil {
+		svc.client = newClient(svc.allowLocal)
+	}
+	return svc
+}
+
+// newClient returns a client which doesn't follow redirects, so a receiver can't point a delivery
+// somewhere else, and unless allowLocal is set checks each address it connects to after the name
+// is resolved, so a public host name can't be used to reach the bank's internal network.
+func newClient(allowLocal bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowLocal {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrReceiverAddressNotAllowed, host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be the address checked rather than the receiver.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+func (svc *WebhookService) CreateSubscription(req CreateSubscriptionRequest) (Subscription, error) {
+	id, err := NewRandSubscriptionID()
+	if err != nil {
+		return Subscription{}, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub, err := newSubscription(id, req.UserID, req.URL, req.EventTypes, secret, svc.allowLocal)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+	err = svc.subStore.Put(sub)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("error creating webhook subscription %w", err)
+	}
+	return sub, nil
+}
+
+func (svc *WebhookService) ListSubscriptions(userID users.UserID) ([]Subscription, error) {
+	subs, err := svc.subStore.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhook subscriptions %w", err)
+	}
+	return subs, nil
+}
+
+func (svc *WebhookService) FetchSubscription(id SubscriptionID, userID users.UserID) (Subscription, error) {
+	return svc.fetchOwnSubscription(id, userID)
+}
+
+// DeleteSubscription stops any more events being queued for the subscription. Deliveries already
+// queued are dead-lettered when next attempted.
+func (svc *WebhookService) DeleteSubscription(id SubscriptionID, userID users.UserID) error {
+	_, err := svc.fetchOwnSubscription(id, userID)
+	if err != nil {
+		return err
+	}
+	err = svc.subStore.Delete(id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook subscription %w", err)
+	}
+	return nil
+}
+
+func (svc *WebhookService) ListDeliveries(id SubscriptionID, userID users.UserID) ([]Delivery, error) {
+	_, err := svc.fetchOwnSubscription(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	dlvs, err := svc.dlvStore.GetBySubscriptionID(id)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhook deliveries %w", err)
+	}
+	return dlvs, nil
+}
+
+func (svc *WebhookService) FetchDelivery(id SubscriptionID, dlvID DeliveryID, userID users.UserID) (Delivery, error) {
+	_, err := svc.fetchOwnSubscription(id, userID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	return svc.fetchDelivery(id, dlvID)
+}
+
+// Redeliver queues a delivered or dead-lettered delivery to be sent again, with a fresh set of
+// attempts. It goes behind any of the subscription's pending deliveries for the same aggregate, so
+// the receiver may see it after later events.
+func (svc *WebhookService) Redeliver(id SubscriptionID, dlvID DeliveryID, userID users.UserID) (Delivery, error) {
+	_, err := svc.fetchOwnSubscription(id, userID)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	dlv, err := svc.fetchDelivery(id, dlvID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if dlv.Status == DeliveryPending {
+		return Delivery{}, ErrDeliveryPending
+	}
+	now := time.Now()
+	dlv.Status = DeliveryPending
+	dlv.Failures = 0
+	dlv.NextAttempt = now
+	dlv.UpdatedTimestamp = now
+	err = svc.dlvStore.Put(dlv)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("error queueing webhook redelivery %w", err)
+	}
+	return dlv, nil
+}
+
+// Handle queues the event for each subscription of the users it concerns which wants its type. It
+// is subscribed to the events dispatcher, so may see an event more than once, but queues it only
+// once per subscription. Subscriptions don't receive events from before they were created.
+func (svc *WebhookService) Handle(e events.Event) error {
+	for _, userID := range e.UserIDs {
+		subs, err := svc.subStore.GetByUserID(users.UserID(userID))
+		if err != nil {
+			return fmt.Errorf("error listing webhook subscriptions %w", err)
+		}
+		for _, sub := range subs {
+			if !sub.Wants(e.Type) || sub.CreatedTimestamp.After(e.OccurredTimestamp) {
+				continue
+			}
+			id, err := NewRandDeliveryID()
+			if err != nil {
+				return err
+			}
+			err = svc.dlvStore.Create(NewDelivery(id, sub, e))
+			if err != nil && !errors.Is(err, ErrDeliveryExists) {
+				return fmt.Errorf("error queueing webhook delivery %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+type DeliveryResult struct {
+	Delivered    []Delivery
+	Failed       []Delivery
+	DeadLettered []Delivery
+}
+
+// DeliverDue attempts each pending delivery whose next attempt is due by now. A failed delivery is
+// retried with exponential backoff, and until it succeeds or is dead-lettered the subscription is
+// sent no later events for the same aggregate, so each receiver sees an account's events in order.
+// Each subscription's deliveries are sent one after another, alongside those of the other
+// subscriptions, so a slow receiver holds up only its own. It is run repeatedly, a few times a second.
+func (svc *WebhookService) DeliverDue(now time.Time) (DeliveryResult, error) {
+	runs, err := svc.claimDue(now)
+	if err != nil {
+		return DeliveryResult{}, err
+	}
+	defer svc.release(runs)
+
+	results := make([]DeliveryResult, len(runs))
+	errs := make([]error, len(runs))
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = svc.deliver(run, now)
+		}()
+	}
+	wg.Wait()
+
+	var result DeliveryResult
+	for i := range runs {
+		result.Delivered = append(result.Delivered, results[i].Delivered...)
+		result.Failed = append(result.Failed, results[i].Failed...)
+		result.DeadLettered = append(result.DeadLettered, results[i].DeadLettered...)
+	}
+	return result, errors.Join(errs...)
+}
+
+// deliveryRun is a subscription's due deliveries, claimed by one DeliverDue call. sub is the zero
+// Subscription when it has been deleted.
+type deliveryRun struct {
+	subID SubscriptionID
+	sub   Subscription
+	dlvs  []Delivery
+}
+
+// claimDue gathers the due deliveries by subscription, oldest first, leaving out those held back
+// behind a delivery for the same aggregate which isn't due yet, and claims their subscriptions.
+// Subscriptions already claimed by another call are left to it.
+func (svc *WebhookService) claimDue(now time.Time) ([]deliveryRun, error) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	pending, err := svc.dlvStore.GetPending()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pending webhook deliveries %w", err)
+	}
+	var runs []deliveryRun
+	bySub := make(map[SubscriptionID]int)
+	blocked := make(map[string]bool)
+	for _, dlv := range pending {
+		if svc.claimed[dlv.SubscriptionID] {
+			continue
+		}
+		key := dlv.SubscriptionID.String() + "/" + dlv.Event.AggregateID
+		if blocked[key] {
+			continue
+		}
+		if now.Before(dlv.NextAttempt) {
+			blocked[key] = true
+			continue
+		}
+		i, ok := bySub[dlv.SubscriptionID]
+		if !ok {
+			sub, err := svc.subStore.Get(dlv.SubscriptionID)
+			if err != nil && !errors.Is(err, ErrSubscriptionNotFound) {
+				return nil, fmt.Errorf("error fetching webhook subscription %w", err)
+			}
+			i = len(runs)
+			bySub[dlv.SubscriptionID] = i
+			runs = append(runs, deliveryRun{subID: dlv.SubscriptionID, sub: sub})
+		}
+		runs[i].dlvs = append(runs[i].dlvs, dlv)
+	}
+	for _, run := range runs {
+		svc.claimed[run.subID] = true
+	}
+	return runs, nil
+}
+
+func (svc *WebhookService) release(runs []deliveryRun) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for _, run := range runs {
+		delete(svc.claimed, run.subID)
+	}
+}
+
+// deliver attempts the run's deliveries in order, without holding the lock while they are sent.
+func (svc *WebhookService) deliver(run deliveryRun, now time.Time) (DeliveryResult, error) {
+	var result DeliveryResult
+	blocked := make(map[string]bool)
+	for _, dlv := range run.dlvs {
+		if blocked[dlv.Event.AggregateID] {
+			continue
+		}
+		var attempt Attempt
+		if run.sub.ID == "" {
+			attempt = Attempt{Timestamp: time.Now(), Error: "webhook subscription deleted"}
+		} else {
+			attempt = svc.attempt(run.sub, dlv)
+		}
+		dlv.Attempts = append(dlv.Attempts, attempt)
+		dlv.UpdatedTimestamp = time.Now()
+		switch {
+		case attempt.Succeeded():
+			dlv.Status = DeliveryDelivered
+			result.Delivered = append(result.Delivered, dlv)
+		case run.sub.ID == "" || dlv.Failures+1 >= svc.cfg.MaxAttempts:
+			// A deleted subscription can't be retried into existence, so its deliveries give up at once.
+			dlv.Failures++
+			dlv.Status = DeliveryDeadLettered
+			result.DeadLettered = append(result.DeadLettered, dlv)
+		default:
+			dlv.Failures++
+			dlv.NextAttempt = now.Add(svc.backoff(dlv.Failures))
+			result.Failed = append(result.Failed, dlv)
+			blocked[dlv.Event.AggregateID] = true
+		}
+		err := svc.update(dlv)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (svc *WebhookService) update(dlv Delivery) error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	err := svc.dlvStore.Put(dlv)
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery %w", err)
+	}
+	return nil
+}
+
+// attempt sends the delivery to the subscription's URL, stamped with the current time so the
+// receiver can check it against its own clock. Anything going wrong is recorded in the attempt.
+func (svc *WebhookService) attempt(sub Subscription, dlv Delivery) Attempt {
+	start := time.Now()
+	attempt := Attempt{Timestamp: start}
+	body, err := json.Marshal(Payload{
+		EventID:           dlv.Event.ID.String(),
+		Type:              dlv.Event.Type.String(),
+		AggregateID:       dlv.Event.AggregateID,
+		OccurredTimestamp: dlv.Event.OccurredTimestamp,
+		Data:              dlv.Event.Data,
+	})
+	if err != nil {
+		attempt.Error = fmt.Sprintf("error encoding payload: %v", err)
+		return attempt
+	}
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("error building request: %v", err)
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, dlv.ID.String())
+	req.Header.Set(TimestampHeader, fmt.Sprint(start.Unix()))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, start, body))
+
+	resp, err := svc.client.Do(req)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("receiver responded %s", resp.Status)
+	}
+	return attempt
+}
+
+// backoff is how long to wait after the given number of failed attempts.
+func (svc *WebhookService) backoff(failures int) time.Duration {
+	wait := svc.cfg.MinBackoff
+	for range failures - 1 {
+		wait *= 2
+		if wait >= svc.cfg.MaxBackoff {
+			return svc.cfg.MaxBackoff
+		}
+	}
+	return wait
+}
+
+func (svc *WebhookService) fetchOwnSubscription(id SubscriptionID, userID users.UserID) (Subscription, error) {
+	sub, err := svc.subStore.Get(id)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return Subscription{}, err
+		}
+		return Subscription{}, fmt.Errorf("error fetching webhook subscription %w", err)
+	}
+	if sub.UserID != userID {
+		return Subscription{}, ErrNotSubscriber
+	}
+	return sub, nil
+}
+
+func (svc *WebhookService) fetchDelivery(id SubscriptionID, dlvID DeliveryID) (Delivery, error) {
+	dlv, err := svc.dlvStore.Get(dlvID)
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			return Delivery{}, err
+		}
+		return Delivery{}, fmt.Errorf("error fetching webhook delivery %w", err)
+	}
+	if dlv.SubscriptionID != id {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	return dlv, nil
+}
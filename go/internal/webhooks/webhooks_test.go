@@ -0,0 +1,430 @@
+package webhooks_test
+
+import (
+	"eaglebank/internal/events"
+	"eaglebank/internal/webhooks"
+	"eaglebank/internal/webhooks/adapters"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver stands in for an integrator's endpoint, checking each delivery's signature as they
+// should and answering with whatever status it is told to.
+type receiver struct {
+	t      *testing.T
+	mu     sync.Mutex
+	secret string
+	status int
+	got    []webhooks.Payload
+}
+
+func (rcv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	require.NoError(rcv.t, err)
+	err = webhooks.Verify(rcv.secret, r.Header, body, time.Now(), webhooks.DefaultTolerance)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if rcv.status != http.StatusOK {
+		w.WriteHeader(rcv.status)
+		return
+	}
+	var payload webhooks.Payload
+	require.NoError(rcv.t, json.Unmarshal(body, &payload))
+	rcv.got = append(rcv.got, payload)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rcv *receiver) respond(status int) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.status = status
+}
+
+func (rcv *receiver) received() []string {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	ids := make([]string, 0, len(rcv.got))
+	for _, p := range rcv.got {
+		ids = append(ids, p.EventID)
+	}
+	return ids
+}
+
+func postedEvent(t *testing.T, acctNum string, userIDs ...string) events.Event {
+	t.Helper()
+	e, err := events.NewEvent(events.TransactionPosted, acctNum, userIDs, events.TransactionData{AccountNumber: acctNum, Type: "deposit", Amount: 10, Currency: "GBP"})
+	require.NoError(t, err)
+	return e
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"eventId":"evt-1"}`)
+	now := time.Now()
+	sign := func(secret string, at time.Time, body []byte) http.Header {
+		h := http.Header{}
+		h.Set(webhooks.TimestampHeader, strconv.FormatInt(at.Unix(), 10))
+		h.Set(webhooks.SignatureHeader, webhooks.Sign(secret, at, body))
+		return h
+	}
+
+	t.Run("should verify a signature made with the same secret", func(t *testing.T) {
+		assert.NoError(t, webhooks.Verify("whsec_a", sign("whsec_a", now, body), body, now, webhooks.DefaultTolerance))
+	})
+	t.Run("should reject a different secret or body", func(t *testing.T) {
+		assert.ErrorIs(t, webhooks.Verify("whsec_b", sign("whsec_a", now, body), body, now, webhooks.DefaultTolerance), webhooks.ErrInvalidSignature)
+		assert.ErrorIs(t, webhooks.Verify("whsec_a", sign("whsec_a", now, body), []byte(`{"eventId":"evt-2"}`), now, webhooks.DefaultTolerance), webhooks.ErrInvalidSignature)
+	})
+	t.Run("should reject a timestamp which was changed after signing", func(t *testing.T) {
+		h := sign("whsec_a", now.Add(-time.Minute), body)
+		h.Set(webhooks.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		assert.ErrorIs(t, webhooks.Verify("whsec_a", h, body, now, webhooks.DefaultTolerance), webhooks.ErrInvalidSignature)
+	})
+	t.Run("should reject a replay outside the tolerance", func(t *testing.T) {
+		sent := now.Add(-webhooks.DefaultTolerance - time.Second)
+		assert.ErrorIs(t, webhooks.Verify("whsec_a", sign("whsec_a", sent, body), body, now, webhooks.DefaultTolerance), webhooks.ErrSignatureExpired)
+	})
+	t.Run("should reject a missing timestamp", func(t *testing.T) {
+		assert.ErrorIs(t, webhooks.Verify("whsec_a", http.Header{}, body, now, webhooks.DefaultTolerance), webhooks.ErrInvalidSignature)
+	})
+}
+
+func TestWebhookService(t *testing.T) {
+	rcv := &receiver{t: t, status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	dlvStore := adapters.NewInMemoryDeliveryStore()
+	svc := webhooks.NewWebhookService(adapters.NewInMemorySubscriptionStore(), dlvStore, webhooks.Config{
+		MaxAttempts: 3,
+		MinBackoff:  time.Minute,
+		MaxBackoff:  time.Hour,
+	}, webhooks.WithHTTPClient(srv.Client()), webhooks.WithLocalReceivers())
+
+	newReq := func() webhooks.CreateSubscriptionRequest {
+		return webhooks.CreateSubscriptionRequest{
+			UserID:     "usr-owner",
+			URL:        srv.URL + "/hooks",
+			EventTypes: []events.EventType{events.TransactionPosted},
+		}
+	}
+
+	t.Run("create subscription", func(t *testing.T) {
+		t.Run("should generate a secret", func(t *testing.T) {
+			sub, err := svc.CreateSubscription(newReq())
+			require.NoError(t, err)
+			assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, sub.Secret)
+			require.NoError(t, svc.DeleteSubscription(sub.ID, "usr-owner"))
+		})
+		t.Run("should fail for plain http to another host", func(t *testing.T) {
+			req := newReq()
+			req.URL = "http://example.com/hooks"
+			_, err := svc.CreateSubscription(req)
+			assert.ErrorIs(t, err, webhooks.ErrInvalidSubscription)
+		})
+		t.Run("should fail for plain http to the local machine unless local receivers are allowed", func(t *testing.T) {
+			svc := webhooks.NewWebhookService(adapters.NewInMemorySubscriptionStore(), adapters.NewInMemoryDeliveryStore(), webhooks.DefaultConfig)
+			_, err := svc.CreateSubscription(newReq())
+			assert.ErrorIs(t, err, webhooks.ErrInvalidSubscription)
+		})
+		t.Run("should fail for unknown or missing event types", func(t *testing.T) {
+			req := newReq()
+			req.EventTypes = []events.EventType{"transaction.deleted"}
+			_, err := svc.CreateSubscription(req)
+			assert.ErrorIs(t, err, webhooks.ErrInvalidSubscription)
+
+			req.EventTypes = nil
+			_, err = svc.CreateSubscription(req)
+			assert.ErrorIs(t, err, webhooks.ErrInvalidSubscription)
+		})
+	})
+
+	before := postedEvent(t, "01000004", "usr-owner")
+	sub, err := svc.CreateSubscription(newReq())
+	require.NoError(t, err)
+	rcv.secret = sub.Secret
+
+	t.Run("should not let another user manage the subscription", func(t *testing.T) {
+		_, err := svc.FetchSubscription(sub.ID, "usr-other")
+		assert.ErrorIs(t, err, webhooks.ErrNotSubscriber)
+		_, err = svc.ListDeliveries(sub.ID, "usr-other")
+		assert.ErrorIs(t, err, webhooks.ErrNotSubscriber)
+		assert.ErrorIs(t, svc.DeleteSubscription(sub.ID, "usr-other"), webhooks.ErrNotSubscriber)
+	})
+	t.Run("handle", func(t *testing.T) {
+		t.Run("should queue wanted events about the subscriber once each", func(t *testing.T) {
+			e := postedEvent(t, "01000004", "usr-owner")
+			require.NoError(t, svc.Handle(e))
+			require.NoError(t, svc.Handle(e))
+
+			dlvs, err := svc.ListDeliveries(sub.ID, "usr-owner")
+			require.NoError(t, err)
+			require.Len(t, dlvs, 1)
+			assert.Equal(t, e.ID, dlvs[0].Event.ID)
+			assert.Equal(t, webhooks.DeliveryPending, dlvs[0].Status)
+		})
+		t.Run("should skip other users, unwanted types and events from before the subscription", func(t *testing.T) {
+			require.NoError(t, svc.Handle(postedEvent(t, "01000005", "usr-other")))
+			opened, err := events.NewEvent(events.AccountOpened, "01000004", []string{"usr-owner"}, events.AccountData{})
+			require.NoError(t, err)
+			require.NoError(t, svc.Handle(opened))
+			require.NoError(t, svc.Handle(before))
+
+			dlvs, err := svc.ListDeliveries(sub.ID, "usr-owner")
+			require.NoError(t, err)
+			assert.Len(t, dlvs, 1)
+		})
+	})
+	t.Run("deliver due", func(t *testing.T) {
+		t.Run("should sign and deliver pending deliveries", func(t *testing.T) {
+			dlvs, err := svc.ListDeliveries(sub.ID, "usr-owner")
+			require.NoError(t, err)
+
+			result, err := svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			require.Len(t, result.Delivered, 1)
+			assert.Equal(t, []string{dlvs[0].Event.ID.String()}, rcv.received())
+
+			got, err := svc.FetchDelivery(sub.ID, dlvs[0].ID, "usr-owner")
+			require.NoError(t, err)
+			assert.Equal(t, webhooks.DeliveryDelivered, got.Status)
+			require.Len(t, got.Attempts, 1)
+			assert.Equal(t, http.StatusOK, got.Attempts[0].StatusCode)
+		})
+		t.Run("should retry failures with backoff, holding back later events for the account", func(t *testing.T) {
+			rcv.respond(http.StatusServiceUnavailable)
+			first, second, other := postedEvent(t, "01000006", "usr-owner"), postedEvent(t, "01000006", "usr-owner"), postedEvent(t, "01000007", "usr-owner")
+			for _, e := range []events.Event{first, second, other} {
+				require.NoError(t, svc.Handle(e))
+			}
+			now := time.Now()
+
+			result, err := svc.DeliverDue(now)
+			require.NoError(t, err)
+			require.Len(t, result.Failed, 2)
+			assert.Equal(t, first.ID, result.Failed[0].Event.ID)
+			assert.Equal(t, other.ID, result.Failed[1].Event.ID)
+			assert.Equal(t, now.Add(time.Minute), result.Failed[0].NextAttempt)
+			assert.Equal(t, http.StatusServiceUnavailable, result.Failed[0].Attempts[0].StatusCode)
+
+			result, err = svc.DeliverDue(now.Add(30 * time.Second))
+			require.NoError(t, err)
+			assert.Empty(t, result.Failed)
+
+			rcv.respond(http.StatusOK)
+			result, err = svc.DeliverDue(now.Add(time.Minute))
+			require.NoError(t, err)
+			require.Len(t, result.Delivered, 3)
+			assert.Equal(t, []events.EventID{first.ID, second.ID, other.ID}, []events.EventID{result.Delivered[0].Event.ID, result.Delivered[1].Event.ID, result.Delivered[2].Event.ID})
+		})
+		t.Run("should dead-letter after the maximum attempts and let later events through", func(t *testing.T) {
+			rcv.respond(http.StatusInternalServerError)
+			first, second := postedEvent(t, "01000008", "usr-owner"), postedEvent(t, "01000008", "usr-owner")
+			require.NoError(t, svc.Handle(first))
+			now := time.Now()
+
+			for i, wait := range []time.Duration{0, time.Minute, 3 * time.Minute} {
+				result, err := svc.DeliverDue(now.Add(wait))
+				require.NoError(t, err)
+				if i < 2 {
+					require.Len(t, result.Failed, 1)
+					continue
+				}
+				require.Len(t, result.DeadLettered, 1)
+				assert.Equal(t, webhooks.DeliveryDeadLettered, result.DeadLettered[0].Status)
+				assert.Len(t, result.DeadLettered[0].Attempts, 3)
+			}
+
+			rcv.respond(http.StatusOK)
+			require.NoError(t, svc.Handle(second))
+			result, err := svc.DeliverDue(now.Add(3 * time.Minute))
+			require.NoError(t, err)
+			require.Len(t, result.Delivered, 1)
+			assert.Equal(t, second.ID, result.Delivered[0].Event.ID)
+		})
+		t.Run("should dead-letter deliveries to a deleted subscription", func(t *testing.T) {
+			gone, err := svc.CreateSubscription(newReq())
+			require.NoError(t, err)
+			require.NoError(t, svc.Handle(postedEvent(t, "01000009", "usr-owner")))
+			require.NoError(t, svc.DeleteSubscription(gone.ID, "usr-owner"))
+
+			result, err := svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			require.Len(t, result.DeadLettered, 1)
+			assert.Equal(t, gone.ID, result.DeadLettered[0].SubscriptionID)
+			assert.Equal(t, "webhook subscription deleted", result.DeadLettered[0].Attempts[0].Error)
+			require.Len(t, result.Delivered, 1)
+			assert.Equal(t, sub.ID, result.Delivered[0].SubscriptionID)
+		})
+		t.Run("should not hold up other subscriptions or callers while a receiver is slow", func(t *testing.T) {
+			entered, release := make(chan struct{}), make(chan struct{})
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(entered)
+				<-release
+			}))
+			defer slow.Close()
+			fastGot := make(chan struct{}, 1)
+			fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fastGot <- struct{}{}
+			}))
+			defer fast.Close()
+
+			svc := webhooks.NewWebhookService(adapters.NewInMemorySubscriptionStore(), adapters.NewInMemoryDeliveryStore(), webhooks.DefaultConfig, webhooks.WithLocalReceivers())
+			slowSub, err := svc.CreateSubscription(webhooks.CreateSubscriptionRequest{UserID: "usr-slow", URL: slow.URL, EventTypes: []events.EventType{events.TransactionPosted}})
+			require.NoError(t, err)
+			_, err = svc.CreateSubscription(webhooks.CreateSubscriptionRequest{UserID: "usr-fast", URL: fast.URL, EventTypes: []events.EventType{events.TransactionPosted}})
+			require.NoError(t, err)
+			require.NoError(t, svc.Handle(postedEvent(t, "01000010", "usr-slow")))
+			require.NoError(t, svc.Handle(postedEvent(t, "01000011", "usr-fast")))
+
+			done := make(chan webhooks.DeliveryResult)
+			go func() {
+				result, err := svc.DeliverDue(time.Now())
+				assert.NoError(t, err)
+				done <- result
+			}()
+			<-entered
+			select {
+			case <-fastGot:
+			case <-time.After(5 * time.Second):
+				t.Fatal("delivery to the fast receiver waited for the slow one")
+			}
+
+			// The slow subscription is claimed, so another run leaves it alone, and its delivery
+			// can't be redelivered mid-attempt.
+			result, err := svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			assert.Empty(t, result.Delivered)
+			dlvs, err := svc.ListDeliveries(slowSub.ID, "usr-slow")
+			require.NoError(t, err)
+			_, err = svc.Redeliver(slowSub.ID, dlvs[0].ID, "usr-slow")
+			assert.ErrorIs(t, err, webhooks.ErrDeliveryPending)
+
+			close(release)
+			assert.Len(t, (<-done).Delivered, 2)
+		})
+		t.Run("should not follow a redirect from the receiver", func(t *testing.T) {
+			var followed atomic.Bool
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				followed.Store(true)
+			}))
+			defer target.Close()
+			redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+			defer redirect.Close()
+
+			svc := webhooks.NewWebhookService(adapters.NewInMemorySubscriptionStore(), adapters.NewInMemoryDeliveryStore(), webhooks.DefaultConfig, webhooks.WithLocalReceivers())
+			_, err := svc.CreateSubscription(webhooks.CreateSubscriptionRequest{UserID: "usr-redirect", URL: redirect.URL, EventTypes: []events.EventType{events.TransactionPosted}})
+			require.NoError(t, err)
+			require.NoError(t, svc.Handle(postedEvent(t, "01000012", "usr-redirect")))
+
+			result, err := svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			require.Len(t, result.Failed, 1)
+			assert.Equal(t, http.StatusTemporaryRedirect, result.Failed[0].Attempts[0].StatusCode)
+			assert.False(t, followed.Load())
+		})
+		t.Run("should refuse to connect to a private address", func(t *testing.T) {
+			var reached atomic.Bool
+			internal := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached.Store(true)
+			}))
+			defer internal.Close()
+
+			svc := webhooks.NewWebhookService(adapters.NewInMemorySubscriptionStore(), adapters.NewInMemoryDeliveryStore(), webhooks.DefaultConfig)
+			_, err := svc.CreateSubscription(webhooks.CreateSubscriptionRequest{UserID: "usr-internal", URL: internal.URL, EventTypes: []events.EventType{events.TransactionPosted}})
+			require.NoError(t, err)
+			require.NoError(t, svc.Handle(postedEvent(t, "01000013", "usr-internal")))
+
+			result, err := svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			require.Len(t, result.Failed, 1)
+			assert.Contains(t, result.Failed[0].Attempts[0].Error, webhooks.ErrReceiverAddressNotAllowed.Error())
+			assert.False(t, reached.Load())
+		})
+	})
+	t.Run("redeliver", func(t *testing.T) {
+		dlvs, err := svc.ListDeliveries(sub.ID, "usr-owner")
+		require.NoError(t, err)
+		var dead webhooks.Delivery
+		for _, dlv := range dlvs {
+			if dlv.Status == webhooks.DeliveryDeadLettered {
+				dead = dlv
+			}
+		}
+		require.NotEmpty(t, dead.ID)
+
+		t.Run("should queue a dead-lettered delivery with fresh attempts", func(t *testing.T) {
+			got, err := svc.Redeliver(sub.ID, dead.ID, "usr-owner")
+			require.NoError(t, err)
+			assert.Equal(t, webhooks.DeliveryPending, got.Status)
+			assert.Zero(t, got.Failures)
+
+			_, err = svc.Redeliver(sub.ID, dead.ID, "usr-owner")
+			assert.ErrorIs(t, err, webhooks.ErrDeliveryPending)
+
+			result, err := svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			require.Len(t, result.Delivered, 1)
+			assert.Equal(t, dead.ID, result.Delivered[0].ID)
+			assert.Len(t, result.Delivered[0].Attempts, 4)
+		})
+		t.Run("should send a redelivery after the pending deliveries for the same aggregate", func(t *testing.T) {
+			rcv := &receiver{t: t, status: http.StatusOK}
+			srv := httptest.NewServer(rcv)
+			defer srv.Close()
+			svc := webhooks.NewWebhookService(adapters.NewInMemorySubscriptionStore(), adapters.NewInMemoryDeliveryStore(), webhooks.Config{
+				MaxAttempts: 3,
+				MinBackoff:  time.Minute,
+				MaxBackoff:  time.Hour,
+			}, webhooks.WithLocalReceivers())
+			sub, err := svc.CreateSubscription(webhooks.CreateSubscriptionRequest{UserID: "usr-order", URL: srv.URL, EventTypes: []events.EventType{events.TransactionPosted}})
+			require.NoError(t, err)
+			rcv.secret = sub.Secret
+
+			first := postedEvent(t, "01000014", "usr-order")
+			require.NoError(t, svc.Handle(first))
+			result, err := svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			require.Len(t, result.Delivered, 1)
+			sent := result.Delivered[0]
+
+			rcv.respond(http.StatusInternalServerError)
+			second := postedEvent(t, "01000014", "usr-order")
+			require.NoError(t, svc.Handle(second))
+			_, err = svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+
+			_, err = svc.Redeliver(sub.ID, sent.ID, "usr-order")
+			require.NoError(t, err)
+			result, err = svc.DeliverDue(time.Now())
+			require.NoError(t, err)
+			assert.Empty(t, result.Delivered)
+
+			rcv.respond(http.StatusOK)
+			result, err = svc.DeliverDue(time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.Len(t, result.Delivered, 2)
+			assert.Equal(t, []string{first.ID.String(), second.ID.String(), first.ID.String()}, rcv.received())
+		})
+		t.Run("should not find a delivery under another subscription", func(t *testing.T) {
+			other, err := svc.CreateSubscription(newReq())
+			require.NoError(t, err)
+			_, err = svc.Redeliver(other.ID, dead.ID, "usr-owner")
+			assert.ErrorIs(t, err, webhooks.ErrDeliveryNotFound)
+		})
+	})
+}